@@ -23,6 +23,7 @@ import (
 	"github.com/iotaledger/hornet/v2/plugins/debug"
 	"github.com/iotaledger/hornet/v2/plugins/inx"
 	"github.com/iotaledger/hornet/v2/plugins/prometheus"
+	"github.com/iotaledger/hornet/v2/plugins/promoter"
 	"github.com/iotaledger/hornet/v2/plugins/receipt"
 	"github.com/iotaledger/hornet/v2/plugins/restapi"
 	"github.com/iotaledger/hornet/v2/plugins/urts"
@@ -69,6 +70,7 @@ Command line flags:
 			receipt.Plugin,
 			prometheus.Plugin,
 			inx.Plugin,
+			promoter.Plugin,
 			dashboard_metrics.Plugin,
 			debug.Plugin,
 		}...),
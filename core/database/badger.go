@@ -0,0 +1,45 @@
+package database
+
+import (
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/kvstore/badger"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/metrics"
+)
+
+func newBadger(path string, metrics *metrics.DatabaseMetrics) *database.Database {
+
+	dbEvents := &database.Events{
+		DatabaseCleanup:    events.NewEvent(database.DatabaseCleanupCaller),
+		DatabaseCompaction: events.NewEvent(events.BoolCaller),
+	}
+
+	reportCompactionRunning := func(running bool) {
+		metrics.CompactionRunning.Store(running)
+		if running {
+			metrics.CompactionCount.Inc()
+		}
+		dbEvents.DatabaseCompaction.Trigger(running)
+	}
+
+	db, err := database.NewBadgerDB(path)
+	if err != nil {
+		CoreComponent.LogPanicf("badger database initialization failed: %s", err)
+	}
+
+	return database.New(
+		path,
+		badger.New(db),
+		database.EngineBadger,
+		metrics,
+		dbEvents,
+		true,
+		func() bool {
+			return metrics.CompactionRunning.Load()
+		},
+		database.WithSnapshotFunc(database.BadgerSnapshotFunc(db)),
+		// badger compacts the LSM tree on its own, but the space of the value log
+		// is only reclaimed by running the garbage collection after pruning and periodically.
+		database.WithGarbageCollectionFunc(database.BadgerGarbageCollectionFunc(db, reportCompactionRunning)),
+	)
+}
@@ -4,12 +4,15 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"time"
 
+	"github.com/pkg/errors"
 	flag "github.com/spf13/pflag"
 	"go.uber.org/dig"
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/v2/pkg/daemon"
 	"github.com/iotaledger/hornet/v2/pkg/database"
 	"github.com/iotaledger/hornet/v2/pkg/metrics"
@@ -30,6 +33,10 @@ const (
 	TangleDatabaseDirectoryName = "tangle"
 	// UTXODatabaseDirectoryName defines the subfolder for the UTXO database
 	UTXODatabaseDirectoryName = "utxo"
+
+	// the interval in which the value log garbage collection of badger databases is run,
+	// additionally to the garbage collection after every pruning.
+	valueLogGCInterval = 10 * time.Minute
 )
 
 func init() {
@@ -59,10 +66,12 @@ var (
 
 type dependencies struct {
 	dig.In
-	TangleDatabase *database.Database `name:"tangleDatabase"`
-	UTXODatabase   *database.Database `name:"utxoDatabase"`
-	Storage        *storage.Storage
-	StorageMetrics *metrics.StorageMetrics
+	TangleDatabase  *database.Database `name:"tangleDatabase"`
+	UTXODatabase    *database.Database `name:"utxoDatabase"`
+	Storage         *storage.Storage
+	StorageMetrics  *metrics.StorageMetrics
+	OnlineMigration *database.OnlineMigration
+	Checkpointer    *storage.Checkpointer
 }
 
 func initConfigPars(c *dig.Container) error {
@@ -71,6 +80,7 @@ func initConfigPars(c *dig.Container) error {
 		dig.Out
 		DatabaseEngine           database.Engine `name:"databaseEngine"`
 		DatabasePath             string          `name:"databasePath"`
+		DatabaseCheckpointsPath  string          `name:"databaseCheckpointsPath"`
 		TangleDatabasePath       string          `name:"tangleDatabasePath"`
 		UTXODatabasePath         string          `name:"utxoDatabasePath"`
 		DeleteDatabaseFlag       bool            `name:"deleteDatabase"`
@@ -88,6 +98,7 @@ func initConfigPars(c *dig.Container) error {
 		return cfgResult{
 			DatabaseEngine:           dbEngine,
 			DatabasePath:             ParamsDatabase.Path,
+			DatabaseCheckpointsPath:  ParamsDatabase.CheckpointsPath,
 			TangleDatabasePath:       filepath.Join(ParamsDatabase.Path, TangleDatabaseDirectoryName),
 			UTXODatabasePath:         filepath.Join(ParamsDatabase.Path, UTXODatabaseDirectoryName),
 			DeleteDatabaseFlag:       *deleteDatabase,
@@ -132,6 +143,22 @@ func provide(c *dig.Container) error {
 				if err := os.RemoveAll(deps.DatabasePath); err != nil {
 					CoreComponent.LogPanicf("deleting database folder failed: %s", err)
 				}
+				// delete the databases of an online migration
+				if err := os.RemoveAll(database.OnlineMigrationTargetPath(deps.DatabasePath)); err != nil {
+					CoreComponent.LogPanicf("deleting database migration folder failed: %s", err)
+				}
+			}
+
+			// switch to the databases of a completed online migration
+			migrationInfo, err := database.ApplyOnlineMigration(deps.DatabasePath, deps.DatabaseEngine)
+			if err != nil {
+				if errors.Is(err, database.ErrOnlineMigrationEngineMismatch) {
+					CoreComponent.LogPanicf("applying database migration failed: %s, please set \"db.engine\" to the migrated engine and restart the node", err)
+				}
+				CoreComponent.LogPanicf("applying database migration failed: %s", err)
+			}
+			if migrationInfo != nil {
+				CoreComponent.LogInfof("Switched to the databases migrated to %s at ledger index %d, the old databases were moved to %s", migrationInfo.Engine, migrationInfo.LedgerIndex, migrationInfo.OldDatabasePath)
 			}
 
 			tangleTargetEngine, err := database.CheckDatabaseEngine(deps.TangleDatabasePath, true, deps.DatabaseEngine)
@@ -175,6 +202,13 @@ func provide(c *dig.Container) error {
 				UTXODatabase:   newRocksDB(deps.UTXODatabasePath, utxoDatabaseMetrics),
 			}
 
+		case database.EngineBadger:
+			return databaseOut{
+				StorageMetrics: &metrics.StorageMetrics{},
+				TangleDatabase: newBadger(deps.TangleDatabasePath, tangleDatabaseMetrics),
+				UTXODatabase:   newBadger(deps.UTXODatabasePath, utxoDatabaseMetrics),
+			}
+
 		case database.EngineMapDB:
 			return databaseOut{
 				StorageMetrics: &metrics.StorageMetrics{},
@@ -183,13 +217,29 @@ func provide(c *dig.Container) error {
 			}
 
 		default:
-			CoreComponent.LogPanicf("unknown database engine: %s, supported engines: pebble/rocksdb/mapdb", targetEngine)
+			CoreComponent.LogPanicf("unknown database engine: %s, supported engines: pebble/rocksdb/badger/mapdb", targetEngine)
 			return databaseOut{}
 		}
 	}); err != nil {
 		CoreComponent.LogPanic(err)
 	}
 
+	type onlineMigrationDeps struct {
+		dig.In
+		TangleDatabase *database.Database `name:"tangleDatabase"`
+		UTXODatabase   *database.Database `name:"utxoDatabase"`
+		DatabasePath   string             `name:"databasePath"`
+	}
+
+	if err := c.Provide(func(deps onlineMigrationDeps) *database.OnlineMigration {
+		return database.NewOnlineMigration(deps.DatabasePath, map[string]*database.Database{
+			TangleDatabaseDirectoryName: deps.TangleDatabase,
+			UTXODatabaseDirectoryName:   deps.UTXODatabase,
+		})
+	}); err != nil {
+		CoreComponent.LogPanic(err)
+	}
+
 	type storageDeps struct {
 		dig.In
 		TangleDatabase *database.Database `name:"tangleDatabase"`
@@ -212,6 +262,50 @@ func provide(c *dig.Container) error {
 
 		store.PrintSnapshotInfo()
 
+		rebuilt, err := store.UTXOManager().InitAddressIndex(ParamsDatabase.AddressIndex)
+		if err != nil {
+			CoreComponent.LogPanicf("can't initialize address index: %s", err)
+		}
+		if rebuilt {
+			ledgerIndex, err := store.UTXOManager().AddressIndexLedgerIndexWithoutLocking()
+			if err != nil {
+				CoreComponent.LogPanicf("can't initialize address index: %s", err)
+			}
+			CoreComponent.LogInfof("Address index built at ledger index %d", ledgerIndex)
+		}
+
+		rebuilt, err = store.InitArchive(ParamsDatabase.Archive)
+		if err != nil {
+			CoreComponent.LogPanicf("can't initialize archive: %s", err)
+		}
+		if rebuilt {
+			archiveState, err := store.UTXOManager().ArchiveStateWithoutLocking()
+			if err != nil {
+				CoreComponent.LogPanicf("can't initialize archive: %s", err)
+			}
+			CoreComponent.LogInfof("Archive indexes built, referenced blocks since milestone %d, spent outputs since milestone %d", archiveState.BlocksStartIndex, archiveState.SpentsStartIndex)
+		}
+
+		rebuilt, err = store.UTXOManager().InitLedgerCommitment(ParamsDatabase.LedgerCommitment)
+		if err != nil {
+			CoreComponent.LogPanicf("can't initialize ledger commitment: %s", err)
+		}
+		if rebuilt {
+			ledgerIndex, err := store.UTXOManager().LedgerCommitmentLedgerIndexWithoutLocking()
+			if err != nil {
+				CoreComponent.LogPanicf("can't initialize ledger commitment: %s", err)
+			}
+			CoreComponent.LogInfof("Ledger commitment built at ledger index %d", ledgerIndex)
+		}
+
+		computed, err := store.UTXOManager().InitLedgerStateHash()
+		if err != nil {
+			CoreComponent.LogPanicf("can't initialize ledger state hash: %s", err)
+		}
+		if computed {
+			CoreComponent.LogInfo("Ledger state hash computed")
+		}
+
 		return storageOut{
 			Storage:     store,
 			UTXOManager: store.UTXOManager(),
@@ -220,6 +314,22 @@ func provide(c *dig.Container) error {
 		CoreComponent.LogPanic(err)
 	}
 
+	type checkpointerDeps struct {
+		dig.In
+		TangleDatabase *database.Database `name:"tangleDatabase"`
+		UTXODatabase   *database.Database `name:"utxoDatabase"`
+		Storage        *storage.Storage
+	}
+
+	if err := c.Provide(func(deps checkpointerDeps) *storage.Checkpointer {
+		return storage.NewCheckpointer(deps.Storage, map[string]*database.Database{
+			TangleDatabaseDirectoryName: deps.TangleDatabase,
+			UTXODatabaseDirectoryName:   deps.UTXODatabase,
+		})
+	}); err != nil {
+		CoreComponent.LogPanic(err)
+	}
+
 	type syncManagerDeps struct {
 		dig.In
 		UTXOManager     *utxo.Manager
@@ -265,15 +375,35 @@ func configure() error {
 	if err = CoreComponent.Daemon().BackgroundWorker("Close database", func(ctx context.Context) {
 		<-ctx.Done()
 
+		// a checkpoint that is written in the background needs to be stopped before the databases are closed
+		deps.Checkpointer.Stop()
+
 		if err = deps.Storage.MarkDatabasesHealthy(); err != nil {
 			CoreComponent.LogPanic(err)
 		}
 
+		// the ledger index the migrated databases are switched over at
+		ledgerIndex, err := deps.Storage.UTXOManager().ReadLedgerIndex()
+		if err != nil {
+			CoreComponent.LogPanic(err)
+		}
+
+		// the copy of an online migration iterates the databases, so it needs to be stopped before they are closed
+		deps.OnlineMigration.Stop()
+
 		CoreComponent.LogInfo("Syncing databases to disk...")
 		if err = deps.Storage.FlushAndCloseStores(); err != nil {
 			CoreComponent.LogPanicf("Syncing databases to disk... failed: %s", err)
 		}
 		CoreComponent.LogInfo("Syncing databases to disk... done")
+
+		migrated, err := deps.OnlineMigration.Finish(ledgerIndex)
+		if err != nil {
+			CoreComponent.LogWarnf("Online database migration aborted: %s", err)
+		}
+		if migrated {
+			CoreComponent.LogInfof("Online database migration finished at ledger index %d, the migrated databases are used after the restart", ledgerIndex)
+		}
 	}, daemon.PriorityCloseDatabase); err != nil {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
 	}
@@ -292,9 +422,32 @@ func run() error {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
 	}
 
+	if deps.TangleDatabase.GarbageCollectionSupported() || deps.UTXODatabase.GarbageCollectionSupported() {
+		if err := CoreComponent.Daemon().BackgroundWorker("Database[ValueLogGC]", func(ctx context.Context) {
+			ticker := timeutil.NewTicker(runValueLogGC, valueLogGCInterval, ctx)
+			ticker.WaitForGracefulShutdown()
+		}, daemon.PriorityPruning); err != nil {
+			CoreComponent.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
 	return nil
 }
 
+// runValueLogGC reclaims the disk space of deleted values in the badger databases.
+func runValueLogGC() {
+	if deps.StorageMetrics.PruningRunning.Load() {
+		// the garbage collection is run by the pruning after the milestones were deleted
+		return
+	}
+
+	for _, db := range []*database.Database{deps.TangleDatabase, deps.UTXODatabase} {
+		if err := db.RunGarbageCollection(); err != nil {
+			CoreComponent.LogWarnf("value log garbage collection failed: %s", err)
+		}
+	}
+}
+
 func configureEvents() {
 	onPruningStateChanged = events.NewClosure(func(running bool) {
 		deps.StorageMetrics.PruningRunning.Store(running)
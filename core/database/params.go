@@ -6,12 +6,20 @@ import (
 
 // ParametersDatabase contains the definition of the parameters used by the ParametersDatabase.
 type ParametersDatabase struct {
-	// Engine defines the used database engine (pebble/rocksdb/mapdb).
-	Engine string `default:"rocksdb" usage:"the used database engine (pebble/rocksdb/mapdb)"`
+	// Engine defines the used database engine (pebble/rocksdb/badger/mapdb).
+	Engine string `default:"rocksdb" usage:"the used database engine (pebble/rocksdb/badger/mapdb)"`
 	// Path defines the path to the database folder.
 	Path string `default:"testnet/database" usage:"the path to the database folder"`
+	// CheckpointsPath defines the path to the folder the checkpoints of the database are stored in.
+	CheckpointsPath string `default:"testnet/checkpoints" usage:"the path to the folder the checkpoints of the database are stored in"`
 	// AutoRevalidation defines whether to automatically start revalidation on startup if the database is corrupted.
 	AutoRevalidation bool `default:"false" usage:"whether to automatically start revalidation on startup if the database is corrupted"`
+	// AddressIndex defines whether to maintain an index of the unspent and spent outputs by address.
+	AddressIndex bool `default:"false" usage:"whether to maintain an index of the unspent and spent outputs by address"`
+	// Archive defines whether to keep the whole history of the ledger and index it for historical queries (pruning needs to be disabled).
+	Archive bool `default:"false" usage:"whether to keep the whole history of the ledger and index it for historical queries (pruning needs to be disabled)"`
+	// LedgerCommitment defines whether to maintain a sparse merkle tree over the unspent outputs to serve proofs against the ledger commitment.
+	LedgerCommitment bool `default:"false" usage:"whether to maintain a sparse merkle tree over the unspent outputs to serve proofs against the ledger commitment"`
 	// Debug defines whether to ignore the check for corrupted databases (should only be used for debug reasons).
 	Debug bool `default:"false" usage:"ignore the check for corrupted databases (should only be used for debug reasons)"`
 }
@@ -37,6 +37,7 @@ func newPebble(path string, metrics *metrics.DatabaseMetrics) *database.Database
 		func() bool {
 			return metrics.CompactionRunning.Load()
 		},
+		database.WithCheckpointFunc(database.PebbleCheckpointFunc(db)),
 	)
 
 }
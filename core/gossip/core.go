@@ -35,7 +35,8 @@ const (
 	heartbeatReceiveTimeout = 100 * time.Second
 	checkHeartbeatsInterval = 5 * time.Second
 
-	iotaGossipProtocolIDTemplate = "/iota-gossip/%d/1.0.0"
+	// the gossip protocol ID for a given network ID and gossip protocol version.
+	iotaGossipProtocolIDTemplate = "/iota-gossip/%d/%d.0.0"
 )
 
 func init() {
@@ -59,6 +60,7 @@ var (
 	onGossipServiceProtocolStarted     *events.Closure
 	onGossipServiceProtocolTerminated  *events.Closure
 	onMessageProcessorBroadcastMessage *events.Closure
+	onMessageProcessorBlockProcessed   *events.Closure
 )
 
 type dependencies struct {
@@ -127,15 +129,41 @@ func provide(c *dig.Container) error {
 	}
 
 	if err := c.Provide(func(deps serviceDeps) *gossip.Service {
-		return gossip.NewService(
-			protocol.ID(fmt.Sprintf(iotaGossipProtocolIDTemplate, deps.ProtocolManager.Current().NetworkID())),
-			deps.Host,
-			deps.PeeringManager,
-			deps.ServerMetrics,
+		serviceOpts := []gossip.ServiceOption{
 			gossip.WithLogger(logger.NewLogger("GossipService")),
 			gossip.WithUnknownPeersLimit(ParamsGossip.UnknownPeersLimit),
 			gossip.WithStreamReadTimeout(ParamsGossip.StreamReadTimeout),
 			gossip.WithStreamWriteTimeout(ParamsGossip.StreamWriteTimeout),
+		}
+
+		if ParamsGossip.Quotas.Enabled {
+			serviceOpts = append(serviceOpts, gossip.WithQuotas(&gossip.QuotaOptions{
+				Bandwidth:             gossip.Quota{Rate: ParamsGossip.Quotas.Bandwidth.Rate, Burst: ParamsGossip.Quotas.Bandwidth.Burst},
+				Blocks:                gossip.Quota{Rate: ParamsGossip.Quotas.Blocks.Rate, Burst: ParamsGossip.Quotas.Blocks.Burst},
+				BlockRequests:         gossip.Quota{Rate: ParamsGossip.Quotas.BlockRequests.Rate, Burst: ParamsGossip.Quotas.BlockRequests.Burst},
+				MilestoneRequests:     gossip.Quota{Rate: ParamsGossip.Quotas.MilestoneRequests.Rate, Burst: ParamsGossip.Quotas.MilestoneRequests.Burst},
+				Heartbeats:            gossip.Quota{Rate: ParamsGossip.Quotas.Heartbeats.Rate, Burst: ParamsGossip.Quotas.Heartbeats.Burst},
+				DisconnectOnViolation: ParamsGossip.Quotas.DisconnectOnViolation,
+			}))
+		}
+
+		gossipProtocolID := func(version byte) protocol.ID {
+			return protocol.ID(fmt.Sprintf(iotaGossipProtocolIDTemplate, deps.ProtocolManager.Current().NetworkID(), version))
+		}
+
+		// offer all supported gossip protocol versions, so that the highest version supported by both peers is used
+		protocolVersions := make(map[byte]protocol.ID)
+		for _, version := range proto.SupportedGossipVersions {
+			protocolVersions[byte(version)] = gossipProtocolID(byte(version))
+		}
+		serviceOpts = append(serviceOpts, gossip.WithProtocolVersions(protocolVersions))
+
+		return gossip.NewService(
+			gossipProtocolID(proto.GossipVersionLegacy),
+			deps.Host,
+			deps.PeeringManager,
+			deps.ServerMetrics,
+			serviceOpts...,
 		)
 	}); err != nil {
 		CoreComponent.LogPanic(err)
@@ -155,6 +183,8 @@ func provide(c *dig.Container) error {
 			deps.RequestQueue,
 			gossip.WithRequesterDiscardRequestsOlderThan(ParamsRequests.DiscardOlderThan),
 			gossip.WithRequesterPendingRequestReEnqueueInterval(ParamsRequests.PendingReEnqueueInterval),
+			gossip.WithRequesterMaxInFlightPerPeer(ParamsRequests.MaxInFlightPerPeer),
+			gossip.WithRequesterRequestTimeout(ParamsRequests.Timeout),
 		)
 	}); err != nil {
 		CoreComponent.LogPanic(err)
@@ -214,7 +244,9 @@ func run() error {
 	}
 
 	if err := CoreComponent.Daemon().BackgroundWorker("RequestQueueDrainer", func(ctx context.Context) {
+		attachEventsRequester()
 		deps.Requester.RunRequestQueueDrainer(ctx)
+		detachEventsRequester()
 	}, daemon.PriorityRequestsProcessor); err != nil {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
 	}
@@ -384,6 +416,10 @@ func configureEvents() {
 	})
 
 	onMessageProcessorBroadcastMessage = events.NewClosure(deps.Broadcaster.Broadcast)
+
+	onMessageProcessorBlockProcessed = events.NewClosure(func(_ *storage.Block, requests gossip.Requests, proto *gossip.Protocol) {
+		deps.Requester.RequestsReceived(requests, proto)
+	})
 }
 
 func attachEventsGossipService() {
@@ -391,6 +427,10 @@ func attachEventsGossipService() {
 	deps.GossipService.Events.ProtocolTerminated.Attach(onGossipServiceProtocolTerminated)
 }
 
+func attachEventsRequester() {
+	deps.MessageProcessor.Events.BlockProcessed.Attach(onMessageProcessorBlockProcessed)
+}
+
 func attachEventsBroadcastQueue() {
 	deps.MessageProcessor.Events.BroadcastBlock.Attach(onMessageProcessorBroadcastMessage)
 }
@@ -400,6 +440,10 @@ func detachEventsGossipService() {
 	deps.GossipService.Events.ProtocolTerminated.Detach(onGossipServiceProtocolTerminated)
 }
 
+func detachEventsRequester() {
+	deps.MessageProcessor.Events.BlockProcessed.Detach(onMessageProcessorBlockProcessed)
+}
+
 func detachEventsBroadcastQueue() {
 	deps.MessageProcessor.Events.BroadcastBlock.Detach(onMessageProcessorBroadcastMessage)
 }
@@ -12,6 +12,10 @@ type ParametersRequests struct {
 	DiscardOlderThan time.Duration `default:"15s" usage:"the maximum time a request stays in the request queue"`
 	// Defines the interval the pending requests are re-enqueued.
 	PendingReEnqueueInterval time.Duration `default:"5s" usage:"the interval the pending requests are re-enqueued"`
+	// Defines the maximum amount of requests in flight per peer.
+	MaxInFlightPerPeer int `default:"128" usage:"the maximum amount of requests in flight per peer (0 = unlimited)"`
+	// Defines the time after which a request is sent to another peer if it was not answered.
+	Timeout time.Duration `default:"3s" usage:"the time after which a request is sent to another peer if it was not answered"`
 }
 
 // ParametersGossip contains the definition of the parameters used by gossip.
@@ -22,6 +26,48 @@ type ParametersGossip struct {
 	StreamReadTimeout time.Duration `default:"60s" usage:"the read timeout for reads from the gossip stream"`
 	// Defines the write timeout for writes to the gossip stream.
 	StreamWriteTimeout time.Duration `default:"10s" usage:"the write timeout for writes to the gossip stream"`
+
+	Quotas struct {
+		// Enabled defines whether the quotas are enforced for the messages received from every peer.
+		Enabled bool `default:"false" usage:"whether the quotas are enforced for the messages received from every peer"`
+		// DisconnectOnViolation defines whether peers which exceed a quota are disconnected. Otherwise only the exceeding messages are dropped.
+		DisconnectOnViolation bool `default:"true" usage:"whether peers which exceed a quota are disconnected. Otherwise only the exceeding messages are dropped"`
+
+		Bandwidth struct {
+			// Rate defines the amount of bytes per second a peer is allowed to send (0 = unlimited).
+			Rate float64 `default:"5242880.0" usage:"the amount of bytes per second a peer is allowed to send (0 = unlimited)"`
+			// Burst defines the maximum amount of bytes a peer is allowed to send at once.
+			Burst int `default:"10485760" usage:"the maximum amount of bytes a peer is allowed to send at once"`
+		}
+
+		Blocks struct {
+			// Rate defines the amount of blocks per second a peer is allowed to send (0 = unlimited).
+			Rate float64 `default:"1000.0" usage:"the amount of blocks per second a peer is allowed to send (0 = unlimited)"`
+			// Burst defines the maximum amount of blocks a peer is allowed to send at once.
+			Burst int `default:"5000" usage:"the maximum amount of blocks a peer is allowed to send at once"`
+		}
+
+		BlockRequests struct {
+			// Rate defines the amount of block requests per second a peer is allowed to send (0 = unlimited).
+			Rate float64 `default:"1000.0" usage:"the amount of block requests per second a peer is allowed to send (0 = unlimited)"`
+			// Burst defines the maximum amount of block requests a peer is allowed to send at once.
+			Burst int `default:"5000" usage:"the maximum amount of block requests a peer is allowed to send at once"`
+		}
+
+		MilestoneRequests struct {
+			// Rate defines the amount of milestone requests per second a peer is allowed to send (0 = unlimited).
+			Rate float64 `default:"50.0" usage:"the amount of milestone requests per second a peer is allowed to send (0 = unlimited)"`
+			// Burst defines the maximum amount of milestone requests a peer is allowed to send at once.
+			Burst int `default:"500" usage:"the maximum amount of milestone requests a peer is allowed to send at once"`
+		}
+
+		Heartbeats struct {
+			// Rate defines the amount of heartbeats per second a peer is allowed to send (0 = unlimited).
+			Rate float64 `default:"1.0" usage:"the amount of heartbeats per second a peer is allowed to send (0 = unlimited)"`
+			// Burst defines the maximum amount of heartbeats a peer is allowed to send at once.
+			Burst int `default:"10" usage:"the maximum amount of heartbeats a peer is allowed to send at once"`
+		}
+	}
 }
 
 var ParamsRequests = &ParametersRequests{}
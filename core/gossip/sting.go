@@ -46,10 +46,60 @@ func attachEventsProtocolMessages(proto *gossip.Protocol) {
 		deps.ServerMetrics.SentMilestoneRequests.Inc()
 	}))
 
+	proto.Parser.Events.Received[gossip.MessageTypeBlockBatch].Attach(events.NewClosure(func(data []byte) {
+		blocksCount := uint32(gossip.MessageBatchSize(gossip.MessageTypeBlockBatch, data))
+		proto.Metrics.ReceivedBlocks.Add(blocksCount)
+		deps.ServerMetrics.Blocks.Add(blocksCount)
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeBlockBatch, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeBlockBatch].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeBlockRequestBatch].Attach(events.NewClosure(func(data []byte) {
+		requestsCount := uint32(gossip.MessageBatchSize(gossip.MessageTypeBlockRequestBatch, data))
+		proto.Metrics.ReceivedBlockRequests.Add(requestsCount)
+		deps.ServerMetrics.ReceivedBlockRequests.Add(requestsCount)
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeBlockRequestBatch, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeBlockRequestBatch].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeMilestoneConeRequest].Attach(events.NewClosure(func(data []byte) {
+		milestonesCount := uint32(gossip.MessageBatchSize(gossip.MessageTypeMilestoneConeRequest, data))
+		proto.Metrics.ReceivedMilestoneRequests.Add(milestonesCount)
+		deps.ServerMetrics.ReceivedMilestoneRequests.Add(milestonesCount)
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeMilestoneConeRequest, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeMilestoneConeRequest].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+		proto.Metrics.SentMilestoneRequests.Inc()
+		deps.ServerMetrics.SentMilestoneRequests.Inc()
+	}))
+
+	proto.Parser.Events.Received[gossip.MessageTypeMilestoneCone].Attach(events.NewClosure(func(data []byte) {
+		blocksCount := uint32(gossip.MessageBatchSize(gossip.MessageTypeMilestoneCone, data))
+		proto.Metrics.ReceivedBlocks.Add(blocksCount)
+		deps.ServerMetrics.Blocks.Add(blocksCount)
+		deps.MessageProcessor.Process(proto, gossip.MessageTypeMilestoneCone, data)
+	}))
+
+	proto.Events.Sent[gossip.MessageTypeMilestoneCone].Attach(events.NewClosure(func() {
+		proto.Metrics.SentPackets.Inc()
+	}))
+
 	proto.Parser.Events.Received[gossip.MessageTypeHeartbeat].Attach(events.NewClosure(func(data []byte) {
 		proto.Metrics.ReceivedHeartbeats.Inc()
 		deps.ServerMetrics.ReceivedHeartbeats.Inc()
 
+		if !deps.MessageProcessor.CheckQuota(proto, gossip.MessageTypeHeartbeat, data) {
+			return
+		}
+
 		proto.LatestHeartbeat = gossip.ParseHeartbeat(data)
 
 		/*
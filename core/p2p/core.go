@@ -17,6 +17,7 @@ import (
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/configuration"
 	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/v2/pkg/daemon"
 	"github.com/iotaledger/hornet/v2/pkg/database"
 	"github.com/iotaledger/hornet/v2/pkg/p2p"
@@ -36,6 +37,11 @@ func init() {
 	}
 }
 
+const (
+	// the interval in which the reputations of the peers are persisted.
+	reputationFlushInterval = 1 * time.Minute
+)
+
 var (
 	CoreComponent *app.CoreComponent
 	deps          dependencies
@@ -46,6 +52,7 @@ type dependencies struct {
 	PeeringManager       *p2p.Manager
 	Host                 host.Host
 	PeerStoreContainer   *p2p.PeerStoreContainer
+	ReputationStore      *p2p.ReputationStore
 	PeeringConfig        *configuration.Configuration `name:"peeringConfig"`
 	PeeringConfigManager *p2p.ConfigManager
 }
@@ -140,18 +147,39 @@ func provide(c *dig.Container) error {
 		CoreComponent.LogPanic(err)
 	}
 
+	if err := c.Provide(func(peerStoreContainer *p2p.PeerStoreContainer) *p2p.ReputationStore {
+		if !ParamsP2P.Reputation.Enabled {
+			return nil
+		}
+
+		reputationKVStore, err := peerStoreContainer.ReputationKVStore()
+		if err != nil {
+			CoreComponent.LogPanicf("unable to initialize reputation store: %s", err)
+		}
+
+		return p2p.NewReputationStore(reputationKVStore, p2p.WithReputationHalfLife(ParamsP2P.Reputation.HalfLife))
+	}); err != nil {
+		CoreComponent.LogPanic(err)
+	}
+
 	type mngDeps struct {
 		dig.In
 		Host                      host.Host
+		ReputationStore           *p2p.ReputationStore
 		AutopeeringRunAsEntryNode bool `name:"autopeeringRunAsEntryNode"`
 	}
 
 	if err := c.Provide(func(deps mngDeps) *p2p.Manager {
 		if !deps.AutopeeringRunAsEntryNode {
-			return p2p.NewManager(deps.Host,
+			mngOpts := []p2p.ManagerOption{
 				p2p.WithManagerLogger(logger.NewLogger("P2P-Manager")),
 				p2p.WithManagerReconnectInterval(ParamsP2P.ReconnectInterval, 1*time.Second),
-			)
+			}
+			if deps.ReputationStore != nil {
+				mngOpts = append(mngOpts, p2p.WithManagerReputation(deps.ReputationStore, ParamsP2P.Reputation.MinScore, ParamsP2P.Reputation.CheckInterval))
+			}
+
+			return p2p.NewManager(deps.Host, mngOpts...)
 		}
 		return nil
 	}); err != nil {
@@ -235,6 +263,10 @@ func configure() error {
 		<-ctx.Done()
 
 		closeDatabases := func() error {
+			if err := deps.ReputationStore.Flush(); err != nil {
+				return err
+			}
+
 			if err := deps.PeerStoreContainer.Flush(); err != nil {
 				return err
 			}
@@ -260,6 +292,19 @@ func run() error {
 		return nil
 	}
 
+	if deps.ReputationStore != nil {
+		if err := CoreComponent.Daemon().BackgroundWorker("P2P reputation", func(ctx context.Context) {
+			ticker := timeutil.NewTicker(func() {
+				if err := deps.ReputationStore.Flush(); err != nil {
+					CoreComponent.LogWarnf("unable to persist peer reputations: %s", err)
+				}
+			}, reputationFlushInterval, ctx)
+			ticker.WaitForGracefulShutdown()
+		}, daemon.PriorityP2PManager); err != nil {
+			CoreComponent.LogPanicf("failed to start worker: %s", err)
+		}
+	}
+
 	// register a daemon to disconnect all peers up on shutdown
 	if err := CoreComponent.Daemon().BackgroundWorker("Manager", func(ctx context.Context) {
 		CoreComponent.LogInfof("listening on: %s", deps.Host.Addrs())
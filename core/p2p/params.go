@@ -33,6 +33,17 @@ type ParametersP2P struct {
 
 	// Defines the time to wait before trying to reconnect to a disconnected peer.
 	ReconnectInterval time.Duration `default:"30s" usage:"the time to wait before trying to reconnect to a disconnected peer"`
+
+	Reputation struct {
+		// Defines whether the reputation of peers is tracked.
+		Enabled bool `default:"true" usage:"whether the reputation of peers is tracked"`
+		// Defines the time after which the recorded behavior of a peer only counts half.
+		HalfLife time.Duration `default:"24h" usage:"the time after which the recorded behavior of a peer only counts half"`
+		// Defines the score below which unknown and autopeered peers are disconnected and rejected.
+		MinScore float64 `default:"-100.0" usage:"the score below which unknown and autopeered peers are disconnected and rejected"`
+		// Defines the interval in which the reputation of unknown and autopeered peers is checked.
+		CheckInterval time.Duration `default:"1m" usage:"the interval in which the reputation of unknown and autopeered peers is checked"`
+	}
 }
 
 // ParametersPeers contains the definition of the parameters used by peers.
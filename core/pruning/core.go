@@ -2,12 +2,14 @@ package pruning
 
 import (
 	"context"
+	"path/filepath"
 
 	"github.com/labstack/gommon/bytes"
 	"go.uber.org/dig"
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hornet/v2/pkg/coldstorage"
 	"github.com/iotaledger/hornet/v2/pkg/daemon"
 	"github.com/iotaledger/hornet/v2/pkg/database"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
@@ -33,6 +35,9 @@ func init() {
 var (
 	CoreComponent *app.CoreComponent
 	deps          dependencies
+
+	// the cold storage the pruned milestone cones are exported to (nil if disabled).
+	coldStorage *coldstorage.ColdStorage
 )
 
 type dependencies struct {
@@ -86,12 +91,45 @@ func provide(c *dig.Container) error {
 			CoreComponent.LogPanicf("%s has to be specified if %s is enabled", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Size.TargetSize)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Size.Enabled)))
 		}
 
+		pruningAgeEnabled := ParamsPruning.Age.Enabled
+		if pruningAgeEnabled && ParamsPruning.Age.MaxAge <= 0 {
+			CoreComponent.LogPanicf("%s has to be specified if %s is enabled", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Age.MaxAge)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Age.Enabled)))
+		}
+
+		if deps.Storage.UTXOManager().ArchiveEnabled() && (pruningMilestonesEnabled || pruningSizeEnabled || pruningAgeEnabled) {
+			CoreComponent.LogPanicf("%s, %s and %s have to be disabled in archive mode", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Milestones.Enabled)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Size.Enabled)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.Age.Enabled)))
+		}
+
+		if ParamsPruning.ColdStorage.Enabled {
+			if ParamsPruning.ColdStorage.MilestonesPerSegment <= 0 {
+				CoreComponent.LogPanicf("%s has to be greater than zero if %s is enabled", CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.ColdStorage.MilestonesPerSegment)), CoreComponent.App.Config().GetParameterPath(&(ParamsPruning.ColdStorage.Enabled)))
+			}
+
+			indexStore, err := database.StoreWithDefaultSettings(filepath.Join(ParamsPruning.ColdStorage.Path, "index"), true, deps.TangleDatabase.Engine())
+			if err != nil {
+				CoreComponent.LogPanicf("cold storage index initialization failed: %s", err)
+			}
+
+			coldStorage, err = coldstorage.New(
+				ParamsPruning.ColdStorage.Path,
+				indexStore,
+				coldstorage.WithMilestonesPerSegment(iotago.MilestoneIndex(ParamsPruning.ColdStorage.MilestonesPerSegment)),
+			)
+			if err != nil {
+				CoreComponent.LogPanicf("cold storage initialization failed: %s", err)
+			}
+
+			// pruned blocks are served from the cold storage
+			deps.Storage.SetColdStorage(coldStorage)
+		}
+
 		return pruning.NewPruningManager(
 			CoreComponent.Logger(),
 			deps.Storage,
 			deps.SyncManager,
 			deps.TangleDatabase,
 			deps.UTXODatabase,
+			coldStorage,
 			deps.SnapshotManager.MinimumMilestoneIndex,
 			pruningMilestonesEnabled,
 			pruningMilestonesMaxMilestonesToKeep,
@@ -99,6 +137,8 @@ func provide(c *dig.Container) error {
 			pruningTargetDatabaseSizeBytes,
 			ParamsPruning.Size.ThresholdPercentage,
 			ParamsPruning.Size.CooldownTime,
+			pruningAgeEnabled,
+			ParamsPruning.Age.MaxAge,
 			deps.PruningPruneReceipts,
 		)
 	})
@@ -118,6 +158,12 @@ func run() error {
 
 		CoreComponent.LogInfo("Stopping pruning background worker...")
 		deps.SnapshotManager.Events.HandledConfirmedMilestoneIndexChanged.Detach(onSnapshotHandledConfirmedMilestoneIndexChanged)
+
+		if coldStorage != nil {
+			if err := coldStorage.Close(); err != nil {
+				CoreComponent.LogWarnf("closing cold storage failed: %s", err)
+			}
+		}
 		CoreComponent.LogInfo("Stopping pruning background worker... done")
 	}, daemon.PriorityPruning); err != nil {
 		CoreComponent.LogPanicf("failed to start worker: %s", err)
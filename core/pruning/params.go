@@ -24,6 +24,20 @@ type ParametersPruning struct {
 		// CooldownTime defines the cooldown time between two pruning by database size events
 		CooldownTime time.Duration `default:"5m" usage:"cooldown time between two pruning by database size events"`
 	}
+	Age struct {
+		// Enabled defines whether to delete old block data from the database based on the maximum age of the milestones to keep
+		Enabled bool `default:"false" usage:"whether to delete old block data from the database based on the maximum age of the milestones to keep"`
+		// MaxAge defines the maximum age of the milestone cones to keep in the database
+		MaxAge time.Duration `default:"720h" usage:"maximum age of the milestone cones to keep in the database"`
+	}
+	ColdStorage struct {
+		// Enabled defines whether to export pruned milestone cones to the cold storage
+		Enabled bool `default:"false" usage:"whether to export pruned milestone cones to the cold storage"`
+		// Path defines the path to the cold storage folder
+		Path string `default:"testnet/coldstorage" usage:"the path to the cold storage folder"`
+		// MilestonesPerSegment defines the amount of milestone cones stored in the same segment file
+		MilestonesPerSegment int `default:"10000" usage:"the amount of milestone cones stored in the same segment file"`
+	}
 
 	// PruneReceipts defines whether to delete old receipts data from the database
 	PruneReceipts bool `default:"false" usage:"whether to delete old receipts data from the database"`
@@ -186,6 +186,7 @@ func provide(c *dig.Container) error {
 			pruningThreshold,
 			snapshotDepth,
 			syncmanager.MilestoneIndexDelta(ParamsSnapshots.Interval),
+			ParamsSnapshots.Compression,
 		)
 	})
 }
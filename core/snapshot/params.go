@@ -21,6 +21,9 @@ type ParametersSnapshots struct {
 	// DeltaSizeThresholdMinSize defines the minimum size of the delta snapshot file before the threshold percentage condition is checked
 	// (below that size the delta snapshot is always created)
 	DeltaSizeThresholdMinSize string `default:"50M" usage:"the minimum size of the delta snapshot file before the threshold percentage condition is checked (below that size the delta snapshot is always created)"`
+	// Compression defines whether snapshot files are written in the compressed file format.
+	// Compressed snapshot files can't be read by older versions of HORNET and existing tools.
+	Compression bool `default:"false" usage:"whether snapshot files are written in the compressed file format (can't be read by older versions of HORNET and existing tools)"`
 	// DownloadURLs defines the URLs to load the snapshot files from.
 	DownloadURLs []*snapshot.DownloadTarget `noflag:"true" usage:"URLs to load the snapshot files from"`
 }
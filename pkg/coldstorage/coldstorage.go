@@ -0,0 +1,466 @@
+package coldstorage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the default amount of milestones whose cones are stored in the same segment file.
+	defaultMilestonesPerSegment = 10000
+
+	// the record types in the segment files.
+	recordTypeBlock     byte = 1
+	recordTypeMilestone byte = 2
+
+	// the prefixes of the keys in the index store.
+	indexKeyPrefixBlock     byte = 1
+	indexKeyPrefixMilestone byte = 2
+
+	// the length of a record location (segment index + offset).
+	recordLocationLength = 4 + 8
+)
+
+var (
+	// ErrMilestoneNotInColdStorage is returned if a milestone cone is not part of the cold storage.
+	ErrMilestoneNotInColdStorage = errors.New("milestone cone not found in cold storage")
+	// ErrInvalidRecord is returned if a record in a segment file is invalid.
+	ErrInvalidRecord = errors.New("invalid cold storage record")
+)
+
+// MilestoneCone holds the information about a milestone cone in the cold storage.
+type MilestoneCone struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex
+	// The IDs of the blocks that were pruned together with the milestone.
+	BlockIDs iotago.BlockIDs
+	// The ledger changes of the milestone, encoded like the milestone diffs in the snapshot files.
+	MilestoneDiff []byte
+}
+
+// Options define options for the ColdStorage.
+type Options struct {
+	// the amount of milestones whose cones are stored in the same segment file.
+	milestonesPerSegment iotago.MilestoneIndex
+}
+
+// applies the given Option.
+func (o *Options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithMilestonesPerSegment sets the amount of milestones whose cones are stored in the same segment file.
+func WithMilestonesPerSegment(milestonesPerSegment iotago.MilestoneIndex) Option {
+	return func(opts *Options) {
+		opts.milestonesPerSegment = milestonesPerSegment
+	}
+}
+
+// Option is a function setting an Options option.
+type Option func(opts *Options)
+
+// ColdStorage stores pruned milestone cones in append-only compressed segment files.
+// The location of every block and milestone in the segment files is kept in a separate index store.
+type ColdStorage struct {
+	// the directory the segment files are stored in.
+	directory string
+	// the store which holds the locations of the records.
+	indexStore kvstore.KVStore
+	// the options of the cold storage.
+	opts *Options
+
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+
+	// lock used to append to the segment files.
+	writeLock sync.Mutex
+}
+
+// New creates a new ColdStorage which stores the segment files in the given directory.
+func New(directory string, indexStore kvstore.KVStore, opts ...Option) (*ColdStorage, error) {
+
+	options := &Options{
+		milestonesPerSegment: defaultMilestonesPerSegment,
+	}
+	options.apply(opts...)
+
+	if options.milestonesPerSegment == 0 {
+		return nil, errors.New("milestones per segment must be greater than zero")
+	}
+
+	if err := os.MkdirAll(directory, 0700); err != nil {
+		return nil, fmt.Errorf("could not create cold storage directory '%s': %w", directory, err)
+	}
+
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ColdStorage{
+		directory:  directory,
+		indexStore: indexStore,
+		opts:       options,
+		encoder:    encoder,
+		decoder:    decoder,
+	}, nil
+}
+
+// Close closes the index store of the cold storage.
+func (cs *ColdStorage) Close() error {
+	cs.writeLock.Lock()
+	defer cs.writeLock.Unlock()
+
+	cs.decoder.Close()
+
+	if err := cs.indexStore.Flush(); err != nil {
+		return err
+	}
+
+	return cs.indexStore.Close()
+}
+
+func blockIndexKey(blockID iotago.BlockID) []byte {
+	ms := marshalutil.New(1 + iotago.BlockIDLength)
+	ms.WriteByte(indexKeyPrefixBlock) // 1 byte
+	ms.WriteBytes(blockID[:])         // 32 bytes
+	return ms.Bytes()
+}
+
+func milestoneIndexKey(msIndex iotago.MilestoneIndex) []byte {
+	ms := marshalutil.New(1 + 4)
+	ms.WriteByte(indexKeyPrefixMilestone) // 1 byte
+	ms.WriteUint32(msIndex)               // 4 bytes
+	return ms.Bytes()
+}
+
+// segmentIndex returns the index of the segment file the cone of the given milestone is stored in.
+func (cs *ColdStorage) segmentIndex(msIndex iotago.MilestoneIndex) uint32 {
+	return msIndex / cs.opts.milestonesPerSegment
+}
+
+func (cs *ColdStorage) segmentFilePath(segmentIndex uint32) string {
+	return filepath.Join(cs.directory, fmt.Sprintf("segment_%010d.bin", segmentIndex))
+}
+
+// HasMilestoneCone returns whether the cone of the given milestone is part of the cold storage.
+func (cs *ColdStorage) HasMilestoneCone(msIndex iotago.MilestoneIndex) (bool, error) {
+	return cs.indexStore.Has(milestoneIndexKey(msIndex))
+}
+
+// milestoneDiffBytes returns the ledger changes of the given milestone, encoded like the milestone diffs in the snapshot files.
+func milestoneDiffBytes(dbStorage *storage.Storage, msIndex iotago.MilestoneIndex) ([]byte, error) {
+
+	milestonePayload, err := snapshot.MilestoneRetrieverFromStorage(dbStorage)(msIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	dbStorage.UTXOManager().ReadLockLedger()
+	defer dbStorage.UTXOManager().ReadUnlockLedger()
+
+	diff, err := dbStorage.UTXOManager().MilestoneDiffWithoutLocking(msIndex)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read milestone diff of milestone %d: %w", msIndex, err)
+	}
+
+	return (&snapshot.MilestoneDiff{
+		Milestone:           milestonePayload,
+		Created:             diff.Outputs,
+		Consumed:            diff.Spents,
+		SpentTreasuryOutput: diff.SpentTreasuryOutput,
+	}).MarshalBinary()
+}
+
+// ExportMilestoneCone appends the cone of the given milestone to the cold storage.
+// The cone consists of the given blocks with their metadata and the ledger changes of the milestone.
+// This has to be called before the milestone is pruned from the database.
+// Cones that are already part of the cold storage are skipped.
+func (cs *ColdStorage) ExportMilestoneCone(dbStorage *storage.Storage, msIndex iotago.MilestoneIndex, blockIDs iotago.BlockIDs) error {
+	cs.writeLock.Lock()
+	defer cs.writeLock.Unlock()
+
+	exported, err := cs.HasMilestoneCone(msIndex)
+	if err != nil {
+		return err
+	}
+	if exported {
+		// the pruning of the milestone was aborted after the export
+		return nil
+	}
+
+	milestoneDiff, err := milestoneDiffBytes(dbStorage, msIndex)
+	if err != nil {
+		return err
+	}
+
+	segmentIndex := cs.segmentIndex(msIndex)
+
+	segmentFile, err := os.OpenFile(cs.segmentFilePath(segmentIndex), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to open segment file: %w", err)
+	}
+	defer func() { _ = segmentFile.Close() }()
+
+	fileInfo, err := segmentFile.Stat()
+	if err != nil {
+		return err
+	}
+	offset := uint64(fileInfo.Size())
+
+	// the locations are only added to the index after all records were written,
+	// so the records of an interrupted export are never referenced.
+	locations := make(map[string][]byte)
+
+	appendRecord := func(indexKey []byte, record []byte) error {
+		compressed := cs.encoder.EncodeAll(record, nil)
+
+		header := make([]byte, 4)
+		binary.LittleEndian.PutUint32(header, uint32(len(compressed)))
+
+		if _, err := segmentFile.Write(append(header, compressed...)); err != nil {
+			return fmt.Errorf("unable to write segment file: %w", err)
+		}
+
+		location := make([]byte, recordLocationLength)
+		binary.LittleEndian.PutUint32(location[:4], segmentIndex)
+		binary.LittleEndian.PutUint64(location[4:], offset)
+		locations[string(indexKey)] = location
+
+		offset += uint64(len(header) + len(compressed))
+
+		return nil
+	}
+
+	for _, blockID := range blockIDs {
+		record, err := blockRecord(dbStorage, blockID)
+		if err != nil {
+			return err
+		}
+		if record == nil {
+			// block was already deleted
+			continue
+		}
+
+		if err := appendRecord(blockIndexKey(blockID), record); err != nil {
+			return err
+		}
+	}
+
+	if err := appendRecord(milestoneIndexKey(msIndex), milestoneRecord(msIndex, blockIDs, milestoneDiff)); err != nil {
+		return err
+	}
+
+	if err := segmentFile.Sync(); err != nil {
+		return fmt.Errorf("unable to sync segment file: %w", err)
+	}
+
+	batch, err := cs.indexStore.Batched()
+	if err != nil {
+		return err
+	}
+
+	for key, location := range locations {
+		if err := batch.Set([]byte(key), location); err != nil {
+			batch.Cancel()
+			return err
+		}
+	}
+
+	return batch.Commit()
+}
+
+// blockRecord returns the record of the given block and its metadata.
+// Returns nil if the block is not part of the database.
+func blockRecord(dbStorage *storage.Storage, blockID iotago.BlockID) ([]byte, error) {
+
+	cachedBlock := dbStorage.CachedBlockOrNil(blockID) // block +1
+	if cachedBlock == nil {
+		return nil, nil
+	}
+	defer cachedBlock.Release(true) // block -1
+
+	metadataBytes := cachedBlock.Metadata().ObjectStorageValue()
+	blockBytes := cachedBlock.Block().Data()
+
+	ms := marshalutil.New(1 + iotago.BlockIDLength + 4 + len(metadataBytes) + 4 + len(blockBytes))
+	ms.WriteByte(recordTypeBlock)              // 1 byte
+	ms.WriteBytes(blockID[:])                  // 32 bytes
+	ms.WriteUint32(uint32(len(metadataBytes))) // 4 bytes
+	ms.WriteBytes(metadataBytes)               // metadata bytes
+	ms.WriteUint32(uint32(len(blockBytes)))    // 4 bytes
+	ms.WriteBytes(blockBytes)                  // block bytes
+	return ms.Bytes(), nil
+}
+
+// milestoneRecord returns the record of the given milestone cone.
+func milestoneRecord(msIndex iotago.MilestoneIndex, blockIDs iotago.BlockIDs, milestoneDiff []byte) []byte {
+	ms := marshalutil.New(1 + 4 + 4 + len(blockIDs)*iotago.BlockIDLength + 4 + len(milestoneDiff))
+	ms.WriteByte(recordTypeMilestone)     // 1 byte
+	ms.WriteUint32(msIndex)               // 4 bytes
+	ms.WriteUint32(uint32(len(blockIDs))) // 4 bytes
+	for _, blockID := range blockIDs {
+		ms.WriteBytes(blockID[:]) // 32 bytes
+	}
+	ms.WriteUint32(uint32(len(milestoneDiff))) // 4 bytes
+	ms.WriteBytes(milestoneDiff)               // milestone diff bytes
+	return ms.Bytes()
+}
+
+// readRecord reads the record at the location stored under the given index key.
+func (cs *ColdStorage) readRecord(indexKey []byte, recordType byte) (*marshalutil.MarshalUtil, error) {
+
+	location, err := cs.indexStore.Get(indexKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(location) != recordLocationLength {
+		return nil, errors.Wrap(ErrInvalidRecord, "invalid location length")
+	}
+
+	segmentIndex := binary.LittleEndian.Uint32(location[:4])
+	offset := int64(binary.LittleEndian.Uint64(location[4:]))
+
+	segmentFile, err := os.Open(cs.segmentFilePath(segmentIndex))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open segment file: %w", err)
+	}
+	defer func() { _ = segmentFile.Close() }()
+
+	header := make([]byte, 4)
+	if _, err := segmentFile.ReadAt(header, offset); err != nil {
+		return nil, fmt.Errorf("unable to read record header: %w", err)
+	}
+
+	compressed := make([]byte, binary.LittleEndian.Uint32(header))
+	n, err := segmentFile.ReadAt(compressed, offset+int64(len(header)))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("unable to read record: %w", err)
+	}
+	if n < len(compressed) {
+		return nil, errors.Wrapf(ErrInvalidRecord, "segment %d is truncated, record at offset %d is incomplete (%d/%d bytes)", segmentIndex, offset, n, len(compressed))
+	}
+
+	record, err := cs.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress record: %w", err)
+	}
+
+	ms := marshalutil.New(record)
+	readRecordType, err := ms.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if readRecordType != recordType {
+		return nil, errors.Wrapf(ErrInvalidRecord, "invalid record type: %d != %d", readRecordType, recordType)
+	}
+
+	return ms, nil
+}
+
+// Block returns the block and its metadata at the time it was pruned.
+// Returns storage.ErrBlockNotInColdStorage if the block is not part of the cold storage.
+func (cs *ColdStorage) Block(blockID iotago.BlockID) (*storage.Block, *storage.BlockMetadata, error) {
+
+	ms, err := cs.readRecord(blockIndexKey(blockID), recordTypeBlock)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, nil, errors.Wrapf(storage.ErrBlockNotInColdStorage, "block ID: %s", blockID.ToHex())
+		}
+		return nil, nil, err
+	}
+
+	recordBlockID, err := ms.ReadBytes(iotago.BlockIDLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadataBytes, err := readLengthPrefixedBytes(ms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blockBytes, err := readLengthPrefixedBytes(ms)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := storage.MetadataFactory(recordBlockID, metadataBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	block, err := storage.BlockFactory(recordBlockID, blockBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return block.(*storage.Block), metadata.(*storage.BlockMetadata), nil
+}
+
+// MilestoneCone returns the cone of the given milestone.
+// Returns ErrMilestoneNotInColdStorage if the milestone cone is not part of the cold storage.
+func (cs *ColdStorage) MilestoneCone(msIndex iotago.MilestoneIndex) (*MilestoneCone, error) {
+
+	ms, err := cs.readRecord(milestoneIndexKey(msIndex), recordTypeMilestone)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, errors.Wrapf(ErrMilestoneNotInColdStorage, "index: %d", msIndex)
+		}
+		return nil, err
+	}
+
+	cone := &MilestoneCone{}
+	if cone.Index, err = ms.ReadUint32(); err != nil {
+		return nil, err
+	}
+
+	blockIDsCount, err := ms.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+
+	cone.BlockIDs = make(iotago.BlockIDs, blockIDsCount)
+	for i := range cone.BlockIDs {
+		blockIDBytes, err := ms.ReadBytes(iotago.BlockIDLength)
+		if err != nil {
+			return nil, err
+		}
+		copy(cone.BlockIDs[i][:], blockIDBytes)
+	}
+
+	if cone.MilestoneDiff, err = readLengthPrefixedBytes(ms); err != nil {
+		return nil, err
+	}
+
+	return cone, nil
+}
+
+func readLengthPrefixedBytes(ms *marshalutil.MarshalUtil) ([]byte, error) {
+	length, err := ms.ReadUint32()
+	if err != nil {
+		return nil, err
+	}
+
+	return ms.ReadBytes(int(length))
+}
@@ -0,0 +1,135 @@
+package coldstorage_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/coldstorage"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	ProtocolVersion = 2
+	BelowMaxDepth   = 5
+	MinPoWScore     = 10
+)
+
+func TestExportAndReadMilestoneCones(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	milestoneCones := make(map[iotago.MilestoneIndex]iotago.BlockIDs)
+
+	// build a tangle with 5 milestones and 5 - 10 blocks between the milestones
+	_, _ = te.BuildTangle(5, BelowMaxDepth, 5, 5, 10,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		func(msIndex iotago.MilestoneIndex, _ iotago.BlockIDs, conf *whiteflag.Confirmation, _ *whiteflag.ConfirmedMilestoneStats) {
+			blockIDs := make(iotago.BlockIDs, 0, len(conf.Mutations.ReferencedBlocks))
+			for _, referencedBlock := range conf.Mutations.ReferencedBlocks {
+				blockIDs = append(blockIDs, referencedBlock.BlockID)
+			}
+			milestoneCones[msIndex] = blockIDs
+		},
+	)
+	require.NotEmpty(t, milestoneCones)
+
+	// store two milestones per segment file to test reads across segments
+	coldStorage, err := coldstorage.New(t.TempDir(), mapdb.NewMapDB(), coldstorage.WithMilestonesPerSegment(2))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, coldStorage.Close()) }()
+
+	for msIndex, blockIDs := range milestoneCones {
+		require.NoError(t, coldStorage.ExportMilestoneCone(te.Storage(), msIndex, blockIDs))
+
+		// exporting the same cone again is a no-op
+		require.NoError(t, coldStorage.ExportMilestoneCone(te.Storage(), msIndex, blockIDs))
+	}
+
+	for msIndex, blockIDs := range milestoneCones {
+		cone, err := coldStorage.MilestoneCone(msIndex)
+		require.NoError(t, err)
+		require.Equal(t, msIndex, cone.Index)
+		require.ElementsMatch(t, blockIDs, cone.BlockIDs)
+		require.NotEmpty(t, cone.MilestoneDiff)
+
+		for _, blockID := range blockIDs {
+			block, metadata, err := coldStorage.Block(blockID)
+			require.NoError(t, err)
+
+			cachedBlock := te.Storage().CachedBlockOrNil(blockID) // block +1
+			require.NotNil(t, cachedBlock)
+			require.Equal(t, cachedBlock.Block().Data(), block.Data())
+			require.Equal(t, cachedBlock.Metadata().ObjectStorageValue(), metadata.ObjectStorageValue())
+			cachedBlock.Release(true) // block -1
+
+			referenced, referencedIndex := metadata.ReferencedWithIndex()
+			require.True(t, referenced)
+			require.Equal(t, msIndex, referencedIndex)
+		}
+	}
+
+	_, err = coldStorage.MilestoneCone(te.LastMilestoneIndex() + 1)
+	require.ErrorIs(t, err, coldstorage.ErrMilestoneNotInColdStorage)
+
+	_, _, err = coldStorage.Block(iotago.EmptyBlockID())
+	require.ErrorIs(t, err, storage.ErrBlockNotInColdStorage)
+}
+
+func TestReadTruncatedSegment(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	var msIndex iotago.MilestoneIndex
+	var blockIDs iotago.BlockIDs
+
+	_, _ = te.BuildTangle(5, BelowMaxDepth, 3, 5, 10,
+		nil,
+		func(blockIDs iotago.BlockIDs, blockIDsPerMilestones []iotago.BlockIDs) iotago.BlockIDs {
+			return iotago.BlockIDs{blockIDs[len(blockIDs)-1]}
+		},
+		func(index iotago.MilestoneIndex, _ iotago.BlockIDs, conf *whiteflag.Confirmation, _ *whiteflag.ConfirmedMilestoneStats) {
+			msIndex = index
+			blockIDs = make(iotago.BlockIDs, 0, len(conf.Mutations.ReferencedBlocks))
+			for _, referencedBlock := range conf.Mutations.ReferencedBlocks {
+				blockIDs = append(blockIDs, referencedBlock.BlockID)
+			}
+		},
+	)
+	require.NotEmpty(t, blockIDs)
+
+	directory := t.TempDir()
+	coldStorage, err := coldstorage.New(directory, mapdb.NewMapDB())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, coldStorage.Close()) }()
+
+	require.NoError(t, coldStorage.ExportMilestoneCone(te.Storage(), msIndex, blockIDs))
+
+	segmentFiles, err := filepath.Glob(filepath.Join(directory, "segment_*.bin"))
+	require.NoError(t, err)
+	require.Len(t, segmentFiles, 1)
+
+	fileInfo, err := os.Stat(segmentFiles[0])
+	require.NoError(t, err)
+
+	// the milestone record is the last record of the segment
+	require.NoError(t, os.Truncate(segmentFiles[0], fileInfo.Size()-1))
+
+	_, err = coldStorage.MilestoneCone(msIndex)
+	require.ErrorIs(t, err, coldstorage.ErrInvalidRecord)
+
+	// the records before the truncated one can still be read
+	_, _, err = coldStorage.Block(blockIDs[0])
+	require.NoError(t, err)
+}
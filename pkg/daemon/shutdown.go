@@ -26,7 +26,8 @@ const (
 	PriorityPruning
 	PriorityMetricsUpdater
 	PriorityPoWHandler
-	PriorityRestAPI // depends on PriorityPoWHandler
+	PriorityRestAPI  // depends on PriorityPoWHandler
+	PriorityPromoter // depends on PriorityPoWHandler, PriorityTipselection
 	PriorityIndexer
 	PriorityStatusReport
 	PriorityPrometheus
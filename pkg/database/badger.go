@@ -0,0 +1,85 @@
+package database
+
+import (
+	"runtime"
+
+	badgerDB "github.com/dgraph-io/badger/v2"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore/badger"
+)
+
+const (
+	// badgerValueLogGCDiscardRatio is the ratio of stale data in a value log file
+	// at which the file gets rewritten during the garbage collection.
+	badgerValueLogGCDiscardRatio = 0.5
+)
+
+// NewBadgerDB creates a new badger DB instance.
+// Badger is written in pure Go, so it can be used in statically linked builds without cgo.
+func NewBadgerDB(directory string) (*badgerDB.DB, error) {
+
+	opts := badgerDB.DefaultOptions(directory)
+
+	// Logger is the logger for badger internal messages.
+	// The internal messages are not needed, errors are returned to the caller.
+	opts.Logger = nil
+
+	// SyncWrites syncs all writes to disk immediately.
+	// The writes are synced on flush and close instead, similar to the disabled WAL of pebble.
+	//
+	// The default value is true.
+	opts.SyncWrites = false
+
+	// NumVersionsToKeep is the number of versions of a key that are kept.
+	//
+	// The default value is 1.
+	opts.NumVersionsToKeep = 1
+
+	// ValueLogFileSize is the maximum size of a single value log file.
+	// Smaller files can be rewritten faster by the value log garbage collection.
+	//
+	// The default value is 1 GB.
+	opts.ValueLogFileSize = 256 << 20 // 256 MB
+
+	// CompactL0OnClose compacts level 0 on close, so the database is smaller on the next start.
+	//
+	// The default value is true.
+	opts.CompactL0OnClose = true
+
+	// Truncate indicates whether value log files should be truncated to delete corrupt data.
+	// Windows does not support opening value log files without truncating.
+	//
+	// The default value is false.
+	opts.Truncate = runtime.GOOS == "windows"
+
+	return badger.CreateDB(directory, opts)
+}
+
+// RunBadgerValueLogGC runs the value log garbage collection of the given badger DB
+// until no more value log files can be rewritten. The LSM tree is compacted automatically by badger,
+// but the space of deleted values in the value log is only reclaimed by the garbage collection.
+func RunBadgerValueLogGC(db *badgerDB.DB, reportCompactionRunning func(running bool)) error {
+	if reportCompactionRunning != nil {
+		reportCompactionRunning(true)
+		defer reportCompactionRunning(false)
+	}
+
+	for {
+		if err := db.RunValueLogGC(badgerValueLogGCDiscardRatio); err != nil {
+			if errors.Is(err, badgerDB.ErrNoRewrite) {
+				// no more value log files to rewrite
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// BadgerGarbageCollectionFunc returns a GarbageCollectionFunc which runs the value log garbage collection of badger.
+func BadgerGarbageCollectionFunc(db *badgerDB.DB, reportCompactionRunning func(running bool)) GarbageCollectionFunc {
+	return func() error {
+		return RunBadgerValueLogGC(db, reportCompactionRunning)
+	}
+}
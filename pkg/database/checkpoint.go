@@ -0,0 +1,274 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	pebbleDB "github.com/cockroachdb/pebble"
+	badgerDB "github.com/dgraph-io/badger/v2"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/ioutils"
+	"github.com/iotaledger/hive.go/kvstore"
+)
+
+const (
+	// the amount of entries that are written to the checkpoint at once if the engine has no native checkpoints.
+	checkpointBatchSize = 10000
+)
+
+var (
+	// ErrCheckpointNotSupported is returned if the database engine does not support checkpoints.
+	ErrCheckpointNotSupported = errors.New("database engine does not support checkpoints")
+	// ErrCheckpointExists is returned if the target directory of a checkpoint already exists.
+	ErrCheckpointExists = errors.New("checkpoint directory already exists")
+)
+
+// CheckpointFunc creates a consistent checkpoint of the database in the given directory,
+// using the native checkpoint facility of the database engine.
+// The immutable files of the engine are hard linked if possible, so the checkpoint is cheap to create.
+type CheckpointFunc func(directory string) error
+
+// Snapshot is a consistent read-only view of the database.
+type Snapshot interface {
+	// Iterate iterates over all entries of the snapshot.
+	Iterate(consumerFunc kvstore.IteratorKeyValueConsumerFunc) error
+	// Release releases the snapshot.
+	Release()
+}
+
+// SnapshotFunc opens a consistent read-only view of the database.
+// It is used to create checkpoints of engines without native checkpoints.
+type SnapshotFunc func() (Snapshot, error)
+
+// GarbageCollectionFunc reclaims the disk space of deleted entries
+// that is not reclaimed by the compaction of the database engine.
+type GarbageCollectionFunc func() error
+
+// Options define options for the Database.
+type Options struct {
+	// the function to create native checkpoints of the database.
+	checkpointFunc CheckpointFunc
+	// the function to open snapshots of the database.
+	snapshotFunc SnapshotFunc
+	// the function to reclaim the disk space of deleted entries.
+	garbageCollectionFunc GarbageCollectionFunc
+}
+
+// applies the given Option.
+func (o *Options) apply(opts ...Option) {
+	for _, opt := range opts {
+		opt(o)
+	}
+}
+
+// WithCheckpointFunc sets the function to create native checkpoints of the database.
+func WithCheckpointFunc(checkpointFunc CheckpointFunc) Option {
+	return func(opts *Options) {
+		opts.checkpointFunc = checkpointFunc
+	}
+}
+
+// WithSnapshotFunc sets the function to open snapshots of the database.
+// It is used to create checkpoints if the engine has no native checkpoints,
+// by copying the entries of the snapshot.
+func WithSnapshotFunc(snapshotFunc SnapshotFunc) Option {
+	return func(opts *Options) {
+		opts.snapshotFunc = snapshotFunc
+	}
+}
+
+// WithGarbageCollectionFunc sets the function to reclaim the disk space of deleted entries.
+func WithGarbageCollectionFunc(garbageCollectionFunc GarbageCollectionFunc) Option {
+	return func(opts *Options) {
+		opts.garbageCollectionFunc = garbageCollectionFunc
+	}
+}
+
+// Option is a function setting an Options option.
+type Option func(opts *Options)
+
+// PebbleCheckpointFunc returns a CheckpointFunc which uses the native checkpoints of pebble.
+// The sstables are hard linked into the checkpoint if possible.
+func PebbleCheckpointFunc(db *pebbleDB.DB) CheckpointFunc {
+	return func(directory string) error {
+		// the WAL is disabled, so only the flushed memtables are part of the checkpoint.
+		// the database is flushed before the checkpoint is created.
+		return db.Checkpoint(directory, pebbleDB.WithFlushedWAL())
+	}
+}
+
+type badgerSnapshot struct {
+	txn *badgerDB.Txn
+}
+
+func (s *badgerSnapshot) Iterate(consumerFunc kvstore.IteratorKeyValueConsumerFunc) error {
+	it := s.txn.NewIterator(badgerDB.DefaultIteratorOptions)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+
+		value, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		if !consumerFunc(item.KeyCopy(nil), value) {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *badgerSnapshot) Release() {
+	s.txn.Discard()
+}
+
+// BadgerSnapshotFunc returns a SnapshotFunc which uses read-only transactions of badger.
+// A transaction reads the state of the database at the time it was opened.
+func BadgerSnapshotFunc(db *badgerDB.DB) SnapshotFunc {
+	return func() (Snapshot, error) {
+		return &badgerSnapshot{txn: db.NewTransaction(false)}, nil
+	}
+}
+
+// PreparedCheckpoint is a checkpoint of the database whose state was already fixed,
+// but which may not be written to its directory yet.
+type PreparedCheckpoint struct {
+	db        *Database
+	directory string
+	// the snapshot that is copied to the checkpoint, nil if the native checkpoint was already created.
+	snapshot Snapshot
+}
+
+// PrepareCheckpoint fixes the state of a checkpoint of the database in the given directory.
+// The directory must not exist yet. The checkpoint can be opened as a database with the same engine.
+// Native checkpoints are created immediately, otherwise a snapshot of the database is opened,
+// which is copied to the checkpoint by Write. The database may be written after this call returns.
+func (db *Database) PrepareCheckpoint(directory string) (*PreparedCheckpoint, error) {
+	if db.opts.checkpointFunc == nil && db.opts.snapshotFunc == nil {
+		return nil, errors.Wrapf(ErrCheckpointNotSupported, "engine: %s", db.engine)
+	}
+
+	exists, err := ioutils.PathExists(directory)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errors.Wrapf(ErrCheckpointExists, "checkpoint directory: %s", directory)
+	}
+
+	if err := db.store.Flush(); err != nil {
+		return nil, fmt.Errorf("flushing the database failed: %w", err)
+	}
+
+	checkpoint := &PreparedCheckpoint{
+		db:        db,
+		directory: directory,
+	}
+
+	if db.opts.checkpointFunc != nil {
+		if err := db.opts.checkpointFunc(directory); err != nil {
+			return nil, err
+		}
+
+		return checkpoint, nil
+	}
+
+	checkpoint.snapshot, err = db.opts.snapshotFunc()
+	if err != nil {
+		return nil, fmt.Errorf("opening a snapshot of the database failed: %w", err)
+	}
+
+	return checkpoint, nil
+}
+
+// Write writes the prepared checkpoint to its directory.
+// The snapshot of the database is released afterwards.
+func (c *PreparedCheckpoint) Write(ctx context.Context) error {
+	if c.snapshot != nil {
+		defer c.Discard()
+
+		if err := c.copySnapshot(ctx); err != nil {
+			return err
+		}
+	}
+
+	// the checkpoints only contain the files of the engine
+	return storeDatabaseInfoToFile(filepath.Join(c.directory, "dbinfo"), c.db.engine)
+}
+
+// Discard releases the snapshot of a checkpoint that is not written.
+func (c *PreparedCheckpoint) Discard() {
+	if c.snapshot != nil {
+		c.snapshot.Release()
+		c.snapshot = nil
+	}
+}
+
+// copySnapshot copies all entries of the snapshot to a new database in the directory of the checkpoint.
+func (c *PreparedCheckpoint) copySnapshot(ctx context.Context) error {
+
+	target, err := StoreWithDefaultSettings(c.directory, true, c.db.engine)
+	if err != nil {
+		return fmt.Errorf("checkpoint database initialization failed: %w", err)
+	}
+	defer func() { _ = target.Close() }()
+
+	batch, err := target.Batched()
+	if err != nil {
+		return err
+	}
+
+	entries := 0
+	var innerErr error
+	if err := c.snapshot.Iterate(func(key kvstore.Key, value kvstore.Value) bool {
+		if innerErr = ctx.Err(); innerErr != nil {
+			return false
+		}
+
+		if innerErr = batch.Set(key, value); innerErr != nil {
+			return false
+		}
+
+		entries++
+		if entries%checkpointBatchSize == 0 {
+			if innerErr = batch.Commit(); innerErr != nil {
+				return false
+			}
+
+			batch, innerErr = target.Batched()
+		}
+
+		return innerErr == nil
+	}); err != nil {
+		innerErr = err
+	}
+
+	if innerErr != nil {
+		if batch != nil {
+			batch.Cancel()
+		}
+		return innerErr
+	}
+
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+
+	return target.Flush()
+}
+
+// Checkpoint creates a consistent checkpoint of the database in the given directory.
+// The directory must not exist yet. The checkpoint can be opened as a database with the same engine.
+func (db *Database) Checkpoint(directory string) error {
+	checkpoint, err := db.PrepareCheckpoint(directory)
+	if err != nil {
+		return err
+	}
+
+	return checkpoint.Write(context.Background())
+}
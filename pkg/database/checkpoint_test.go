@@ -0,0 +1,48 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/database"
+)
+
+func TestDatabaseCheckpoint(t *testing.T) {
+	for _, engine := range []database.Engine{database.EnginePebble, database.EngineBadger} {
+		t.Run(string(engine), func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "database")
+			checkpointPath := filepath.Join(t.TempDir(), "checkpoint")
+
+			// pebble uses native checkpoints, badger copies the entries of a snapshot
+			db, err := database.DatabaseWithDefaultSettings(dbPath, true, engine)
+			require.NoError(t, err)
+			defer func() { _ = db.KVStore().Close() }()
+
+			for i := byte(0); i < 100; i++ {
+				require.NoError(t, db.KVStore().Set([]byte{i}, []byte{i}))
+			}
+
+			prepared, err := db.PrepareCheckpoint(checkpointPath)
+			require.NoError(t, err)
+
+			// writes after the checkpoint was prepared are not part of it
+			require.NoError(t, db.KVStore().Set([]byte{0}, []byte("new")))
+
+			require.NoError(t, prepared.Write(context.Background()))
+			require.ErrorIs(t, db.Checkpoint(checkpointPath), database.ErrCheckpointExists)
+
+			checkpoint, err := database.StoreWithDefaultSettings(checkpointPath, false)
+			require.NoError(t, err)
+			defer func() { _ = checkpoint.Close() }()
+
+			for i := byte(0); i < 100; i++ {
+				value, err := checkpoint.Get([]byte{i})
+				require.NoError(t, err)
+				require.Equal(t, []byte{i}, value)
+			}
+		})
+	}
+}
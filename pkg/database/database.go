@@ -2,6 +2,7 @@ package database
 
 import (
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ const (
 	EngineAuto    Engine = "auto"
 	EngineRocksDB Engine = "rocksdb"
 	EnginePebble  Engine = "pebble"
+	EngineBadger  Engine = "badger"
 	EngineMapDB   Engine = "mapdb"
 )
 
@@ -65,28 +67,36 @@ type Events struct {
 // Database holds the underlying KVStore and database specific functions.
 type Database struct {
 	databaseDir           string
-	store                 kvstore.KVStore
+	store                 *mirrorStore
 	engine                Engine
 	metrics               *metrics.DatabaseMetrics
 	events                *Events
 	compactionSupported   bool
 	compactionRunningFunc func() bool
+	opts                  *Options
+	garbageCollectionLock sync.Mutex
 }
 
 // New creates a new Database instance.
-func New(databaseDirectory string, kvStore kvstore.KVStore, engine Engine, metrics *metrics.DatabaseMetrics, events *Events, compactionSupported bool, compactionRunningFunc func() bool) *Database {
+func New(databaseDirectory string, kvStore kvstore.KVStore, engine Engine, metrics *metrics.DatabaseMetrics, events *Events, compactionSupported bool, compactionRunningFunc func() bool, opts ...Option) *Database {
+
+	options := &Options{}
+	options.apply(opts...)
+
 	return &Database{
 		databaseDir:           databaseDirectory,
-		store:                 kvStore,
+		store:                 newMirrorStore(kvStore),
 		engine:                engine,
 		metrics:               metrics,
 		events:                events,
 		compactionSupported:   compactionSupported,
 		compactionRunningFunc: compactionRunningFunc,
+		opts:                  options,
 	}
 }
 
 // KVStore returns the underlying KVStore.
+// All writes to the KVStore are mirrored to the target store of an online migration.
 func (db *Database) KVStore() kvstore.KVStore {
 	return db.store
 }
@@ -119,6 +129,26 @@ func (db *Database) CompactionRunning() bool {
 	return db.compactionRunningFunc()
 }
 
+// GarbageCollectionSupported returns whether the database engine needs a garbage collection
+// to reclaim the disk space of deleted entries.
+func (db *Database) GarbageCollectionSupported() bool {
+	return db.opts.garbageCollectionFunc != nil
+}
+
+// RunGarbageCollection reclaims the disk space of deleted entries.
+// The size of the database is only reduced after the garbage collection finished,
+// so it should be run after big amounts of entries were deleted, e.g. by pruning.
+func (db *Database) RunGarbageCollection() error {
+	if db.opts.garbageCollectionFunc == nil {
+		return nil
+	}
+
+	db.garbageCollectionLock.Lock()
+	defer db.garbageCollectionLock.Unlock()
+
+	return db.opts.garbageCollectionFunc()
+}
+
 // Size returns the size of the database.
 func (db *Database) Size() (int64, error) {
 	if db.engine == EngineMapDB {
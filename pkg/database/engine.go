@@ -10,6 +10,7 @@ import (
 
 	"github.com/iotaledger/hive.go/ioutils"
 	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/badger"
 	"github.com/iotaledger/hive.go/kvstore/mapdb"
 	"github.com/iotaledger/hive.go/kvstore/pebble"
 	"github.com/iotaledger/hive.go/kvstore/rocksdb"
@@ -35,10 +36,12 @@ func DatabaseEngineFromString(engineStr string) (Engine, error) {
 		return EngineRocksDB, nil
 	case EnginePebble:
 		return EnginePebble, nil
+	case EngineBadger:
+		return EngineBadger, nil
 	case EngineMapDB:
 		return EngineMapDB, nil
 	default:
-		return EngineUnknown, fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/mapdb/auto", dbEngine)
+		return EngineUnknown, fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/badger/mapdb/auto", dbEngine)
 	}
 }
 
@@ -64,9 +67,10 @@ func DatabaseEngineAllowed(dbEngine Engine, allowedEngines ...Engine) (Engine, e
 	switch dbEngine {
 	case EngineRocksDB:
 	case EnginePebble:
+	case EngineBadger:
 	case EngineMapDB:
 	default:
-		return "", fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/mapdb", dbEngine)
+		return "", fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/badger/mapdb", dbEngine)
 	}
 
 	return dbEngine, nil
@@ -205,10 +209,58 @@ func StoreWithDefaultSettings(path string, createDatabaseIfNotExists bool, dbEng
 		}
 		return rocksdb.New(db), nil
 
+	case EngineBadger:
+		db, err := NewBadgerDB(path)
+		if err != nil {
+			return nil, err
+		}
+		return badger.New(db), nil
+
 	case EngineMapDB:
 		return mapdb.NewMapDB(), nil
 
 	default:
-		return nil, fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/mapdb", dbEngine)
+		return nil, fmt.Errorf("unknown database engine: %s, supported engines: pebble/rocksdb/badger/mapdb", dbEngine)
+	}
+}
+
+// DatabaseWithDefaultSettings returns a Database with default settings.
+// It also checks if the database engine is correct.
+// Native checkpoints are used for pebble and RocksDB databases, snapshots for badger databases.
+func DatabaseWithDefaultSettings(path string, createDatabaseIfNotExists bool, dbEngine ...Engine) (*Database, error) {
+
+	targetEngine, err := CheckDatabaseEngine(path, createDatabaseIfNotExists, dbEngine...)
+	if err != nil {
+		return nil, err
 	}
+
+	switch targetEngine {
+	case EnginePebble:
+		db, err := NewPebbleDB(path, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		return New(path, pebble.New(db), targetEngine, nil, nil, false, nil, WithCheckpointFunc(PebbleCheckpointFunc(db))), nil
+
+	case EngineRocksDB:
+		db, err := NewRocksDB(path)
+		if err != nil {
+			return nil, err
+		}
+		return New(path, rocksdb.New(db), targetEngine, nil, nil, false, nil), nil
+
+	case EngineBadger:
+		db, err := NewBadgerDB(path)
+		if err != nil {
+			return nil, err
+		}
+		return New(path, badger.New(db), targetEngine, nil, nil, false, nil, WithSnapshotFunc(BadgerSnapshotFunc(db)), WithGarbageCollectionFunc(BadgerGarbageCollectionFunc(db, nil))), nil
+	}
+
+	store, err := StoreWithDefaultSettings(path, createDatabaseIfNotExists, targetEngine)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(path, store, targetEngine, nil, nil, false, nil), nil
 }
@@ -0,0 +1,107 @@
+package database_test
+
+import (
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	badgerDB "github.com/dgraph-io/badger/v2"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/badger"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+)
+
+func TestStoreWithDefaultSettingsBadger(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "badger")
+
+	dbEngine, err := database.DatabaseEngineFromString("Badger")
+	require.NoError(t, err)
+	require.Equal(t, database.EngineBadger, dbEngine)
+
+	store, err := database.StoreWithDefaultSettings(dbPath, true, dbEngine)
+	require.NoError(t, err)
+
+	require.NoError(t, store.Set([]byte("key"), []byte("value")))
+	require.NoError(t, store.Flush())
+	require.NoError(t, store.Close())
+
+	// the engine is detected from the database info file
+	store, err = database.StoreWithDefaultSettings(dbPath, false)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	value, err := store.Get([]byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), value)
+
+	// the engine of an existing database can't be changed
+	_, err = database.CheckDatabaseEngine(dbPath, false, database.EnginePebble)
+	require.Error(t, err)
+}
+
+func TestBadgerGarbageCollectionAfterPruning(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "badger")
+
+	// small value log files and memtables, so the deleted values are spread over several files that can be rewritten.
+	opts := badgerDB.DefaultOptions(dbPath)
+	opts.Logger = nil
+	opts.ValueLogFileSize = 1 << 20
+	opts.ValueThreshold = 1 << 10
+	opts.MaxTableSize = 16 << 10
+
+	badgerInstance, err := badger.CreateDB(dbPath, opts)
+	require.NoError(t, err)
+
+	db := database.New(dbPath, badger.New(badgerInstance), database.EngineBadger, nil, nil, true, nil,
+		database.WithGarbageCollectionFunc(database.BadgerGarbageCollectionFunc(badgerInstance, nil)))
+	defer func() { _ = db.KVStore().Close() }()
+	require.True(t, db.GarbageCollectionSupported())
+
+	const entriesCount = 512
+	value := make([]byte, 32<<10)
+
+	key := func(i int) []byte {
+		k := make([]byte, 4)
+		binary.LittleEndian.PutUint32(k, uint32(i))
+		return k
+	}
+
+	for i := 0; i < entriesCount; i++ {
+		require.NoError(t, db.KVStore().Set(key(i), value))
+	}
+	require.NoError(t, db.KVStore().Flush())
+
+	sizeBeforePruning, err := db.Size()
+	require.NoError(t, err)
+
+	// prune most of the entries
+	for i := 0; i < entriesCount-entriesCount/8; i++ {
+		require.NoError(t, db.KVStore().Delete(key(i)))
+	}
+	require.NoError(t, db.KVStore().Flush())
+
+	// the size is not reduced before the garbage collection was run
+	sizeAfterPruning, err := db.Size()
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, sizeAfterPruning, sizeBeforePruning)
+
+	// badger only rewrites the value log files behind the head of the flushed memtables,
+	// and picks the files by the discard statistics of the compactions, which both run in the background.
+	require.Eventually(t, func() bool {
+		require.NoError(t, db.RunGarbageCollection())
+
+		sizeAfterGarbageCollection, err := db.Size()
+		require.NoError(t, err)
+
+		return sizeAfterGarbageCollection < sizeBeforePruning/2
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// the remaining entries are still available
+	for i := entriesCount - entriesCount/8; i < entriesCount; i++ {
+		has, err := db.KVStore().Has(key(i))
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+}
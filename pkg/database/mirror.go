@@ -0,0 +1,625 @@
+package database
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+
+	"github.com/iotaledger/hive.go/byteutils"
+	"github.com/iotaledger/hive.go/kvstore"
+)
+
+var (
+	// ErrMirroringActive is returned if the writes of a database are already mirrored to another store.
+	ErrMirroringActive = errors.New("database writes are already mirrored")
+	// ErrNotMirroring is returned if the writes of a database are not mirrored.
+	ErrNotMirroring = errors.New("database writes are not mirrored")
+)
+
+const (
+	// the interval in which the start of the mirroring checks whether the unmirrored writes were applied.
+	unmirroredWritesCheckInterval = time.Millisecond
+)
+
+// mirrorHandle is shared between a mirrorStore and all stores derived from it with WithRealm.
+// The mirrorState is only installed while the writes are mirrored to a target store,
+// otherwise the writes are passed to the source store without any locking.
+type mirrorHandle struct {
+	// the installed *mirrorState, nil while the writes are not mirrored.
+	state atomic.Value
+	// the amount of writes that are applied to the source store without a mirrorState.
+	// the mirroring waits for these writes after its state was installed, before the copy of the source store starts.
+	unmirroredWrites atomic.Int64
+}
+
+// mirrorState holds the state of the mirroring while it is installed.
+// Writes hold the lock across the source and the target write,
+// so concurrent writes of the same key are applied to both stores in the same order.
+type mirrorState struct {
+	sync.Mutex
+
+	// active is set while the writes are mirrored to the target store.
+	active bool
+	// the target store without a realm.
+	target kvstore.KVStore
+	// the target stores per realm.
+	targetRealms map[string]kvstore.KVStore
+	// tracking is set while the database is copied to the target store.
+	tracking bool
+	// the last key (including the realm) the copy of the source store reached.
+	// the source store is copied in lexical order, so the copy never visits the keys up to this key again.
+	copiedUpTo kvstore.Key
+	// the keys and prefixes (including the realm) after copiedUpTo that were written since the copy started.
+	// the copy skips these entries, so newer values are not overwritten by older ones.
+	writtenKeys     map[string]struct{}
+	writtenPrefixes []kvstore.KeyPrefix
+	// the error that occurred while mirroring the writes.
+	err error
+}
+
+// targetWithRealm returns the target store for the given realm.
+// the caller needs to hold the lock.
+func (m *mirrorState) targetWithRealm(realm kvstore.Realm) (kvstore.KVStore, error) {
+	if len(realm) == 0 {
+		return m.target, nil
+	}
+
+	if target, exists := m.targetRealms[string(realm)]; exists {
+		return target, nil
+	}
+
+	target, err := m.target.WithRealm(realm)
+	if err != nil {
+		return nil, err
+	}
+	m.targetRealms[string(realm)] = target
+
+	return target, nil
+}
+
+// written checks whether the given key (including the realm) was written since the copy started.
+// the caller needs to hold the lock.
+func (m *mirrorState) written(key kvstore.Key) bool {
+	if _, written := m.writtenKeys[string(key)]; written {
+		return true
+	}
+
+	for _, prefix := range m.writtenPrefixes {
+		if bytes.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// prefixCopied checks whether all keys with the given prefix are lexically not greater than the given key.
+func prefixCopied(prefix kvstore.KeyPrefix, key kvstore.Key) bool {
+	return bytes.Compare(prefix, key) <= 0 && !bytes.HasPrefix(key, prefix)
+}
+
+// trackWritten marks the given keys and prefixes (including the realm) as written, if the copy didn't reach them yet.
+// the caller needs to hold the lock.
+func (m *mirrorState) trackWritten(keys []kvstore.Key, prefixes []kvstore.KeyPrefix) {
+	for _, key := range keys {
+		if m.copiedUpTo != nil && bytes.Compare(key, m.copiedUpTo) <= 0 {
+			continue
+		}
+		m.writtenKeys[string(key)] = struct{}{}
+	}
+
+	for _, prefix := range prefixes {
+		if m.copiedUpTo != nil && prefixCopied(prefix, m.copiedUpTo) {
+			continue
+		}
+		m.writtenPrefixes = append(m.writtenPrefixes, prefix)
+	}
+}
+
+// advanceCopy marks all keys up to the given key as copied and drops the tracked keys and prefixes the copy passed.
+// the caller needs to hold the lock.
+func (m *mirrorState) advanceCopy(key kvstore.Key) {
+	m.copiedUpTo = key
+
+	for writtenKey := range m.writtenKeys {
+		if bytes.Compare([]byte(writtenKey), key) <= 0 {
+			delete(m.writtenKeys, writtenKey)
+		}
+	}
+
+	writtenPrefixes := m.writtenPrefixes[:0]
+	for _, prefix := range m.writtenPrefixes {
+		if !prefixCopied(prefix, key) {
+			writtenPrefixes = append(writtenPrefixes, prefix)
+		}
+	}
+	m.writtenPrefixes = writtenPrefixes
+}
+
+// fail stops the mirroring because of the given error.
+// the caller needs to hold the lock.
+func (m *mirrorState) fail(err error) {
+	m.err = err
+	m.stop()
+}
+
+// stop stops the mirroring.
+// the caller needs to hold the lock.
+func (m *mirrorState) stop() {
+	m.active = false
+	m.target = nil
+	m.targetRealms = nil
+	m.tracking = false
+	m.copiedUpTo = nil
+	m.writtenKeys = nil
+	m.writtenPrefixes = nil
+}
+
+// mirrorStore is a kvstore.KVStore which mirrors all writes to a target store while the mirroring is active.
+// The reads are always served by the source store.
+type mirrorStore struct {
+	source kvstore.KVStore
+	handle *mirrorHandle
+}
+
+func newMirrorStore(source kvstore.KVStore) *mirrorStore {
+	handle := &mirrorHandle{}
+	handle.state.Store((*mirrorState)(nil))
+
+	return &mirrorStore{
+		source: source,
+		handle: handle,
+	}
+}
+
+// installedState returns the installed mirrorState, or nil if the writes are not mirrored.
+func (s *mirrorStore) installedState() *mirrorState {
+	return s.handle.state.Load().(*mirrorState)
+}
+
+// startMirroring installs a new mirrorState and starts to mirror all writes to the given target store.
+// It returns once all writes that were started before are applied to the source store,
+// so the copy of the source store contains them.
+// the written keys are tracked until stopTracking is called.
+func (s *mirrorStore) startMirroring(target kvstore.KVStore) error {
+	state := &mirrorState{
+		active:       true,
+		target:       target,
+		targetRealms: make(map[string]kvstore.KVStore),
+		tracking:     true,
+		writtenKeys:  make(map[string]struct{}),
+	}
+
+	if !s.handle.state.CompareAndSwap((*mirrorState)(nil), state) {
+		return ErrMirroringActive
+	}
+
+	for s.handle.unmirroredWrites.Load() > 0 {
+		time.Sleep(unmirroredWritesCheckInterval)
+	}
+
+	return nil
+}
+
+// stopTracking stops tracking the written keys, once the copy of the source store is finished.
+func (s *mirrorStore) stopTracking() {
+	state := s.installedState()
+	if state == nil {
+		return
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	state.tracking = false
+	state.copiedUpTo = nil
+	state.writtenKeys = nil
+	state.writtenPrefixes = nil
+}
+
+// stopMirroring stops the mirroring, uninstalls its state and returns the error that occurred while mirroring the writes.
+func (s *mirrorStore) stopMirroring() error {
+	state := s.installedState()
+	if state == nil {
+		return nil
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	err := state.err
+	state.stop()
+	state.err = nil
+
+	// writes that still hold the uninstalled state only apply to the source store, because it is not active anymore.
+	s.handle.state.CompareAndSwap(state, (*mirrorState)(nil))
+
+	return err
+}
+
+// mirroringError returns the error that occurred while mirroring the writes.
+func (s *mirrorStore) mirroringError() error {
+	state := s.installedState()
+	if state == nil {
+		return nil
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	return state.err
+}
+
+// copyEntries copies the given entries of the source store to the target store.
+// The keys need to be in lexical order and greater than the keys of the previous call.
+// entries which were written since the copy started are skipped, because the newer values were already mirrored.
+// The batch is committed without holding the lock, so the mirrored writes are not blocked by the copy.
+// Entries that are written while the batch is committed are copied again from the source store afterwards,
+// because the commit could have overwritten the mirrored value.
+func (s *mirrorStore) copyEntries(keys []kvstore.Key, values []kvstore.Value) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	state := s.installedState()
+	if state == nil {
+		return ErrNotMirroring
+	}
+
+	target, err := state.copyTarget()
+	if err != nil {
+		return err
+	}
+
+	batch, err := target.Batched()
+	if err != nil {
+		return err
+	}
+
+	state.Lock()
+	copiedKeys := make([]kvstore.Key, 0, len(keys))
+	for i, key := range keys {
+		if state.written(key) {
+			continue
+		}
+
+		if err := batch.Set(key, values[i]); err != nil {
+			state.Unlock()
+			batch.Cancel()
+			return err
+		}
+		copiedKeys = append(copiedKeys, key)
+	}
+	state.Unlock()
+
+	if err := batch.Commit(); err != nil {
+		return err
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	if !state.active {
+		return state.notMirroringError()
+	}
+
+	for _, key := range copiedKeys {
+		if !state.written(key) {
+			continue
+		}
+
+		// the source store contains the newest value, the pending mirrored write will apply the same value again.
+		if err := recopyEntry(s.source, state.target, key); err != nil {
+			state.fail(err)
+			return err
+		}
+	}
+
+	state.advanceCopy(keys[len(keys)-1])
+
+	return nil
+}
+
+// copyTarget returns the target store if the mirroring is active.
+func (m *mirrorState) copyTarget() (kvstore.KVStore, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if !m.active {
+		return nil, m.notMirroringError()
+	}
+
+	return m.target, nil
+}
+
+// notMirroringError returns the error why the writes are not mirrored.
+// the caller needs to hold the lock.
+func (m *mirrorState) notMirroringError() error {
+	if m.err != nil {
+		return m.err
+	}
+
+	return ErrNotMirroring
+}
+
+// recopyEntry copies the current value of the given key from the source store to the target store.
+func recopyEntry(source kvstore.KVStore, target kvstore.KVStore, key kvstore.Key) error {
+	value, err := source.Get(key)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return target.Delete(key)
+		}
+		return err
+	}
+
+	return target.Set(key, value)
+}
+
+// write applies the given write to the source store, and to the target store if the mirroring is active.
+// the given keys and prefixes (without the realm) are marked as written.
+func (s *mirrorStore) write(sourceWrite func() error, targetWrite func(target kvstore.KVStore) error, keys []kvstore.Key, prefixes []kvstore.KeyPrefix) error {
+	state := s.installedState()
+	if state == nil {
+		s.handle.unmirroredWrites.Inc()
+
+		// the mirroring could have been started in the meantime, it waits for the counted writes.
+		if state = s.installedState(); state == nil {
+			defer s.handle.unmirroredWrites.Dec()
+
+			return sourceWrite()
+		}
+		s.handle.unmirroredWrites.Dec()
+	}
+
+	state.Lock()
+	defer state.Unlock()
+
+	if err := sourceWrite(); err != nil {
+		return err
+	}
+
+	if !state.active {
+		return nil
+	}
+
+	realm := s.source.Realm()
+	target, err := state.targetWithRealm(realm)
+	if err != nil {
+		state.fail(err)
+		return nil
+	}
+
+	if err := targetWrite(target); err != nil {
+		// failed mirroring does not affect the source store, the migration is aborted instead.
+		state.fail(err)
+		return nil
+	}
+
+	if !state.tracking {
+		return nil
+	}
+
+	realmKeys := make([]kvstore.Key, len(keys))
+	for i, key := range keys {
+		realmKeys[i] = byteutils.ConcatBytes(realm, key)
+	}
+	realmPrefixes := make([]kvstore.KeyPrefix, len(prefixes))
+	for i, prefix := range prefixes {
+		realmPrefixes[i] = byteutils.ConcatBytes(realm, prefix)
+	}
+	state.trackWritten(realmKeys, realmPrefixes)
+
+	return nil
+}
+
+func (s *mirrorStore) WithRealm(realm kvstore.Realm) (kvstore.KVStore, error) {
+	source, err := s.source.WithRealm(realm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mirrorStore{
+		source: source,
+		handle: s.handle,
+	}, nil
+}
+
+func (s *mirrorStore) Realm() kvstore.Realm {
+	return s.source.Realm()
+}
+
+func (s *mirrorStore) Iterate(prefix kvstore.KeyPrefix, kvConsumerFunc kvstore.IteratorKeyValueConsumerFunc, direction ...kvstore.IterDirection) error {
+	return s.source.Iterate(prefix, kvConsumerFunc, direction...)
+}
+
+func (s *mirrorStore) IterateKeys(prefix kvstore.KeyPrefix, consumerFunc kvstore.IteratorKeyConsumerFunc, direction ...kvstore.IterDirection) error {
+	return s.source.IterateKeys(prefix, consumerFunc, direction...)
+}
+
+func (s *mirrorStore) Clear() error {
+	return s.write(s.source.Clear, func(target kvstore.KVStore) error {
+		return target.Clear()
+	}, nil, []kvstore.KeyPrefix{kvstore.EmptyPrefix})
+}
+
+func (s *mirrorStore) Get(key kvstore.Key) (kvstore.Value, error) {
+	return s.source.Get(key)
+}
+
+func (s *mirrorStore) Set(key kvstore.Key, value kvstore.Value) error {
+	return s.write(func() error {
+		return s.source.Set(key, value)
+	}, func(target kvstore.KVStore) error {
+		return target.Set(key, value)
+	}, []kvstore.Key{key}, nil)
+}
+
+func (s *mirrorStore) Has(key kvstore.Key) (bool, error) {
+	return s.source.Has(key)
+}
+
+func (s *mirrorStore) Delete(key kvstore.Key) error {
+	return s.write(func() error {
+		return s.source.Delete(key)
+	}, func(target kvstore.KVStore) error {
+		return target.Delete(key)
+	}, []kvstore.Key{key}, nil)
+}
+
+func (s *mirrorStore) DeletePrefix(prefix kvstore.KeyPrefix) error {
+	return s.write(func() error {
+		return s.source.DeletePrefix(prefix)
+	}, func(target kvstore.KVStore) error {
+		return target.DeletePrefix(prefix)
+	}, nil, []kvstore.KeyPrefix{prefix})
+}
+
+func (s *mirrorStore) Flush() error {
+	return s.write(s.source.Flush, func(target kvstore.KVStore) error {
+		return target.Flush()
+	}, nil, nil)
+}
+
+// Close closes the source store. The target store is closed by the owner of the target store.
+func (s *mirrorStore) Close() error {
+	return s.source.Close()
+}
+
+func (s *mirrorStore) Batched() (kvstore.BatchedMutations, error) {
+	batch, err := s.source.Batched()
+	if err != nil {
+		return nil, err
+	}
+
+	if s.installedState() == nil {
+		return &unmirroredBatchedMutations{
+			BatchedMutations: batch,
+			store:            s,
+		}, nil
+	}
+
+	return &mirrorBatchedMutations{
+		store: s,
+		batch: batch,
+	}, nil
+}
+
+// mirrorMutation is a single mutation of a batch.
+type mirrorMutation struct {
+	key    kvstore.Key
+	value  kvstore.Value
+	delete bool
+}
+
+// unmirroredBatchedMutations is a batch that was created while the writes were not mirrored.
+// Only the keys of the mutations are collected. If the mirroring was started before the batch is committed,
+// the current values of the keys are replayed from the source store to the target store after the commit.
+type unmirroredBatchedMutations struct {
+	kvstore.BatchedMutations
+	store *mirrorStore
+	keys  []kvstore.Key
+}
+
+func (b *unmirroredBatchedMutations) Set(key kvstore.Key, value kvstore.Value) error {
+	if err := b.BatchedMutations.Set(key, value); err != nil {
+		return err
+	}
+	b.keys = append(b.keys, key)
+
+	return nil
+}
+
+func (b *unmirroredBatchedMutations) Delete(key kvstore.Key) error {
+	if err := b.BatchedMutations.Delete(key); err != nil {
+		return err
+	}
+	b.keys = append(b.keys, key)
+
+	return nil
+}
+
+func (b *unmirroredBatchedMutations) Cancel() {
+	b.BatchedMutations.Cancel()
+	b.keys = nil
+}
+
+func (b *unmirroredBatchedMutations) Commit() error {
+	return b.store.write(b.BatchedMutations.Commit, func(target kvstore.KVStore) error {
+		// the write holds the lock of the mirroring, so the source store contains the values of the batch.
+		for _, key := range b.keys {
+			if err := recopyEntry(b.store.source, target, key); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, b.keys, nil)
+}
+
+// mirrorBatchedMutations collects the mutations of a batch that was created while the mirroring is active,
+// to mirror them when the batch is committed.
+type mirrorBatchedMutations struct {
+	store     *mirrorStore
+	batch     kvstore.BatchedMutations
+	mutations []*mirrorMutation
+}
+
+func (b *mirrorBatchedMutations) Set(key kvstore.Key, value kvstore.Value) error {
+	if err := b.batch.Set(key, value); err != nil {
+		return err
+	}
+
+	b.mutations = append(b.mutations, &mirrorMutation{key: key, value: value})
+
+	return nil
+}
+
+func (b *mirrorBatchedMutations) Delete(key kvstore.Key) error {
+	if err := b.batch.Delete(key); err != nil {
+		return err
+	}
+
+	b.mutations = append(b.mutations, &mirrorMutation{key: key, delete: true})
+
+	return nil
+}
+
+func (b *mirrorBatchedMutations) Cancel() {
+	b.batch.Cancel()
+	b.mutations = nil
+}
+
+func (b *mirrorBatchedMutations) Commit() error {
+	if len(b.mutations) == 0 {
+		return b.batch.Commit()
+	}
+
+	keys := make([]kvstore.Key, len(b.mutations))
+	for i, mutation := range b.mutations {
+		keys[i] = mutation.key
+	}
+
+	return b.store.write(b.batch.Commit, func(target kvstore.KVStore) error {
+		batch, err := target.Batched()
+		if err != nil {
+			return err
+		}
+
+		for _, mutation := range b.mutations {
+			if mutation.delete {
+				err = batch.Delete(mutation.key)
+			} else {
+				err = batch.Set(mutation.key, mutation.value)
+			}
+			if err != nil {
+				batch.Cancel()
+				return err
+			}
+		}
+
+		return batch.Commit()
+	}, keys, nil)
+}
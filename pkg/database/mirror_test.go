@@ -0,0 +1,294 @@
+package database
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/metrics"
+)
+
+func storeEntries(t *testing.T, store kvstore.KVStore) map[string]string {
+	entries := make(map[string]string)
+	require.NoError(t, store.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		entries[string(key)] = string(value)
+		return true
+	}))
+
+	return entries
+}
+
+// commitHookStore calls a hook before the batches of the store are committed.
+type commitHookStore struct {
+	kvstore.KVStore
+	onCommit func()
+}
+
+func (s *commitHookStore) Batched() (kvstore.BatchedMutations, error) {
+	batch, err := s.KVStore.Batched()
+	if err != nil {
+		return nil, err
+	}
+
+	return &commitHookBatch{BatchedMutations: batch, onCommit: s.onCommit}, nil
+}
+
+type commitHookBatch struct {
+	kvstore.BatchedMutations
+	onCommit func()
+}
+
+func (b *commitHookBatch) Commit() error {
+	if b.onCommit != nil {
+		b.onCommit()
+	}
+
+	return b.BatchedMutations.Commit()
+}
+
+func TestMirrorStore(t *testing.T) {
+	source := newMirrorStore(mapdb.NewMapDB())
+	target := mapdb.NewMapDB()
+
+	realm, err := source.WithRealm([]byte{1})
+	require.NoError(t, err)
+
+	// writes before the mirroring started are only copied
+	require.NoError(t, realm.Set([]byte("a"), []byte("old")))
+	require.NoError(t, realm.Set([]byte("b"), []byte("old")))
+	require.NoError(t, realm.Set([]byte("c"), []byte("old")))
+	require.NoError(t, realm.Set([]byte("d"), []byte("old")))
+
+	// the entries of the source store before the mirroring started
+	var keys []kvstore.Key
+	var values []kvstore.Value
+	require.NoError(t, source.source.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		keys = append(keys, append([]byte{}, key...))
+		values = append(values, append([]byte{}, value...))
+		return true
+	}))
+
+	require.NoError(t, source.startMirroring(target))
+	require.ErrorIs(t, source.startMirroring(target), ErrMirroringActive)
+
+	// writes during the copy are mirrored and not overwritten by the copied entries
+	require.NoError(t, realm.Set([]byte("a"), []byte("new")))
+	require.NoError(t, realm.Delete([]byte("b")))
+	batch, err := realm.Batched()
+	require.NoError(t, err)
+	require.NoError(t, batch.Set([]byte("e"), []byte("new")))
+	require.NoError(t, batch.Delete([]byte("c")))
+	require.NoError(t, batch.Commit())
+
+	require.NoError(t, source.copyEntries(keys, values))
+	source.stopTracking()
+
+	// writes after the copy are mirrored
+	require.NoError(t, realm.DeletePrefix([]byte("d")))
+	require.NoError(t, realm.Set([]byte("f"), []byte("new")))
+
+	require.Equal(t, storeEntries(t, source.source), storeEntries(t, target))
+	require.Equal(t, map[string]string{"\x01a": "new", "\x01e": "new", "\x01f": "new"}, storeEntries(t, target))
+
+	// writes after the mirroring stopped are not mirrored
+	require.NoError(t, source.stopMirroring())
+	require.NoError(t, realm.Set([]byte("g"), []byte("new")))
+	require.NotEqual(t, storeEntries(t, source.source), storeEntries(t, target))
+}
+
+func TestMirrorStoreUnmirroredBatch(t *testing.T) {
+	source := newMirrorStore(mapdb.NewMapDB())
+	target := mapdb.NewMapDB()
+
+	// the mirroring is only installed while the writes are mirrored
+	require.Nil(t, source.installedState())
+
+	// batches created while the writes are not mirrored only collect the keys of their mutations
+	batch, err := source.Batched()
+	require.NoError(t, err)
+	require.NoError(t, batch.Set([]byte("a"), []byte("old")))
+	require.NoError(t, batch.Set([]byte("c"), []byte("old")))
+	require.NoError(t, batch.Commit())
+	require.Empty(t, storeEntries(t, target))
+
+	batch, err = source.Batched()
+	require.NoError(t, err)
+	require.NoError(t, batch.Set([]byte("b"), []byte("new")))
+	require.NoError(t, batch.Delete([]byte("c")))
+
+	// the batch is replayed from the source store, because it was committed after the mirroring started
+	require.NoError(t, source.startMirroring(target))
+	require.NoError(t, batch.Commit())
+	require.NoError(t, source.mirroringError())
+	require.Equal(t, map[string]string{"b": "new"}, storeEntries(t, target))
+
+	// the replayed keys are tracked, so the copy does not overwrite them
+	require.NoError(t, source.copyEntries([]kvstore.Key{[]byte("a"), []byte("b"), []byte("c")}, []kvstore.Value{[]byte("old"), []byte("old"), []byte("old")}))
+	source.stopTracking()
+	require.Equal(t, storeEntries(t, source.source), storeEntries(t, target))
+
+	require.NoError(t, source.stopMirroring())
+	require.Nil(t, source.installedState())
+}
+
+func TestMirrorStoreCopyProgress(t *testing.T) {
+	source := newMirrorStore(mapdb.NewMapDB())
+
+	realm, err := source.WithRealm([]byte{1})
+	require.NoError(t, err)
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, realm.Set([]byte(key), []byte("old")))
+	}
+
+	var keys []kvstore.Key
+	var values []kvstore.Value
+	require.NoError(t, source.source.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		keys = append(keys, append([]byte{}, key...))
+		values = append(values, append([]byte{}, value...))
+		return true
+	}))
+
+	// the mirrored write is applied while the copied batch is committed, so it is overwritten by the old value
+	var hookRealm kvstore.KVStore
+	target := &commitHookStore{KVStore: mapdb.NewMapDB()}
+	target.onCommit = func() {
+		target.onCommit = nil
+		require.NoError(t, hookRealm.Set([]byte("a"), []byte("new")))
+	}
+	hookRealm = realm
+
+	require.NoError(t, source.startMirroring(target))
+
+	// writes to keys the copy didn't reach yet are tracked
+	require.NoError(t, realm.Set([]byte("c"), []byte("new")))
+	require.NoError(t, realm.DeletePrefix([]byte("d")))
+	require.Len(t, source.installedState().writtenKeys, 1)
+	require.Len(t, source.installedState().writtenPrefixes, 1)
+
+	// the entries written during the commit are copied again from the source store
+	require.NoError(t, source.copyEntries(keys[:2], values[:2]))
+	require.Equal(t, map[string]string{"\x01a": "new", "\x01b": "old", "\x01c": "new"}, storeEntries(t, target))
+
+	// writes to keys the copy already passed are not tracked anymore
+	require.NoError(t, realm.Set([]byte("b"), []byte("new")))
+	require.Len(t, source.installedState().writtenKeys, 1)
+
+	// the tracked keys and prefixes are dropped once the copy passed them
+	require.NoError(t, source.copyEntries(keys[2:], values[2:]))
+	require.Empty(t, source.installedState().writtenKeys)
+	require.Empty(t, source.installedState().writtenPrefixes)
+
+	source.stopTracking()
+	require.NoError(t, source.stopMirroring())
+
+	require.Equal(t, storeEntries(t, source.source), storeEntries(t, target))
+	require.Equal(t, map[string]string{"\x01a": "new", "\x01b": "new", "\x01c": "new", "\x01e": "old"}, storeEntries(t, target))
+}
+
+// yieldingStore randomly yields the processor around writes, so concurrent writes interleave
+// between the write to the source and to the target store.
+type yieldingStore struct {
+	kvstore.KVStore
+}
+
+func (s *yieldingStore) yield() {
+	if rand.Intn(2) == 0 {
+		runtime.Gosched()
+	}
+}
+
+func (s *yieldingStore) Set(key kvstore.Key, value kvstore.Value) error {
+	defer s.yield()
+
+	return s.KVStore.Set(key, value)
+}
+
+func (s *yieldingStore) Delete(key kvstore.Key) error {
+	defer s.yield()
+
+	return s.KVStore.Delete(key)
+}
+
+func (s *yieldingStore) Batched() (kvstore.BatchedMutations, error) {
+	batch, err := s.KVStore.Batched()
+	if err != nil {
+		return nil, err
+	}
+
+	return &commitHookBatch{BatchedMutations: batch, onCommit: s.yield}, nil
+}
+
+func TestMirrorStoreConcurrentWrites(t *testing.T) {
+	source := newMirrorStore(&yieldingStore{KVStore: mapdb.NewMapDB()})
+	target := mapdb.NewMapDB()
+
+	require.NoError(t, source.startMirroring(target))
+	source.stopTracking()
+
+	const (
+		writers          = 8
+		writesPerWriter  = 500
+		keysUnderContest = 2
+	)
+
+	var wg sync.WaitGroup
+	for writer := 0; writer < writers; writer++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+
+			for i := 0; i < writesPerWriter; i++ {
+				key := []byte{byte(i % keysUnderContest)}
+				value := []byte(fmt.Sprintf("%d-%d", writer, i))
+
+				switch i % 3 {
+				case 0:
+					require.NoError(t, source.Set(key, value))
+				case 1:
+					require.NoError(t, source.Delete(key))
+				default:
+					batch, err := source.Batched()
+					require.NoError(t, err)
+					require.NoError(t, batch.Set(key, value))
+					require.NoError(t, batch.Commit())
+				}
+			}
+		}(writer)
+	}
+	wg.Wait()
+
+	require.NoError(t, source.stopMirroring())
+
+	// the writes of the same key reached both stores in the same order
+	require.Equal(t, storeEntries(t, source.source), storeEntries(t, target))
+}
+
+func TestOnlineMigrationStatusMirroringError(t *testing.T) {
+	db := New(t.TempDir(), mapdb.NewMapDB(), EngineMapDB, &metrics.DatabaseMetrics{}, nil, false, nil)
+
+	migration := NewOnlineMigration(t.TempDir(), map[string]*Database{"tangle": db})
+	migration.targetStores = map[string]kvstore.KVStore{"tangle": mapdb.NewMapDB()}
+	migration.status.Completed = true
+
+	require.NoError(t, db.store.startMirroring(migration.targetStores["tangle"]))
+	db.store.stopTracking()
+	require.True(t, migration.Status().Completed)
+
+	// a failed mirrored write after the copy aborts the migration on shutdown, so it is not reported as completed
+	state := db.store.installedState()
+	state.Lock()
+	state.fail(errors.New("target store failure"))
+	state.Unlock()
+
+	status := migration.Status()
+	require.False(t, status.Completed)
+	require.ErrorContains(t, status.Err, "target store failure")
+}
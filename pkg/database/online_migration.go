@@ -0,0 +1,459 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+
+	"github.com/iotaledger/hive.go/ioutils"
+	"github.com/iotaledger/hive.go/kvstore"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the amount of entries that are copied to the target store at once.
+	onlineMigrationBatchSize = 10000
+	// the suffix of the directory the databases are migrated to.
+	onlineMigrationDirectorySuffix = "_migration"
+	// the suffix of the directory the old databases are moved to after the migration was applied.
+	onlineMigrationOldDirectorySuffix = "_old"
+	// the name of the file which marks a completed migration.
+	onlineMigrationInfoFileName = "migrationinfo"
+)
+
+var (
+	// ErrOnlineMigrationRunning is returned if an online migration is already running or waits for the restart of the node.
+	ErrOnlineMigrationRunning = errors.New("online database migration is already running")
+	// ErrOnlineMigrationNotSupported is returned if the databases can't be migrated online.
+	ErrOnlineMigrationNotSupported = errors.New("online database migration is not supported")
+	// ErrOnlineMigrationEngineMismatch is returned if the databases were migrated to another engine than the configured one.
+	ErrOnlineMigrationEngineMismatch = errors.New("the databases were migrated to another database engine")
+)
+
+// copyTo copies all entries of the database to the given target store while the database is in use.
+// All writes to the database are mirrored to the target store from the start of the copy on,
+// until the mirroring is stopped.
+func (db *Database) copyTo(ctx context.Context, target kvstore.KVStore, onCopied func(entries int)) error {
+	if err := db.store.startMirroring(target); err != nil {
+		return err
+	}
+
+	keys := make([]kvstore.Key, 0, onlineMigrationBatchSize)
+	values := make([]kvstore.Value, 0, onlineMigrationBatchSize)
+
+	copyEntries := func() error {
+		if err := db.store.copyEntries(keys, values); err != nil {
+			return err
+		}
+
+		onCopied(len(keys))
+		keys = keys[:0]
+		values = values[:0]
+
+		return nil
+	}
+
+	var innerErr error
+	if err := db.store.source.Iterate(kvstore.EmptyPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+		if innerErr = ctx.Err(); innerErr != nil {
+			return false
+		}
+
+		// the key and value are only valid during the iteration
+		keys = append(keys, append([]byte{}, key...))
+		values = append(values, append([]byte{}, value...))
+
+		if len(keys) >= onlineMigrationBatchSize {
+			innerErr = copyEntries()
+		}
+
+		return innerErr == nil
+	}); err != nil {
+		return err
+	}
+
+	if innerErr != nil {
+		return innerErr
+	}
+
+	if err := copyEntries(); err != nil {
+		return err
+	}
+
+	// from now on the target store only receives the mirrored writes
+	db.store.stopTracking()
+
+	return db.store.mirroringError()
+}
+
+// OnlineMigrationStatus is the status of an online database migration.
+type OnlineMigrationStatus struct {
+	// Running is set while the databases are copied.
+	Running bool
+	// Completed is set once the databases were copied.
+	// The migrated databases are used after the next restart of the node.
+	Completed bool
+	// TargetEngine is the engine the databases are migrated to.
+	TargetEngine Engine
+	// TargetPath is the path the databases are migrated to.
+	TargetPath string
+	// StartTime is the time the migration was started.
+	StartTime time.Time
+	// CopiedEntries is the amount of entries that were copied.
+	CopiedEntries int64
+	// Err is the error that aborted the migration.
+	Err error
+}
+
+// OnlineMigrationInfo contains the information about an applied online migration.
+type OnlineMigrationInfo struct {
+	// Engine is the engine of the migrated databases.
+	Engine Engine
+	// LedgerIndex is the ledger index of the databases when the node was shut down.
+	LedgerIndex iotago.MilestoneIndex
+	// OldDatabasePath is the path the old databases were moved to.
+	OldDatabasePath string
+}
+
+type onlineMigrationInfo struct {
+	Engine      string `toml:"databaseEngine"`
+	LedgerIndex uint32 `toml:"ledgerIndex"`
+}
+
+// OnlineMigration migrates the databases of a running node to another engine.
+// The databases are copied in the background while all writes to the databases are mirrored to the new databases.
+// The node switches to the new databases on the next restart, at the milestone the node was shut down at.
+type OnlineMigration struct {
+	sync.Mutex
+
+	// the path of the databases.
+	databasePath string
+	// the databases to migrate by the name of their directory.
+	databases map[string]*Database
+	// the stores the databases are migrated to by the name of their directory.
+	targetStores map[string]kvstore.KVStore
+	// the status of the migration.
+	status OnlineMigrationStatus
+	// the amount of entries that were copied.
+	copiedEntries atomic.Int64
+	// cancels the copy of the databases.
+	cancel context.CancelFunc
+	// closed once the copy of the databases finished.
+	done chan struct{}
+}
+
+// NewOnlineMigration creates a new OnlineMigration instance for the databases in the given directories of the database path.
+func NewOnlineMigration(databasePath string, databases map[string]*Database) *OnlineMigration {
+	return &OnlineMigration{
+		databasePath: databasePath,
+		databases:    databases,
+	}
+}
+
+// OnlineMigrationTargetPath returns the path the databases at the given path are migrated to.
+func OnlineMigrationTargetPath(databasePath string) string {
+	return filepath.Clean(databasePath) + onlineMigrationDirectorySuffix
+}
+
+// Status returns the status of the migration.
+// A migration whose mirrored writes failed after the copy is not reported as completed,
+// because it is aborted once the node shuts down.
+func (m *OnlineMigration) Status() *OnlineMigrationStatus {
+	m.Lock()
+	defer m.Unlock()
+
+	status := m.status
+	status.CopiedEntries = m.copiedEntries.Load()
+
+	if status.Err == nil && m.targetStores != nil {
+		if err := m.mirroringError(); err != nil {
+			status.Err = err
+			status.Completed = false
+		}
+	}
+
+	return &status
+}
+
+// mirroringError returns the first error that occurred while mirroring the writes to the target databases.
+// the caller needs to hold the lock.
+func (m *OnlineMigration) mirroringError() error {
+	names := make([]string, 0, len(m.databases))
+	for name := range m.databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if err := m.databases[name].store.mirroringError(); err != nil {
+			return fmt.Errorf("mirroring the writes to the %s database failed: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Start starts to copy the databases to new databases with the given engine in the background.
+// The copy is aborted if the given context is done.
+func (m *OnlineMigration) Start(ctx context.Context, targetEngine Engine) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.status.Running || m.status.Completed {
+		return ErrOnlineMigrationRunning
+	}
+
+	targetEngine, err := DatabaseEngineAllowed(targetEngine, EnginePebble, EngineRocksDB, EngineBadger)
+	if err != nil {
+		return err
+	}
+
+	for _, db := range m.databases {
+		if db.Engine() == EngineMapDB {
+			return errors.Wrap(ErrOnlineMigrationNotSupported, "in-memory databases can't be migrated")
+		}
+		if db.Engine() == targetEngine {
+			return errors.Wrapf(ErrOnlineMigrationNotSupported, "the databases already use the %s engine", targetEngine)
+		}
+	}
+
+	// remove the leftovers of an aborted migration
+	targetPath := OnlineMigrationTargetPath(m.databasePath)
+	if err := os.RemoveAll(targetPath); err != nil {
+		return fmt.Errorf("unable to remove the target path (%s): %w", targetPath, err)
+	}
+
+	m.targetStores = make(map[string]kvstore.KVStore)
+	for name := range m.databases {
+		store, err := StoreWithDefaultSettings(filepath.Join(targetPath, name), true, targetEngine)
+		if err != nil {
+			m.abort()
+			return fmt.Errorf("target database initialization failed: %w", err)
+		}
+		m.targetStores[name] = store
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m.cancel = cancel
+	m.done = make(chan struct{})
+	m.copiedEntries.Store(0)
+	m.status = OnlineMigrationStatus{
+		Running:      true,
+		TargetEngine: targetEngine,
+		TargetPath:   targetPath,
+		StartTime:    time.Now(),
+	}
+
+	go m.copyDatabases(ctx, m.done)
+
+	return nil
+}
+
+// copyDatabases copies all databases to the target stores.
+func (m *OnlineMigration) copyDatabases(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	names := make([]string, 0, len(m.databases))
+	for name := range m.databases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var err error
+	for _, name := range names {
+		if err = m.databases[name].copyTo(ctx, m.targetStores[name], func(entries int) {
+			m.copiedEntries.Add(int64(entries))
+		}); err != nil {
+			err = fmt.Errorf("copying the %s database failed: %w", name, err)
+			break
+		}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.status.Running = false
+	if err != nil {
+		m.status.Err = err
+		m.abort()
+		return
+	}
+	m.status.Completed = true
+}
+
+// abort stops the mirroring and removes the target databases.
+// the caller needs to hold the lock.
+func (m *OnlineMigration) abort() {
+	for _, db := range m.databases {
+		_ = db.store.stopMirroring()
+	}
+
+	for _, store := range m.targetStores {
+		_ = store.Close()
+	}
+	m.targetStores = nil
+
+	_ = os.RemoveAll(OnlineMigrationTargetPath(m.databasePath))
+}
+
+// Stop stops the copy of the databases, waits until it returned and stops mirroring the writes.
+// It needs to be called after the last write to the databases and before the databases are closed,
+// because the copy still iterates the source databases.
+// If the databases were not copied completely, the migration is aborted and the error is reported by Finish.
+func (m *OnlineMigration) Stop() {
+	m.Lock()
+	if m.cancel == nil {
+		// the migration was never started
+		m.Unlock()
+		return
+	}
+	m.cancel()
+	done := m.done
+	m.Unlock()
+
+	// wait until the copy of the databases was stopped
+	<-done
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.targetStores == nil {
+		// the migration was aborted
+		return
+	}
+
+	var err error
+	for _, db := range m.databases {
+		if mirroringErr := db.store.stopMirroring(); mirroringErr != nil && err == nil {
+			err = fmt.Errorf("mirroring the writes failed: %w", mirroringErr)
+		}
+	}
+
+	if err == nil && !m.status.Completed {
+		err = errors.New("the databases were not copied completely")
+	}
+
+	if err != nil {
+		m.status.Err = err
+		m.status.Completed = false
+		m.abort()
+	}
+}
+
+// Finish finishes the migration after it was stopped and the databases were closed.
+// If the databases were copied completely, the target databases are marked to be used on the next start of the node.
+// Returns false if no migration was completed.
+func (m *OnlineMigration) Finish(ledgerIndex iotago.MilestoneIndex) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.targetStores == nil {
+		// the migration was never started or was aborted
+		return false, m.status.Err
+	}
+
+	if m.status.Running {
+		return false, errors.New("the migration was not stopped")
+	}
+
+	for name, store := range m.targetStores {
+		if err := store.Flush(); err != nil {
+			m.abort()
+			return false, fmt.Errorf("flushing the target %s database failed: %w", name, err)
+		}
+		if err := store.Close(); err != nil {
+			m.abort()
+			return false, fmt.Errorf("closing the target %s database failed: %w", name, err)
+		}
+	}
+	m.targetStores = nil
+
+	infoFilePath := filepath.Join(m.status.TargetPath, onlineMigrationInfoFileName)
+	if err := ioutils.WriteTOMLToFile(infoFilePath, &onlineMigrationInfo{
+		Engine:      string(m.status.TargetEngine),
+		LedgerIndex: ledgerIndex,
+	}, 0660, "# auto-generated\n# !!! do not modify this file !!!"); err != nil {
+		return false, fmt.Errorf("unable to write migration info file (%s): %w", infoFilePath, err)
+	}
+
+	return true, nil
+}
+
+// ApplyOnlineMigration replaces the databases at the given path with the databases of a completed online migration.
+// The old databases are moved to a separate directory and the leftovers of aborted migrations are removed.
+// If the databases were migrated to another engine than the configured one, nothing is moved
+// and ErrOnlineMigrationEngineMismatch is returned.
+// Returns nil if no migration was completed.
+func ApplyOnlineMigration(databasePath string, configuredEngine Engine) (*OnlineMigrationInfo, error) {
+
+	targetPath := OnlineMigrationTargetPath(databasePath)
+	targetExists, err := ioutils.PathExists(targetPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check migration path (%s): %w", targetPath, err)
+	}
+	if !targetExists {
+		return nil, nil
+	}
+
+	infoFilePath := filepath.Join(targetPath, onlineMigrationInfoFileName)
+	infoFileExists, err := ioutils.PathExists(infoFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check migration info file (%s): %w", infoFilePath, err)
+	}
+
+	if !infoFileExists {
+		// the migration was aborted
+		if err := os.RemoveAll(targetPath); err != nil {
+			return nil, fmt.Errorf("unable to remove aborted migration (%s): %w", targetPath, err)
+		}
+		return nil, nil
+	}
+
+	var info onlineMigrationInfo
+	if err := ioutils.ReadTOMLFromFile(infoFilePath, &info); err != nil {
+		return nil, fmt.Errorf("unable to read migration info file: %w", err)
+	}
+
+	engine, err := DatabaseEngineFromStringAllowed(info.Engine)
+	if err != nil {
+		return nil, err
+	}
+
+	if engine != configuredEngine {
+		return nil, errors.Wrapf(ErrOnlineMigrationEngineMismatch, "migrated engine: %s, configured engine: %s", engine, configuredEngine)
+	}
+
+	oldPath := filepath.Clean(databasePath) + onlineMigrationOldDirectorySuffix
+	oldExists, err := ioutils.PathExists(oldPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check old database path (%s): %w", oldPath, err)
+	}
+	if oldExists {
+		return nil, fmt.Errorf("unable to apply the database migration, the old database path (%s) already exists", oldPath)
+	}
+
+	if err := os.Rename(databasePath, oldPath); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to move the old databases to %s: %w", oldPath, err)
+	}
+
+	if err := os.Rename(targetPath, databasePath); err != nil {
+		return nil, fmt.Errorf("unable to move the migrated databases to %s: %w", databasePath, err)
+	}
+
+	if err := os.Remove(filepath.Join(databasePath, onlineMigrationInfoFileName)); err != nil {
+		return nil, fmt.Errorf("unable to remove migration info file: %w", err)
+	}
+
+	return &OnlineMigrationInfo{
+		Engine:          engine,
+		LedgerIndex:     info.LedgerIndex,
+		OldDatabasePath: oldPath,
+	}, nil
+}
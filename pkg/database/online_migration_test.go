@@ -0,0 +1,99 @@
+package database_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/metrics"
+)
+
+func TestOnlineMigration(t *testing.T) {
+	databasePath := filepath.Join(t.TempDir(), "database")
+	tanglePath := filepath.Join(databasePath, "tangle")
+
+	store, err := database.StoreWithDefaultSettings(tanglePath, true, database.EnginePebble)
+	require.NoError(t, err)
+
+	db := database.New(tanglePath, store, database.EnginePebble, &metrics.DatabaseMetrics{}, nil, false, nil)
+	realm, err := db.KVStore().WithRealm([]byte{1})
+	require.NoError(t, err)
+
+	for i := byte(0); i < 100; i++ {
+		require.NoError(t, realm.Set([]byte{i}, []byte{i}))
+	}
+
+	migration := database.NewOnlineMigration(databasePath, map[string]*database.Database{"tangle": db})
+	require.ErrorIs(t, migration.Start(context.Background(), database.EnginePebble), database.ErrOnlineMigrationNotSupported)
+	require.NoError(t, migration.Start(context.Background(), database.EngineBadger))
+	require.ErrorIs(t, migration.Start(context.Background(), database.EngineBadger), database.ErrOnlineMigrationRunning)
+
+	// the node keeps writing while the database is copied
+	require.NoError(t, realm.Set([]byte{0}, []byte("new")))
+	require.NoError(t, realm.Delete([]byte{1}))
+
+	require.Eventually(t, func() bool {
+		return migration.Status().Completed
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NoError(t, migration.Status().Err)
+
+	// writes after the copy are mirrored until the node is shut down
+	require.NoError(t, realm.Set([]byte{2}, []byte("new")))
+	migration.Stop()
+	require.NoError(t, db.KVStore().Flush())
+	require.NoError(t, db.KVStore().Close())
+
+	migrated, err := migration.Finish(5)
+	require.NoError(t, err)
+	require.True(t, migrated)
+
+	// the migrated database is not applied if another engine is configured
+	_, err = database.ApplyOnlineMigration(databasePath, database.EnginePebble)
+	require.ErrorIs(t, err, database.ErrOnlineMigrationEngineMismatch)
+
+	engine, err := database.CheckDatabaseEngine(tanglePath, false)
+	require.NoError(t, err)
+	require.Equal(t, database.EnginePebble, engine)
+
+	// the migrated database is used on the next start
+	info, err := database.ApplyOnlineMigration(databasePath, database.EngineBadger)
+	require.NoError(t, err)
+	require.Equal(t, database.EngineBadger, info.Engine)
+	require.EqualValues(t, 5, info.LedgerIndex)
+
+	engine, err = database.CheckDatabaseEngine(tanglePath, false)
+	require.NoError(t, err)
+	require.Equal(t, database.EngineBadger, engine)
+
+	store, err = database.StoreWithDefaultSettings(tanglePath, false)
+	require.NoError(t, err)
+	defer func() { _ = store.Close() }()
+
+	realm, err = store.WithRealm([]byte{1})
+	require.NoError(t, err)
+
+	value, err := realm.Get([]byte{0})
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), value)
+
+	has, err := realm.Has([]byte{1})
+	require.NoError(t, err)
+	require.False(t, has)
+
+	value, err = realm.Get([]byte{2})
+	require.NoError(t, err)
+	require.Equal(t, []byte("new"), value)
+
+	value, err = realm.Get([]byte{99})
+	require.NoError(t, err)
+	require.Equal(t, []byte{99}, value)
+
+	// nothing to apply on the next start
+	info, err = database.ApplyOnlineMigration(databasePath, database.EngineBadger)
+	require.NoError(t, err)
+	require.Nil(t, info)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// InitArchive enables or disables the archive mode of the ledger.
+// If the archive indexes need to be rebuilt, the referenced blocks are backfilled from the block metadata in the database.
+// The rebuilt indexes are complete since the oldest milestone whose history was not pruned yet.
+// Returns whether the indexes were rebuilt.
+func (s *Storage) InitArchive(enabled bool) (bool, error) {
+
+	var blocksStartIndex, spentsStartIndex iotago.MilestoneIndex
+	if snapshotInfo := s.SnapshotInfo(); snapshotInfo != nil {
+		// the blocks before the entry point index are not part of the database
+		blocksStartIndex = snapshotInfo.EntryPointIndex() + 1
+		// the milestone diffs up to the pruning index were removed
+		spentsStartIndex = snapshotInfo.PruningIndex() + 1
+	}
+
+	return s.utxoManager.InitArchive(enabled, blocksStartIndex, spentsStartIndex, func(consumer utxo.ReferencedBlockConsumer) error {
+		s.NonCachedStorage().ForEachBlockMetadataBlockID(func(blockID iotago.BlockID) bool {
+			cachedBlockMeta := s.CachedBlockMetadataOrNil(blockID) // meta +1
+			if cachedBlockMeta == nil {
+				return true
+			}
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			referenced, msIndex, wfIndex := cachedBlockMeta.Metadata().ReferencedWithIndexAndWhiteFlagIndex()
+			if !referenced {
+				return true
+			}
+
+			return consumer(blockID, msIndex, wfIndex)
+		})
+
+		return nil
+	})
+}
@@ -0,0 +1,371 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/ioutils"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the name of the file which contains the information about a checkpoint.
+	checkpointInfoFileName = "checkpointinfo"
+)
+
+var (
+	// ErrCheckpointInfoNotFound is returned if the checkpoint info file was not found.
+	ErrCheckpointInfoNotFound = errors.New("checkpoint info file not found")
+	// ErrCheckpointRunning is returned if a checkpoint is already written.
+	ErrCheckpointRunning = errors.New("database checkpoint is already running")
+)
+
+// CheckpointInfo contains the information about a checkpoint of the databases.
+type CheckpointInfo struct {
+	// Engine is the engine of the databases in the checkpoint.
+	Engine database.Engine
+	// LedgerIndex is the confirmed milestone index of the ledger state in the checkpoint.
+	LedgerIndex iotago.MilestoneIndex
+	// Timestamp is the time the checkpoint was created at.
+	Timestamp time.Time
+}
+
+type checkpointInfo struct {
+	Engine      string `toml:"databaseEngine"`
+	LedgerIndex uint32 `toml:"ledgerIndex"`
+	Timestamp   int64  `toml:"timestamp"`
+}
+
+// CreateCheckpoint creates consistent checkpoints of the given databases while the node is running.
+// The checkpoints are stored in the directories with the names of the databases in the checkpoint path.
+// The checkpoints contain the ledger state of the confirmed milestone at the time of the call.
+// The checkpoints are marked as healthy, because they can be used without a clean shutdown of the node.
+func (s *Storage) CreateCheckpoint(ctx context.Context, checkpointPath string, databases map[string]*database.Database) (*CheckpointInfo, error) {
+
+	info, prepared, err := s.prepareCheckpoints(checkpointPath, databases)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeCheckpoints(ctx, checkpointPath, info, prepared); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// prepareCheckpoints fixes the state of the checkpoints of the given databases while the ledger is locked.
+// The ledger is only locked while the caches are flushed, the ledger index is read and the state of the checkpoints is fixed.
+// Native checkpoints only hard link the flushed files, engines without native checkpoints only open a snapshot,
+// so no data is copied while the ledger is locked.
+func (s *Storage) prepareCheckpoints(checkpointPath string, databases map[string]*database.Database) (*CheckpointInfo, map[string]*database.PreparedCheckpoint, error) {
+
+	if len(databases) == 0 {
+		return nil, nil, errors.New("no databases given")
+	}
+
+	exists, err := ioutils.PathExists(checkpointPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists {
+		return nil, nil, errors.Wrapf(database.ErrCheckpointExists, "checkpoint directory: %s", checkpointPath)
+	}
+
+	info := &CheckpointInfo{
+		Timestamp: time.Now(),
+	}
+	for _, db := range databases {
+		if info.Engine != "" && info.Engine != db.Engine() {
+			return nil, nil, fmt.Errorf("the databases use different engines: %s != %s", info.Engine, db.Engine())
+		}
+		info.Engine = db.Engine()
+	}
+
+	if err := os.MkdirAll(checkpointPath, 0700); err != nil {
+		return nil, nil, fmt.Errorf("could not create checkpoint directory '%s': %w", checkpointPath, err)
+	}
+
+	prepared := make(map[string]*database.PreparedCheckpoint, len(databases))
+	discard := func() {
+		for _, checkpoint := range prepared {
+			checkpoint.Discard()
+		}
+		_ = os.RemoveAll(checkpointPath)
+	}
+
+	// lock the ledger, so no milestone is confirmed while the state of the checkpoints is fixed
+	s.utxoManager.WriteLockLedger()
+	defer s.utxoManager.WriteUnlockLedger()
+
+	// write all cached objects to the stores
+	s.FlushStorages()
+
+	info.LedgerIndex, err = s.utxoManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		discard()
+		return nil, nil, err
+	}
+
+	for name, db := range databases {
+		checkpoint, err := db.PrepareCheckpoint(filepath.Join(checkpointPath, name))
+		if err != nil {
+			discard()
+			return nil, nil, fmt.Errorf("creating the %s checkpoint failed: %w", name, err)
+		}
+		prepared[name] = checkpoint
+	}
+
+	return info, prepared, nil
+}
+
+// writeCheckpoints writes the prepared checkpoints, marks them as healthy and stores the checkpoint info file.
+// The checkpoint path is removed if writing the checkpoints failed.
+func writeCheckpoints(ctx context.Context, checkpointPath string, info *CheckpointInfo, prepared map[string]*database.PreparedCheckpoint) (err error) {
+
+	defer func() {
+		if err != nil {
+			for _, checkpoint := range prepared {
+				checkpoint.Discard()
+			}
+			_ = os.RemoveAll(checkpointPath)
+		}
+	}()
+
+	for name, checkpoint := range prepared {
+		if err := checkpoint.Write(ctx); err != nil {
+			return fmt.Errorf("writing the %s checkpoint failed: %w", name, err)
+		}
+
+		if err := markCheckpointHealthy(filepath.Join(checkpointPath, name)); err != nil {
+			return fmt.Errorf("marking the %s checkpoint as healthy failed: %w", name, err)
+		}
+	}
+
+	infoFilePath := filepath.Join(checkpointPath, checkpointInfoFileName)
+	if err := ioutils.WriteTOMLToFile(infoFilePath, &checkpointInfo{
+		Engine:      string(info.Engine),
+		LedgerIndex: info.LedgerIndex,
+		Timestamp:   info.Timestamp.Unix(),
+	}, 0660, "# auto-generated\n# !!! do not modify this file !!!"); err != nil {
+		return fmt.Errorf("unable to write checkpoint info file (%s): %w", infoFilePath, err)
+	}
+
+	return nil
+}
+
+// CheckpointStatus is the status of a checkpoint that is created in the background.
+type CheckpointStatus struct {
+	// Running is set while the checkpoints are written.
+	Running bool
+	// Completed is set once the checkpoints were written.
+	Completed bool
+	// Path is the path of the checkpoint.
+	Path string
+	// Engine is the engine of the databases in the checkpoint.
+	Engine database.Engine
+	// LedgerIndex is the confirmed milestone index of the ledger state in the checkpoint.
+	LedgerIndex iotago.MilestoneIndex
+	// StartTime is the time the checkpoint was started at.
+	StartTime time.Time
+	// Err is the error that aborted the checkpoint.
+	Err error
+}
+
+// Checkpointer creates checkpoints of the databases of a running node.
+// The state of a checkpoint is fixed while the ledger is locked, the checkpoint is written in the background.
+type Checkpointer struct {
+	sync.Mutex
+
+	// the storage of the node.
+	storage *Storage
+	// the databases by the name of their directory.
+	databases map[string]*database.Database
+	// the status of the latest checkpoint.
+	status CheckpointStatus
+	// cancels the writing of the checkpoint.
+	cancel context.CancelFunc
+	// closed once the checkpoint was written.
+	done chan struct{}
+}
+
+// NewCheckpointer creates a new Checkpointer instance for the given databases.
+func NewCheckpointer(storage *Storage, databases map[string]*database.Database) *Checkpointer {
+	return &Checkpointer{
+		storage:   storage,
+		databases: databases,
+	}
+}
+
+// Status returns the status of the latest checkpoint.
+func (c *Checkpointer) Status() *CheckpointStatus {
+	c.Lock()
+	defer c.Unlock()
+
+	status := c.status
+
+	return &status
+}
+
+// Start fixes the state of a checkpoint at the given path and writes the checkpoint in the background.
+// The writing is aborted if the given context is done.
+func (c *Checkpointer) Start(ctx context.Context, checkpointPath string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.status.Running {
+		return ErrCheckpointRunning
+	}
+
+	info, prepared, err := c.storage.prepareCheckpoints(checkpointPath, c.databases)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.done = make(chan struct{})
+	c.status = CheckpointStatus{
+		Running:     true,
+		Path:        checkpointPath,
+		Engine:      info.Engine,
+		LedgerIndex: info.LedgerIndex,
+		StartTime:   info.Timestamp,
+	}
+
+	go func(done chan struct{}) {
+		defer close(done)
+		defer cancel()
+
+		err := writeCheckpoints(ctx, checkpointPath, info, prepared)
+
+		c.Lock()
+		defer c.Unlock()
+
+		c.status.Running = false
+		if err != nil {
+			c.status.Err = err
+			return
+		}
+		c.status.Completed = true
+	}(c.done)
+
+	return nil
+}
+
+// Stop aborts the writing of a running checkpoint and waits until it was stopped.
+// It needs to be called before the databases are closed.
+func (c *Checkpointer) Stop() {
+	c.Lock()
+	if c.cancel == nil {
+		// no checkpoint was started
+		c.Unlock()
+		return
+	}
+	c.cancel()
+	done := c.done
+	c.Unlock()
+
+	<-done
+}
+
+// markCheckpointHealthy removes the corrupted flag of the running node from the checkpoint at the given path.
+func markCheckpointHealthy(path string) error {
+
+	store, err := database.StoreWithDefaultSettings(path, false)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = store.Close() }()
+
+	healthTracker, err := NewStoreHealthTracker(store, DBVersionNone)
+	if err != nil {
+		return err
+	}
+
+	if err := healthTracker.MarkHealthy(); err != nil {
+		return err
+	}
+
+	return store.Flush()
+}
+
+// ReadCheckpointInfo reads the information about the checkpoint at the given path.
+func ReadCheckpointInfo(checkpointPath string) (*CheckpointInfo, error) {
+
+	infoFilePath := filepath.Join(checkpointPath, checkpointInfoFileName)
+
+	exists, err := ioutils.PathExists(infoFilePath)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.Wrapf(ErrCheckpointInfoNotFound, "path: %s", infoFilePath)
+	}
+
+	var info checkpointInfo
+	if err := ioutils.ReadTOMLFromFile(infoFilePath, &info); err != nil {
+		return nil, fmt.Errorf("unable to read checkpoint info file: %w", err)
+	}
+
+	engine, err := database.DatabaseEngineFromStringAllowed(info.Engine, database.EnginePebble, database.EngineRocksDB, database.EngineBadger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CheckpointInfo{
+		Engine:      engine,
+		LedgerIndex: info.LedgerIndex,
+		Timestamp:   time.Unix(info.Timestamp, 0),
+	}, nil
+}
+
+// VerifyCheckpoint verifies that the storage, which was loaded from a checkpoint, can be used by a node.
+// The databases need to be healthy and of the correct version, and the ledger state needs to match the checkpoint.
+func (s *Storage) VerifyCheckpoint(info *CheckpointInfo) error {
+
+	corrupted, err := s.AreDatabasesCorrupted()
+	if err != nil {
+		return err
+	}
+	if corrupted {
+		return errors.New("checkpoint databases are corrupted")
+	}
+
+	tainted, err := s.AreDatabasesTainted()
+	if err != nil {
+		return err
+	}
+	if tainted {
+		return errors.New("checkpoint databases are tainted")
+	}
+
+	correctVersion, err := s.CheckCorrectDatabasesVersion()
+	if err != nil {
+		return err
+	}
+	if !correctVersion {
+		return errors.New("checkpoint databases have an outdated version")
+	}
+
+	ledgerIndex, err := s.utxoManager.ReadLedgerIndex()
+	if err != nil {
+		return err
+	}
+	if ledgerIndex != info.LedgerIndex {
+		return fmt.Errorf("ledger index of the checkpoint does not match: %d != %d", ledgerIndex, info.LedgerIndex)
+	}
+
+	if err := s.CheckLedgerState(); err != nil {
+		return fmt.Errorf("ledger state of the checkpoint is invalid: %w", err)
+	}
+
+	return nil
+}
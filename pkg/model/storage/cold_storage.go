@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"github.com/pkg/errors"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrBlockNotInColdStorage is returned if a block is not part of the cold storage.
+	ErrBlockNotInColdStorage = errors.New("block not found in cold storage")
+)
+
+// ColdStorage is a read-only storage for the blocks of pruned milestone cones.
+type ColdStorage interface {
+	// Block returns the block and its metadata at the time it was pruned.
+	// Returns ErrBlockNotInColdStorage if the block is not part of the cold storage.
+	Block(blockID iotago.BlockID) (*Block, *BlockMetadata, error)
+}
+
+// SetColdStorage sets the cold storage the pruned blocks are read from.
+// This needs to be set before the storage is used by other components.
+func (s *Storage) SetColdStorage(coldStorage ColdStorage) {
+	s.coldStorage = coldStorage
+}
+
+// ColdBlockOrNil returns the block and its metadata from the cold storage.
+// This should only be used for blocks that are not part of the database anymore,
+// the cold storage is much slower than the database.
+// Returns nil if there is no cold storage or the block is not part of it.
+func (s *Storage) ColdBlockOrNil(blockID iotago.BlockID) (*Block, *BlockMetadata, error) {
+	if s.coldStorage == nil {
+		return nil, nil, nil
+	}
+
+	block, metadata, err := s.coldStorage.Block(blockID)
+	if err != nil {
+		if errors.Is(err, ErrBlockNotInColdStorage) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+
+	return block, metadata, nil
+}
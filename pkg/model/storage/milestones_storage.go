@@ -447,6 +447,35 @@ func (s *Storage) MilestoneTimestampUnixByIndex(milestoneIndex iotago.MilestoneI
 	return cachedMilestone.Milestone().TimestampUnix(), nil
 }
 
+// LatestMilestoneIndexBeforeTimestamp returns the index of the latest milestone in the given range
+// that was issued before the given timestamp.
+// The milestones in the range must be stored in the database, their timestamps are increasing.
+// Returns 0 if no milestone in the range was issued before the given timestamp.
+func (s *Storage) LatestMilestoneIndexBeforeTimestamp(timestamp time.Time, startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+
+	var latestIndex iotago.MilestoneIndex
+
+	// binary search for the latest milestone before the timestamp
+	low, high := int64(startIndex), int64(endIndex)
+	for low <= high {
+		mid := low + (high-low)/2
+
+		milestoneTimestamp, err := s.MilestoneTimestampByIndex(iotago.MilestoneIndex(mid))
+		if err != nil {
+			return 0, errors.Wrapf(err, "milestone index: %d", mid)
+		}
+
+		if milestoneTimestamp.Before(timestamp) {
+			latestIndex = iotago.MilestoneIndex(mid)
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+
+	return latestIndex, nil
+}
+
 // SearchLatestMilestoneIndexInStore searches the latest milestone without accessing the cache layer.
 func (s *Storage) SearchLatestMilestoneIndexInStore() iotago.MilestoneIndex {
 	var latestMilestoneIndex iotago.MilestoneIndex
@@ -113,6 +113,9 @@ type Storage struct {
 	// utxo
 	utxoManager *utxo.Manager
 
+	// the read-only storage of pruned blocks
+	coldStorage ColdStorage
+
 	// events
 	Events *packageEvents
 }
@@ -0,0 +1,126 @@
+package storage_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func newPebbleStorage(t *testing.T, databasePath string) (*storage.Storage, map[string]*database.Database) {
+	return newStorage(t, databasePath, database.EnginePebble)
+}
+
+func newStorage(t *testing.T, databasePath string, engine database.Engine) (*storage.Storage, map[string]*database.Database) {
+	tangleDatabase, err := database.DatabaseWithDefaultSettings(filepath.Join(databasePath, "tangle"), true, engine)
+	require.NoError(t, err)
+
+	utxoDatabase, err := database.DatabaseWithDefaultSettings(filepath.Join(databasePath, "utxo"), true, engine)
+	require.NoError(t, err)
+
+	dbStorage, err := storage.New(tangleDatabase.KVStore(), utxoDatabase.KVStore())
+	require.NoError(t, err)
+
+	return dbStorage, map[string]*database.Database{
+		"tangle": tangleDatabase,
+		"utxo":   utxoDatabase,
+	}
+}
+
+func TestStorageCheckpoint(t *testing.T) {
+	databasePath := filepath.Join(t.TempDir(), "database")
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint")
+
+	dbStorage, databases := newPebbleStorage(t, databasePath)
+
+	protoParams := tpkg.RandProtocolParameters()
+	protoParamsBytes, err := protoParams.Serialize(serializer.DeSeriModeNoValidation, nil)
+	require.NoError(t, err)
+	require.NoError(t, dbStorage.StoreProtocolParametersMilestoneOption(&iotago.ProtocolParamsMilestoneOpt{
+		TargetMilestoneIndex: 0,
+		ProtocolVersion:      protoParams.Version,
+		Params:               protoParamsBytes,
+	}))
+
+	// the whole token supply is held by a single output
+	output := tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, tpkg.RandAddress(iotago.AddressEd25519), protoParams.TokenSupply)
+	require.NoError(t, dbStorage.UTXOManager().AddUnspentOutput(output))
+	require.NoError(t, dbStorage.UTXOManager().StoreUnspentTreasuryOutput(&utxo.TreasuryOutput{MilestoneID: tpkg.RandMilestoneID(), Amount: 0}))
+	require.NoError(t, dbStorage.UTXOManager().StoreLedgerIndex(5))
+
+	// the databases of a running node are marked as corrupted until the node is shut down
+	require.NoError(t, dbStorage.MarkDatabasesCorrupted())
+
+	info, err := dbStorage.CreateCheckpoint(context.Background(), checkpointPath, databases)
+	require.NoError(t, err)
+	require.Equal(t, database.EnginePebble, info.Engine)
+	require.EqualValues(t, 5, info.LedgerIndex)
+
+	_, err = dbStorage.CreateCheckpoint(context.Background(), checkpointPath, databases)
+	require.ErrorIs(t, err, database.ErrCheckpointExists)
+
+	require.NoError(t, dbStorage.Shutdown())
+
+	readInfo, err := storage.ReadCheckpointInfo(checkpointPath)
+	require.NoError(t, err)
+	require.Equal(t, info.Engine, readInfo.Engine)
+	require.Equal(t, info.LedgerIndex, readInfo.LedgerIndex)
+
+	checkpointStorage, _ := newPebbleStorage(t, checkpointPath)
+	defer func() { require.NoError(t, checkpointStorage.Shutdown()) }()
+
+	require.NoError(t, checkpointStorage.VerifyCheckpoint(readInfo))
+
+	// the ledger state needs to match the checkpoint info
+	readInfo.LedgerIndex = 6
+	require.Error(t, checkpointStorage.VerifyCheckpoint(readInfo))
+}
+
+func TestStorageCheckpointer(t *testing.T) {
+	databasePath := filepath.Join(t.TempDir(), "database")
+	checkpointPath := filepath.Join(t.TempDir(), "checkpoint")
+
+	// badger has no native checkpoints, the entries of a snapshot are copied in the background
+	dbStorage, databases := newStorage(t, databasePath, database.EngineBadger)
+	defer func() { require.NoError(t, dbStorage.Shutdown()) }()
+
+	require.NoError(t, dbStorage.UTXOManager().StoreLedgerIndex(5))
+
+	checkpointer := storage.NewCheckpointer(dbStorage, databases)
+	require.NoError(t, checkpointer.Start(context.Background(), checkpointPath))
+
+	// the ledger may be changed while the checkpoint is written
+	require.NoError(t, dbStorage.UTXOManager().StoreLedgerIndex(6))
+
+	require.Eventually(t, func() bool {
+		return !checkpointer.Status().Running
+	}, 10*time.Second, 10*time.Millisecond)
+	checkpointer.Stop()
+
+	status := checkpointer.Status()
+	require.NoError(t, status.Err)
+	require.True(t, status.Completed)
+	require.Equal(t, checkpointPath, status.Path)
+	require.Equal(t, database.EngineBadger, status.Engine)
+	require.EqualValues(t, 5, status.LedgerIndex)
+
+	readInfo, err := storage.ReadCheckpointInfo(checkpointPath)
+	require.NoError(t, err)
+	require.EqualValues(t, 5, readInfo.LedgerIndex)
+
+	checkpointStorage, _ := newStorage(t, checkpointPath, database.EngineBadger)
+	defer func() { require.NoError(t, checkpointStorage.Shutdown()) }()
+
+	ledgerIndex, err := checkpointStorage.UTXOManager().ReadLedgerIndex()
+	require.NoError(t, err)
+	require.EqualValues(t, 5, ledgerIndex)
+}
@@ -0,0 +1,60 @@
+package storage_test
+
+import (
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestLatestMilestoneIndexBeforeTimestamp(t *testing.T) {
+	dbStorage, err := storage.New(mapdb.NewMapDB(), mapdb.NewMapDB())
+	require.NoError(t, err)
+
+	pub, prv, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	var mappingPubKey iotago.MilestonePublicKey
+	copy(mappingPubKey[:], pub)
+	pubKeys := []iotago.MilestonePublicKey{mappingPubKey}
+
+	keyMapping := iotago.MilestonePublicKeyMapping{}
+	keyMapping[mappingPubKey] = prv
+
+	// milestone n is issued at n*100 seconds
+	for msIndex := iotago.MilestoneIndex(1); msIndex <= 10; msIndex++ {
+		milestonePayload := iotago.NewMilestone(msIndex, msIndex*100, 2, tpkg.RandMilestoneID(), iotago.BlockIDs{tpkg.RandBlockID()}, tpkg.Rand32ByteHash(), tpkg.Rand32ByteHash())
+		require.NoError(t, milestonePayload.Sign(pubKeys, iotago.InMemoryEd25519MilestoneSigner(keyMapping)))
+
+		cachedMilestone, _ := dbStorage.StoreMilestoneIfAbsent(milestonePayload, tpkg.RandBlockID())
+		require.NotNil(t, cachedMilestone)
+		cachedMilestone.Release(true)
+	}
+
+	for _, test := range []struct {
+		timestamp  int64
+		startIndex iotago.MilestoneIndex
+		expected   iotago.MilestoneIndex
+	}{
+		{timestamp: 50, startIndex: 1, expected: 0},
+		{timestamp: 100, startIndex: 1, expected: 0},
+		{timestamp: 101, startIndex: 1, expected: 1},
+		{timestamp: 550, startIndex: 1, expected: 5},
+		{timestamp: 550, startIndex: 6, expected: 0},
+		{timestamp: 5000, startIndex: 3, expected: 10},
+	} {
+		msIndex, err := dbStorage.LatestMilestoneIndexBeforeTimestamp(time.Unix(test.timestamp, 0), test.startIndex, 10)
+		require.NoError(t, err)
+		require.Equal(t, test.expected, msIndex, "timestamp: %d", test.timestamp)
+	}
+
+	// all milestones in the range need to be stored
+	_, err = dbStorage.LatestMilestoneIndexBeforeTimestamp(time.Unix(5000, 0), 1, 11)
+	require.ErrorIs(t, err, storage.ErrMilestoneNotFound)
+}
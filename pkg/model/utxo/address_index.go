@@ -0,0 +1,560 @@
+package utxo
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/byteutils"
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// addressKeyLength is the length of the address key (address type + 32 bytes).
+	addressKeyLength = 1 + 32
+
+	// maxSkippedEntriesPerCursorLevel is the amount of entries that are skipped while seeking to the cursor
+	// before the remaining keys of the level are iterated separately for every possible byte value.
+	maxSkippedEntriesPerCursorLevel = 256
+)
+
+var (
+	// ErrAddressIndexDisabled is returned if the address index is queried but not enabled.
+	ErrAddressIndexDisabled = errors.New("address index is disabled")
+	// ErrInvalidAddressIndexCursor is returned if an invalid cursor is passed to an address index query.
+	ErrInvalidAddressIndexCursor = errors.New("invalid address index cursor")
+	// ErrAddressOutputsLimitReached is returned if an address owns more unspent outputs than may be aggregated.
+	ErrAddressOutputsLimitReached = errors.New("address outputs limit reached")
+)
+
+// SpentByAddress holds the information about an output that was spent by an address.
+type SpentByAddress struct {
+	// The ID of the spent output.
+	OutputID iotago.OutputID
+	// The index of the milestone that spent the output.
+	MilestoneIndexSpent iotago.MilestoneIndex
+}
+
+// addressesForOutput returns all addresses that own the given output.
+// Basic and NFT outputs are owned by their address unlock condition,
+// alias outputs by their state controller and governor, and foundry outputs by their alias.
+func addressesForOutput(output iotago.Output) []iotago.Address {
+	unlockConditions := output.UnlockConditionSet()
+
+	var addresses []iotago.Address
+	addAddress := func(address iotago.Address) {
+		for _, existing := range addresses {
+			if existing.Equal(address) {
+				return
+			}
+		}
+		addresses = append(addresses, address)
+	}
+
+	if addressUnlock := unlockConditions.Address(); addressUnlock != nil {
+		addAddress(addressUnlock.Address)
+	}
+	if stateControllerUnlock := unlockConditions.StateControllerAddress(); stateControllerUnlock != nil {
+		addAddress(stateControllerUnlock.Address)
+	}
+	if governorUnlock := unlockConditions.GovernorAddress(); governorUnlock != nil {
+		addAddress(governorUnlock.Address)
+	}
+	if immutableAliasUnlock := unlockConditions.ImmutableAlias(); immutableAliasUnlock != nil {
+		addAddress(immutableAliasUnlock.Address)
+	}
+
+	return addresses
+}
+
+func addressIndexKeyPrefix(prefix byte, address iotago.Address) []byte {
+	ms := marshalutil.New(1 + addressKeyLength)
+	ms.WriteByte(prefix)                 // 1 byte
+	ms.WriteBytes([]byte(address.Key())) // 33 bytes
+	return ms.Bytes()
+}
+
+func addressUnspentKey(address iotago.Address, outputID iotago.OutputID) []byte {
+	ms := marshalutil.New(1 + addressKeyLength + iotago.OutputIDLength)
+	ms.WriteBytes(addressIndexKeyPrefix(UTXOStoreKeyPrefixAddressUnspent, address)) // 34 bytes
+	ms.WriteBytes(outputID[:])                                                      // 34 bytes
+	return ms.Bytes()
+}
+
+func addressSpentKey(address iotago.Address, msIndexSpent iotago.MilestoneIndex, outputID iotago.OutputID) []byte {
+	msIndexBytes := make([]byte, 4)
+	// big endian to keep the lexical order of the keys in order of the milestone indexes
+	binary.BigEndian.PutUint32(msIndexBytes, msIndexSpent)
+
+	ms := marshalutil.New(1 + addressKeyLength + 4 + iotago.OutputIDLength)
+	ms.WriteBytes(addressIndexKeyPrefix(UTXOStoreKeyPrefixAddressSpent, address)) // 34 bytes
+	ms.WriteBytes(msIndexBytes)                                                   // 4 bytes
+	ms.WriteBytes(outputID[:])                                                    // 34 bytes
+	return ms.Bytes()
+}
+
+func storeAddressUnspent(output *Output, mutations kvstore.BatchedMutations) error {
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint64(value, output.Deposit())
+
+	for _, address := range addressesForOutput(output.output) {
+		if err := mutations.Set(addressUnspentKey(address, output.outputID), value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteAddressUnspent(output *Output, mutations kvstore.BatchedMutations) error {
+	for _, address := range addressesForOutput(output.output) {
+		if err := mutations.Delete(addressUnspentKey(address, output.outputID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storeAddressSpent(spent *Spent, mutations kvstore.BatchedMutations) error {
+	for _, address := range addressesForOutput(spent.output.output) {
+		if err := mutations.Set(addressSpentKey(address, spent.msIndexSpent, spent.outputID), []byte{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteAddressSpent(spent *Spent, mutations kvstore.BatchedMutations) error {
+	for _, address := range addressesForOutput(spent.output.output) {
+		if err := mutations.Delete(addressSpentKey(address, spent.msIndexSpent, spent.outputID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func storeAddressIndexState(ledgerIndex iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, ledgerIndex)
+
+	return mutations.Set([]byte{UTXOStoreKeyPrefixAddressIndexState}, value)
+}
+
+// invalidateAddressIndex deletes the state of the address index.
+// this is done for every ledger mutation if the index is disabled,
+// so the index gets rebuilt before it is used again.
+func invalidateAddressIndex(mutations kvstore.BatchedMutations) error {
+	return mutations.Delete([]byte{UTXOStoreKeyPrefixAddressIndexState})
+}
+
+// addressIndexApplyOutputs adds the given outputs to the address index.
+func (u *Manager) addressIndexApplyOutputs(newOutputs Outputs, mutations kvstore.BatchedMutations) error {
+	if !u.addressIndexEnabled {
+		return invalidateAddressIndex(mutations)
+	}
+
+	for _, output := range newOutputs {
+		if err := storeAddressUnspent(output, mutations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addressIndexApplyConfirmation applies the mutations of a milestone confirmation to the address index.
+func (u *Manager) addressIndexApplyConfirmation(newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations) error {
+	if !u.addressIndexEnabled {
+		return invalidateAddressIndex(mutations)
+	}
+
+	for _, output := range newOutputs {
+		if err := storeAddressUnspent(output, mutations); err != nil {
+			return err
+		}
+	}
+
+	for _, spent := range newSpents {
+		if err := deleteAddressUnspent(spent.output, mutations); err != nil {
+			return err
+		}
+		if err := storeAddressSpent(spent, mutations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addressIndexRollbackConfirmation reverts the mutations of a milestone confirmation in the address index.
+func (u *Manager) addressIndexRollbackConfirmation(newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations) error {
+	if !u.addressIndexEnabled {
+		return invalidateAddressIndex(mutations)
+	}
+
+	for _, spent := range newSpents {
+		if err := deleteAddressSpent(spent, mutations); err != nil {
+			return err
+		}
+		if err := storeAddressUnspent(spent.output, mutations); err != nil {
+			return err
+		}
+	}
+
+	for _, output := range newOutputs {
+		if err := deleteAddressUnspent(output, mutations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addressIndexPruneSpents removes the pruned spents from the address index.
+func (u *Manager) addressIndexPruneSpents(spents Spents, mutations kvstore.BatchedMutations) error {
+	if !u.addressIndexEnabled {
+		return invalidateAddressIndex(mutations)
+	}
+
+	for _, spent := range spents {
+		if err := deleteAddressSpent(spent, mutations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (u *Manager) clearAddressIndex() error {
+	if err := u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixAddressUnspent}); err != nil {
+		return err
+	}
+	if err := u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixAddressSpent}); err != nil {
+		return err
+	}
+
+	return u.utxoStorage.Delete([]byte{UTXOStoreKeyPrefixAddressIndexState})
+}
+
+// addressIndexEmpty checks whether the database contains no entries of the address index.
+func (u *Manager) addressIndexEmpty() (bool, error) {
+	for _, prefix := range []byte{UTXOStoreKeyPrefixAddressUnspent, UTXOStoreKeyPrefixAddressSpent, UTXOStoreKeyPrefixAddressIndexState} {
+		empty := true
+		if err := u.utxoStorage.IterateKeys([]byte{prefix}, func(_ kvstore.Key) bool {
+			empty = false
+			return false
+		}); err != nil {
+			return false, err
+		}
+
+		if !empty {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// storeEmptyAddressIndexState marks the address index of an empty ledger as consistent.
+func (u *Manager) storeEmptyAddressIndexState() error {
+	if !u.addressIndexEnabled {
+		return nil
+	}
+
+	value := make([]byte, 4)
+	return u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixAddressIndexState}, value)
+}
+
+// AddressIndexEnabled returns whether the address index is maintained.
+func (u *Manager) AddressIndexEnabled() bool {
+	return u.addressIndexEnabled
+}
+
+// AddressIndexLedgerIndexWithoutLocking returns the ledger index since which the spent outputs by address are complete.
+func (u *Manager) AddressIndexLedgerIndexWithoutLocking() (iotago.MilestoneIndex, error) {
+	if !u.addressIndexEnabled {
+		return 0, ErrAddressIndexDisabled
+	}
+
+	value, err := u.utxoStorage.Get([]byte{UTXOStoreKeyPrefixAddressIndexState})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load address index state: %w", err)
+	}
+
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// InitAddressIndex enables or disables the address index.
+// If the index is enabled but was not maintained for the current ledger state,
+// it is rebuilt from the unspent outputs. Spent outputs by address are only
+// available for milestones confirmed after the rebuild.
+// If the index is disabled, all existing index entries are removed.
+// Returns whether the index was rebuilt.
+func (u *Manager) InitAddressIndex(enabled bool) (bool, error) {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	u.addressIndexEnabled = enabled
+
+	if !enabled {
+		// the index is only removed once after it was disabled, not on every start
+		empty, err := u.addressIndexEmpty()
+		if err != nil {
+			return false, err
+		}
+
+		if empty {
+			return false, nil
+		}
+
+		return false, u.clearAddressIndex()
+	}
+
+	consistent, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixAddressIndexState})
+	if err != nil {
+		return false, err
+	}
+
+	if consistent {
+		return false, nil
+	}
+
+	if err := u.clearAddressIndex(); err != nil {
+		return false, err
+	}
+
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return false, err
+	}
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return false, err
+	}
+
+	var innerErr error
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		if err := storeAddressUnspent(output, mutations); err != nil {
+			innerErr = err
+			return false
+		}
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if innerErr != nil {
+		mutations.Cancel()
+		return false, innerErr
+	}
+
+	if err := storeAddressIndexState(ledgerIndex, mutations); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// iterateAfterCursor iterates over the entries with the given prefix that are lexically greater than prefix+cursor, in lexical order.
+// The store can't seek to a key, so the iteration walks up from the longest prefix the entries share with the cursor.
+// On every level the entries up to the byte of the cursor are skipped. If there are too many of them,
+// the remaining keys of the level are iterated per byte value instead, so a page never scans the entries before the cursor.
+// All keys with the given prefix must be longer than prefix+cursor.
+func (u *Manager) iterateAfterCursor(prefix []byte, cursor []byte, consumer kvstore.IteratorKeyValueConsumerFunc) error {
+	for i := len(cursor) - 1; i >= 0; i-- {
+		levelPrefix := byteutils.ConcatBytes(prefix, cursor[:i])
+		cursorByte := cursor[i]
+
+		continueIteration := true
+		skipped := 0
+		tooManySkipped := false
+
+		if err := u.utxoStorage.Iterate(levelPrefix, func(key kvstore.Key, value kvstore.Value) bool {
+			if key[len(levelPrefix)] <= cursorByte {
+				// the entry is smaller than the cursor, or it was already consumed on a deeper level
+				skipped++
+				if skipped > maxSkippedEntriesPerCursorLevel {
+					tooManySkipped = true
+					return false
+				}
+				return true
+			}
+
+			continueIteration = consumer(key, value)
+			return continueIteration
+		}); err != nil {
+			return err
+		}
+
+		if tooManySkipped {
+			// no entry was consumed on this level yet, since the skipped entries are sorted before the others
+			for b := int(cursorByte) + 1; b <= 0xFF && continueIteration; b++ {
+				if err := u.utxoStorage.Iterate(byteutils.ConcatBytes(levelPrefix, []byte{byte(b)}), func(key kvstore.Key, value kvstore.Value) bool {
+					continueIteration = consumer(key, value)
+					return continueIteration
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if !continueIteration {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// iterateIndex iterates over the entries with the given prefix, starting after the given cursor.
+// The consumer is called with the key part after the prefix and the value of each entry.
+// Returns the cursor of the last consumed entry if there are more entries left.
+func (u *Manager) iterateIndex(prefix []byte, cursor []byte, maxResults int, consumer func(keySuffix []byte, value []byte) error) ([]byte, error) {
+	var lastKeySuffix []byte
+	var hasMore bool
+	var count int
+	var innerErr error
+
+	consumeEntry := func(key kvstore.Key, value kvstore.Value) bool {
+		keySuffix := key[len(prefix):]
+
+		if maxResults > 0 && count >= maxResults {
+			hasMore = true
+			return false
+		}
+
+		if err := consumer(keySuffix, value); err != nil {
+			innerErr = err
+			return false
+		}
+		count++
+
+		lastKeySuffix = make([]byte, len(keySuffix))
+		copy(lastKeySuffix, keySuffix)
+
+		return true
+	}
+
+	if cursor == nil {
+		if err := u.utxoStorage.Iterate(prefix, consumeEntry); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := u.iterateAfterCursor(prefix, cursor, consumeEntry); err != nil {
+			return nil, err
+		}
+	}
+
+	if innerErr != nil {
+		return nil, innerErr
+	}
+
+	if !hasMore {
+		return nil, nil
+	}
+
+	return lastKeySuffix, nil
+}
+
+// UnspentOutputIDsByAddressWithoutLocking returns the IDs of the unspent outputs owned by the given address.
+// The iteration starts after the given cursor and returns at most maxResults entries (0 means no limit).
+// The returned cursor can be used to query the next page, it is nil if there are no more results.
+func (u *Manager) UnspentOutputIDsByAddressWithoutLocking(address iotago.Address, cursor []byte, maxResults int) (iotago.OutputIDs, []byte, error) {
+	if !u.addressIndexEnabled {
+		return nil, nil, ErrAddressIndexDisabled
+	}
+
+	if cursor != nil && len(cursor) != iotago.OutputIDLength {
+		return nil, nil, ErrInvalidAddressIndexCursor
+	}
+
+	outputIDs := iotago.OutputIDs{}
+	nextCursor, err := u.iterateIndex(addressIndexKeyPrefix(UTXOStoreKeyPrefixAddressUnspent, address), cursor, maxResults, func(keySuffix []byte, _ []byte) error {
+		outputID, err := ParseOutputID(marshalutil.New(keySuffix))
+		if err != nil {
+			return err
+		}
+		outputIDs = append(outputIDs, outputID)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return outputIDs, nextCursor, nil
+}
+
+// SpentOutputsByAddressWithoutLocking returns the outputs spent by the given address ordered by the milestone index they were spent at.
+// The iteration starts after the given cursor and returns at most maxResults entries (0 means no limit).
+// The returned cursor can be used to query the next page, it is nil if there are no more results.
+func (u *Manager) SpentOutputsByAddressWithoutLocking(address iotago.Address, cursor []byte, maxResults int) ([]*SpentByAddress, []byte, error) {
+	if !u.addressIndexEnabled {
+		return nil, nil, ErrAddressIndexDisabled
+	}
+
+	if cursor != nil && len(cursor) != 4+iotago.OutputIDLength {
+		return nil, nil, ErrInvalidAddressIndexCursor
+	}
+
+	spents := []*SpentByAddress{}
+	nextCursor, err := u.iterateIndex(addressIndexKeyPrefix(UTXOStoreKeyPrefixAddressSpent, address), cursor, maxResults, func(keySuffix []byte, _ []byte) error {
+		if len(keySuffix) != 4+iotago.OutputIDLength {
+			return errors.New("invalid spent by address key length")
+		}
+
+		outputID, err := ParseOutputID(marshalutil.New(keySuffix[4:]))
+		if err != nil {
+			return err
+		}
+
+		spents = append(spents, &SpentByAddress{
+			OutputID:            outputID,
+			MilestoneIndexSpent: binary.BigEndian.Uint32(keySuffix[:4]),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return spents, nextCursor, nil
+}
+
+// AddressBalanceWithoutLocking returns the sum of the deposits and the amount of the unspent outputs owned by the given address.
+// Returns ErrAddressOutputsLimitReached if the address owns more than maxOutputs unspent outputs (0 means no limit).
+func (u *Manager) AddressBalanceWithoutLocking(address iotago.Address, maxOutputs int) (balance uint64, count int, err error) {
+	if !u.addressIndexEnabled {
+		return 0, 0, ErrAddressIndexDisabled
+	}
+
+	nextCursor, err := u.iterateIndex(addressIndexKeyPrefix(UTXOStoreKeyPrefixAddressUnspent, address), nil, maxOutputs, func(_ []byte, value []byte) error {
+		if len(value) != 8 {
+			return errors.New("invalid unspent by address value length")
+		}
+		balance += binary.LittleEndian.Uint64(value)
+		count++
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if nextCursor != nil {
+		return 0, 0, fmt.Errorf("%w: more than %d unspent outputs", ErrAddressOutputsLimitReached, maxOutputs)
+	}
+
+	return balance, count, nil
+}
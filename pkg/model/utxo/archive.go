@@ -0,0 +1,498 @@
+package utxo
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrArchiveDisabled is returned if the archive indexes are queried but the archive mode is not enabled.
+	ErrArchiveDisabled = errors.New("archive mode is disabled")
+	// ErrArchivePruningNotAllowed is returned if the ledger should be pruned while the archive mode is enabled.
+	ErrArchivePruningNotAllowed = errors.New("pruning is not allowed in archive mode")
+	// ErrInvalidArchiveCursor is returned if an invalid cursor is passed to an archive query.
+	ErrInvalidArchiveCursor = errors.New("invalid archive cursor")
+)
+
+// ReferencingMilestone holds the information about the milestone that referenced a block.
+type ReferencingMilestone struct {
+	// The index of the milestone that referenced the block.
+	MilestoneIndex iotago.MilestoneIndex
+	// The index of the block in the white flag ordering of the milestone.
+	WhiteFlagIndex uint32
+}
+
+// SpendingTransaction holds the information about the transaction that spent an output.
+type SpendingTransaction struct {
+	// The ID of the spent output.
+	OutputID iotago.OutputID
+	// The ID of the transaction that spent the output.
+	TransactionIDSpent iotago.TransactionID
+	// The index of the milestone that confirmed the spending transaction.
+	MilestoneIndexSpent iotago.MilestoneIndex
+}
+
+// ArchiveState holds the milestone indexes since which the archive indexes are complete.
+// An index of 0 means that the history of the index has not started yet.
+type ArchiveState struct {
+	// The index of the first milestone whose referenced blocks are indexed.
+	BlocksStartIndex iotago.MilestoneIndex
+	// The index of the first milestone whose spent outputs are indexed.
+	SpentsStartIndex iotago.MilestoneIndex
+}
+
+// ReferencedBlockConsumer is a function that consumes a block that was referenced by a milestone.
+// Returning false from this function indicates to abort the iteration.
+type ReferencedBlockConsumer func(blockID iotago.BlockID, msIndex iotago.MilestoneIndex, wfIndex uint32) bool
+
+// ReferencedBlocksIteratorFunc iterates over all referenced blocks that should be added to the archive.
+type ReferencedBlocksIteratorFunc func(consumer ReferencedBlockConsumer) error
+
+func archiveBlockMilestoneKey(blockID iotago.BlockID) []byte {
+	ms := marshalutil.New(1 + iotago.BlockIDLength)
+	ms.WriteByte(UTXOStoreKeyPrefixArchiveBlockMilestone) // 1 byte
+	ms.WriteBytes(blockID[:])                             // 32 bytes
+	return ms.Bytes()
+}
+
+func archiveMilestoneBlocksKeyPrefix(msIndex iotago.MilestoneIndex) []byte {
+	msIndexBytes := make([]byte, 4)
+	// big endian to keep the lexical order of the keys in order of the milestone indexes
+	binary.BigEndian.PutUint32(msIndexBytes, msIndex)
+
+	ms := marshalutil.New(1 + 4)
+	ms.WriteByte(UTXOStoreKeyPrefixArchiveMilestoneBlocks) // 1 byte
+	ms.WriteBytes(msIndexBytes)                            // 4 bytes
+	return ms.Bytes()
+}
+
+func archiveMilestoneBlocksKey(msIndex iotago.MilestoneIndex, wfIndex uint32) []byte {
+	wfIndexBytes := make([]byte, 4)
+	// big endian to keep the lexical order of the keys in the white flag ordering
+	binary.BigEndian.PutUint32(wfIndexBytes, wfIndex)
+
+	ms := marshalutil.New(1 + 4 + 4)
+	ms.WriteBytes(archiveMilestoneBlocksKeyPrefix(msIndex)) // 5 bytes
+	ms.WriteBytes(wfIndexBytes)                             // 4 bytes
+	return ms.Bytes()
+}
+
+func archiveSpendingTransactionKey(outputID iotago.OutputID) []byte {
+	ms := marshalutil.New(1 + iotago.OutputIDLength)
+	ms.WriteByte(UTXOStoreKeyPrefixArchiveSpendingTransaction) // 1 byte
+	ms.WriteBytes(outputID[:])                                 // 34 bytes
+	return ms.Bytes()
+}
+
+func storeArchivedBlock(blockID iotago.BlockID, msIndex iotago.MilestoneIndex, wfIndex uint32, mutations kvstore.BatchedMutations) error {
+	ms := marshalutil.New(4 + 4)
+	ms.WriteUint32(msIndex) // 4 bytes
+	ms.WriteUint32(wfIndex) // 4 bytes
+
+	if err := mutations.Set(archiveBlockMilestoneKey(blockID), ms.Bytes()); err != nil {
+		return err
+	}
+
+	return mutations.Set(archiveMilestoneBlocksKey(msIndex, wfIndex), blockID[:])
+}
+
+func storeSpendingTransaction(outputID iotago.OutputID, transactionIDSpent iotago.TransactionID, msIndexSpent iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	ms := marshalutil.New(iotago.TransactionIDLength + 4)
+	ms.WriteBytes(transactionIDSpent[:]) // 32 bytes
+	ms.WriteUint32(msIndexSpent)         // 4 bytes
+
+	return mutations.Set(archiveSpendingTransactionKey(outputID), ms.Bytes())
+}
+
+func storeArchiveState(state *ArchiveState, mutations kvstore.BatchedMutations) error {
+	ms := marshalutil.New(4 + 4)
+	ms.WriteUint32(state.BlocksStartIndex) // 4 bytes
+	ms.WriteUint32(state.SpentsStartIndex) // 4 bytes
+
+	return mutations.Set([]byte{UTXOStoreKeyPrefixArchiveState}, ms.Bytes())
+}
+
+// invalidateArchive deletes the state of the archive indexes.
+// this is done for every ledger mutation if the archive mode is disabled,
+// so the indexes get rebuilt before they are used again.
+func invalidateArchive(mutations kvstore.BatchedMutations) error {
+	return mutations.Delete([]byte{UTXOStoreKeyPrefixArchiveState})
+}
+
+func (u *Manager) readArchiveState() (*ArchiveState, error) {
+	value, err := u.utxoStorage.Get([]byte{UTXOStoreKeyPrefixArchiveState})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive state: %w", err)
+	}
+
+	if len(value) != 8 {
+		return nil, errors.New("invalid archive state length")
+	}
+
+	return &ArchiveState{
+		BlocksStartIndex: binary.LittleEndian.Uint32(value[:4]),
+		SpentsStartIndex: binary.LittleEndian.Uint32(value[4:]),
+	}, nil
+}
+
+// archiveApplyConfirmation adds the spending transactions and the referenced blocks of a milestone confirmation to the archive.
+// The referenced blocks need to be in the white flag ordering of the milestone, they are nil if the blocks of the milestone are unknown.
+func (u *Manager) archiveApplyConfirmation(msIndex iotago.MilestoneIndex, newSpents Spents, referencedBlockIDs iotago.BlockIDs, mutations kvstore.BatchedMutations) error {
+	if !u.archiveEnabled {
+		return invalidateArchive(mutations)
+	}
+
+	for _, spent := range newSpents {
+		if err := storeSpendingTransaction(spent.outputID, spent.transactionIDSpent, spent.msIndexSpent, mutations); err != nil {
+			return err
+		}
+	}
+
+	for wfIndex, blockID := range referencedBlockIDs {
+		if err := storeArchivedBlock(blockID, msIndex, uint32(wfIndex), mutations); err != nil {
+			return err
+		}
+	}
+
+	state, err := u.readArchiveState()
+	if err != nil {
+		return err
+	}
+
+	// the history of the indexes starts with the first confirmation after the archive was started on an empty ledger.
+	changed := false
+	if state.BlocksStartIndex == 0 && referencedBlockIDs != nil {
+		state.BlocksStartIndex = msIndex
+		changed = true
+	}
+	if state.SpentsStartIndex == 0 {
+		state.SpentsStartIndex = msIndex
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return storeArchiveState(state, mutations)
+}
+
+// archiveRollbackConfirmation removes the spending transactions and the referenced blocks of a milestone confirmation from the archive.
+func (u *Manager) archiveRollbackConfirmation(msIndex iotago.MilestoneIndex, newSpents Spents, mutations kvstore.BatchedMutations) error {
+	if !u.archiveEnabled {
+		return invalidateArchive(mutations)
+	}
+
+	for _, spent := range newSpents {
+		if err := mutations.Delete(archiveSpendingTransactionKey(spent.outputID)); err != nil {
+			return err
+		}
+	}
+
+	var innerErr error
+	if err := u.utxoStorage.Iterate(archiveMilestoneBlocksKeyPrefix(msIndex), func(key kvstore.Key, value kvstore.Value) bool {
+		blockID := iotago.BlockID{}
+		copy(blockID[:], value)
+
+		if innerErr = mutations.Delete(archiveBlockMilestoneKey(blockID)); innerErr != nil {
+			return false
+		}
+
+		innerErr = mutations.Delete(append([]byte{}, key...))
+		return innerErr == nil
+	}); err != nil {
+		return err
+	}
+	if innerErr != nil {
+		return innerErr
+	}
+
+	state, err := u.readArchiveState()
+	if err != nil {
+		return err
+	}
+
+	// the history of the indexes starts again with the next confirmation
+	// if the first indexed milestone is rolled back.
+	changed := false
+	if msIndex <= state.BlocksStartIndex {
+		state.BlocksStartIndex = 0
+		changed = true
+	}
+	if msIndex <= state.SpentsStartIndex {
+		state.SpentsStartIndex = 0
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return storeArchiveState(state, mutations)
+}
+
+func (u *Manager) clearArchive() error {
+	for _, prefix := range []byte{
+		UTXOStoreKeyPrefixArchiveBlockMilestone,
+		UTXOStoreKeyPrefixArchiveMilestoneBlocks,
+		UTXOStoreKeyPrefixArchiveSpendingTransaction,
+	} {
+		if err := u.utxoStorage.DeletePrefix([]byte{prefix}); err != nil {
+			return err
+		}
+	}
+
+	return u.utxoStorage.Delete([]byte{UTXOStoreKeyPrefixArchiveState})
+}
+
+// storeEmptyArchiveState marks the archive indexes of an empty ledger as consistent.
+func (u *Manager) storeEmptyArchiveState() error {
+	if !u.archiveEnabled {
+		return nil
+	}
+
+	return u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixArchiveState}, make([]byte, 8))
+}
+
+// ArchiveEnabled returns whether the archive mode is enabled.
+func (u *Manager) ArchiveEnabled() bool {
+	return u.archiveEnabled
+}
+
+// ArchiveStateWithoutLocking returns the milestone indexes since which the archive indexes are complete.
+func (u *Manager) ArchiveStateWithoutLocking() (*ArchiveState, error) {
+	if !u.archiveEnabled {
+		return nil, ErrArchiveDisabled
+	}
+
+	return u.readArchiveState()
+}
+
+// ReferencingMilestoneWithoutLocking returns the milestone that referenced the given block.
+func (u *Manager) ReferencingMilestoneWithoutLocking(blockID iotago.BlockID) (*ReferencingMilestone, error) {
+	if !u.archiveEnabled {
+		return nil, ErrArchiveDisabled
+	}
+
+	value, err := u.utxoStorage.Get(archiveBlockMilestoneKey(blockID))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(value) != 8 {
+		return nil, errors.New("invalid archived block value length")
+	}
+
+	return &ReferencingMilestone{
+		MilestoneIndex: binary.LittleEndian.Uint32(value[:4]),
+		WhiteFlagIndex: binary.LittleEndian.Uint32(value[4:]),
+	}, nil
+}
+
+// ReferencedBlocksWithoutLocking returns the IDs of the blocks referenced by the given milestone in white flag ordering.
+// The iteration starts after the given cursor and returns at most maxResults entries (0 means no limit).
+// The returned cursor can be used to query the next page, it is nil if there are no more results.
+func (u *Manager) ReferencedBlocksWithoutLocking(msIndex iotago.MilestoneIndex, cursor []byte, maxResults int) (iotago.BlockIDs, []byte, error) {
+	if !u.archiveEnabled {
+		return nil, nil, ErrArchiveDisabled
+	}
+
+	if cursor != nil && len(cursor) != 4 {
+		return nil, nil, ErrInvalidArchiveCursor
+	}
+
+	blockIDs := iotago.BlockIDs{}
+	nextCursor, err := u.iterateIndex(archiveMilestoneBlocksKeyPrefix(msIndex), cursor, maxResults, func(_ []byte, value []byte) error {
+		if len(value) != iotago.BlockIDLength {
+			return errors.New("invalid archived block ID length")
+		}
+
+		blockID := iotago.BlockID{}
+		copy(blockID[:], value)
+		blockIDs = append(blockIDs, blockID)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blockIDs, nextCursor, nil
+}
+
+// SpendingTransactionsWithoutLocking returns the transactions that spent the outputs of the given transaction.
+// Outputs of the transaction that are still unspent, or were spent before the history of the archive starts, are not included.
+func (u *Manager) SpendingTransactionsWithoutLocking(transactionID iotago.TransactionID) ([]*SpendingTransaction, error) {
+	if !u.archiveEnabled {
+		return nil, ErrArchiveDisabled
+	}
+
+	prefix := append([]byte{UTXOStoreKeyPrefixArchiveSpendingTransaction}, transactionID[:]...)
+
+	spendingTransactions := []*SpendingTransaction{}
+	if _, err := u.iterateIndex(prefix, nil, 0, func(keySuffix []byte, value []byte) error {
+		if len(keySuffix) != iotago.OutputIDLength-iotago.TransactionIDLength {
+			return errors.New("invalid spending transaction key length")
+		}
+		if len(value) != iotago.TransactionIDLength+4 {
+			return errors.New("invalid spending transaction value length")
+		}
+
+		spendingTransaction := &SpendingTransaction{
+			MilestoneIndexSpent: binary.LittleEndian.Uint32(value[iotago.TransactionIDLength:]),
+		}
+		copy(spendingTransaction.OutputID[:], transactionID[:])
+		copy(spendingTransaction.OutputID[iotago.TransactionIDLength:], keySuffix)
+		copy(spendingTransaction.TransactionIDSpent[:], value[:iotago.TransactionIDLength])
+
+		spendingTransactions = append(spendingTransactions, spendingTransaction)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return spendingTransactions, nil
+}
+
+// InitArchive enables or disables the archive mode.
+// If the archive mode is enabled but the indexes were not maintained for the current ledger state,
+// they are rebuilt from the spent outputs in the ledger and the given referenced blocks.
+// The given start indexes define since which milestones the rebuilt indexes are complete.
+// If the archive mode is disabled, all existing index entries are removed.
+// Returns whether the indexes were rebuilt.
+func (u *Manager) InitArchive(enabled bool, blocksStartIndex iotago.MilestoneIndex, spentsStartIndex iotago.MilestoneIndex, referencedBlocksIterator ReferencedBlocksIteratorFunc) (bool, error) {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	u.archiveEnabled = enabled
+
+	if !enabled {
+		return false, u.clearArchive()
+	}
+
+	consistent, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixArchiveState})
+	if err != nil {
+		return false, err
+	}
+
+	if consistent {
+		return false, nil
+	}
+
+	if err := u.clearArchive(); err != nil {
+		return false, err
+	}
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return false, err
+	}
+
+	var innerErr error
+	if err := u.ForEachSpentOutput(func(spent *Spent) bool {
+		if err := storeSpendingTransaction(spent.outputID, spent.transactionIDSpent, spent.msIndexSpent, mutations); err != nil {
+			innerErr = err
+			return false
+		}
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if innerErr != nil {
+		mutations.Cancel()
+		return false, innerErr
+	}
+
+	if referencedBlocksIterator != nil {
+		if err := referencedBlocksIterator(func(blockID iotago.BlockID, msIndex iotago.MilestoneIndex, wfIndex uint32) bool {
+			if msIndex < blocksStartIndex {
+				// blocks before the start of the history are ignored, the history would have gaps otherwise
+				return true
+			}
+
+			if err := storeArchivedBlock(blockID, msIndex, wfIndex, mutations); err != nil {
+				innerErr = err
+				return false
+			}
+			return true
+		}); err != nil {
+			mutations.Cancel()
+			return false, err
+		}
+
+		if innerErr != nil {
+			mutations.Cancel()
+			return false, innerErr
+		}
+	}
+
+	if err := storeArchiveState(&ArchiveState{
+		BlocksStartIndex: blocksStartIndex,
+		SpentsStartIndex: spentsStartIndex,
+	}, mutations); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// BackfillArchiveSpents adds the spending transactions of milestones before the start of the archive history.
+// The spents need to contain the complete milestone diffs of all milestones from msIndexStart to msIndexEnd.
+// The history of the spending transactions is extended to msIndexStart if the milestones connect to it.
+// Returns the new state of the archive.
+func (u *Manager) BackfillArchiveSpents(msIndexStart iotago.MilestoneIndex, msIndexEnd iotago.MilestoneIndex, spents Spents) (*ArchiveState, error) {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	if !u.archiveEnabled {
+		return nil, ErrArchiveDisabled
+	}
+
+	state, err := u.readArchiveState()
+	if err != nil {
+		return nil, err
+	}
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spent := range spents {
+		if state.SpentsStartIndex != 0 && spent.msIndexSpent >= state.SpentsStartIndex {
+			// already part of the history
+			continue
+		}
+
+		if err := storeSpendingTransaction(spent.outputID, spent.transactionIDSpent, spent.msIndexSpent, mutations); err != nil {
+			mutations.Cancel()
+			return nil, err
+		}
+	}
+
+	if state.SpentsStartIndex != 0 && msIndexStart < state.SpentsStartIndex && msIndexEnd+1 >= state.SpentsStartIndex {
+		state.SpentsStartIndex = msIndexStart
+		if err := storeArchiveState(state, mutations); err != nil {
+			mutations.Cancel()
+			return nil, err
+		}
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
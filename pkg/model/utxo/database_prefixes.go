@@ -16,6 +16,34 @@ const (
 	// UTXOStoreKeyPrefixTreasuryOutput defines the prefix for the Treasury Output
 	UTXOStoreKeyPrefixTreasuryOutput byte = 5
 	UTXOStoreKeyPrefixReceipts       byte = 6
+
+	// UTXOStoreKeyPrefixAddressUnspent defines the prefix for the unspent outputs by address lookup
+	UTXOStoreKeyPrefixAddressUnspent byte = 7
+	// UTXOStoreKeyPrefixAddressSpent defines the prefix for the spent outputs by address lookup
+	UTXOStoreKeyPrefixAddressSpent byte = 8
+	// UTXOStoreKeyPrefixAddressIndexState defines the prefix for the state of the address index
+	UTXOStoreKeyPrefixAddressIndexState byte = 9
+
+	// UTXOStoreKeyPrefixLedgerStateHash defines the prefix for the ledger state hash of the current ledger state
+	UTXOStoreKeyPrefixLedgerStateHash byte = 10
+	// UTXOStoreKeyPrefixLedgerStateHashByMilestone defines the prefix for the ledger state hashes of confirmed milestones
+	UTXOStoreKeyPrefixLedgerStateHashByMilestone byte = 11
+
+	// UTXOStoreKeyPrefixArchiveBlockMilestone defines the prefix for the referencing milestone by block lookup
+	UTXOStoreKeyPrefixArchiveBlockMilestone byte = 12
+	// UTXOStoreKeyPrefixArchiveMilestoneBlocks defines the prefix for the referenced blocks by milestone lookup
+	UTXOStoreKeyPrefixArchiveMilestoneBlocks byte = 13
+	// UTXOStoreKeyPrefixArchiveSpendingTransaction defines the prefix for the spending transaction by output lookup
+	UTXOStoreKeyPrefixArchiveSpendingTransaction byte = 14
+	// UTXOStoreKeyPrefixArchiveState defines the prefix for the state of the archive indexes
+	UTXOStoreKeyPrefixArchiveState byte = 15
+
+	// UTXOStoreKeyPrefixLedgerCommitmentNode defines the prefix for the nodes of the ledger commitment tree
+	UTXOStoreKeyPrefixLedgerCommitmentNode byte = 16
+	// UTXOStoreKeyPrefixLedgerCommitmentByMilestone defines the prefix for the ledger commitments of confirmed milestones
+	UTXOStoreKeyPrefixLedgerCommitmentByMilestone byte = 17
+	// UTXOStoreKeyPrefixLedgerCommitmentState defines the prefix for the state of the ledger commitment tree
+	UTXOStoreKeyPrefixLedgerCommitmentState byte = 18
 )
 
 /*
@@ -90,4 +118,123 @@ const (
    Value:
        Receipt (iotago.ReceiptMilestoneOpt.Serialized())
                 1 byte type + X bytes
+
+   Unspent Outputs by Address:
+   ===========================
+   Key:
+       UTXOStoreKeyPrefixAddressUnspent + iotago.Address.Key() + iotago.OutputID
+                   1 byte               +       33 bytes       +     34 bytes
+
+   Value:
+       Amount
+       8 bytes
+
+   Spent Outputs by Address:
+   =========================
+   Key:
+       UTXOStoreKeyPrefixAddressSpent + iotago.Address.Key() + MilestoneIndexSpent (big endian) + iotago.OutputID
+                   1 byte             +       33 bytes       +            4 bytes               +     34 bytes
+
+   Value:
+       Empty
+
+   Address Index State:
+   ====================
+   Key:
+       UTXOStoreKeyPrefixAddressIndexState
+                   1 byte
+
+   Value:
+       iotago.MilestoneIndex (ledger index since which the spent outputs by address are complete)
+          4 bytes
+
+   Ledger State Hash:
+   ==================
+   Key:
+       UTXOStoreKeyPrefixLedgerStateHash
+                   1 byte
+
+   Value:
+       LedgerStateHash (sum of SHA-256(iotago.OutputID + Output) of all unspent outputs, modulo 2^256)
+          32 bytes
+
+   Ledger State Hash by Milestone:
+   ===============================
+   Key:
+       UTXOStoreKeyPrefixLedgerStateHashByMilestone + iotago.MilestoneIndex
+                        1 byte                      +     4 bytes
+
+   Value:
+       LedgerStateHash (after the confirmation of the milestone)
+          32 bytes
+
+   Referencing Milestone by Block:
+   ===============================
+   Key:
+       UTXOStoreKeyPrefixArchiveBlockMilestone + iotago.BlockID
+                       1 byte                  +    32 bytes
+
+   Value:
+       iotago.MilestoneIndex + WhiteFlagIndex
+             4 bytes         +    4 bytes
+
+   Referenced Blocks by Milestone:
+   ===============================
+   Key:
+       UTXOStoreKeyPrefixArchiveMilestoneBlocks + iotago.MilestoneIndex (big endian) + WhiteFlagIndex (big endian)
+                       1 byte                   +             4 bytes                +          4 bytes
+
+   Value:
+       iotago.BlockID
+          32 bytes
+
+   Spending Transaction by Output:
+   ===============================
+   Key:
+       UTXOStoreKeyPrefixArchiveSpendingTransaction + iotago.OutputID
+                          1 byte                    +     34 bytes
+
+   Value:
+       TransactionIDSpent + MilestoneIndexSpent
+            32 bytes      +       4 bytes
+
+   Archive State:
+   ==============
+   Key:
+       UTXOStoreKeyPrefixArchiveState
+                   1 byte
+
+   Value:
+       BlocksStartIndex + SpentsStartIndex (milestone indexes since which the archive indexes are complete)
+           4 bytes      +      4 bytes
+
+   Ledger Commitment Node:
+   =======================
+   Key:
+       UTXOStoreKeyPrefixLedgerCommitmentNode + Depth (big endian) + Path (bits of the key up to the depth, rest zeroed)
+                       1 byte                 +      2 bytes       +     32 bytes
+
+   Value:
+       NodeType (0 = leaf, 1 = internal) + Key | LeftHash + ValueHash | RightHash
+                    1 byte               +     32 bytes   +        32 bytes
+
+   Ledger Commitment by Milestone:
+   ===============================
+   Key:
+       UTXOStoreKeyPrefixLedgerCommitmentByMilestone + iotago.MilestoneIndex
+                         1 byte                      +     4 bytes
+
+   Value:
+       LedgerCommitment (root of the sparse merkle tree over the unspent outputs after the confirmation of the milestone)
+          32 bytes
+
+   Ledger Commitment State:
+   ========================
+   Key:
+       UTXOStoreKeyPrefixLedgerCommitmentState
+                   1 byte
+
+   Value:
+       iotago.MilestoneIndex (ledger index since which the ledger commitments of milestones are available)
+          4 bytes
 */
@@ -0,0 +1,277 @@
+package utxo
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrLedgerCommitmentDisabled is returned if the ledger commitment is queried but not enabled.
+	ErrLedgerCommitmentDisabled = errors.New("ledger commitment is disabled")
+	// ErrLedgerCommitmentNotAvailable is returned if the ledger commitment is not available for the requested milestone.
+	ErrLedgerCommitmentNotAvailable = errors.New("ledger commitment not available")
+)
+
+func ledgerCommitmentKeyForMilestoneIndex(msIndex iotago.MilestoneIndex) []byte {
+	m := marshalutil.New(5)
+	m.WriteByte(UTXOStoreKeyPrefixLedgerCommitmentByMilestone)
+	m.WriteUint32(msIndex)
+	return m.Bytes()
+}
+
+func storeLedgerCommitmentState(ledgerIndex iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	value := make([]byte, 4)
+	binary.LittleEndian.PutUint32(value, ledgerIndex)
+
+	return mutations.Set([]byte{UTXOStoreKeyPrefixLedgerCommitmentState}, value)
+}
+
+// invalidateLedgerCommitment marks the ledger commitment tree as outdated if the ledger is modified while it is not maintained,
+// e.g. by the toolset. The tree gets rebuilt at the next start of the node if the ledger commitment is enabled.
+func invalidateLedgerCommitment(mutations kvstore.BatchedMutations) error {
+	return mutations.Delete([]byte{UTXOStoreKeyPrefixLedgerCommitmentState})
+}
+
+// ledgerCommitmentApply applies the mutations to the ledger commitment tree.
+// If msIndex is given, the resulting ledger commitment is also stored for the milestone.
+func (u *Manager) ledgerCommitmentApply(newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations, msIndex ...iotago.MilestoneIndex) error {
+	if !u.ledgerCommitmentEnabled {
+		return invalidateLedgerCommitment(mutations)
+	}
+
+	tree := newLedgerCommitmentTree(u.utxoStorage)
+
+	// outputs that are created and spent in the same milestone are part of both lists
+	for _, output := range newOutputs {
+		if err := tree.insertOutput(output); err != nil {
+			return err
+		}
+	}
+	for _, spent := range newSpents {
+		if err := tree.removeOutput(spent.output); err != nil {
+			return err
+		}
+	}
+
+	if err := tree.write(mutations); err != nil {
+		return err
+	}
+
+	if len(msIndex) > 0 {
+		root, err := tree.root()
+		if err != nil {
+			return err
+		}
+
+		return mutations.Set(ledgerCommitmentKeyForMilestoneIndex(msIndex[0]), root[:])
+	}
+
+	return nil
+}
+
+// ledgerCommitmentRollback reverts the mutations of a milestone confirmation in the ledger commitment tree.
+func (u *Manager) ledgerCommitmentRollback(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations) error {
+	if !u.ledgerCommitmentEnabled {
+		return invalidateLedgerCommitment(mutations)
+	}
+
+	if err := mutations.Delete(ledgerCommitmentKeyForMilestoneIndex(msIndex)); err != nil {
+		return err
+	}
+
+	tree := newLedgerCommitmentTree(u.utxoStorage)
+
+	for _, spent := range newSpents {
+		if err := tree.insertOutput(spent.output); err != nil {
+			return err
+		}
+	}
+	for _, output := range newOutputs {
+		if err := tree.removeOutput(output); err != nil {
+			return err
+		}
+	}
+
+	return tree.write(mutations)
+}
+
+func deleteLedgerCommitmentForMilestoneIndex(msIndex iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	return mutations.Delete(ledgerCommitmentKeyForMilestoneIndex(msIndex))
+}
+
+// clearLedgerCommitment removes the ledger commitment tree.
+// the state is removed first, so a partly removed tree is never marked as consistent.
+func (u *Manager) clearLedgerCommitment() error {
+	if err := u.utxoStorage.Delete([]byte{UTXOStoreKeyPrefixLedgerCommitmentState}); err != nil {
+		return err
+	}
+
+	for _, prefix := range []byte{
+		UTXOStoreKeyPrefixLedgerCommitmentNode,
+		UTXOStoreKeyPrefixLedgerCommitmentByMilestone,
+	} {
+		if err := u.utxoStorage.DeletePrefix([]byte{prefix}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeEmptyLedgerCommitmentState marks the ledger commitment tree of an empty ledger as consistent.
+func (u *Manager) storeEmptyLedgerCommitmentState() error {
+	if !u.ledgerCommitmentEnabled {
+		return nil
+	}
+
+	return u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixLedgerCommitmentState}, make([]byte, 4))
+}
+
+// LedgerCommitmentEnabled returns whether the ledger commitment is maintained.
+func (u *Manager) LedgerCommitmentEnabled() bool {
+	return u.ledgerCommitmentEnabled
+}
+
+// LedgerCommitmentLedgerIndexWithoutLocking returns the ledger index since which the ledger commitments of milestones are available.
+func (u *Manager) LedgerCommitmentLedgerIndexWithoutLocking() (iotago.MilestoneIndex, error) {
+	if !u.ledgerCommitmentEnabled {
+		return 0, ErrLedgerCommitmentDisabled
+	}
+
+	value, err := u.utxoStorage.Get([]byte{UTXOStoreKeyPrefixLedgerCommitmentState})
+	if err != nil {
+		return 0, fmt.Errorf("failed to load ledger commitment state: %w", err)
+	}
+
+	return binary.LittleEndian.Uint32(value), nil
+}
+
+// InitLedgerCommitment enables or disables the ledger commitment.
+// If the ledger commitment is enabled but was not maintained for the current ledger state,
+// the tree is rebuilt from the unspent outputs. The ledger commitments of milestones are
+// only available for the current ledger index and milestones confirmed after the rebuild.
+// If the ledger commitment is disabled, the tree is removed.
+// Returns whether the tree was rebuilt.
+func (u *Manager) InitLedgerCommitment(enabled bool) (bool, error) {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	u.ledgerCommitmentEnabled = enabled
+
+	if !enabled {
+		return false, u.clearLedgerCommitment()
+	}
+
+	consistent, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixLedgerCommitmentState})
+	if err != nil {
+		return false, err
+	}
+
+	if consistent {
+		return false, nil
+	}
+
+	if err := u.clearLedgerCommitment(); err != nil {
+		return false, err
+	}
+
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return false, err
+	}
+
+	var leaves []*ledgerCommitmentLeaf
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		leaves = append(leaves, &ledgerCommitmentLeaf{
+			key:       ledgerCommitmentKey(output.outputID),
+			valueHash: ledgerCommitmentOutputValueHash(output),
+		})
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		return false, err
+	}
+	sortLedgerCommitmentLeaves(leaves)
+
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := buildLedgerCommitmentTree(ledgerCommitmentPosition{}, leaves, mutations); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if err := storeLedgerCommitmentState(ledgerIndex, mutations); err != nil {
+		mutations.Cancel()
+		return false, err
+	}
+
+	if err := mutations.Commit(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// LedgerCommitmentWithoutLocking returns the ledger commitment of the current ledger state.
+func (u *Manager) LedgerCommitmentWithoutLocking() (LedgerCommitment, error) {
+	if !u.ledgerCommitmentEnabled {
+		return LedgerCommitment{}, ErrLedgerCommitmentDisabled
+	}
+
+	return newLedgerCommitmentTree(u.utxoStorage).root()
+}
+
+// LedgerCommitmentByMilestoneIndexWithoutLocking returns the ledger commitment after the confirmation of the given milestone.
+func (u *Manager) LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex iotago.MilestoneIndex) (LedgerCommitment, error) {
+	var commitment LedgerCommitment
+
+	if !u.ledgerCommitmentEnabled {
+		return commitment, ErrLedgerCommitmentDisabled
+	}
+
+	value, err := u.utxoStorage.Get(ledgerCommitmentKeyForMilestoneIndex(msIndex))
+	if err != nil {
+		if !errors.Is(err, kvstore.ErrKeyNotFound) {
+			return commitment, fmt.Errorf("failed to load ledger commitment: %w", err)
+		}
+
+		// the ledger commitment of the current ledger index is not stored for the milestone
+		// if the ledger state was loaded from a snapshot or the tree was rebuilt at startup.
+		ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+		if err != nil {
+			return commitment, err
+		}
+
+		if msIndex != ledgerIndex {
+			return commitment, ErrLedgerCommitmentNotAvailable
+		}
+
+		return u.LedgerCommitmentWithoutLocking()
+	}
+
+	if len(value) != LedgerCommitmentLength {
+		return commitment, fmt.Errorf("failed to load ledger commitment: invalid length %d", len(value))
+	}
+	copy(commitment[:], value)
+
+	return commitment, nil
+}
+
+// LedgerCommitmentProofWithoutLocking returns the proof whether the output is unspent in the current ledger state.
+// Only the latest version of the tree is kept, so proofs can only be verified against the ledger commitment of the current ledger index.
+// The ledger commitments of older milestones stay available to cross-check the ledger state, but nothing can be proven against them.
+func (u *Manager) LedgerCommitmentProofWithoutLocking(outputID iotago.OutputID) (*LedgerCommitmentProof, error) {
+	if !u.ledgerCommitmentEnabled {
+		return nil, ErrLedgerCommitmentDisabled
+	}
+
+	return newLedgerCommitmentTree(u.utxoStorage).proof(outputID)
+}
@@ -0,0 +1,653 @@
+package utxo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+/*
+   The ledger commitment is the root of a compact sparse merkle tree over the unspent outputs.
+
+   Every unspent output is a leaf at the path given by the bits of its key, BLAKE2b-256(OutputID).
+   A subtree that contains a single leaf is replaced by that leaf, so the depth of the tree
+   only depends on the amount of unspent outputs and not on the key length.
+
+   Hashes:
+       empty subtree:  32 zero bytes
+       leaf:           BLAKE2b-256(0x00 + key + BLAKE2b-256(serialized output))
+       internal node:  BLAKE2b-256(0x01 + left + right)
+*/
+
+const (
+	// LedgerCommitmentLength is the length of a LedgerCommitment.
+	LedgerCommitmentLength = blake2b.Size256
+
+	// ledgerCommitmentKeyBits is the amount of bits of the keys in the tree.
+	ledgerCommitmentKeyBits = LedgerCommitmentLength * 8
+
+	ledgerCommitmentLeafDomain byte = 0
+	ledgerCommitmentNodeDomain byte = 1
+
+	// ledgerCommitmentNodeLength is the length of a stored node (node type + 2 * 32 bytes).
+	ledgerCommitmentNodeLength = 1 + 2*LedgerCommitmentLength
+)
+
+var (
+	// ErrInvalidLedgerCommitmentProof is returned if a ledger commitment proof doesn't prove the requested statement.
+	ErrInvalidLedgerCommitmentProof = errors.New("invalid ledger commitment proof")
+	// ErrLedgerCommitmentInconsistent is returned if a mutation doesn't match the unspent outputs in the tree.
+	ErrLedgerCommitmentInconsistent = errors.New("ledger commitment inconsistent")
+)
+
+// LedgerCommitment is the root of the sparse merkle tree over the unspent outputs of the ledger.
+type LedgerCommitment [LedgerCommitmentLength]byte
+
+// ToHex converts the LedgerCommitment to its hex representation.
+func (c LedgerCommitment) ToHex() string {
+	return iotago.EncodeHex(c[:])
+}
+
+// ledgerCommitmentKey returns the key of the output in the tree.
+func ledgerCommitmentKey(outputID iotago.OutputID) [LedgerCommitmentLength]byte {
+	return blake2b.Sum256(outputID[:])
+}
+
+// ledgerCommitmentValueHash returns the hash of the serialized output that is committed in the leaf.
+func ledgerCommitmentValueHash(outputBytes []byte) [LedgerCommitmentLength]byte {
+	return blake2b.Sum256(outputBytes)
+}
+
+func ledgerCommitmentOutputValueHash(output *Output) [LedgerCommitmentLength]byte {
+	outputBytes, err := output.output.Serialize(serializer.DeSeriModeNoValidation, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return ledgerCommitmentValueHash(outputBytes)
+}
+
+func ledgerCommitmentLeafHash(key [LedgerCommitmentLength]byte, valueHash [LedgerCommitmentLength]byte) [LedgerCommitmentLength]byte {
+	var data [1 + 2*LedgerCommitmentLength]byte
+	data[0] = ledgerCommitmentLeafDomain
+	copy(data[1:], key[:])
+	copy(data[1+LedgerCommitmentLength:], valueHash[:])
+
+	return blake2b.Sum256(data[:])
+}
+
+func ledgerCommitmentNodeHash(left [LedgerCommitmentLength]byte, right [LedgerCommitmentLength]byte) [LedgerCommitmentLength]byte {
+	var data [1 + 2*LedgerCommitmentLength]byte
+	data[0] = ledgerCommitmentNodeDomain
+	copy(data[1:], left[:])
+	copy(data[1+LedgerCommitmentLength:], right[:])
+
+	return blake2b.Sum256(data[:])
+}
+
+// keyBit returns the bit of the key at the given depth of the tree.
+func keyBit(key [LedgerCommitmentLength]byte, depth int) byte {
+	return (key[depth/8] >> (7 - depth%8)) & 1
+}
+
+// ledgerCommitmentPosition is the position of a subtree, given by its depth and the bits of the path to it.
+type ledgerCommitmentPosition struct {
+	depth int
+	path  [LedgerCommitmentLength]byte
+}
+
+func (p ledgerCommitmentPosition) child(bit byte) ledgerCommitmentPosition {
+	child := ledgerCommitmentPosition{
+		depth: p.depth + 1,
+		path:  p.path,
+	}
+	if bit == 1 {
+		child.path[p.depth/8] |= 1 << (7 - p.depth%8)
+	}
+
+	return child
+}
+
+func (p ledgerCommitmentPosition) storageKey() []byte {
+	key := make([]byte, 1+2+LedgerCommitmentLength)
+	key[0] = UTXOStoreKeyPrefixLedgerCommitmentNode
+	binary.BigEndian.PutUint16(key[1:3], uint16(p.depth))
+	copy(key[3:], p.path[:])
+
+	return key
+}
+
+// ledgerCommitmentNode is either a leaf or an internal node of the tree.
+type ledgerCommitmentNode struct {
+	leaf bool
+	// the key and value hash of a leaf, the hashes of the children of an internal node
+	first  [LedgerCommitmentLength]byte
+	second [LedgerCommitmentLength]byte
+}
+
+func newLedgerCommitmentLeaf(key [LedgerCommitmentLength]byte, valueHash [LedgerCommitmentLength]byte) *ledgerCommitmentNode {
+	return &ledgerCommitmentNode{leaf: true, first: key, second: valueHash}
+}
+
+func (n *ledgerCommitmentNode) hash() [LedgerCommitmentLength]byte {
+	if n == nil {
+		return [LedgerCommitmentLength]byte{}
+	}
+	if n.leaf {
+		return ledgerCommitmentLeafHash(n.first, n.second)
+	}
+
+	return ledgerCommitmentNodeHash(n.first, n.second)
+}
+
+func (n *ledgerCommitmentNode) setChildHash(bit byte, hash [LedgerCommitmentLength]byte) {
+	if bit == 0 {
+		n.first = hash
+		return
+	}
+	n.second = hash
+}
+
+func (n *ledgerCommitmentNode) childHash(bit byte) [LedgerCommitmentLength]byte {
+	if bit == 0 {
+		return n.first
+	}
+
+	return n.second
+}
+
+func (n *ledgerCommitmentNode) bytes() []byte {
+	value := make([]byte, ledgerCommitmentNodeLength)
+	if !n.leaf {
+		value[0] = ledgerCommitmentNodeDomain
+	}
+	copy(value[1:], n.first[:])
+	copy(value[1+LedgerCommitmentLength:], n.second[:])
+
+	return value
+}
+
+func parseLedgerCommitmentNode(value []byte) (*ledgerCommitmentNode, error) {
+	if len(value) != ledgerCommitmentNodeLength {
+		return nil, fmt.Errorf("invalid ledger commitment node length %d", len(value))
+	}
+
+	n := &ledgerCommitmentNode{leaf: value[0] == ledgerCommitmentLeafDomain}
+	copy(n.first[:], value[1:])
+	copy(n.second[:], value[1+LedgerCommitmentLength:])
+
+	return n, nil
+}
+
+// ledgerCommitmentTree applies mutations to the tree in the store.
+// Modified nodes are kept in memory until they are written to a batch,
+// because the batched mutations can't be read before they are committed.
+type ledgerCommitmentTree struct {
+	store kvstore.KVStore
+	// the modified nodes by storage key, nil for deleted nodes
+	modified map[string]*ledgerCommitmentNode
+}
+
+func newLedgerCommitmentTree(store kvstore.KVStore) *ledgerCommitmentTree {
+	return &ledgerCommitmentTree{
+		store:    store,
+		modified: make(map[string]*ledgerCommitmentNode),
+	}
+}
+
+func (t *ledgerCommitmentTree) node(pos ledgerCommitmentPosition) (*ledgerCommitmentNode, error) {
+	key := pos.storageKey()
+
+	if n, modified := t.modified[string(key)]; modified {
+		return n, nil
+	}
+
+	value, err := t.store.Get(key)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return parseLedgerCommitmentNode(value)
+}
+
+func (t *ledgerCommitmentTree) setNode(pos ledgerCommitmentPosition, n *ledgerCommitmentNode) {
+	t.modified[string(pos.storageKey())] = n
+}
+
+func (t *ledgerCommitmentTree) root() (LedgerCommitment, error) {
+	n, err := t.node(ledgerCommitmentPosition{})
+	if err != nil {
+		return LedgerCommitment{}, err
+	}
+
+	return n.hash(), nil
+}
+
+func (t *ledgerCommitmentTree) insertOutput(output *Output) error {
+	key := ledgerCommitmentKey(output.outputID)
+	if _, err := t.insert(ledgerCommitmentPosition{}, key, ledgerCommitmentOutputValueHash(output)); err != nil {
+		return errors.WithMessagef(err, "inserting output %s into ledger commitment failed", output.outputID.ToHex())
+	}
+
+	return nil
+}
+
+func (t *ledgerCommitmentTree) removeOutput(output *Output) error {
+	key := ledgerCommitmentKey(output.outputID)
+	if _, err := t.remove(ledgerCommitmentPosition{}, key); err != nil {
+		return errors.WithMessagef(err, "removing output %s from ledger commitment failed", output.outputID.ToHex())
+	}
+
+	return nil
+}
+
+// insert adds the leaf to the subtree at the given position and returns the new hash of the subtree.
+func (t *ledgerCommitmentTree) insert(pos ledgerCommitmentPosition, key [LedgerCommitmentLength]byte, valueHash [LedgerCommitmentLength]byte) ([LedgerCommitmentLength]byte, error) {
+	n, err := t.node(pos)
+	if err != nil {
+		return [LedgerCommitmentLength]byte{}, err
+	}
+
+	switch {
+	case n == nil, n.leaf && n.first == key:
+		leaf := newLedgerCommitmentLeaf(key, valueHash)
+		t.setNode(pos, leaf)
+
+		return leaf.hash(), nil
+
+	case n.leaf:
+		// the subtree contains two leaves now
+		return t.split(pos, n, newLedgerCommitmentLeaf(key, valueHash))
+
+	default:
+		bit := keyBit(key, pos.depth)
+		childHash, err := t.insert(pos.child(bit), key, valueHash)
+		if err != nil {
+			return [LedgerCommitmentLength]byte{}, err
+		}
+		n.setChildHash(bit, childHash)
+		t.setNode(pos, n)
+
+		return n.hash(), nil
+	}
+}
+
+// split replaces the leaf at the given position by the internal nodes down to the depth at which the keys of both leaves differ.
+func (t *ledgerCommitmentTree) split(pos ledgerCommitmentPosition, existing *ledgerCommitmentNode, added *ledgerCommitmentNode) ([LedgerCommitmentLength]byte, error) {
+	if pos.depth >= ledgerCommitmentKeyBits {
+		return [LedgerCommitmentLength]byte{}, errors.WithMessage(ErrLedgerCommitmentInconsistent, "duplicate key")
+	}
+
+	n := &ledgerCommitmentNode{}
+
+	existingBit := keyBit(existing.first, pos.depth)
+	addedBit := keyBit(added.first, pos.depth)
+	if existingBit == addedBit {
+		childHash, err := t.split(pos.child(existingBit), existing, added)
+		if err != nil {
+			return [LedgerCommitmentLength]byte{}, err
+		}
+		n.setChildHash(existingBit, childHash)
+	} else {
+		t.setNode(pos.child(existingBit), existing)
+		t.setNode(pos.child(addedBit), added)
+		n.setChildHash(existingBit, existing.hash())
+		n.setChildHash(addedBit, added.hash())
+	}
+	t.setNode(pos, n)
+
+	return n.hash(), nil
+}
+
+// remove deletes the leaf from the subtree at the given position and returns the node that is now at that position.
+// A subtree that only contains a single leaf afterwards is replaced by that leaf.
+func (t *ledgerCommitmentTree) remove(pos ledgerCommitmentPosition, key [LedgerCommitmentLength]byte) (*ledgerCommitmentNode, error) {
+	n, err := t.node(pos)
+	if err != nil {
+		return nil, err
+	}
+
+	if n == nil || (n.leaf && n.first != key) {
+		return nil, errors.WithMessage(ErrLedgerCommitmentInconsistent, "leaf not found")
+	}
+
+	if n.leaf {
+		t.setNode(pos, nil)
+		return nil, nil
+	}
+
+	bit := keyBit(key, pos.depth)
+	child, err := t.remove(pos.child(bit), key)
+	if err != nil {
+		return nil, err
+	}
+
+	siblingPos := pos.child(1 - bit)
+	siblingHash := n.childHash(1 - bit)
+	siblingEmpty := siblingHash == [LedgerCommitmentLength]byte{}
+
+	switch {
+	case siblingEmpty && (child == nil || child.leaf):
+		// the child is the only content of the subtree
+		if child != nil {
+			t.setNode(pos.child(bit), nil)
+		}
+		t.setNode(pos, child)
+
+		return child, nil
+
+	case child == nil:
+		sibling, err := t.node(siblingPos)
+		if err != nil {
+			return nil, err
+		}
+
+		if sibling != nil && sibling.leaf {
+			// the sibling is the only leaf left in the subtree
+			t.setNode(siblingPos, nil)
+			t.setNode(pos, sibling)
+
+			return sibling, nil
+		}
+	}
+
+	n.setChildHash(bit, child.hash())
+	t.setNode(pos, n)
+
+	return n, nil
+}
+
+// write adds the modified nodes to the batched mutations.
+func (t *ledgerCommitmentTree) write(mutations kvstore.BatchedMutations) error {
+	for key, n := range t.modified {
+		if n == nil {
+			if err := mutations.Delete([]byte(key)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := mutations.Set([]byte(key), n.bytes()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ledgerCommitmentLeaf is a leaf of the tree used to build the tree from scratch.
+type ledgerCommitmentLeaf struct {
+	key       [LedgerCommitmentLength]byte
+	valueHash [LedgerCommitmentLength]byte
+}
+
+// buildLedgerCommitmentTree writes the tree of the given leaves to the batched mutations and returns its root.
+// The leaves need to be sorted by key.
+func buildLedgerCommitmentTree(pos ledgerCommitmentPosition, leaves []*ledgerCommitmentLeaf, mutations kvstore.BatchedMutations) (LedgerCommitment, error) {
+
+	switch len(leaves) {
+	case 0:
+		return LedgerCommitment{}, nil
+
+	case 1:
+		leaf := newLedgerCommitmentLeaf(leaves[0].key, leaves[0].valueHash)
+		if err := mutations.Set(pos.storageKey(), leaf.bytes()); err != nil {
+			return LedgerCommitment{}, err
+		}
+
+		return leaf.hash(), nil
+	}
+
+	if pos.depth >= ledgerCommitmentKeyBits {
+		return LedgerCommitment{}, errors.WithMessage(ErrLedgerCommitmentInconsistent, "duplicate key")
+	}
+
+	// the leaves are sorted, so all leaves with bit 0 at this depth come first
+	split := sort.Search(len(leaves), func(i int) bool {
+		return keyBit(leaves[i].key, pos.depth) == 1
+	})
+
+	left, err := buildLedgerCommitmentTree(pos.child(0), leaves[:split], mutations)
+	if err != nil {
+		return LedgerCommitment{}, err
+	}
+
+	right, err := buildLedgerCommitmentTree(pos.child(1), leaves[split:], mutations)
+	if err != nil {
+		return LedgerCommitment{}, err
+	}
+
+	n := &ledgerCommitmentNode{first: left, second: right}
+	if err := mutations.Set(pos.storageKey(), n.bytes()); err != nil {
+		return LedgerCommitment{}, err
+	}
+
+	return n.hash(), nil
+}
+
+func sortLedgerCommitmentLeaves(leaves []*ledgerCommitmentLeaf) {
+	sort.Slice(leaves, func(i, j int) bool {
+		return bytes.Compare(leaves[i].key[:], leaves[j].key[:]) < 0
+	})
+}
+
+// LedgerCommitmentProof proves whether an output is part of the unspent outputs committed in a LedgerCommitment.
+type LedgerCommitmentProof struct {
+	// The ID of the output the proof is for.
+	OutputID iotago.OutputID
+	// The hashes of the siblings on the path from the root to the position of the output, ordered from the root.
+	Siblings []LedgerCommitment
+	// The key of the leaf found at the position of the output.
+	// It is nil if the subtree at the position is empty.
+	LeafKey *LedgerCommitment
+	// The value hash of the leaf found at the position of the output.
+	LeafValueHash *LedgerCommitment
+}
+
+// proof returns the proof for the output with the given ID.
+func (t *ledgerCommitmentTree) proof(outputID iotago.OutputID) (*LedgerCommitmentProof, error) {
+	key := ledgerCommitmentKey(outputID)
+
+	proof := &LedgerCommitmentProof{
+		OutputID: outputID,
+		Siblings: make([]LedgerCommitment, 0),
+	}
+
+	pos := ledgerCommitmentPosition{}
+	for {
+		n, err := t.node(pos)
+		if err != nil {
+			return nil, err
+		}
+
+		if n == nil {
+			return proof, nil
+		}
+
+		if n.leaf {
+			leafKey := LedgerCommitment(n.first)
+			leafValueHash := LedgerCommitment(n.second)
+			proof.LeafKey = &leafKey
+			proof.LeafValueHash = &leafValueHash
+
+			return proof, nil
+		}
+
+		bit := keyBit(key, pos.depth)
+		proof.Siblings = append(proof.Siblings, n.childHash(1-bit))
+		pos = pos.child(bit)
+	}
+}
+
+// Included returns whether the proof claims that the output is unspent.
+func (p *LedgerCommitmentProof) Included() bool {
+	return p.LeafKey != nil && *p.LeafKey == ledgerCommitmentKey(p.OutputID)
+}
+
+// root computes the root the proof leads to.
+func (p *LedgerCommitmentProof) root() (LedgerCommitment, error) {
+	if len(p.Siblings) > ledgerCommitmentKeyBits {
+		return LedgerCommitment{}, errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "too many siblings: %d", len(p.Siblings))
+	}
+	if (p.LeafKey == nil) != (p.LeafValueHash == nil) {
+		return LedgerCommitment{}, errors.WithMessage(ErrInvalidLedgerCommitmentProof, "incomplete leaf")
+	}
+
+	key := ledgerCommitmentKey(p.OutputID)
+
+	var hash [LedgerCommitmentLength]byte
+	if p.LeafKey != nil {
+		// the leaf needs to be located in the subtree on the path of the output
+		for depth := 0; depth < len(p.Siblings); depth++ {
+			if keyBit(*p.LeafKey, depth) != keyBit(key, depth) {
+				return LedgerCommitment{}, errors.WithMessage(ErrInvalidLedgerCommitmentProof, "leaf not on the path of the output")
+			}
+		}
+		hash = ledgerCommitmentLeafHash(*p.LeafKey, *p.LeafValueHash)
+	}
+
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		if keyBit(key, depth) == 0 {
+			hash = ledgerCommitmentNodeHash(hash, p.Siblings[depth])
+			continue
+		}
+		hash = ledgerCommitmentNodeHash(p.Siblings[depth], hash)
+	}
+
+	return hash, nil
+}
+
+// VerifyMembership checks that the given serialized output is unspent in the ledger state committed by the root.
+func (p *LedgerCommitmentProof) VerifyMembership(root LedgerCommitment, outputBytes []byte) error {
+	if !p.Included() {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "output %s not included", p.OutputID.ToHex())
+	}
+
+	if *p.LeafValueHash != ledgerCommitmentValueHash(outputBytes) {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "output %s doesn't match the committed output", p.OutputID.ToHex())
+	}
+
+	proofRoot, err := p.root()
+	if err != nil {
+		return err
+	}
+
+	if proofRoot != root {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "root mismatch: %s != %s", proofRoot.ToHex(), root.ToHex())
+	}
+
+	return nil
+}
+
+// VerifyNonMembership checks that the output is not unspent in the ledger state committed by the root.
+func (p *LedgerCommitmentProof) VerifyNonMembership(root LedgerCommitment) error {
+	if p.Included() {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "output %s included", p.OutputID.ToHex())
+	}
+
+	proofRoot, err := p.root()
+	if err != nil {
+		return err
+	}
+
+	if proofRoot != root {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "root mismatch: %s != %s", proofRoot.ToHex(), root.ToHex())
+	}
+
+	return nil
+}
+
+type jsonLedgerCommitmentProof struct {
+	OutputID      string   `json:"outputId"`
+	Siblings      []string `json:"siblings"`
+	LeafKey       string   `json:"leafKey,omitempty"`
+	LeafValueHash string   `json:"leafValueHash,omitempty"`
+}
+
+// MarshalJSON returns the JSON representation of the proof.
+func (p *LedgerCommitmentProof) MarshalJSON() ([]byte, error) {
+	j := &jsonLedgerCommitmentProof{
+		OutputID: p.OutputID.ToHex(),
+		Siblings: make([]string, len(p.Siblings)),
+	}
+
+	for i, sibling := range p.Siblings {
+		j.Siblings[i] = sibling.ToHex()
+	}
+
+	if p.LeafKey != nil && p.LeafValueHash != nil {
+		j.LeafKey = p.LeafKey.ToHex()
+		j.LeafValueHash = p.LeafValueHash.ToHex()
+	}
+
+	return json.Marshal(j)
+}
+
+func decodeLedgerCommitment(hexString string) (LedgerCommitment, error) {
+	var commitment LedgerCommitment
+
+	commitmentBytes, err := iotago.DecodeHex(hexString)
+	if err != nil {
+		return commitment, err
+	}
+
+	if len(commitmentBytes) != LedgerCommitmentLength {
+		return commitment, fmt.Errorf("invalid length %d", len(commitmentBytes))
+	}
+	copy(commitment[:], commitmentBytes)
+
+	return commitment, nil
+}
+
+// UnmarshalJSON parses the JSON representation of a proof.
+func (p *LedgerCommitmentProof) UnmarshalJSON(data []byte) error {
+	j := &jsonLedgerCommitmentProof{}
+	if err := json.Unmarshal(data, j); err != nil {
+		return err
+	}
+
+	outputID, err := iotago.OutputIDFromHex(j.OutputID)
+	if err != nil {
+		return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "invalid output ID: %s", err)
+	}
+
+	siblings := make([]LedgerCommitment, len(j.Siblings))
+	for i, sibling := range j.Siblings {
+		if siblings[i], err = decodeLedgerCommitment(sibling); err != nil {
+			return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "invalid sibling %d: %s", i, err)
+		}
+	}
+
+	var leafKey, leafValueHash *LedgerCommitment
+	if len(j.LeafKey) > 0 || len(j.LeafValueHash) > 0 {
+		key, err := decodeLedgerCommitment(j.LeafKey)
+		if err != nil {
+			return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "invalid leaf key: %s", err)
+		}
+		valueHash, err := decodeLedgerCommitment(j.LeafValueHash)
+		if err != nil {
+			return errors.WithMessagef(ErrInvalidLedgerCommitmentProof, "invalid leaf value hash: %s", err)
+		}
+		leafKey = &key
+		leafValueHash = &valueHash
+	}
+
+	p.OutputID = outputID
+	p.Siblings = siblings
+	p.LeafKey = leafKey
+	p.LeafValueHash = leafValueHash
+
+	return nil
+}
@@ -0,0 +1,287 @@
+package utxo
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// LedgerStateHashLength is the length of a LedgerStateHash.
+const LedgerStateHashLength = sha256.Size
+
+var (
+	// ErrLedgerStateHashNotAvailable is returned if the ledger state hash is not maintained for the requested ledger state.
+	ErrLedgerStateHashNotAvailable = errors.New("ledger state hash not available")
+	// ErrLedgerStateHashMismatch is returned if the maintained ledger state hash does not match the computed one.
+	ErrLedgerStateHashMismatch = errors.New("ledger state hash mismatch")
+)
+
+// LedgerStateHash is an order-independent hash of the unspent outputs of the ledger.
+// It is the sum (modulo 2^256) of the SHA-256 hashes of all unspent outputs (outputID + stored output),
+// so it can be updated incrementally by adding the created and subtracting the consumed outputs.
+type LedgerStateHash [LedgerStateHashLength]byte
+
+// ToHex converts the LedgerStateHash to its hex representation.
+func (h LedgerStateHash) ToHex() string {
+	return iotago.EncodeHex(h[:])
+}
+
+func outputStateHash(output *Output) [LedgerStateHashLength]byte {
+	hash := sha256.New()
+	hash.Write(output.outputID[:])
+	hash.Write(output.KVStorableValue())
+
+	var sum [LedgerStateHashLength]byte
+	copy(sum[:], hash.Sum(nil))
+
+	return sum
+}
+
+// add adds the hash of the output to the LedgerStateHash.
+func (h *LedgerStateHash) add(output *Output) {
+	outputHash := outputStateHash(output)
+
+	var carry uint64
+	for i := LedgerStateHashLength - 8; i >= 0; i -= 8 {
+		var sum uint64
+		sum, carry = bits.Add64(binary.BigEndian.Uint64(h[i:i+8]), binary.BigEndian.Uint64(outputHash[i:i+8]), carry)
+		binary.BigEndian.PutUint64(h[i:i+8], sum)
+	}
+}
+
+// remove subtracts the hash of the output from the LedgerStateHash.
+func (h *LedgerStateHash) remove(output *Output) {
+	outputHash := outputStateHash(output)
+
+	var borrow uint64
+	for i := LedgerStateHashLength - 8; i >= 0; i -= 8 {
+		var diff uint64
+		diff, borrow = bits.Sub64(binary.BigEndian.Uint64(h[i:i+8]), binary.BigEndian.Uint64(outputHash[i:i+8]), borrow)
+		binary.BigEndian.PutUint64(h[i:i+8], diff)
+	}
+}
+
+func ledgerStateHashKeyForMilestoneIndex(msIndex iotago.MilestoneIndex) []byte {
+	m := marshalutil.New(5)
+	m.WriteByte(UTXOStoreKeyPrefixLedgerStateHashByMilestone)
+	m.WriteUint32(msIndex)
+	return m.Bytes()
+}
+
+func (u *Manager) readLedgerStateHash(key []byte) (LedgerStateHash, error) {
+	var ledgerStateHash LedgerStateHash
+
+	value, err := u.utxoStorage.Get(key)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return ledgerStateHash, ErrLedgerStateHashNotAvailable
+		}
+		return ledgerStateHash, fmt.Errorf("failed to load ledger state hash: %w", err)
+	}
+
+	if len(value) != LedgerStateHashLength {
+		return ledgerStateHash, fmt.Errorf("failed to load ledger state hash: invalid length %d", len(value))
+	}
+	copy(ledgerStateHash[:], value)
+
+	return ledgerStateHash, nil
+}
+
+// ledgerStateHashApply applies the mutations to the current ledger state hash.
+// If msIndex is given, the resulting ledger state hash is also stored for the milestone.
+// Nothing is done if the ledger state hash is not maintained for the current ledger state.
+func (u *Manager) ledgerStateHashApply(newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations, msIndex ...iotago.MilestoneIndex) error {
+	ledgerStateHash, err := u.readLedgerStateHash([]byte{UTXOStoreKeyPrefixLedgerStateHash})
+	if err != nil {
+		if errors.Is(err, ErrLedgerStateHashNotAvailable) {
+			// the ledger state hash gets rebuilt at the next start of the node
+			return nil
+		}
+		return err
+	}
+
+	for _, output := range newOutputs {
+		ledgerStateHash.add(output)
+	}
+	for _, spent := range newSpents {
+		ledgerStateHash.remove(spent.output)
+	}
+
+	if err := mutations.Set([]byte{UTXOStoreKeyPrefixLedgerStateHash}, ledgerStateHash[:]); err != nil {
+		return err
+	}
+
+	if len(msIndex) > 0 {
+		return mutations.Set(ledgerStateHashKeyForMilestoneIndex(msIndex[0]), ledgerStateHash[:])
+	}
+
+	return nil
+}
+
+// ledgerStateHashRollback reverts the mutations of a milestone confirmation in the current ledger state hash.
+func (u *Manager) ledgerStateHashRollback(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, mutations kvstore.BatchedMutations) error {
+	if err := mutations.Delete(ledgerStateHashKeyForMilestoneIndex(msIndex)); err != nil {
+		return err
+	}
+
+	ledgerStateHash, err := u.readLedgerStateHash([]byte{UTXOStoreKeyPrefixLedgerStateHash})
+	if err != nil {
+		if errors.Is(err, ErrLedgerStateHashNotAvailable) {
+			// the ledger state hash gets rebuilt at the next start of the node
+			return nil
+		}
+		return err
+	}
+
+	for _, spent := range newSpents {
+		ledgerStateHash.add(spent.output)
+	}
+	for _, output := range newOutputs {
+		ledgerStateHash.remove(output)
+	}
+
+	return mutations.Set([]byte{UTXOStoreKeyPrefixLedgerStateHash}, ledgerStateHash[:])
+}
+
+func deleteLedgerStateHashForMilestoneIndex(msIndex iotago.MilestoneIndex, mutations kvstore.BatchedMutations) error {
+	return mutations.Delete(ledgerStateHashKeyForMilestoneIndex(msIndex))
+}
+
+func (u *Manager) clearLedgerStateHash() error {
+	if err := u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixLedgerStateHashByMilestone}); err != nil {
+		return err
+	}
+
+	return u.utxoStorage.Delete([]byte{UTXOStoreKeyPrefixLedgerStateHash})
+}
+
+// storeEmptyLedgerStateHash stores the ledger state hash of an empty ledger.
+func (u *Manager) storeEmptyLedgerStateHash() error {
+	var ledgerStateHash LedgerStateHash
+	return u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixLedgerStateHash}, ledgerStateHash[:])
+}
+
+// ComputeLedgerStateHashWithoutLocking computes the ledger state hash by iterating over all unspent outputs.
+func (u *Manager) ComputeLedgerStateHashWithoutLocking() (LedgerStateHash, error) {
+	var ledgerStateHash LedgerStateHash
+
+	if err := u.ForEachUnspentOutput(func(output *Output) bool {
+		ledgerStateHash.add(output)
+		return true
+	}, ReadLockLedger(false)); err != nil {
+		return LedgerStateHash{}, err
+	}
+
+	return ledgerStateHash, nil
+}
+
+// CheckLedgerStateHash checks that the maintained ledger state hash matches the hash computed from all unspent outputs.
+// The ledger state hash does not depend on the order in which the outputs were added to the ledger,
+// so it also verifies a ledger whose outputs were imported in parallel from a snapshot.
+// The check is skipped if the ledger state hash is not maintained.
+func (u *Manager) CheckLedgerStateHash() error {
+	u.ReadLockLedger()
+	defer u.ReadUnlockLedger()
+
+	ledgerStateHash, err := u.LedgerStateHashWithoutLocking()
+	if err != nil {
+		if errors.Is(err, ErrLedgerStateHashNotAvailable) {
+			return nil
+		}
+		return err
+	}
+
+	computedLedgerStateHash, err := u.ComputeLedgerStateHashWithoutLocking()
+	if err != nil {
+		return err
+	}
+
+	if ledgerStateHash != computedLedgerStateHash {
+		return errors.Wrapf(ErrLedgerStateHashMismatch, "%s != %s", ledgerStateHash.ToHex(), computedLedgerStateHash.ToHex())
+	}
+
+	return nil
+}
+
+// InitLedgerStateHash computes and stores the ledger state hash if it is not maintained for the current ledger state yet.
+// The ledger state hashes of milestones are only available for milestones confirmed after the computation.
+// Returns whether the ledger state hash was computed.
+func (u *Manager) InitLedgerStateHash() (bool, error) {
+	u.WriteLockLedger()
+	defer u.WriteUnlockLedger()
+
+	available, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixLedgerStateHash})
+	if err != nil {
+		return false, err
+	}
+
+	if available {
+		return false, nil
+	}
+
+	if err := u.clearLedgerStateHash(); err != nil {
+		return false, err
+	}
+
+	ledgerStateHash, err := u.ComputeLedgerStateHashWithoutLocking()
+	if err != nil {
+		return false, err
+	}
+
+	if err := u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixLedgerStateHash}, ledgerStateHash[:]); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// LedgerStateHashWithoutLocking returns the ledger state hash of the current ledger state.
+func (u *Manager) LedgerStateHashWithoutLocking() (LedgerStateHash, error) {
+	return u.readLedgerStateHash([]byte{UTXOStoreKeyPrefixLedgerStateHash})
+}
+
+// LedgerStateHash returns the ledger index and the ledger state hash of the current ledger state.
+func (u *Manager) LedgerStateHash() (iotago.MilestoneIndex, LedgerStateHash, error) {
+	u.ReadLockLedger()
+	defer u.ReadUnlockLedger()
+
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return 0, LedgerStateHash{}, err
+	}
+
+	ledgerStateHash, err := u.LedgerStateHashWithoutLocking()
+	if err != nil {
+		return 0, LedgerStateHash{}, err
+	}
+
+	return ledgerIndex, ledgerStateHash, nil
+}
+
+// LedgerStateHashByMilestoneIndexWithoutLocking returns the ledger state hash after the confirmation of the given milestone.
+func (u *Manager) LedgerStateHashByMilestoneIndexWithoutLocking(msIndex iotago.MilestoneIndex) (LedgerStateHash, error) {
+	ledgerStateHash, err := u.readLedgerStateHash(ledgerStateHashKeyForMilestoneIndex(msIndex))
+	if err == nil || !errors.Is(err, ErrLedgerStateHashNotAvailable) {
+		return ledgerStateHash, err
+	}
+
+	// the ledger state hash of the current ledger index is not stored for the milestone
+	// if the ledger state was loaded from a snapshot or the ledger state hash was computed at startup.
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return LedgerStateHash{}, err
+	}
+
+	if msIndex != ledgerIndex {
+		return LedgerStateHash{}, ErrLedgerStateHashNotAvailable
+	}
+
+	return u.LedgerStateHashWithoutLocking()
+}
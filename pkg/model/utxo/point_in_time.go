@@ -0,0 +1,162 @@
+package utxo
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrMilestoneIndexAboveLedgerIndex is returned if the ledger state of a milestone newer than the ledger index is queried.
+	ErrMilestoneIndexAboveLedgerIndex = errors.New("milestone index is above the ledger index")
+	// ErrOutputNotCreatedAtMilestone is returned if an output is queried at a milestone before it was created.
+	ErrOutputNotCreatedAtMilestone = errors.New("output was not created at the given milestone index")
+	// ErrMilestoneIndexTooFarBehindLedgerIndex is returned if reconstructing the ledger state would revert too many milestones.
+	ErrMilestoneIndexTooFarBehindLedgerIndex = errors.New("milestone index is too far behind the ledger index")
+)
+
+// OutputAtMilestone holds the state of an output at a past milestone.
+type OutputAtMilestone struct {
+	// The milestone index the state of the output was reconstructed for.
+	MilestoneIndex iotago.MilestoneIndex
+	// The output, which also contains the milestone that created it.
+	Output *Output
+	// The information about the milestone that consumed the output,
+	// nil if the output is still unspent at the ledger index.
+	// Caution: the output may have been consumed after the queried milestone.
+	Spent *Spent
+}
+
+// SpentAtMilestone returns whether the output was already consumed at the queried milestone.
+func (o *OutputAtMilestone) SpentAtMilestone() bool {
+	return o.Spent != nil && o.Spent.MilestoneIndexSpent() <= o.MilestoneIndex
+}
+
+func (u *Manager) checkMilestoneIndexNotAboveLedgerIndex(msIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+	ledgerIndex, err := u.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return 0, err
+	}
+
+	if msIndex > ledgerIndex {
+		return 0, errors.Wrapf(ErrMilestoneIndexAboveLedgerIndex, "milestone index: %d, ledger index: %d", msIndex, ledgerIndex)
+	}
+
+	return ledgerIndex, nil
+}
+
+// OutputAtMilestoneIndexWithoutLocking reconstructs the state of the given output at the given milestone index.
+// The spent status is derived from the milestone the output was booked at and the milestone it was spent at.
+// Outputs that were spent in pruned milestones are not available anymore, so the milestone index
+// should not be below the pruning index of the node.
+func (u *Manager) OutputAtMilestoneIndexWithoutLocking(outputID iotago.OutputID, msIndex iotago.MilestoneIndex) (*OutputAtMilestone, error) {
+	if _, err := u.checkMilestoneIndexNotAboveLedgerIndex(msIndex); err != nil {
+		return nil, err
+	}
+
+	output, err := u.ReadOutputByOutputIDWithoutLocking(outputID)
+	if err != nil {
+		return nil, err
+	}
+
+	if output.MilestoneIndexBooked() > msIndex {
+		return nil, errors.Wrapf(ErrOutputNotCreatedAtMilestone, "output booked at: %d, milestone index: %d", output.MilestoneIndexBooked(), msIndex)
+	}
+
+	spent, err := u.ReadSpentForOutputIDWithoutLocking(outputID)
+	if err != nil {
+		if !errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, err
+		}
+		spent = nil
+	}
+
+	return &OutputAtMilestone{
+		MilestoneIndex: msIndex,
+		Output:         output,
+		Spent:          spent,
+	}, nil
+}
+
+// outputOwnedByAddress returns whether the given address is one of the owners of the given output.
+func outputOwnedByAddress(output *Output, address iotago.Address) bool {
+	for _, owner := range addressesForOutput(output.output) {
+		if owner.Equal(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnspentOutputsByAddressAtMilestoneIndexWithoutLocking reconstructs the unspent outputs owned by the given address at the given milestone index.
+// The current unspent outputs of the address are taken from the address index, and the milestone diffs
+// between the ledger index and the given milestone index are walked backwards to revert the ledger changes.
+// The milestone diffs are only available for milestones above the pruning index of the node.
+// The outputs are returned in lexical order of their output IDs.
+// Returns ErrMilestoneIndexTooFarBehindLedgerIndex if more than maxMilestones milestones would need to be reverted,
+// and ErrAddressOutputsLimitReached if the address owns more than maxOutputs unspent outputs
+// at the ledger index or at any milestone that is reverted (0 means no limit for both).
+func (u *Manager) UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address iotago.Address, msIndex iotago.MilestoneIndex, maxOutputs int, maxMilestones int) (Outputs, error) {
+	ledgerIndex, err := u.checkMilestoneIndexNotAboveLedgerIndex(msIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxMilestones > 0 && ledgerIndex-msIndex > iotago.MilestoneIndex(maxMilestones) {
+		return nil, errors.Wrapf(ErrMilestoneIndexTooFarBehindLedgerIndex, "milestone index: %d, ledger index: %d, max reverted milestones: %d", msIndex, ledgerIndex, maxMilestones)
+	}
+
+	unspentOutputIDs, nextCursor, err := u.UnspentOutputIDsByAddressWithoutLocking(address, nil, maxOutputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if nextCursor != nil {
+		return nil, fmt.Errorf("%w: more than %d unspent outputs at ledger index %d", ErrAddressOutputsLimitReached, maxOutputs, ledgerIndex)
+	}
+
+	unspentOutputs := make(map[iotago.OutputID]*Output, len(unspentOutputIDs))
+	for _, outputID := range unspentOutputIDs {
+		output, err := u.ReadOutputByOutputIDWithoutLocking(outputID)
+		if err != nil {
+			return nil, fmt.Errorf("reading unspent output %s failed: %w", outputID.ToHex(), err)
+		}
+		unspentOutputs[outputID] = output
+	}
+
+	for index := ledgerIndex; index > msIndex; index-- {
+		msDiff, err := u.MilestoneDiffWithoutLocking(index)
+		if err != nil {
+			return nil, fmt.Errorf("loading milestone diff %d failed: %w", index, err)
+		}
+
+		// the spents need to be reverted before the created outputs,
+		// because outputs can be created and spent in the same milestone.
+		for _, spent := range msDiff.Spents {
+			if outputOwnedByAddress(spent.output, address) {
+				unspentOutputs[spent.outputID] = spent.output
+			}
+		}
+
+		for _, output := range msDiff.Outputs {
+			delete(unspentOutputs, output.outputID)
+		}
+
+		if maxOutputs > 0 && len(unspentOutputs) > maxOutputs {
+			return nil, fmt.Errorf("%w: more than %d unspent outputs at milestone index %d", ErrAddressOutputsLimitReached, maxOutputs, index-1)
+		}
+	}
+
+	outputs := make(LexicalOrderedOutputs, 0, len(unspentOutputs))
+	for _, output := range unspentOutputs {
+		outputs = append(outputs, output)
+	}
+	sort.Sort(outputs)
+
+	return Outputs(outputs), nil
+}
@@ -0,0 +1,246 @@
+package utxo_test
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func unspentOutputIDsByAddress(t *testing.T, manager *utxo.Manager, address iotago.Address, pageSize int) iotago.OutputIDs {
+	var result iotago.OutputIDs
+	var cursor []byte
+	for {
+		outputIDs, nextCursor, err := manager.UnspentOutputIDsByAddressWithoutLocking(address, cursor, pageSize)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(outputIDs), pageSize)
+		result = append(result, outputIDs...)
+
+		if nextCursor == nil {
+			return result
+		}
+		cursor = nextCursor
+	}
+}
+
+func TestAddressIndexApplyRollbackAndPrune(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	rebuilt, err := manager.InitAddressIndex(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+	aliasAddress := tpkg.RandAddress(iotago.AddressAlias)
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 1_000_000),
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 2_000_000),
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputNFT, address, 3_000_000), // spent
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputAlias, address, 4_000_000),
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputFoundry, aliasAddress, 5_000_000),
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+	}
+
+	msIndex := iotago.MilestoneIndex(10)
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	spents := utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[2], msIndex, msTimestamp),
+	}
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+
+	unspentIDs := unspentOutputIDsByAddress(t, manager, address, 1)
+	require.ElementsMatch(t, iotago.OutputIDs{outputs[0].OutputID(), outputs[1].OutputID(), outputs[3].OutputID()}, unspentIDs)
+
+	unspentIDs = unspentOutputIDsByAddress(t, manager, aliasAddress, 10)
+	require.ElementsMatch(t, iotago.OutputIDs{outputs[4].OutputID()}, unspentIDs)
+
+	balance, count, err := manager.AddressBalanceWithoutLocking(address, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7_000_000), balance)
+	require.Equal(t, 3, count)
+
+	spentsByAddress, nextCursor, err := manager.SpentOutputsByAddressWithoutLocking(address, nil, 10)
+	require.NoError(t, err)
+	require.Nil(t, nextCursor)
+	require.Len(t, spentsByAddress, 1)
+	require.Equal(t, outputs[2].OutputID(), spentsByAddress[0].OutputID)
+	require.Equal(t, msIndex, spentsByAddress[0].MilestoneIndexSpent)
+
+	// rolling back the confirmation removes all entries
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+
+	require.Empty(t, unspentOutputIDsByAddress(t, manager, address, 10))
+
+	spentsByAddress, _, err = manager.SpentOutputsByAddressWithoutLocking(address, nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, spentsByAddress)
+
+	// pruning the milestone removes the spent history
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(msIndex, false))
+
+	spentsByAddress, _, err = manager.SpentOutputsByAddressWithoutLocking(address, nil, 10)
+	require.NoError(t, err)
+	require.Empty(t, spentsByAddress)
+
+	require.Len(t, unspentOutputIDsByAddress(t, manager, address, 10), 3)
+}
+
+func TestAddressIndexRebuild(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 1_000_000),
+		tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputNFT, address, 2_000_000),
+	}
+	for _, output := range outputs {
+		require.NoError(t, manager.AddUnspentOutput(output))
+	}
+
+	_, _, err := manager.AddressBalanceWithoutLocking(address, 0)
+	require.ErrorIs(t, err, utxo.ErrAddressIndexDisabled)
+
+	rebuilt, err := manager.InitAddressIndex(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	balance, count, err := manager.AddressBalanceWithoutLocking(address, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3_000_000), balance)
+	require.Equal(t, 2, count)
+
+	// the index is consistent, so it doesn't need to be rebuilt
+	rebuilt, err = manager.InitAddressIndex(true)
+	require.NoError(t, err)
+	require.False(t, rebuilt)
+
+	// mutating the ledger while the index is disabled invalidates the index
+	_, err = manager.InitAddressIndex(false)
+	require.NoError(t, err)
+	require.NoError(t, manager.AddUnspentOutput(tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 3_000_000)))
+
+	rebuilt, err = manager.InitAddressIndex(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	balance, count, err = manager.AddressBalanceWithoutLocking(address, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(6_000_000), balance)
+	require.Equal(t, 3, count)
+}
+
+func TestAddressIndexSnapshotImport(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	_, err := manager.InitAddressIndex(true)
+	require.NoError(t, err)
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+	output := tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 1_000_000)
+
+	// the ledger of a snapshot is imported into the cleared ledger
+	require.NoError(t, manager.ClearLedger(false))
+	require.NoError(t, manager.AddUnspentOutput(output))
+	require.NoError(t, manager.StoreLedgerIndex(100))
+
+	ledgerIndex, err := manager.AddressIndexLedgerIndexWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(100), ledgerIndex)
+
+	require.Equal(t, iotago.OutputIDs{output.OutputID()}, unspentOutputIDsByAddress(t, manager, address, 10))
+
+	// disabling the index removes all entries
+	rebuilt, err := manager.InitAddressIndex(false)
+	require.NoError(t, err)
+	require.False(t, rebuilt)
+
+	rebuilt, err = manager.InitAddressIndex(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	ledgerIndex, err = manager.AddressIndexLedgerIndexWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(100), ledgerIndex)
+}
+
+func TestAddressIndexPagination(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	_, err := manager.InitAddressIndex(true)
+	require.NoError(t, err)
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+
+	// enough outputs that seeking to the cursor needs to skip more entries than allowed per level
+	outputs := make(utxo.Outputs, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		outputs = append(outputs, tpkg.RandUTXOOutputOnAddressWithAmount(iotago.OutputBasic, address, 1_000_000))
+	}
+
+	msIndex := iotago.MilestoneIndex(10)
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	// all spents share the milestone index at the beginning of the key
+	spents := make(utxo.Spents, 0, 500)
+	for _, output := range outputs[:500] {
+		spents = append(spents, tpkg.RandUTXOSpentWithOutput(output, msIndex, msTimestamp))
+	}
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+
+	expectedUnspentIDs := make(iotago.OutputIDs, 0, 500)
+	for _, output := range outputs[500:] {
+		expectedUnspentIDs = append(expectedUnspentIDs, output.OutputID())
+	}
+	sort.Slice(expectedUnspentIDs, func(i, j int) bool {
+		return bytes.Compare(expectedUnspentIDs[i][:], expectedUnspentIDs[j][:]) < 0
+	})
+
+	for _, pageSize := range []int{1, 7, 100, 500, 1000} {
+		require.Equal(t, expectedUnspentIDs, unspentOutputIDsByAddress(t, manager, address, pageSize))
+	}
+
+	for _, pageSize := range []int{3, 100} {
+		var spentIDs iotago.OutputIDs
+		var cursor []byte
+		for {
+			spentsByAddress, nextCursor, err := manager.SpentOutputsByAddressWithoutLocking(address, cursor, pageSize)
+			require.NoError(t, err)
+			require.LessOrEqual(t, len(spentsByAddress), pageSize)
+			for _, spentByAddress := range spentsByAddress {
+				spentIDs = append(spentIDs, spentByAddress.OutputID)
+			}
+
+			if nextCursor == nil {
+				break
+			}
+			cursor = nextCursor
+		}
+
+		require.Len(t, spentIDs, 500)
+		require.True(t, sort.SliceIsSorted(spentIDs, func(i, j int) bool {
+			return bytes.Compare(spentIDs[i][:], spentIDs[j][:]) < 0
+		}))
+	}
+
+	// the balance is only aggregated up to the given amount of outputs
+	balance, count, err := manager.AddressBalanceWithoutLocking(address, 500)
+	require.NoError(t, err)
+	require.Equal(t, uint64(500_000_000), balance)
+	require.Equal(t, 500, count)
+
+	_, _, err = manager.AddressBalanceWithoutLocking(address, 499)
+	require.ErrorIs(t, err, utxo.ErrAddressOutputsLimitReached)
+}
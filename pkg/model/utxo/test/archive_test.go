@@ -0,0 +1,163 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func referencedBlocks(t *testing.T, manager *utxo.Manager, msIndex iotago.MilestoneIndex, pageSize int) iotago.BlockIDs {
+	var result iotago.BlockIDs
+	var cursor []byte
+	for {
+		blockIDs, nextCursor, err := manager.ReferencedBlocksWithoutLocking(msIndex, cursor, pageSize)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(blockIDs), pageSize)
+		result = append(result, blockIDs...)
+
+		if nextCursor == nil {
+			return result
+		}
+		cursor = nextCursor
+	}
+}
+
+func TestArchiveApplyAndRollback(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	rebuilt, err := manager.InitArchive(true, 0, 0, nil)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+		tpkg.RandUTXOOutputWithType(iotago.OutputNFT),
+	}
+
+	blockIDs := iotago.BlockIDs{tpkg.RandBlockID(), tpkg.RandBlockID(), tpkg.RandBlockID()}
+	require.NoError(t, manager.ApplyConfirmationWithReferencedBlocksWithoutLocking(10, outputs, utxo.Spents{}, nil, nil, blockIDs))
+
+	spents := utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[0], 11, tpkg.RandMilestoneTimestamp()),
+	}
+	require.NoError(t, manager.ApplyConfirmationWithReferencedBlocksWithoutLocking(11, utxo.Outputs{}, spents, nil, nil, iotago.BlockIDs{tpkg.RandBlockID()}))
+
+	// the history starts with the first confirmation on the empty ledger
+	archiveState, err := manager.ArchiveStateWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, &utxo.ArchiveState{BlocksStartIndex: 10, SpentsStartIndex: 10}, archiveState)
+
+	require.Equal(t, blockIDs, referencedBlocks(t, manager, 10, 2))
+
+	referencingMilestone, err := manager.ReferencingMilestoneWithoutLocking(blockIDs[2])
+	require.NoError(t, err)
+	require.Equal(t, &utxo.ReferencingMilestone{MilestoneIndex: 10, WhiteFlagIndex: 2}, referencingMilestone)
+
+	spendingTransactions, err := manager.SpendingTransactionsWithoutLocking(outputs[0].OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Equal(t, []*utxo.SpendingTransaction{{
+		OutputID:            outputs[0].OutputID(),
+		TransactionIDSpent:  spents[0].TransactionIDSpent(),
+		MilestoneIndexSpent: 11,
+	}}, spendingTransactions)
+
+	spendingTransactions, err = manager.SpendingTransactionsWithoutLocking(outputs[1].OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Empty(t, spendingTransactions)
+
+	// the history is never pruned in archive mode
+	require.ErrorIs(t, manager.PruneMilestoneIndexWithoutLocking(10, false), utxo.ErrArchivePruningNotAllowed)
+
+	// rolling back the confirmations removes all entries
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(11, utxo.Outputs{}, spents, nil, nil))
+
+	spendingTransactions, err = manager.SpendingTransactionsWithoutLocking(outputs[0].OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Empty(t, spendingTransactions)
+
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(10, outputs, utxo.Spents{}, nil, nil))
+
+	require.Empty(t, referencedBlocks(t, manager, 10, 2))
+
+	_, err = manager.ReferencingMilestoneWithoutLocking(blockIDs[0])
+	require.ErrorIs(t, err, kvstore.ErrKeyNotFound)
+
+	archiveState, err = manager.ArchiveStateWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, &utxo.ArchiveState{}, archiveState)
+}
+
+func TestArchiveRebuildAndBackfill(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+	}
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(20, outputs, utxo.Spents{}, nil, nil))
+
+	spents := utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[0], 21, tpkg.RandMilestoneTimestamp()),
+	}
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(21, utxo.Outputs{}, spents, nil, nil))
+
+	_, err := manager.ArchiveStateWithoutLocking()
+	require.ErrorIs(t, err, utxo.ErrArchiveDisabled)
+
+	blockID := tpkg.RandBlockID()
+	rebuilt, err := manager.InitArchive(true, 21, 20, func(consumer utxo.ReferencedBlockConsumer) error {
+		// blocks before the start of the history are ignored
+		consumer(tpkg.RandBlockID(), 20, 0)
+		consumer(blockID, 21, 0)
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	require.Empty(t, referencedBlocks(t, manager, 20, 10))
+	require.Equal(t, iotago.BlockIDs{blockID}, referencedBlocks(t, manager, 21, 10))
+
+	// the spents are rebuilt from the ledger
+	spendingTransactions, err := manager.SpendingTransactionsWithoutLocking(outputs[0].OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Len(t, spendingTransactions, 1)
+
+	// the indexes are consistent, so they don't need to be rebuilt
+	rebuilt, err = manager.InitArchive(true, 21, 20, nil)
+	require.NoError(t, err)
+	require.False(t, rebuilt)
+
+	// backfilling milestones that connect to the history extends it
+	backfilledSpent := tpkg.RandUTXOSpent(17, tpkg.RandMilestoneTimestamp())
+	archiveState, err := manager.BackfillArchiveSpents(15, 19, utxo.Spents{backfilledSpent})
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(15), archiveState.SpentsStartIndex)
+
+	spendingTransactions, err = manager.SpendingTransactionsWithoutLocking(backfilledSpent.OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Len(t, spendingTransactions, 1)
+
+	// backfilling milestones with a gap to the history doesn't extend it
+	archiveState, err = manager.BackfillArchiveSpents(5, 10, utxo.Spents{tpkg.RandUTXOSpent(7, tpkg.RandMilestoneTimestamp())})
+	require.NoError(t, err)
+	require.Equal(t, iotago.MilestoneIndex(15), archiveState.SpentsStartIndex)
+
+	// disabling the archive mode removes the indexes
+	_, err = manager.InitArchive(false, 0, 0, nil)
+	require.NoError(t, err)
+
+	rebuilt, err = manager.InitArchive(true, 21, 20, nil)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	spendingTransactions, err = manager.SpendingTransactionsWithoutLocking(backfilledSpent.OutputID().TransactionID())
+	require.NoError(t, err)
+	require.Empty(t, spendingTransactions)
+}
@@ -0,0 +1,151 @@
+package utxo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// requireLedgerCommitmentMatchesLedger checks that the incrementally updated ledger commitment
+// equals the ledger commitment of a tree that is built from scratch from the same unspent outputs.
+func requireLedgerCommitmentMatchesLedger(t *testing.T, manager *utxo.Manager) utxo.LedgerCommitment {
+	ledgerCommitment, err := manager.LedgerCommitmentWithoutLocking()
+	require.NoError(t, err)
+
+	unspentOutputs, err := manager.UnspentOutputs()
+	require.NoError(t, err)
+
+	rebuiltManager := utxo.New(mapdb.NewMapDB())
+	if len(unspentOutputs) > 0 {
+		require.NoError(t, rebuiltManager.AddUnspentOutputs(unspentOutputs))
+	}
+	rebuilt, err := rebuiltManager.InitLedgerCommitment(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+
+	rebuiltCommitment, err := rebuiltManager.LedgerCommitmentWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, rebuiltCommitment, ledgerCommitment)
+
+	return ledgerCommitment
+}
+
+func outputBytes(t *testing.T, output *utxo.Output) []byte {
+	outputBytes, err := output.Output().Serialize(serializer.DeSeriModeNoValidation, nil)
+	require.NoError(t, err)
+
+	return outputBytes
+}
+
+func TestLedgerCommitmentProofs(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	rebuilt, err := manager.InitLedgerCommitment(true)
+	require.NoError(t, err)
+	require.True(t, rebuilt)
+	require.Equal(t, utxo.LedgerCommitment{}, requireLedgerCommitmentMatchesLedger(t, manager))
+
+	genesisOutputs := make(utxo.Outputs, 0, 100)
+	for i := 0; i < 100; i++ {
+		genesisOutputs = append(genesisOutputs, tpkg.RandUTXOOutputWithType(iotago.OutputBasic))
+	}
+	require.NoError(t, manager.AddUnspentOutputs(genesisOutputs))
+	genesisCommitment := requireLedgerCommitmentMatchesLedger(t, manager)
+
+	msIndex := iotago.MilestoneIndex(10)
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+		tpkg.RandUTXOOutputWithType(iotago.OutputNFT),
+		tpkg.RandUTXOOutputWithType(iotago.OutputAlias),
+	}
+
+	// spending most of the outputs collapses the subtrees that are left with a single leaf
+	spents := utxo.Spents{tpkg.RandUTXOSpentWithOutput(outputs[1], msIndex, msTimestamp)}
+	for _, output := range genesisOutputs[:80] {
+		spents = append(spents, tpkg.RandUTXOSpentWithOutput(output, msIndex, msTimestamp))
+	}
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	msCommitment := requireLedgerCommitmentMatchesLedger(t, manager)
+	require.NotEqual(t, genesisCommitment, msCommitment)
+
+	byMilestone, err := manager.LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex)
+	require.NoError(t, err)
+	require.Equal(t, msCommitment, byMilestone)
+
+	// the unspent outputs are members of the tree
+	for _, output := range append(utxo.Outputs{outputs[0], outputs[2]}, genesisOutputs[80:]...) {
+		proof, err := manager.LedgerCommitmentProofWithoutLocking(output.OutputID())
+		require.NoError(t, err)
+		require.True(t, proof.Included())
+		require.NoError(t, proof.VerifyMembership(msCommitment, outputBytes(t, output)))
+		require.ErrorIs(t, proof.VerifyNonMembership(msCommitment), utxo.ErrInvalidLedgerCommitmentProof)
+
+		// the proof is only valid for the committed output
+		require.ErrorIs(t, proof.VerifyMembership(msCommitment, outputBytes(t, outputs[1])), utxo.ErrInvalidLedgerCommitmentProof)
+	}
+
+	// the spent outputs are not members of the tree
+	for _, spent := range spents {
+		proof, err := manager.LedgerCommitmentProofWithoutLocking(spent.OutputID())
+		require.NoError(t, err)
+		require.False(t, proof.Included())
+		require.NoError(t, proof.VerifyNonMembership(msCommitment))
+		require.ErrorIs(t, proof.VerifyNonMembership(genesisCommitment), utxo.ErrInvalidLedgerCommitmentProof)
+
+		// the proof can be verified after a JSON round trip
+		proofJSON, err := json.Marshal(proof)
+		require.NoError(t, err)
+
+		proofFromJSON := &utxo.LedgerCommitmentProof{}
+		require.NoError(t, json.Unmarshal(proofJSON, proofFromJSON))
+		require.NoError(t, proofFromJSON.VerifyNonMembership(msCommitment))
+	}
+
+	// rolling back the confirmation restores the previous commitment
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	require.Equal(t, genesisCommitment, requireLedgerCommitmentMatchesLedger(t, manager))
+
+	_, err = manager.LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex)
+	require.ErrorIs(t, err, utxo.ErrLedgerCommitmentNotAvailable)
+
+	// pruning removes the commitment of the milestone
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex+1, utxo.Outputs{tpkg.RandUTXOOutputWithType(iotago.OutputBasic)}, nil, nil, nil))
+	requireLedgerCommitmentMatchesLedger(t, manager)
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(msIndex, false))
+
+	_, err = manager.LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex)
+	require.ErrorIs(t, err, utxo.ErrLedgerCommitmentNotAvailable)
+	_, err = manager.LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex + 1)
+	require.NoError(t, err)
+
+	// clearing the ledger resets the commitment
+	require.NoError(t, manager.ClearLedger(false))
+	require.Equal(t, utxo.LedgerCommitment{}, requireLedgerCommitmentMatchesLedger(t, manager))
+
+	commitmentLedgerIndex, err := manager.LedgerCommitmentLedgerIndexWithoutLocking()
+	require.NoError(t, err)
+	require.Zero(t, commitmentLedgerIndex)
+
+	// storing the ledger index of an imported snapshot moves the commitment ledger index along
+	require.NoError(t, manager.StoreLedgerIndex(msIndex+1))
+	commitmentLedgerIndex, err = manager.LedgerCommitmentLedgerIndexWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, msIndex+1, commitmentLedgerIndex)
+
+	// disabling the ledger commitment removes the tree
+	_, err = manager.InitLedgerCommitment(false)
+	require.NoError(t, err)
+	_, err = manager.LedgerCommitmentProofWithoutLocking(outputs[0].OutputID())
+	require.ErrorIs(t, err, utxo.ErrLedgerCommitmentDisabled)
+}
@@ -0,0 +1,97 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func requireLedgerStateHashMatchesLedger(t *testing.T, manager *utxo.Manager) utxo.LedgerStateHash {
+	ledgerStateHash, err := manager.LedgerStateHashWithoutLocking()
+	require.NoError(t, err)
+
+	computed, err := manager.ComputeLedgerStateHashWithoutLocking()
+	require.NoError(t, err)
+	require.Equal(t, computed, ledgerStateHash)
+
+	return ledgerStateHash
+}
+
+func TestLedgerStateHashApplyRollbackAndPrune(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	computed, err := manager.InitLedgerStateHash()
+	require.NoError(t, err)
+	require.True(t, computed)
+
+	genesisOutput := tpkg.RandUTXOOutputWithType(iotago.OutputBasic)
+	require.NoError(t, manager.AddUnspentOutput(genesisOutput))
+	genesisHash := requireLedgerStateHashMatchesLedger(t, manager)
+
+	outputs := utxo.Outputs{
+		tpkg.RandUTXOOutputWithType(iotago.OutputBasic),
+		tpkg.RandUTXOOutputWithType(iotago.OutputNFT),
+		tpkg.RandUTXOOutputWithType(iotago.OutputAlias),
+	}
+
+	msIndex := iotago.MilestoneIndex(10)
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	spents := utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(genesisOutput, msIndex, msTimestamp),
+		tpkg.RandUTXOSpentWithOutput(outputs[1], msIndex, msTimestamp),
+	}
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	msHash := requireLedgerStateHashMatchesLedger(t, manager)
+	require.NotEqual(t, genesisHash, msHash)
+
+	byMilestone, err := manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex)
+	require.NoError(t, err)
+	require.Equal(t, msHash, byMilestone)
+
+	_, err = manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex - 1)
+	require.ErrorIs(t, err, utxo.ErrLedgerStateHashNotAvailable)
+
+	// the hash is independent of the order in which the outputs were applied
+	otherManager := utxo.New(mapdb.NewMapDB())
+	_, err = otherManager.InitLedgerStateHash()
+	require.NoError(t, err)
+	for i := len(outputs) - 1; i >= 0; i-- {
+		if i == 1 {
+			continue
+		}
+		require.NoError(t, otherManager.AddUnspentOutput(outputs[i]))
+	}
+	otherHash := requireLedgerStateHashMatchesLedger(t, otherManager)
+	require.Equal(t, msHash, otherHash)
+
+	// rolling back the confirmation restores the previous hash
+	require.NoError(t, manager.RollbackConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	require.Equal(t, genesisHash, requireLedgerStateHashMatchesLedger(t, manager))
+
+	_, err = manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex)
+	require.ErrorIs(t, err, utxo.ErrLedgerStateHashNotAvailable)
+
+	// pruning removes the hash of the milestone
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex, outputs, spents, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(msIndex+1, utxo.Outputs{tpkg.RandUTXOOutputWithType(iotago.OutputBasic)}, nil, nil, nil))
+	requireLedgerStateHashMatchesLedger(t, manager)
+	require.NoError(t, manager.PruneMilestoneIndexWithoutLocking(msIndex, false))
+
+	_, err = manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex)
+	require.ErrorIs(t, err, utxo.ErrLedgerStateHashNotAvailable)
+	_, err = manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex + 1)
+	require.NoError(t, err)
+
+	// clearing the ledger resets the hash
+	require.NoError(t, manager.ClearLedger(false))
+	require.Equal(t, utxo.LedgerStateHash{}, requireLedgerStateHashMatchesLedger(t, manager))
+	_, err = manager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex + 1)
+	require.ErrorIs(t, err, utxo.ErrLedgerStateHashNotAvailable)
+}
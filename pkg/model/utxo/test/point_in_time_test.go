@@ -0,0 +1,104 @@
+package utxo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/tpkg"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func outputIDsOfOutputs(outputs utxo.Outputs) iotago.OutputIDs {
+	outputIDs := make(iotago.OutputIDs, 0, len(outputs))
+	for _, output := range outputs {
+		outputIDs = append(outputIDs, output.OutputID())
+	}
+
+	return outputIDs
+}
+
+func randUTXOOutputOnAddressBookedAt(outputType iotago.OutputType, address iotago.Address, amount uint64, msIndexBooked iotago.MilestoneIndex) *utxo.Output {
+	return utxo.CreateOutput(tpkg.RandOutputID(), tpkg.RandBlockID(), msIndexBooked, tpkg.RandMilestoneTimestamp(), tpkg.RandOutputOnAddressWithAmount(outputType, address, amount))
+}
+
+func TestLedgerStateAtMilestoneIndex(t *testing.T) {
+
+	manager := utxo.New(mapdb.NewMapDB())
+	_, err := manager.InitAddressIndex(true)
+	require.NoError(t, err)
+
+	address := tpkg.RandAddress(iotago.AddressEd25519)
+
+	outputs := utxo.Outputs{
+		randUTXOOutputOnAddressBookedAt(iotago.OutputBasic, address, 1_000_000, 10), // spent at 11
+		randUTXOOutputOnAddressBookedAt(iotago.OutputBasic, address, 2_000_000, 10), // spent at 12
+		randUTXOOutputOnAddressBookedAt(iotago.OutputBasic, tpkg.RandAddress(iotago.AddressEd25519), 3_000_000, 10),
+		randUTXOOutputOnAddressBookedAt(iotago.OutputNFT, address, 4_000_000, 11),
+		randUTXOOutputOnAddressBookedAt(iotago.OutputBasic, address, 5_000_000, 12), // spent at 12
+	}
+
+	msTimestamp := tpkg.RandMilestoneTimestamp()
+
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(10, outputs[:3], utxo.Spents{}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(11, outputs[3:4], utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[0], 11, msTimestamp),
+	}, nil, nil))
+	require.NoError(t, manager.ApplyConfirmationWithoutLocking(12, outputs[4:], utxo.Spents{
+		tpkg.RandUTXOSpentWithOutput(outputs[1], 12, msTimestamp),
+		tpkg.RandUTXOSpentWithOutput(outputs[4], 12, msTimestamp),
+	}, nil, nil))
+
+	// the unspent outputs of the address are reconstructed for every milestone
+	for msIndex, expected := range map[iotago.MilestoneIndex]utxo.Outputs{
+		10: {outputs[0], outputs[1]},
+		11: {outputs[1], outputs[3]},
+		12: {outputs[3]},
+	} {
+		unspentOutputs, err := manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, msIndex, 0, 0)
+		require.NoError(t, err)
+		require.ElementsMatch(t, outputIDsOfOutputs(expected), outputIDsOfOutputs(unspentOutputs), "milestone index %d", msIndex)
+	}
+
+	_, err = manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 13, 0, 0)
+	require.ErrorIs(t, err, utxo.ErrMilestoneIndexAboveLedgerIndex)
+
+	// the reconstruction is bounded by the amount of unspent outputs at the ledger index and at every reverted milestone
+	unspentOutputs, err := manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 10, 2, 0)
+	require.NoError(t, err)
+	require.Len(t, unspentOutputs, 2)
+
+	_, err = manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 12, 1, 0)
+	require.NoError(t, err)
+
+	_, err = manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 10, 1, 0)
+	require.ErrorIs(t, err, utxo.ErrAddressOutputsLimitReached)
+
+	// the reconstruction is bounded by the amount of milestones that need to be reverted
+	_, err = manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 10, 0, 2)
+	require.NoError(t, err)
+
+	_, err = manager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, 10, 0, 1)
+	require.ErrorIs(t, err, utxo.ErrMilestoneIndexTooFarBehindLedgerIndex)
+
+	// the output is unspent before the milestone that consumed it
+	outputAtMilestone, err := manager.OutputAtMilestoneIndexWithoutLocking(outputs[0].OutputID(), 10)
+	require.NoError(t, err)
+	require.False(t, outputAtMilestone.SpentAtMilestone())
+	require.Equal(t, iotago.MilestoneIndex(10), outputAtMilestone.Output.MilestoneIndexBooked())
+	require.Equal(t, iotago.MilestoneIndex(11), outputAtMilestone.Spent.MilestoneIndexSpent())
+
+	outputAtMilestone, err = manager.OutputAtMilestoneIndexWithoutLocking(outputs[0].OutputID(), 11)
+	require.NoError(t, err)
+	require.True(t, outputAtMilestone.SpentAtMilestone())
+
+	outputAtMilestone, err = manager.OutputAtMilestoneIndexWithoutLocking(outputs[3].OutputID(), 12)
+	require.NoError(t, err)
+	require.False(t, outputAtMilestone.SpentAtMilestone())
+	require.Nil(t, outputAtMilestone.Spent)
+
+	_, err = manager.OutputAtMilestoneIndexWithoutLocking(outputs[3].OutputID(), 10)
+	require.ErrorIs(t, err, utxo.ErrOutputNotCreatedAtMilestone)
+}
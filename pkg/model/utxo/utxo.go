@@ -20,6 +20,15 @@ var (
 type Manager struct {
 	utxoStorage kvstore.KVStore
 	utxoLock    sync.RWMutex
+
+	// whether the unspent and spent outputs are indexed by address
+	addressIndexEnabled bool
+
+	// whether the history of the ledger is kept and indexed for historical queries
+	archiveEnabled bool
+
+	// whether the sparse merkle tree over the unspent outputs is maintained
+	ledgerCommitmentEnabled bool
 }
 
 func New(store kvstore.KVStore) *Manager {
@@ -44,9 +53,46 @@ func (u *Manager) ClearLedger(pruneReceipts bool) (err error) {
 		}
 	}()
 
+	// the ledger commitment state is removed together with the ledger index before anything else is cleared,
+	// so the tree is rebuilt at the next start if the node crashes while the ledger is cleared.
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return err
+	}
+
+	if err = mutations.Delete([]byte{UTXOStoreKeyPrefixLedgerMilestoneIndex}); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err = invalidateLedgerCommitment(mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err = mutations.Commit(); err != nil {
+		return err
+	}
+
 	if pruneReceipts {
 		// if we also prune the receipts, we can just clear everything
-		return u.utxoStorage.Clear()
+		if err = u.utxoStorage.Clear(); err != nil {
+			return err
+		}
+
+		if err = u.storeEmptyLedgerStateHash(); err != nil {
+			return err
+		}
+
+		if err = u.storeEmptyArchiveState(); err != nil {
+			return err
+		}
+
+		if err = u.storeEmptyLedgerCommitmentState(); err != nil {
+			return err
+		}
+
+		return u.storeEmptyAddressIndexState()
 	}
 
 	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixLedgerMilestoneIndex}); err != nil {
@@ -68,8 +114,29 @@ func (u *Manager) ClearLedger(pruneReceipts bool) (err error) {
 	if err = u.utxoStorage.DeletePrefix([]byte{UTXOStoreKeyPrefixTreasuryOutput}); err != nil {
 		return err
 	}
+	if err = u.clearLedgerStateHash(); err != nil {
+		return err
+	}
+	if err = u.storeEmptyLedgerStateHash(); err != nil {
+		return err
+	}
+	if err = u.clearArchive(); err != nil {
+		return err
+	}
+	if err = u.storeEmptyArchiveState(); err != nil {
+		return err
+	}
+	if err = u.clearLedgerCommitment(); err != nil {
+		return err
+	}
+	if err = u.storeEmptyLedgerCommitmentState(); err != nil {
+		return err
+	}
+	if err = u.clearAddressIndex(); err != nil {
+		return err
+	}
 
-	return nil
+	return u.storeEmptyAddressIndexState()
 }
 
 func (u *Manager) ReadLockLedger() {
@@ -90,6 +157,10 @@ func (u *Manager) WriteUnlockLedger() {
 
 func (u *Manager) PruneMilestoneIndexWithoutLocking(msIndex iotago.MilestoneIndex, pruneReceipts bool, receiptMigratedAtIndex ...iotago.MilestoneIndex) error {
 
+	if u.archiveEnabled {
+		return ErrArchivePruningNotAllowed
+	}
+
 	diff, err := u.MilestoneDiffWithoutLocking(msIndex)
 	if err != nil {
 		return err
@@ -112,11 +183,26 @@ func (u *Manager) PruneMilestoneIndexWithoutLocking(msIndex iotago.MilestoneInde
 		}
 	}
 
+	if err := u.addressIndexPruneSpents(diff.Spents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
 	if err := deleteDiff(msIndex, mutations); err != nil {
 		mutations.Cancel()
 		return err
 	}
 
+	if err := deleteLedgerStateHashForMilestoneIndex(msIndex, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := deleteLedgerCommitmentForMilestoneIndex(msIndex, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
 	if len(receiptMigratedAtIndex) > 0 {
 		if pruneReceipts {
 			placeHolder := &ReceiptTuple{Receipt: &iotago.ReceiptMilestoneOpt{MigratedAt: receiptMigratedAtIndex[0]}, MilestoneIndex: msIndex}
@@ -144,14 +230,54 @@ func storeLedgerIndex(msIndex iotago.MilestoneIndex, mutations kvstore.BatchedMu
 	return mutations.Set([]byte{UTXOStoreKeyPrefixLedgerMilestoneIndex}, value)
 }
 
+// StoreLedgerIndex stores the given ledger index, e.g. the ledger index of an imported snapshot.
+// If the ledger commitment is consistent, the ledger commitments of milestones are available since this index.
+// If the address index is consistent, the spent outputs by address are complete since this index.
 func (u *Manager) StoreLedgerIndex(msIndex iotago.MilestoneIndex) error {
 	u.WriteLockLedger()
 	defer u.WriteUnlockLedger()
 
-	value := make([]byte, 4)
-	binary.LittleEndian.PutUint32(value, msIndex)
+	mutations, err := u.utxoStorage.Batched()
+	if err != nil {
+		return err
+	}
 
-	return u.utxoStorage.Set([]byte{UTXOStoreKeyPrefixLedgerMilestoneIndex}, value)
+	if err := storeLedgerIndex(msIndex, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if u.ledgerCommitmentEnabled {
+		consistent, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixLedgerCommitmentState})
+		if err != nil {
+			mutations.Cancel()
+			return err
+		}
+
+		if consistent {
+			if err := storeLedgerCommitmentState(msIndex, mutations); err != nil {
+				mutations.Cancel()
+				return err
+			}
+		}
+	}
+
+	if u.addressIndexEnabled {
+		consistent, err := u.utxoStorage.Has([]byte{UTXOStoreKeyPrefixAddressIndexState})
+		if err != nil {
+			mutations.Cancel()
+			return err
+		}
+
+		if consistent {
+			if err := storeAddressIndexState(msIndex, mutations); err != nil {
+				mutations.Cancel()
+				return err
+			}
+		}
+	}
+
+	return mutations.Commit()
 }
 
 func (u *Manager) ReadLedgerIndexWithoutLocking() (iotago.MilestoneIndex, error) {
@@ -183,6 +309,13 @@ type TreasuryMutationTuple struct {
 }
 
 func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple) error {
+	return u.ApplyConfirmationWithReferencedBlocksWithoutLocking(msIndex, newOutputs, newSpents, tm, rt, nil)
+}
+
+// ApplyConfirmationWithReferencedBlocksWithoutLocking applies the confirmation of a milestone to the ledger.
+// If the archive mode is enabled, the blocks referenced by the milestone are archived in the same batch,
+// so the archive and the ledger index are always consistent. The blocks need to be in the white flag ordering of the milestone.
+func (u *Manager) ApplyConfirmationWithReferencedBlocksWithoutLocking(msIndex iotago.MilestoneIndex, newOutputs Outputs, newSpents Spents, tm *TreasuryMutationTuple, rt *ReceiptTuple, referencedBlockIDs iotago.BlockIDs) error {
 
 	mutations, err := u.utxoStorage.Batched()
 	if err != nil {
@@ -207,6 +340,26 @@ func (u *Manager) ApplyConfirmationWithoutLocking(msIndex iotago.MilestoneIndex,
 		}
 	}
 
+	if err := u.addressIndexApplyConfirmation(newOutputs, newSpents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.archiveApplyConfirmation(msIndex, newSpents, referencedBlockIDs, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.ledgerStateHashApply(newOutputs, newSpents, mutations, msIndex); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.ledgerCommitmentApply(newOutputs, newSpents, mutations, msIndex); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
 	msDiff := &MilestoneDiff{
 		Index:   msIndex,
 		Outputs: newOutputs,
@@ -288,6 +441,26 @@ func (u *Manager) RollbackConfirmationWithoutLocking(msIndex iotago.MilestoneInd
 		}
 	}
 
+	if err := u.addressIndexRollbackConfirmation(newOutputs, newSpents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.archiveRollbackConfirmation(msIndex, newSpents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.ledgerStateHashRollback(msIndex, newOutputs, newSpents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.ledgerCommitmentRollback(msIndex, newOutputs, newSpents, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
 	if rt != nil {
 		if err := deleteReceipt(rt, mutations); err != nil {
 			mutations.Cancel()
@@ -348,6 +521,11 @@ func (u *Manager) CheckLedgerState(tokenSupply uint64) error {
 }
 
 func (u *Manager) AddUnspentOutput(unspentOutput *Output) error {
+	return u.AddUnspentOutputs(Outputs{unspentOutput})
+}
+
+// AddUnspentOutputs adds the given unspent outputs to the ledger in a single batch.
+func (u *Manager) AddUnspentOutputs(unspentOutputs Outputs) error {
 
 	u.WriteLockLedger()
 	defer u.WriteUnlockLedger()
@@ -357,12 +535,29 @@ func (u *Manager) AddUnspentOutput(unspentOutput *Output) error {
 		return err
 	}
 
-	if err := storeOutput(unspentOutput, mutations); err != nil {
+	for _, unspentOutput := range unspentOutputs {
+		if err := storeOutput(unspentOutput, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+
+		if err := markAsUnspent(unspentOutput, mutations); err != nil {
+			mutations.Cancel()
+			return err
+		}
+	}
+
+	if err := u.addressIndexApplyOutputs(unspentOutputs, mutations); err != nil {
+		mutations.Cancel()
+		return err
+	}
+
+	if err := u.ledgerStateHashApply(unspentOutputs, nil, mutations); err != nil {
 		mutations.Cancel()
 		return err
 	}
 
-	if err := markAsUnspent(unspentOutput, mutations); err != nil {
+	if err := u.ledgerCommitmentApply(unspentOutputs, nil, mutations); err != nil {
 		mutations.Cancel()
 		return err
 	}
@@ -25,6 +25,10 @@ import (
 
 const (
 	PrivKeyFileName = "identity.key"
+
+	// the realm of the peer reputations within the peer store database.
+	// the keys of the libp2p peer store always start with "/", so they don't collide with this realm.
+	reputationStoreRealm = "reputation"
 )
 
 var (
@@ -43,6 +47,11 @@ func (psc *PeerStoreContainer) Peerstore() peerstore.Peerstore {
 	return psc.peerStore
 }
 
+// ReputationKVStore returns the store in which the reputations of peers are persisted.
+func (psc *PeerStoreContainer) ReputationKVStore() (kvstore.KVStore, error) {
+	return psc.store.WithRealm([]byte(reputationStoreRealm))
+}
+
 // Flush persists all outstanding write operations to disc.
 func (psc *PeerStoreContainer) Flush() error {
 	return psc.store.Flush()
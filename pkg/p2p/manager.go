@@ -152,6 +152,12 @@ type ManagerOptions struct {
 	reconnectInterval time.Duration
 	// The randomized jitter applied to the reconnect interval.
 	reconnectIntervalJitter time.Duration
+	// The store holding the reputation of the peers.
+	reputationStore *ReputationStore
+	// The score below which not known peers are disconnected.
+	reputationMinScore float64
+	// The interval in which the reputation of not known peers is checked.
+	reputationCheckInterval time.Duration
 }
 
 // ManagerOption is a function setting a ManagerOptions option.
@@ -173,6 +179,17 @@ func WithManagerReconnectInterval(interval time.Duration, jitter time.Duration)
 	}
 }
 
+// WithManagerReputation lets the Manager disconnect and reject not known peers
+// of which the reputation score is below the given minimum score.
+// Known peers are never disconnected because of their reputation.
+func WithManagerReputation(store *ReputationStore, minScore float64, checkInterval time.Duration) ManagerOption {
+	return func(opts *ManagerOptions) {
+		opts.reputationStore = store
+		opts.reputationMinScore = minScore
+		opts.reputationCheckInterval = checkInterval
+	}
+}
+
 // applies the given ManagerOption.
 func (mo *ManagerOptions) apply(opts ...ManagerOption) {
 	for _, opt := range opts {
@@ -465,13 +482,18 @@ func (m *Manager) ConnectedCount(relation ...PeerRelation) int {
 	return count
 }
 
+// Reputation returns the store holding the reputation of the peers.
+// Returns nil if the reputation of the peers is not tracked.
+func (m *Manager) Reputation() *ReputationStore {
+	return m.opts.reputationStore
+}
+
 // PeerInfoSnapshot returns a snapshot of information of a peer with given id.
 // If the peer is not known to the Manager, result is nil.
 func (m *Manager) PeerInfoSnapshot(id peer.ID) *PeerInfoSnapshot {
 	var info *PeerInfoSnapshot
 	m.Call(id, func(p *Peer) {
-		info = p.InfoSnapshot()
-		info.Connected = m.host.Network().Connectedness(p.ID) == network.Connected
+		info = m.peerInfoSnapshot(p)
 	})
 	return info
 }
@@ -480,14 +502,21 @@ func (m *Manager) PeerInfoSnapshot(id peer.ID) *PeerInfoSnapshot {
 func (m *Manager) PeerInfoSnapshots() []*PeerInfoSnapshot {
 	infos := make([]*PeerInfoSnapshot, 0)
 	m.ForEach(func(p *Peer) bool {
-		info := p.InfoSnapshot()
-		info.Connected = m.host.Network().Connectedness(p.ID) == network.Connected
-		infos = append(infos, info)
+		infos = append(infos, m.peerInfoSnapshot(p))
 		return true
 	})
 	return infos
 }
 
+func (m *Manager) peerInfoSnapshot(p *Peer) *PeerInfoSnapshot {
+	info := p.InfoSnapshot()
+	info.Connected = m.host.Network().Connectedness(p.ID) == network.Connected
+	if m.opts.reputationStore != nil {
+		info.Reputation = m.opts.reputationStore.Score(p.ID)
+	}
+	return info
+}
+
 // PeerFunc gets called with the given Peer.
 type PeerFunc func(p *Peer)
 
@@ -583,12 +612,24 @@ type callmsg struct {
 // becomes very messy, especially since libp2p's notifiee system isn't clear on
 // what event is triggered when.
 func (m *Manager) eventLoop(ctx context.Context) {
+
+	// the reputation check is disabled if the channel is nil
+	var reputationCheckChan <-chan time.Time
+	if m.opts.reputationStore != nil && m.opts.reputationCheckInterval > 0 {
+		reputationCheckTicker := time.NewTicker(m.opts.reputationCheckInterval)
+		defer reputationCheckTicker.Stop()
+		reputationCheckChan = reputationCheckTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			m.shutdown()
 			return
 
+		case <-reputationCheckChan:
+			m.checkReputations()
+
 		case connectPeerMsg := <-m.connectPeerChan:
 			m.connectPeer(connectPeerMsg)
 
@@ -661,6 +702,10 @@ func (m *Manager) eventLoop(ctx context.Context) {
 			isConnectedReqMsg.back <- connected
 
 		case connectedMsg := <-m.connectedChan:
+			if m.rejectIfReputationTooLow(connectedMsg.conn) {
+				continue
+			}
+
 			p := m.peers[connectedMsg.conn.RemotePeer()]
 			m.addPeerAsUnknownIfAbsent(connectedMsg.conn)
 			if p != nil {
@@ -949,6 +994,65 @@ func (m *Manager) cleanupPeerIfNotKnown(peerID peer.ID) {
 	}
 }
 
+// checks whether the reputation score of the given not known peer is below the minimum score.
+func (m *Manager) isReputationTooLow(peerID peer.ID) bool {
+	if m.opts.reputationStore == nil {
+		return false
+	}
+
+	if p, has := m.peers[peerID]; has && p.Relation == PeerRelationKnown {
+		return false
+	}
+
+	return m.opts.reputationStore.Score(peerID).Total < m.opts.reputationMinScore
+}
+
+// closes the given inbound connection if the peer is not known and its reputation score is too low.
+func (m *Manager) rejectIfReputationTooLow(conn network.Conn) bool {
+	if conn.Stat().Direction == network.DirOutbound {
+		return false
+	}
+
+	peerID := conn.RemotePeer()
+	if _, has := m.peers[peerID]; has {
+		// the connection is checked by the periodic reputation check
+		return false
+	}
+
+	if !m.isReputationTooLow(peerID) {
+		return false
+	}
+
+	m.Events.Error.Trigger(fmt.Errorf("rejected connection from %s: %w", peerID.ShortString(), ErrPeerReputationTooLow))
+	_ = conn.Close()
+
+	return true
+}
+
+// disconnects all not known peers of which the reputation score is too low
+// and ranks the connections to the remaining not known peers by their score.
+func (m *Manager) checkReputations() {
+	for peerID, p := range m.peers {
+		if p.Relation == PeerRelationKnown {
+			continue
+		}
+
+		if m.isReputationTooLow(peerID) {
+			disconnected, err := m.disconnectPeer(peerID)
+			if err != nil {
+				m.Events.Error.Trigger(fmt.Errorf("error disconnect %s: %w", peerID.ShortString(), err))
+			}
+			if disconnected {
+				m.Events.Disconnected.Trigger(&PeerOptError{Peer: p, Error: ErrPeerReputationTooLow})
+			}
+			continue
+		}
+
+		// the connection manager trims the connections with the lowest tag values first
+		m.host.ConnManager().TagPeer(peerID, PeerReputationTag, int(m.opts.reputationStore.Score(peerID).Total))
+	}
+}
+
 // checks whether the given peer is connected.
 func (m *Manager) isConnected(peerID peer.ID) bool {
 	if _, has := m.peers[peerID]; !has {
@@ -68,4 +68,6 @@ type PeerInfoSnapshot struct {
 	Connected bool `json:"connected"`
 	// The relation to the peer.
 	Relation string `json:"relation"`
+	// The reputation score of the peer, nil if the reputation is not tracked.
+	Reputation *ReputationScore `json:"reputation,omitempty"`
 }
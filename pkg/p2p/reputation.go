@@ -0,0 +1,391 @@
+package p2p
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/marshalutil"
+)
+
+const (
+	// PeerReputationTag is the tag used by the Manager to rank the connections
+	// to not known peers by their reputation within the connmgr.ConnManager.
+	PeerReputationTag = "reputation"
+
+	// the smoothing factor of the exponential moving average of the request latency.
+	requestLatencySmoothing = 0.1
+)
+
+var (
+	// ErrPeerReputationTooLow gets returned if a peer was disconnected because its reputation score is too low.
+	ErrPeerReputationTooLow = errors.New("peer reputation score is too low")
+)
+
+// the default options applied to the ReputationStore.
+var defaultReputationOptions = []ReputationOption{
+	WithReputationHalfLife(24 * time.Hour),
+	WithReputationWeights(5.0, 50.0, 5.0),
+}
+
+// ReputationOptions define options for a ReputationStore.
+type ReputationOptions struct {
+	// the time after which the recorded behavior of a peer only counts half.
+	halfLife time.Duration
+	// the weight of the logarithm of the amount of useful new blocks sent by the peer.
+	newBlocksWeight float64
+	// the penalty applied per invalid block sent by the peer.
+	invalidBlockPenalty float64
+	// the penalty applied per second of average request latency of the peer.
+	requestLatencyPenalty float64
+}
+
+// ReputationOption is a function setting a ReputationOptions option.
+type ReputationOption func(opts *ReputationOptions)
+
+// WithReputationHalfLife defines the time after which the recorded behavior of a peer only counts half.
+func WithReputationHalfLife(halfLife time.Duration) ReputationOption {
+	return func(opts *ReputationOptions) {
+		opts.halfLife = halfLife
+	}
+}
+
+// WithReputationWeights defines how the recorded behavior of a peer is weighted in its score.
+func WithReputationWeights(newBlocksWeight float64, invalidBlockPenalty float64, requestLatencyPenalty float64) ReputationOption {
+	return func(opts *ReputationOptions) {
+		opts.newBlocksWeight = newBlocksWeight
+		opts.invalidBlockPenalty = invalidBlockPenalty
+		opts.requestLatencyPenalty = requestLatencyPenalty
+	}
+}
+
+// applies the given ReputationOption.
+func (ro *ReputationOptions) apply(opts ...ReputationOption) {
+	for _, opt := range opts {
+		opt(ro)
+	}
+}
+
+// ReputationScore is the score of a peer and the breakdown of its components.
+type ReputationScore struct {
+	// The total score of the peer.
+	Total float64 `json:"total"`
+	// The part of the score resulting from useful new blocks sent by the peer.
+	NewBlocks float64 `json:"newBlocks"`
+	// The part of the score resulting from invalid blocks sent by the peer.
+	InvalidBlocks float64 `json:"invalidBlocks"`
+	// The part of the score resulting from the latency of the requests answered by the peer.
+	RequestLatency float64 `json:"requestLatency"`
+}
+
+// PeerReputation holds the recorded behavior of a single peer.
+// The counters decay over time, so that old behavior has less impact on the score.
+type PeerReputation struct {
+	sync.Mutex
+
+	opts *ReputationOptions
+
+	// the decayed amount of useful new blocks sent by the peer.
+	newBlocks float64
+	// the decayed amount of invalid blocks sent by the peer.
+	invalidBlocks float64
+	// the exponential moving average of the request latency of the peer.
+	avgRequestLatency time.Duration
+	// the amount of requests answered by the peer.
+	answeredRequests uint64
+	// the time the counters were last decayed.
+	lastUpdated time.Time
+	// whether the reputation changed since it was last stored.
+	modified bool
+	// the amount of users of the reputation, guarded by the lock of the ReputationStore.
+	refs int
+}
+
+func newPeerReputation(opts *ReputationOptions) *PeerReputation {
+	return &PeerReputation{
+		opts:        opts,
+		lastUpdated: time.Now(),
+	}
+}
+
+// decays the counters to the given time.
+// the lock must be held by the caller.
+func (r *PeerReputation) decay(now time.Time) {
+	elapsed := now.Sub(r.lastUpdated)
+	if elapsed <= 0 {
+		return
+	}
+
+	factor := 1.0
+	if r.opts.halfLife > 0 {
+		factor = math.Pow(0.5, float64(elapsed)/float64(r.opts.halfLife))
+	}
+
+	r.newBlocks *= factor
+	r.invalidBlocks *= factor
+	r.lastUpdated = now
+}
+
+// RecordNewBlock records a useful new block sent by the peer.
+func (r *PeerReputation) RecordNewBlock() {
+	if r == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.decay(time.Now())
+	r.newBlocks++
+	r.modified = true
+}
+
+// RecordInvalidBlock records an invalid block sent by the peer.
+func (r *PeerReputation) RecordInvalidBlock() {
+	if r == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.decay(time.Now())
+	r.invalidBlocks++
+	r.modified = true
+}
+
+// RecordRequestLatency records the latency of a request answered by the peer.
+func (r *PeerReputation) RecordRequestLatency(latency time.Duration) {
+	if r == nil {
+		return
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	if r.answeredRequests == 0 {
+		r.avgRequestLatency = latency
+	} else {
+		r.avgRequestLatency += time.Duration(requestLatencySmoothing * float64(latency-r.avgRequestLatency))
+	}
+	r.answeredRequests++
+	r.modified = true
+}
+
+// Score returns the current score of the peer.
+func (r *PeerReputation) Score() *ReputationScore {
+	if r == nil {
+		return &ReputationScore{}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.decay(time.Now())
+
+	score := &ReputationScore{
+		NewBlocks:     r.opts.newBlocksWeight * math.Log2(1+r.newBlocks),
+		InvalidBlocks: -r.opts.invalidBlockPenalty * r.invalidBlocks,
+	}
+	if r.answeredRequests > 0 {
+		score.RequestLatency = -r.opts.requestLatencyPenalty * r.avgRequestLatency.Seconds()
+	}
+	score.Total = score.NewBlocks + score.InvalidBlocks + score.RequestLatency
+
+	return score
+}
+
+// the lock must be held by the caller.
+func (r *PeerReputation) bytes() []byte {
+	marshalUtil := marshalutil.New(48)
+	marshalUtil.WriteFloat64(r.newBlocks)
+	marshalUtil.WriteFloat64(r.invalidBlocks)
+	marshalUtil.WriteInt64(int64(r.avgRequestLatency))
+	marshalUtil.WriteUint64(r.answeredRequests)
+	marshalUtil.WriteTime(r.lastUpdated)
+
+	return marshalUtil.Bytes()
+}
+
+func (r *PeerReputation) fromBytes(data []byte) error {
+	marshalUtil := marshalutil.New(data)
+
+	var err error
+	if r.newBlocks, err = marshalUtil.ReadFloat64(); err != nil {
+		return err
+	}
+
+	if r.invalidBlocks, err = marshalUtil.ReadFloat64(); err != nil {
+		return err
+	}
+
+	avgRequestLatency, err := marshalUtil.ReadInt64()
+	if err != nil {
+		return err
+	}
+	r.avgRequestLatency = time.Duration(avgRequestLatency)
+
+	if r.answeredRequests, err = marshalUtil.ReadUint64(); err != nil {
+		return err
+	}
+
+	if r.lastUpdated, err = marshalUtil.ReadTime(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ReputationStore keeps track of the reputation of peers and persists it in the p2p store.
+// Only the reputations of the peers which are in use are kept in memory.
+type ReputationStore struct {
+	sync.Mutex
+
+	store kvstore.KVStore
+	opts  *ReputationOptions
+
+	// holds the reputations of the peers which are in use or not persisted yet.
+	peers map[peer.ID]*PeerReputation
+}
+
+// NewReputationStore creates a new ReputationStore which persists the reputations in the given store.
+func NewReputationStore(store kvstore.KVStore, opts ...ReputationOption) *ReputationStore {
+	repOpts := &ReputationOptions{}
+	repOpts.apply(defaultReputationOptions...)
+	repOpts.apply(opts...)
+
+	return &ReputationStore{
+		store: store,
+		opts:  repOpts,
+		peers: make(map[peer.ID]*PeerReputation),
+	}
+}
+
+// loads the reputation of the given peer from memory or from the store.
+// the lock must be held by the caller.
+func (s *ReputationStore) loadPeer(peerID peer.ID) (reputation *PeerReputation, inMemory bool) {
+	if reputation, exists := s.peers[peerID]; exists {
+		return reputation, true
+	}
+
+	reputation = newPeerReputation(s.opts)
+	if data, err := s.store.Get([]byte(peerID)); err == nil {
+		if err := reputation.fromBytes(data); err != nil {
+			// start over if the stored reputation is corrupted
+			reputation = newPeerReputation(s.opts)
+			reputation.modified = true
+		}
+	}
+
+	return reputation, false
+}
+
+// Peer returns the reputation of the given peer to record its behavior.
+// The reputation is loaded from the store if it isn't in memory yet,
+// and is kept in memory until it was released with Release.
+// Returns nil if the ReputationStore is nil.
+func (s *ReputationStore) Peer(peerID peer.ID) *PeerReputation {
+	if s == nil {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	reputation, inMemory := s.loadPeer(peerID)
+	if !inMemory {
+		s.peers[peerID] = reputation
+	}
+	reputation.refs++
+
+	return reputation
+}
+
+// Release releases the reputation of the given peer which was acquired with Peer.
+// The reputation is removed from memory if it is not in use anymore, after it was persisted.
+func (s *ReputationStore) Release(peerID peer.ID) {
+	if s == nil {
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	reputation, exists := s.peers[peerID]
+	if !exists {
+		return
+	}
+
+	if reputation.refs > 0 {
+		reputation.refs--
+	}
+	s.evictIfUnused(peerID, reputation)
+}
+
+// removes the reputation from memory if it is not in use and was persisted.
+// the lock must be held by the caller.
+func (s *ReputationStore) evictIfUnused(peerID peer.ID, reputation *PeerReputation) {
+	if reputation.refs > 0 {
+		return
+	}
+
+	reputation.Lock()
+	defer reputation.Unlock()
+
+	if !reputation.modified {
+		delete(s.peers, peerID)
+	}
+}
+
+// Score returns the current score of the given peer.
+// The reputation of peers which are not in use is read from the store, but not kept in memory.
+func (s *ReputationStore) Score(peerID peer.ID) *ReputationScore {
+	if s == nil {
+		return &ReputationScore{}
+	}
+
+	s.Lock()
+	reputation, _ := s.loadPeer(peerID)
+	s.Unlock()
+
+	return reputation.Score()
+}
+
+// Flush persists the reputations which were modified since the last flush,
+// and removes the reputations which are not in use anymore from memory.
+func (s *ReputationStore) Flush() error {
+	if s == nil {
+		return nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	for peerID, reputation := range s.peers {
+		if err := func() error {
+			reputation.Lock()
+			defer reputation.Unlock()
+
+			if !reputation.modified {
+				return nil
+			}
+
+			if err := s.store.Set([]byte(peerID), reputation.bytes()); err != nil {
+				return errors.Wrapf(err, "storing reputation of peer %s failed", peerID.ShortString())
+			}
+			reputation.modified = false
+
+			return nil
+		}(); err != nil {
+			return err
+		}
+
+		s.evictIfUnused(peerID, reputation)
+	}
+
+	return s.store.Flush()
+}
@@ -0,0 +1,78 @@
+package p2p_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/p2p"
+)
+
+func TestReputationStore(t *testing.T) {
+	store := mapdb.NewMapDB()
+
+	// no decay, to get deterministic scores
+	repOpts := []p2p.ReputationOption{
+		p2p.WithReputationHalfLife(0),
+		p2p.WithReputationWeights(1.0, 10.0, 2.0),
+	}
+
+	goodPeerID := peer.ID("good")
+	badPeerID := peer.ID("bad")
+
+	reputationStore := p2p.NewReputationStore(store, repOpts...)
+
+	goodPeer := reputationStore.Peer(goodPeerID)
+	for i := 0; i < 7; i++ {
+		goodPeer.RecordNewBlock()
+	}
+	goodPeer.RecordRequestLatency(500 * time.Millisecond)
+
+	badPeer := reputationStore.Peer(badPeerID)
+	badPeer.RecordNewBlock()
+	badPeer.RecordInvalidBlock()
+
+	goodScore := reputationStore.Score(goodPeerID)
+	require.Equal(t, &p2p.ReputationScore{
+		Total:          3.0 - 1.0,
+		NewBlocks:      3.0,
+		RequestLatency: -1.0,
+	}, goodScore)
+
+	badScore := reputationStore.Score(badPeerID)
+	require.Equal(t, &p2p.ReputationScore{
+		Total:         1.0 - 10.0,
+		NewBlocks:     1.0,
+		InvalidBlocks: -10.0,
+	}, badScore)
+
+	// unknown peers start with a neutral score
+	require.Equal(t, &p2p.ReputationScore{}, reputationStore.Score(peer.ID("unknown")))
+
+	// the reputations survive a restart
+	require.NoError(t, reputationStore.Flush())
+
+	reloadedStore := p2p.NewReputationStore(store, repOpts...)
+	require.Equal(t, goodScore, reloadedStore.Score(goodPeerID))
+	require.Equal(t, badScore, reloadedStore.Score(badPeerID))
+
+	// released reputations are removed from memory after they were persisted
+	reputationStore.Release(badPeerID)
+	require.NoError(t, reputationStore.Flush())
+	require.NoError(t, store.Delete([]byte(badPeerID)))
+	require.NoError(t, store.Delete([]byte(goodPeerID)))
+	require.Equal(t, &p2p.ReputationScore{}, reputationStore.Score(badPeerID))
+
+	// reputations in use are kept in memory
+	require.Equal(t, goodScore, reputationStore.Score(goodPeerID))
+
+	// a nil store doesn't track anything
+	var nilStore *p2p.ReputationStore
+	nilStore.Peer(goodPeerID).RecordInvalidBlock()
+	require.Equal(t, &p2p.ReputationScore{}, nilStore.Score(goodPeerID))
+	nilStore.Release(goodPeerID)
+	require.NoError(t, nilStore.Flush())
+}
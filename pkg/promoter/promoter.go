@@ -0,0 +1,420 @@
+package promoter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hive.go/syncutils"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/syncmanager"
+	"github.com/iotaledger/hornet/v2/pkg/pow"
+	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrBlockNotTracked is returned if the status of a block is requested that is not tracked by the promoter.
+	ErrBlockNotTracked = errors.New("block not tracked")
+	// ErrTrackedBlocksLimitReached is returned if a block can't be tracked because the maximum amount of tracked blocks is reached.
+	ErrTrackedBlocksLimitReached = errors.New("maximum amount of tracked blocks reached")
+)
+
+// AttachBlockFunc attaches a block to the tangle. Missing parents are selected and the PoW is done if needed.
+type AttachBlockFunc = func(ctx context.Context, block *iotago.Block) (iotago.BlockID, error)
+
+// BlockState is the state of a tracked block.
+type BlockState string
+
+const (
+	// BlockStatePending means that the block was not referenced by a milestone yet.
+	BlockStatePending BlockState = "pending"
+	// BlockStateReferenced means that the block or one of its reattachments was referenced by a milestone.
+	BlockStateReferenced BlockState = "referenced"
+	// BlockStateFailed means that the block fell below max depth after the maximum amount of reattachments.
+	BlockStateFailed BlockState = "failed"
+)
+
+// BlockStatus is the status of a tracked block.
+type BlockStatus struct {
+	// The ID of the submitted block.
+	BlockID iotago.BlockID
+	// The state of the block.
+	State BlockState
+	// The IDs of the blocks that were issued to promote the block or its reattachments.
+	Promotions iotago.BlockIDs
+	// The IDs of the blocks that were issued to reattach the payload of the block.
+	Reattachments iotago.BlockIDs
+	// The ID of the block or reattachment that was referenced by a milestone.
+	ReferencedBlockID iotago.BlockID
+	// The milestone index that references the block or reattachment.
+	ReferencedByMilestoneIndex iotago.MilestoneIndex
+	// Whether the transaction of the referenced block was included in the ledger.
+	IncludedInLedger bool
+	// The reason why the transaction of the referenced block is conflicting.
+	Conflict storage.Conflict
+	// The last error that occurred while promoting or reattaching the block.
+	LastError string
+}
+
+type trackedBlock struct {
+	status *BlockStatus
+
+	protocolVersion byte
+	payload         iotago.Payload
+
+	// the amount of promotions of the latest attachment of the payload.
+	latestPromotionsCount int
+	// the confirmed milestone index at which the block was referenced or failed.
+	finishedIndex iotago.MilestoneIndex
+}
+
+// latestAttachment returns the ID of the latest attachment of the payload.
+func (t *trackedBlock) latestAttachment() iotago.BlockID {
+	if len(t.status.Reattachments) > 0 {
+		return t.status.Reattachments[len(t.status.Reattachments)-1]
+	}
+	return t.status.BlockID
+}
+
+// attachments returns the IDs of all attachments of the payload.
+func (t *trackedBlock) attachments() iotago.BlockIDs {
+	return append(iotago.BlockIDs{t.status.BlockID}, t.status.Reattachments...)
+}
+
+// Promoter tracks blocks that were submitted to the node and promotes or reattaches them
+// if their tip score indicates that they are lazy.
+type Promoter struct {
+	// the logger used to log events.
+	*logger.WrappedLogger
+
+	storage            *storage.Storage
+	syncManager        *syncmanager.SyncManager
+	tipScoreCalculator *tangle.TipScoreCalculator
+	attachBlockFunc    AttachBlockFunc
+	tipSelFunc         pow.RefreshTipsFunc
+
+	maxTrackedBlocks    int
+	maxPromotions       int
+	maxReattachments    int
+	retentionMilestones iotago.MilestoneIndex
+
+	trackedLock syncutils.RWMutex
+	// the tracked blocks by the ID of the submitted block.
+	trackedBlocks map[iotago.BlockID]*trackedBlock
+	// the IDs of the submitted blocks by the ID of all attachments of their payload.
+	attachments map[iotago.BlockID]iotago.BlockID
+}
+
+// New creates a new promoter instance.
+func New(
+	log *logger.Logger,
+	storage *storage.Storage,
+	syncManager *syncmanager.SyncManager,
+	tipScoreCalculator *tangle.TipScoreCalculator,
+	attachBlockFunc AttachBlockFunc,
+	tipSelFunc pow.RefreshTipsFunc,
+	maxTrackedBlocks int,
+	maxPromotions int,
+	maxReattachments int,
+	retentionMilestones syncmanager.MilestoneIndexDelta) *Promoter {
+
+	return &Promoter{
+		WrappedLogger:       logger.NewWrappedLogger(log),
+		storage:             storage,
+		syncManager:         syncManager,
+		tipScoreCalculator:  tipScoreCalculator,
+		attachBlockFunc:     attachBlockFunc,
+		tipSelFunc:          tipSelFunc,
+		maxTrackedBlocks:    maxTrackedBlocks,
+		maxPromotions:       maxPromotions,
+		maxReattachments:    maxReattachments,
+		retentionMilestones: retentionMilestones,
+		trackedBlocks:       make(map[iotago.BlockID]*trackedBlock),
+		attachments:         make(map[iotago.BlockID]iotago.BlockID),
+	}
+}
+
+// TrackBlock starts tracking a block that was submitted to the node and returns whether the block is tracked.
+// Blocks without payload and milestone blocks are not tracked, since there is nothing to reattach.
+func (p *Promoter) TrackBlock(blockID iotago.BlockID, block *iotago.Block) (bool, error) {
+	switch block.Payload.(type) {
+	case nil, *iotago.Milestone:
+		return false, nil
+	}
+
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	if _, exists := p.attachments[blockID]; exists {
+		return true, nil
+	}
+
+	if len(p.trackedBlocks) >= p.maxTrackedBlocks {
+		return false, ErrTrackedBlocksLimitReached
+	}
+
+	p.trackedBlocks[blockID] = &trackedBlock{
+		status: &BlockStatus{
+			BlockID: blockID,
+			State:   BlockStatePending,
+		},
+		protocolVersion: block.ProtocolVersion,
+		payload:         block.Payload,
+	}
+	p.attachments[blockID] = blockID
+
+	return true, nil
+}
+
+// BlockStatus returns the status of a tracked block.
+// The block can be identified by the ID of the submitted block or by the ID of one of its reattachments.
+func (p *Promoter) BlockStatus(blockID iotago.BlockID) (*BlockStatus, error) {
+	p.trackedLock.RLock()
+	defer p.trackedLock.RUnlock()
+
+	submittedBlockID, exists := p.attachments[blockID]
+	if !exists {
+		return nil, ErrBlockNotTracked
+	}
+
+	status := *p.trackedBlocks[submittedBlockID].status
+	status.Promotions = append(iotago.BlockIDs{}, status.Promotions...)
+	status.Reattachments = append(iotago.BlockIDs{}, status.Reattachments...)
+
+	return &status, nil
+}
+
+// BlockReferenced marks a tracked block as referenced if the block or one of its reattachments was referenced by a milestone.
+func (p *Promoter) BlockReferenced(metadata *storage.BlockMetadata) {
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	p.blockReferencedWithoutLocking(metadata)
+}
+
+func (p *Promoter) blockReferencedWithoutLocking(metadata *storage.BlockMetadata) {
+	submittedBlockID, exists := p.attachments[metadata.BlockID()]
+	if !exists {
+		return
+	}
+
+	referenced, msIndex := metadata.ReferencedWithIndex()
+	if !referenced {
+		return
+	}
+
+	tracked := p.trackedBlocks[submittedBlockID]
+	status := tracked.status
+
+	// several attachments of the same transaction may be referenced by the same milestone,
+	// only one of them is included in the ledger and the others are conflicting.
+	if status.State == BlockStateReferenced && status.Conflict == storage.ConflictNone {
+		return
+	}
+
+	status.State = BlockStateReferenced
+	status.ReferencedBlockID = metadata.BlockID()
+	status.ReferencedByMilestoneIndex = msIndex
+	status.IncludedInLedger = metadata.IsIncludedTxInLedger()
+	status.Conflict = metadata.Conflict()
+	tracked.finishedIndex = msIndex
+}
+
+// checkReferencedWithoutLocking checks whether one of the attachments of the tracked block was already referenced,
+// e.g. before the block was tracked.
+func (p *Promoter) checkReferencedWithoutLocking(tracked *trackedBlock) bool {
+	for _, blockID := range tracked.attachments() {
+		cachedBlockMeta := p.storage.CachedBlockMetadataOrNil(blockID) // meta +1
+		if cachedBlockMeta == nil {
+			continue
+		}
+		p.blockReferencedWithoutLocking(cachedBlockMeta.Metadata())
+		cachedBlockMeta.Release(true) // meta -1
+	}
+
+	return tracked.status.State == BlockStateReferenced
+}
+
+// cleanupWithoutLocking removes the tracked blocks that are finished since more than the retention milestones.
+func (p *Promoter) cleanupWithoutLocking(confirmedMilestoneIndex iotago.MilestoneIndex) {
+	for submittedBlockID, tracked := range p.trackedBlocks {
+		if tracked.status.State == BlockStatePending || tracked.finishedIndex+p.retentionMilestones > confirmedMilestoneIndex {
+			continue
+		}
+
+		for _, blockID := range tracked.attachments() {
+			delete(p.attachments, blockID)
+		}
+		delete(p.trackedBlocks, submittedBlockID)
+	}
+}
+
+// pendingBlocks returns the IDs of the submitted blocks that were not referenced yet.
+func (p *Promoter) pendingBlocks(confirmedMilestoneIndex iotago.MilestoneIndex) iotago.BlockIDs {
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	p.cleanupWithoutLocking(confirmedMilestoneIndex)
+
+	pending := make(iotago.BlockIDs, 0)
+	for submittedBlockID, tracked := range p.trackedBlocks {
+		if tracked.status.State != BlockStatePending || p.checkReferencedWithoutLocking(tracked) {
+			continue
+		}
+		pending = append(pending, submittedBlockID)
+	}
+
+	return pending
+}
+
+// CheckTrackedBlocks checks the tip score of the latest attachment of all pending blocks.
+// Semi-lazy attachments are promoted, attachments below max depth are reattached.
+func (p *Promoter) CheckTrackedBlocks(ctx context.Context) error {
+	confirmedMilestoneIndex := p.syncManager.ConfirmedMilestoneIndex()
+
+	for _, submittedBlockID := range p.pendingBlocks(confirmedMilestoneIndex) {
+		if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
+			return err
+		}
+
+		if err := p.checkTrackedBlock(ctx, submittedBlockID, confirmedMilestoneIndex); err != nil {
+			if errors.Is(err, common.ErrOperationAborted) {
+				return err
+			}
+			p.setLastError(submittedBlockID, err)
+			p.LogDebugf("promoting block %s failed: %s", submittedBlockID.ToHex(), err)
+		}
+	}
+
+	return nil
+}
+
+func (p *Promoter) checkTrackedBlock(ctx context.Context, submittedBlockID iotago.BlockID, confirmedMilestoneIndex iotago.MilestoneIndex) error {
+	p.trackedLock.RLock()
+	tracked, exists := p.trackedBlocks[submittedBlockID]
+	if !exists || tracked.status.State != BlockStatePending {
+		p.trackedLock.RUnlock()
+		return nil
+	}
+	latestAttachment := tracked.latestAttachment()
+	protocolVersion := tracked.protocolVersion
+	payload := tracked.payload
+	promotionsCount := tracked.latestPromotionsCount
+	reattachmentsCount := len(tracked.status.Reattachments)
+	p.trackedLock.RUnlock()
+
+	tipScore, err := p.tipScoreCalculator.TipScore(ctx, latestAttachment, confirmedMilestoneIndex)
+	if err != nil {
+		return err
+	}
+
+	switch tipScore {
+	case tangle.TipScoreBelowMaxDepth:
+		if reattachmentsCount >= p.maxReattachments {
+			p.setFailed(submittedBlockID, confirmedMilestoneIndex)
+			return nil
+		}
+		return p.reattach(ctx, submittedBlockID, protocolVersion, payload)
+
+	case tangle.TipScoreYCRIThresholdReached, tangle.TipScoreOCRIThresholdReached:
+		if promotionsCount >= p.maxPromotions {
+			// the attachment gets reattached as soon as it is below max depth
+			return nil
+		}
+		return p.promote(ctx, submittedBlockID, protocolVersion, latestAttachment)
+
+	default:
+		// the block is not stored yet, or it is healthy
+		return nil
+	}
+}
+
+// promote issues a block that references the given attachment and fresh tips.
+func (p *Promoter) promote(ctx context.Context, submittedBlockID iotago.BlockID, protocolVersion byte, attachment iotago.BlockID) error {
+	tips, err := p.tipSelFunc()
+	if err != nil {
+		return err
+	}
+	if len(tips) > iotago.BlockMaxParents-1 {
+		tips = tips[:iotago.BlockMaxParents-1]
+	}
+
+	promotionBlockID, err := p.attachBlockFunc(ctx, &iotago.Block{
+		ProtocolVersion: protocolVersion,
+		Parents:         append(tips, attachment).RemoveDupsAndSort(),
+	})
+	if err != nil {
+		return err
+	}
+
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	tracked, exists := p.trackedBlocks[submittedBlockID]
+	if !exists {
+		// the block was removed in the meantime
+		return nil
+	}
+	tracked.status.Promotions = append(tracked.status.Promotions, promotionBlockID)
+	tracked.latestPromotionsCount++
+
+	p.LogDebugf("promoted block %s with block %s", attachment.ToHex(), promotionBlockID.ToHex())
+
+	return nil
+}
+
+// reattach issues a new block with the payload of the submitted block and fresh tips.
+func (p *Promoter) reattach(ctx context.Context, submittedBlockID iotago.BlockID, protocolVersion byte, payload iotago.Payload) error {
+	// the parents are selected by the attacher
+	reattachmentBlockID, err := p.attachBlockFunc(ctx, &iotago.Block{
+		ProtocolVersion: protocolVersion,
+		Payload:         payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	tracked, exists := p.trackedBlocks[submittedBlockID]
+	if !exists {
+		// the block was removed in the meantime
+		return nil
+	}
+	tracked.status.Reattachments = append(tracked.status.Reattachments, reattachmentBlockID)
+	tracked.latestPromotionsCount = 0
+	p.attachments[reattachmentBlockID] = submittedBlockID
+
+	p.LogDebugf("reattached block %s with block %s", submittedBlockID.ToHex(), reattachmentBlockID.ToHex())
+
+	return nil
+}
+
+func (p *Promoter) setFailed(submittedBlockID iotago.BlockID, confirmedMilestoneIndex iotago.MilestoneIndex) {
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	tracked, exists := p.trackedBlocks[submittedBlockID]
+	if !exists || tracked.status.State != BlockStatePending {
+		return
+	}
+
+	tracked.status.State = BlockStateFailed
+	tracked.status.LastError = fmt.Sprintf("block is below max depth after %d reattachments", len(tracked.status.Reattachments))
+	tracked.finishedIndex = confirmedMilestoneIndex
+}
+
+func (p *Promoter) setLastError(submittedBlockID iotago.BlockID, err error) {
+	p.trackedLock.Lock()
+	defer p.trackedLock.Unlock()
+
+	if tracked, exists := p.trackedBlocks[submittedBlockID]; exists {
+		tracked.status.LastError = err.Error()
+	}
+}
@@ -0,0 +1,253 @@
+package test
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/promoter"
+	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite/utils"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	ShowConfirmationGraphs = false
+	ProtocolVersion        = 2
+	MinPoWScore            = 1
+	BelowMaxDepth          = 15
+
+	// the tip score thresholds used to drive the tracked blocks into the semi-lazy state and below max depth.
+	MaxDeltaBlockYoungestConeRootIndexToCMI = 8
+	MaxDeltaBlockOldestConeRootIndexToCMI   = 2
+	PromoterBelowMaxDepth                   = 5
+)
+
+var (
+	seed1, _ = hex.DecodeString("96d9ff7a79e4b0a5f3e5848ae7867064402da92a62eabb4ebbe463f12d1f3b1aace1775488f51cb1e3a80732a03ef60b111d6833ab605aa9f8faebeb33bbe3d9")
+	seed2, _ = hex.DecodeString("b15209ddc93cbdb600137ea6a8f88cdd7c5d480d5815c9352a0fb5c4e4b86f7151dcb44c2ba635657a2df5a8fd48cb9bab674a9eceea527dbbb254ef8c9f9cd7")
+)
+
+func TestPromoterBlockStatus(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	genesisAddress := seed1Wallet.Address()
+
+	te := testsuite.SetupTestEnvironment(t, genesisAddress, 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	//Add token supply to our local HDWallet
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	// the tip score calculator and the attacher are not needed, since the block gets referenced without promotion
+	p := promoter.New(logger.NewLogger("Promoter"), te.Storage(), te.SyncManager(), nil, nil, nil, 1, 3, 3, 1)
+
+	blockA := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(te.ProtocolParameters().TokenSupply).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	tracked, err := p.TrackBlock(blockA.StoredBlockID(), blockA.IotaBlock())
+	require.NoError(t, err)
+	require.True(t, tracked)
+
+	// the limit of tracked blocks is reached
+	blockB := te.NewBlockBuilder("B").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+	tracked, err = p.TrackBlock(blockB.StoredBlockID(), blockB.IotaBlock())
+	require.ErrorIs(t, err, promoter.ErrTrackedBlocksLimitReached)
+	require.False(t, tracked)
+
+	_, err = p.BlockStatus(blockB.StoredBlockID())
+	require.ErrorIs(t, err, promoter.ErrBlockNotTracked)
+
+	status, err := p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStatePending, status.State)
+
+	te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockA.StoredBlockID()}, false)
+
+	cachedBlockMeta := te.Storage().CachedBlockMetadataOrNil(blockA.StoredBlockID()) // meta +1
+	require.NotNil(t, cachedBlockMeta)
+	cachedBlockMeta.ConsumeMetadata(p.BlockReferenced) // meta -1
+
+	status, err = p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStateReferenced, status.State)
+	require.Equal(t, blockA.StoredBlockID(), status.ReferencedBlockID)
+	require.Equal(t, te.SyncManager().ConfirmedMilestoneIndex(), status.ReferencedByMilestoneIndex)
+	require.True(t, status.IncludedInLedger)
+	require.Equal(t, storage.ConflictNone, status.Conflict)
+
+	// the status is removed after the retention milestones
+	te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{te.LastMilestoneBlockID()}, false)
+	require.NoError(t, p.CheckTrackedBlocks(context.Background()))
+
+	_, err = p.BlockStatus(blockA.StoredBlockID())
+	require.ErrorIs(t, err, promoter.ErrBlockNotTracked)
+}
+
+// testAttacher stores the blocks issued by the promoter in the test environment.
+type testAttacher struct {
+	te *testsuite.TestEnvironment
+	// the IDs of the attached blocks.
+	attached iotago.BlockIDs
+}
+
+func (a *testAttacher) AttachBlock(ctx context.Context, block *iotago.Block) (iotago.BlockID, error) {
+	if len(block.Parents) == 0 {
+		block.Parents = a.te.LastMilestoneParents()
+	}
+
+	if _, err := a.te.PoWHandler.DoPoW(ctx, block, 1); err != nil {
+		return iotago.EmptyBlockID(), err
+	}
+
+	storedBlock, err := storage.NewBlock(block, serializer.DeSeriModePerformValidation, a.te.ProtocolParameters())
+	if err != nil {
+		return iotago.EmptyBlockID(), err
+	}
+
+	// the cached block is released by the test environment
+	a.te.StoreBlock(storedBlock)
+
+	a.attached = append(a.attached, storedBlock.BlockID())
+
+	return storedBlock.BlockID(), nil
+}
+
+// issueMilestonesAndCheck confirms milestones that don't reference the tracked blocks
+// and lets the promoter check the tracked blocks after each milestone.
+func issueMilestonesAndCheck(t *testing.T, te *testsuite.TestEnvironment, p *promoter.Promoter, count int) {
+	for i := 0; i < count; i++ {
+		te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{te.LastMilestoneBlockID()}, false)
+		require.NoError(t, p.CheckTrackedBlocks(context.Background()))
+	}
+}
+
+func newTestPromoter(te *testsuite.TestEnvironment, attacher *testAttacher, maxPromotions int, maxReattachments int) *promoter.Promoter {
+	return promoter.New(
+		logger.NewLogger("Promoter"),
+		te.Storage(),
+		te.SyncManager(),
+		tangle.NewTipScoreCalculator(te.Storage(), MaxDeltaBlockYoungestConeRootIndexToCMI, MaxDeltaBlockOldestConeRootIndexToCMI, PromoterBelowMaxDepth),
+		attacher.AttachBlock,
+		func() (iotago.BlockIDs, error) { return te.LastMilestoneParents(), nil },
+		10,
+		maxPromotions,
+		maxReattachments,
+		20,
+	)
+}
+
+func TestPromoterPromoteAndReattach(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	attacher := &testAttacher{te: te}
+	p := newTestPromoter(te, attacher, 2, 1)
+
+	blockA := te.NewBlockBuilder("A").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+	tracked, err := p.TrackBlock(blockA.StoredBlockID(), blockA.IotaBlock())
+	require.NoError(t, err)
+	require.True(t, tracked)
+
+	// the block is healthy
+	require.NoError(t, p.CheckTrackedBlocks(context.Background()))
+	require.Empty(t, attacher.attached)
+
+	// the block gets semi-lazy and is promoted until the maximum amount of promotions is reached
+	issueMilestonesAndCheck(t, te, p, 4)
+
+	status, err := p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStatePending, status.State)
+	require.Len(t, status.Promotions, 2)
+	require.Empty(t, status.Reattachments)
+	require.Equal(t, attacher.attached, status.Promotions)
+
+	for _, promotionBlockID := range status.Promotions {
+		cachedBlock := te.Storage().CachedBlockOrNil(promotionBlockID) // block +1
+		require.NotNil(t, cachedBlock)
+		require.Contains(t, cachedBlock.Block().Parents(), blockA.StoredBlockID())
+		require.Nil(t, cachedBlock.Block().Block().Payload)
+		cachedBlock.Release(true) // block -1
+	}
+
+	// the block falls below max depth and gets reattached
+	issueMilestonesAndCheck(t, te, p, 1)
+
+	status, err = p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStatePending, status.State)
+	require.Len(t, status.Reattachments, 1)
+
+	reattachmentBlockID := status.Reattachments[0]
+	cachedBlock := te.Storage().CachedBlockOrNil(reattachmentBlockID) // block +1
+	require.NotNil(t, cachedBlock)
+	require.Equal(t, blockA.IotaBlock().Payload, cachedBlock.Block().Block().Payload)
+	cachedBlock.Release(true) // block -1
+
+	// the status can be requested by the ID of the reattachment
+	reattachmentStatus, err := p.BlockStatus(reattachmentBlockID)
+	require.NoError(t, err)
+	require.Equal(t, blockA.StoredBlockID(), reattachmentStatus.BlockID)
+
+	// the reattachment is referenced by a milestone
+	te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{reattachmentBlockID}, false)
+	require.NoError(t, p.CheckTrackedBlocks(context.Background()))
+
+	status, err = p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStateReferenced, status.State)
+	require.Equal(t, reattachmentBlockID, status.ReferencedBlockID)
+	require.Equal(t, te.SyncManager().ConfirmedMilestoneIndex(), status.ReferencedByMilestoneIndex)
+}
+
+func TestPromoterMaxReattachments(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	attacher := &testAttacher{te: te}
+	p := newTestPromoter(te, attacher, 0, 1)
+
+	blockA := te.NewBlockBuilder("A").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+	tracked, err := p.TrackBlock(blockA.StoredBlockID(), blockA.IotaBlock())
+	require.NoError(t, err)
+	require.True(t, tracked)
+
+	// the block falls below max depth and gets reattached
+	issueMilestonesAndCheck(t, te, p, PromoterBelowMaxDepth+1)
+
+	status, err := p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStatePending, status.State)
+	require.Empty(t, status.Promotions)
+	require.Len(t, status.Reattachments, 1)
+
+	// the reattachment falls below max depth as well and the block is marked as failed
+	issueMilestonesAndCheck(t, te, p, PromoterBelowMaxDepth+1)
+
+	status, err = p.BlockStatus(blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, promoter.BlockStateFailed, status.State)
+	require.Len(t, status.Reattachments, 1)
+	require.NotEmpty(t, status.LastError)
+
+	// failed blocks are neither promoted nor reattached anymore
+	attachedCount := len(attacher.attached)
+	issueMilestonesAndCheck(t, te, p, 1)
+	require.Len(t, attacher.attached, attachedCount)
+}
@@ -17,6 +17,10 @@ func init() {
 		blockMessageDefinition,
 		blockRequestMessageDefinition,
 		heartbeatMessageDefinition,
+		blockRequestBatchMessageDefinition,
+		blockBatchMessageDefinition,
+		milestoneConeRequestMessageDefinition,
+		milestoneConeMessageDefinition,
 	}
 	gossipMessageRegistry = hiveproto.NewRegistry(definitions)
 }
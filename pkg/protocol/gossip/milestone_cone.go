@@ -0,0 +1,189 @@
+package gossip
+
+import (
+	"bytes"
+	"crypto"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/merklehasher"
+
+	// import implementation
+	_ "golang.org/x/crypto/blake2b"
+)
+
+const (
+	// the maximum amount of milestone cones which are received from a peer at the same time.
+	maxBufferedMilestoneConesPerPeer = MaxMilestoneConeRequestRange
+)
+
+var (
+	// ErrInvalidMilestoneCone is returned if the blocks received for a milestone cone are not the past cone of the milestone.
+	ErrInvalidMilestoneCone = errors.New("invalid milestone cone")
+	// ErrMilestoneConeBufferFull is returned if too many milestone cones are received from a peer at the same time.
+	ErrMilestoneConeBufferFull = errors.New("milestone cone buffer full")
+)
+
+// milestoneConeBuffer collects the chunks of a milestone cone until all of them were received.
+type milestoneConeBuffer struct {
+	chunks map[int][][]byte
+	// the index of the last chunk, -1 if the last chunk was not received yet.
+	lastChunkIndex int
+	// the time the latest chunk was received.
+	lastReceived time.Time
+}
+
+// milestoneConeBuffers holds the milestone cones which are currently received from a peer.
+// The chunks of a cone may be processed out of order, so they are buffered until the cone is complete.
+// Chunks may get lost, e.g. if the send queue of the peer was full, so incomplete cones expire after a timeout.
+type milestoneConeBuffers struct {
+	sync.Mutex
+	cones map[iotago.MilestoneIndex]*milestoneConeBuffer
+	// the time after which an incomplete cone is dropped if no more chunks were received.
+	timeout time.Duration
+}
+
+func newMilestoneConeBuffers() *milestoneConeBuffers {
+	return &milestoneConeBuffers{
+		cones: make(map[iotago.MilestoneIndex]*milestoneConeBuffer),
+		// the cone is requested again once the request expired, the old chunks would be duplicates then.
+		timeout: milestoneConeRequestTimeout,
+	}
+}
+
+// dropExpired drops the incomplete cones which didn't receive a chunk within the timeout.
+// the caller needs to hold the lock.
+func (b *milestoneConeBuffers) dropExpired() {
+	for msIndex, cone := range b.cones {
+		if time.Since(cone.lastReceived) >= b.timeout {
+			delete(b.cones, msIndex)
+		}
+	}
+}
+
+// add adds the given chunk to the buffer of its milestone cone.
+// Returns the data of all blocks of the cone in the order they were sent, once all chunks of the cone were received.
+func (b *milestoneConeBuffers) add(chunk *milestoneConeChunk) ([][]byte, bool, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.dropExpired()
+
+	if chunk.chunkIndex >= maxMilestoneConeChunks {
+		delete(b.cones, chunk.msIndex)
+		return nil, false, errors.Wrapf(ErrInvalidMilestoneCone, "chunk index %d exceeds the maximum amount of chunks", chunk.chunkIndex)
+	}
+
+	cone, exists := b.cones[chunk.msIndex]
+	if !exists {
+		if len(b.cones) >= maxBufferedMilestoneConesPerPeer {
+			return nil, false, ErrMilestoneConeBufferFull
+		}
+
+		cone = &milestoneConeBuffer{
+			chunks:         make(map[int][][]byte),
+			lastChunkIndex: -1,
+		}
+		b.cones[chunk.msIndex] = cone
+	}
+
+	if _, duplicated := cone.chunks[chunk.chunkIndex]; duplicated {
+		delete(b.cones, chunk.msIndex)
+		return nil, false, errors.Wrapf(ErrInvalidMilestoneCone, "duplicated chunk %d", chunk.chunkIndex)
+	}
+	cone.chunks[chunk.chunkIndex] = chunk.blocksData
+	cone.lastReceived = time.Now()
+
+	if chunk.lastChunk {
+		cone.lastChunkIndex = chunk.chunkIndex
+	}
+
+	if cone.lastChunkIndex == -1 || len(cone.chunks) < cone.lastChunkIndex+1 {
+		// the cone is not complete yet
+		return nil, false, nil
+	}
+	delete(b.cones, chunk.msIndex)
+
+	if len(cone.chunks) > cone.lastChunkIndex+1 {
+		return nil, false, errors.Wrapf(ErrInvalidMilestoneCone, "received chunks after the last chunk %d", cone.lastChunkIndex)
+	}
+
+	var blocksData [][]byte
+	for i := 0; i <= cone.lastChunkIndex; i++ {
+		blocksData = append(blocksData, cone.chunks[i]...)
+	}
+
+	return blocksData, true, nil
+}
+
+// remove removes the buffered chunks of the given milestone cone.
+func (b *milestoneConeBuffers) remove(msIndex iotago.MilestoneIndex) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.cones, msIndex)
+}
+
+// verifyMilestoneConeClosure verifies that the given blocks form a closed past cone of the milestone in white-flag order.
+// The parents of a block that are part of the cone need to be contained before the block itself,
+// and every block needs to be a parent of the milestone or of a block that follows it in the cone.
+// Parents that are not part of the cone were referenced by older milestones.
+func verifyMilestoneConeClosure(blocks []*storage.Block, milestoneParents iotago.BlockIDs) error {
+	positions := make(map[iotago.BlockID]int, len(blocks))
+	for i, block := range blocks {
+		blockID := block.BlockID()
+		if _, duplicated := positions[blockID]; duplicated {
+			return errors.Wrapf(ErrInvalidMilestoneCone, "duplicated block %s", blockID.ToHex())
+		}
+		positions[blockID] = i
+	}
+
+	referenced := make(map[iotago.BlockID]struct{}, len(blocks))
+	for _, parent := range milestoneParents {
+		referenced[parent] = struct{}{}
+	}
+
+	// walk the cone backwards, so a block was already marked as referenced if a later block or the milestone references it
+	for i := len(blocks) - 1; i >= 0; i-- {
+		blockID := blocks[i].BlockID()
+		if _, isReferenced := referenced[blockID]; !isReferenced {
+			return errors.Wrapf(ErrInvalidMilestoneCone, "block %s is not referenced by the milestone", blockID.ToHex())
+		}
+
+		for _, parent := range blocks[i].Parents() {
+			if position, contained := positions[parent]; contained && position >= i {
+				return errors.Wrapf(ErrInvalidMilestoneCone, "parent %s of block %s is not contained before the block", parent.ToHex(), blockID.ToHex())
+			}
+			referenced[parent] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// verifyMilestoneCone verifies that the given blocks are exactly the blocks referenced by the given milestone,
+// in the order in which the white-flag confirmation of the milestone traverses them.
+// The white-flag confirmation computes the inclusion merkle root over the IDs of the referenced blocks in that order,
+// so the cone is valid if it is closed and the merkle root of the received block IDs matches the inclusion merkle root of the milestone.
+// The ledger mutations of the cone are not verified here, the applied merkle root is checked by the white-flag confirmation of the milestone.
+func verifyMilestoneCone(blocks []*storage.Block, milestonePayload *iotago.Milestone) error {
+	if err := verifyMilestoneConeClosure(blocks, milestonePayload.Parents); err != nil {
+		return err
+	}
+
+	blockIDs := make(iotago.BlockIDs, len(blocks))
+	for i, block := range blocks {
+		blockIDs[i] = block.BlockID()
+	}
+
+	inclusionMerkleRoot := merklehasher.NewHasher(crypto.BLAKE2b_256).HashBlockIDs(blockIDs)
+	if !bytes.Equal(inclusionMerkleRoot, milestonePayload.InclusionMerkleRoot[:]) {
+		return errors.Wrapf(ErrInvalidMilestoneCone, "inclusion merkle root does not match, expected: %s, actual: %s", iotago.EncodeHex(milestonePayload.InclusionMerkleRoot[:]), iotago.EncodeHex(inclusionMerkleRoot))
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package gossip
+
+import (
+	"crypto"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/protocol/message"
+	"github.com/iotaledger/hive.go/protocol/tlv"
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/metrics"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/merklehasher"
+)
+
+func newMilestoneConeTestBlock(t *testing.T, nonce uint64, parents ...iotago.BlockID) *storage.Block {
+	block, err := storage.NewBlock(&iotago.Block{
+		ProtocolVersion: 2,
+		Parents:         parents,
+		Nonce:           nonce,
+	}, serializer.DeSeriModeNoValidation, &iotago.ProtocolParameters{Version: 2})
+	require.NoError(t, err)
+
+	return block
+}
+
+func TestVerifyMilestoneCone(t *testing.T) {
+	// blocks of older milestones are not part of the cone
+	olderBlock := newMilestoneConeTestBlock(t, 0, iotago.EmptyBlockID())
+
+	first := newMilestoneConeTestBlock(t, 1, olderBlock.BlockID())
+	second := newMilestoneConeTestBlock(t, 2, olderBlock.BlockID(), first.BlockID())
+	third := newMilestoneConeTestBlock(t, 3, first.BlockID())
+	unreferenced := newMilestoneConeTestBlock(t, 4, first.BlockID())
+
+	// the white-flag confirmation traverses the parents of the milestone one after another in post-order
+	milestonePayload := &iotago.Milestone{
+		Parents: iotago.BlockIDs{second.BlockID(), third.BlockID()},
+	}
+	inclusionMerkleRoot := merklehasher.NewHasher(crypto.BLAKE2b_256).HashBlockIDs(iotago.BlockIDs{first.BlockID(), second.BlockID(), third.BlockID()})
+	copy(milestonePayload.InclusionMerkleRoot[:], inclusionMerkleRoot)
+
+	require.NoError(t, verifyMilestoneCone([]*storage.Block{first, second, third}, milestonePayload))
+
+	// the blocks need to be sent in the order of the white-flag confirmation
+	require.ErrorIs(t, verifyMilestoneCone([]*storage.Block{first, third, second}, milestonePayload), ErrInvalidMilestoneCone)
+	require.ErrorIs(t, verifyMilestoneCone([]*storage.Block{second, first, third}, milestonePayload), ErrInvalidMilestoneCone)
+
+	// blocks are not allowed to be sent twice
+	require.ErrorIs(t, verifyMilestoneCone([]*storage.Block{first, second, third, third}, milestonePayload), ErrInvalidMilestoneCone)
+
+	// blocks which are not referenced by the milestone are not part of the cone
+	require.ErrorIs(t, verifyMilestoneCone([]*storage.Block{first, second, third, unreferenced}, milestonePayload), ErrInvalidMilestoneCone)
+
+	// blocks referenced by the milestone can't be left out
+	require.ErrorIs(t, verifyMilestoneCone([]*storage.Block{first, second}, milestonePayload), ErrInvalidMilestoneCone)
+}
+
+func TestVerifyMilestoneConeClosure(t *testing.T) {
+	olderBlock := newMilestoneConeTestBlock(t, 0, iotago.EmptyBlockID())
+
+	first := newMilestoneConeTestBlock(t, 1, olderBlock.BlockID())
+	second := newMilestoneConeTestBlock(t, 2, olderBlock.BlockID(), first.BlockID())
+	third := newMilestoneConeTestBlock(t, 3, first.BlockID())
+	unreferenced := newMilestoneConeTestBlock(t, 4, first.BlockID())
+
+	milestoneParents := iotago.BlockIDs{second.BlockID(), third.BlockID()}
+
+	require.NoError(t, verifyMilestoneConeClosure([]*storage.Block{first, second, third}, milestoneParents))
+	require.NoError(t, verifyMilestoneConeClosure([]*storage.Block{first, third, second}, milestoneParents))
+
+	// parents need to be contained before the block
+	require.ErrorIs(t, verifyMilestoneConeClosure([]*storage.Block{second, first, third}, milestoneParents), ErrInvalidMilestoneCone)
+
+	// every block needs to be referenced by the milestone or a later block of the cone
+	require.ErrorIs(t, verifyMilestoneConeClosure([]*storage.Block{first, unreferenced, second, third}, milestoneParents), ErrInvalidMilestoneCone)
+	require.ErrorIs(t, verifyMilestoneConeClosure([]*storage.Block{olderBlock, first, second, third}, iotago.BlockIDs{third.BlockID()}), ErrInvalidMilestoneCone)
+}
+
+func TestMilestoneConeRequestBatchSize(t *testing.T) {
+	msg, err := newMilestoneConeRequestMessage(10, 15)
+	require.NoError(t, err)
+
+	// every requested milestone counts against the quota
+	require.Equal(t, 5, MessageBatchSize(MessageTypeMilestoneConeRequest, msg[tlv.HeaderBytesLength:]))
+}
+
+func TestMilestoneConeRequestRanges(t *testing.T) {
+	require.Empty(t, milestoneConeRequestRanges(nil))
+
+	var msIndexes []iotago.MilestoneIndex
+	for msIndex := iotago.MilestoneIndex(1); msIndex <= 28; msIndex++ {
+		if msIndex == 5 {
+			// milestones which are not consecutive are requested separately
+			continue
+		}
+		msIndexes = append(msIndexes, msIndex)
+	}
+
+	ranges := milestoneConeRequestRanges(msIndexes)
+	require.Len(t, ranges, 4)
+	require.Equal(t, []iotago.MilestoneIndex{1, 2, 3, 4}, ranges[0])
+	// the ranges are capped at the maximum amount of milestones per request
+	require.Len(t, ranges[1], MaxMilestoneConeRequestRange)
+	require.EqualValues(t, 6, ranges[1][0])
+	require.Len(t, ranges[2], MaxMilestoneConeRequestRange)
+	require.Equal(t, []iotago.MilestoneIndex{26, 27, 28}, ranges[3])
+}
+
+func TestMilestoneConeMessages(t *testing.T) {
+	var blocksData [][]byte
+	for i := 0; i < 100; i++ {
+		// big blocks to force the cone to be split into several chunks
+		blocksData = append(blocksData, make([]byte, 2000))
+		blocksData[i][0] = byte(i)
+	}
+
+	msgs, err := newMilestoneConeMessages(5, blocksData)
+	require.NoError(t, err)
+	require.Greater(t, len(msgs), 1)
+
+	buffers := newMilestoneConeBuffers()
+
+	// chunks may be processed out of order
+	var receivedBlocksData [][]byte
+	for i := len(msgs) - 1; i >= 0; i-- {
+		require.Equal(t, MessageTypeMilestoneCone, message.Type(msgs[i][0]))
+
+		chunk, err := extractMilestoneConeChunk(msgs[i][tlv.HeaderBytesLength:])
+		require.NoError(t, err)
+		require.EqualValues(t, 5, chunk.msIndex)
+		require.Equal(t, i, chunk.chunkIndex)
+		require.Equal(t, i == len(msgs)-1, chunk.lastChunk)
+
+		var complete bool
+		receivedBlocksData, complete, err = buffers.add(chunk)
+		require.NoError(t, err)
+		require.Equal(t, i == 0, complete)
+	}
+	require.Equal(t, blocksData, receivedBlocksData)
+	require.Empty(t, buffers.cones)
+
+	// empty cones are sent as a single chunk
+	msgs, err = newMilestoneConeMessages(6, nil)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+
+	chunk, err := extractMilestoneConeChunk(msgs[0][tlv.HeaderBytesLength:])
+	require.NoError(t, err)
+	require.True(t, chunk.lastChunk)
+
+	receivedBlocksData, complete, err := buffers.add(chunk)
+	require.NoError(t, err)
+	require.True(t, complete)
+	require.Empty(t, receivedBlocksData)
+
+	// duplicated chunks drop the cone
+	msgs, err = newMilestoneConeMessages(7, blocksData)
+	require.NoError(t, err)
+	chunk, err = extractMilestoneConeChunk(msgs[0][tlv.HeaderBytesLength:])
+	require.NoError(t, err)
+	_, _, err = buffers.add(chunk)
+	require.NoError(t, err)
+	_, _, err = buffers.add(chunk)
+	require.ErrorIs(t, err, ErrInvalidMilestoneCone)
+	require.Empty(t, buffers.cones)
+}
+
+func TestMilestoneConeBuffersExpire(t *testing.T) {
+	var blocksData [][]byte
+	for i := 0; i < 100; i++ {
+		blocksData = append(blocksData, make([]byte, 2000))
+	}
+
+	firstChunk := func(msIndex iotago.MilestoneIndex) *milestoneConeChunk {
+		msgs, err := newMilestoneConeMessages(msIndex, blocksData)
+		require.NoError(t, err)
+		require.Greater(t, len(msgs), 1)
+
+		chunk, err := extractMilestoneConeChunk(msgs[0][tlv.HeaderBytesLength:])
+		require.NoError(t, err)
+
+		return chunk
+	}
+
+	buffers := newMilestoneConeBuffers()
+
+	// the remaining chunks of the cones got lost
+	for i := 0; i < maxBufferedMilestoneConesPerPeer; i++ {
+		_, complete, err := buffers.add(firstChunk(iotago.MilestoneIndex(10 + i)))
+		require.NoError(t, err)
+		require.False(t, complete)
+	}
+
+	_, _, err := buffers.add(firstChunk(100))
+	require.ErrorIs(t, err, ErrMilestoneConeBufferFull)
+
+	// the incomplete cones are dropped once they expired
+	for _, cone := range buffers.cones {
+		cone.lastReceived = time.Now().Add(-buffers.timeout)
+	}
+
+	_, complete, err := buffers.add(firstChunk(100))
+	require.NoError(t, err)
+	require.False(t, complete)
+	require.Len(t, buffers.cones, 1)
+
+	// a cone which is requested again after it expired is received from the start
+	buffers.cones[100].lastReceived = time.Now().Add(-buffers.timeout)
+	_, _, err = buffers.add(firstChunk(100))
+	require.NoError(t, err)
+}
+
+func TestProtocolEnqueueAll(t *testing.T) {
+	proto := NewProtocol("peer", nil, 3, time.Second, time.Second, &metrics.ServerMetrics{})
+
+	require.True(t, proto.EnqueueAll([][]byte{{1}, {2}}))
+	require.Len(t, proto.SendQueue, 2)
+
+	// the messages are not enqueued at all if they don't fit into the send queue
+	require.False(t, proto.EnqueueAll([][]byte{{3}, {4}}))
+	require.Len(t, proto.SendQueue, 2)
+	require.EqualValues(t, 2, proto.Metrics.DroppedPackets.Load())
+
+	require.True(t, proto.EnqueueAll([][]byte{{3}}))
+	require.Len(t, proto.SendQueue, 3)
+}
@@ -160,6 +160,14 @@ func NewMessageProcessor(
 			proc.processBlockRequest(p, data)
 		case MessageTypeMilestoneRequest:
 			proc.processMilestoneRequest(p, data)
+		case MessageTypeBlockBatch:
+			proc.processBlockBatch(p, data)
+		case MessageTypeBlockRequestBatch:
+			proc.processBlockRequestBatch(p, data)
+		case MessageTypeMilestoneConeRequest:
+			proc.processMilestoneConeRequest(p, data)
+		case MessageTypeMilestoneCone:
+			proc.processMilestoneCone(p, data)
 		}
 
 		task.Return(nil)
@@ -187,10 +195,34 @@ func (proc *MessageProcessor) Shutdown() {
 }
 
 // Process submits the given message to the processor for processing.
+// Messages exceeding the quotas of the peer are dropped.
 func (proc *MessageProcessor) Process(p *Protocol, msgType message.Type, data []byte) {
+	if !proc.CheckQuota(p, msgType, data) {
+		return
+	}
+
 	proc.wp.Submit(p, msgType, data)
 }
 
+// CheckQuota consumes the quotas of the given peer for a received message.
+// Returns false if the peer exceeded one of its quotas, the message should be dropped in that case.
+// If configured, the connection to the peer is dropped as well.
+func (proc *MessageProcessor) CheckQuota(p *Protocol, msgType message.Type, data []byte) bool {
+	if p.Quotas == nil {
+		return true
+	}
+
+	if err := p.Quotas.ConsumeBatch(msgType, MessageBatchSize(msgType, data), len(data)); err != nil {
+		if p.Quotas.DisconnectOnViolation() {
+			// drop the connection to the peer
+			_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "peer exceeded its gossip quota"))
+		}
+		return false
+	}
+
+	return true
+}
+
 // Emit triggers BlockProcessed and BroadcastBlock events for the given block.
 // All blocks passed to this function must be checked with "DeSeriModePerformValidation" before.
 // We also check if the parents are solid and not BMD before we broadcast the block, otherwise
@@ -373,8 +405,215 @@ func (proc *MessageProcessor) processBlockRequest(p *Protocol, data []byte) {
 	p.Enqueue(msg)
 }
 
+// processes the given block request batch by parsing it and then replying to the peer with the requested blocks it knows about.
+func (proc *MessageProcessor) processBlockRequestBatch(p *Protocol, data []byte) {
+	blockIDs, err := extractRequestedBlockIDs(data)
+	if err != nil {
+		proc.serverMetrics.InvalidRequests.Inc()
+
+		// drop the connection to the peer
+		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "processBlockRequestBatch failed"))
+		return
+	}
+
+	blocksData := make([][]byte, 0, len(blockIDs))
+	for _, blockID := range blockIDs {
+		cachedBlock := proc.storage.CachedBlockOrNil(blockID) // block +1
+		if cachedBlock == nil {
+			// can't reply if we don't have the requested block
+			continue
+		}
+
+		requestedData, err := cachedBlock.Block().Block().Serialize(serializer.DeSeriModeNoValidation, nil)
+		cachedBlock.Release(true) // block -1
+		if err != nil {
+			// can't reply if serialization fails
+			continue
+		}
+
+		blocksData = append(blocksData, requestedData)
+	}
+
+	p.SendBlocks(blocksData)
+}
+
+// processes the given block batch by parsing it and then processing every contained block.
+func (proc *MessageProcessor) processBlockBatch(p *Protocol, data []byte) {
+	blocksData, err := extractBatchedBlocks(data)
+	if err != nil {
+		proc.serverMetrics.InvalidBlocks.Inc()
+		p.Reputation.RecordInvalidBlock()
+
+		// drop the connection to the peer
+		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "processBlockBatch failed"))
+		return
+	}
+
+	for _, blockData := range blocksData {
+		proc.processBlockData(p, blockData)
+	}
+}
+
+// processes the given milestone cone request by replying to the peer with the past cones of the requested milestones.
+// the cones are sent in topological order, one milestone after another.
+func (proc *MessageProcessor) processMilestoneConeRequest(p *Protocol, data []byte) {
+	startIndex, endIndex, err := extractRequestedMilestoneRange(data)
+	if err == nil && (endIndex <= startIndex || endIndex-startIndex > MaxMilestoneConeRequestRange) {
+		err = fmt.Errorf("invalid milestone range (%d-%d]", startIndex, endIndex)
+	}
+	if err != nil {
+		proc.serverMetrics.InvalidRequests.Inc()
+
+		// drop the connection to the peer
+		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "processMilestoneConeRequest failed"))
+		return
+	}
+
+	for msIndex := startIndex + 1; msIndex <= endIndex; msIndex++ {
+		blocksData, err := proc.milestoneConeBlocks(msIndex)
+		if err != nil {
+			// can't reply if we don't have the cone of the milestone
+			return
+		}
+
+		msgs, err := newMilestoneConeMessages(msIndex, blocksData)
+		if err != nil {
+			// can't reply if serialization fails
+			return
+		}
+
+		// a cone is only useful if all of its chunks are received,
+		// so the remaining cones are not sent if the send queue can't take all chunks of a cone.
+		// the peer requests the missing cones again once its request expired.
+		if !p.EnqueueAll(msgs) {
+			return
+		}
+	}
+}
+
+// returns the data of the blocks referenced by the given milestone in the order of the white-flag confirmation,
+// which is the order the inclusion merkle root of the milestone was computed in.
+func (proc *MessageProcessor) milestoneConeBlocks(msIndex iotago.MilestoneIndex) ([][]byte, error) {
+	milestoneParents, err := proc.storage.MilestoneParentsByIndex(msIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var blocksData [][]byte
+	if err := dag.TraverseParents(
+		context.Background(),
+		proc.storage,
+		milestoneParents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storage.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			// only traverse the blocks referenced by the given milestone
+			referenced, at := cachedBlockMeta.Metadata().ReferencedWithIndex()
+			return referenced && at == msIndex, nil
+		},
+		// consumer
+		// the parents are consumed before the blocks referencing them
+		func(cachedBlockMeta *storage.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			cachedBlock := proc.storage.CachedBlockOrNil(cachedBlockMeta.Metadata().BlockID()) // block +1
+			if cachedBlock == nil {
+				return fmt.Errorf("block not found: %s", cachedBlockMeta.Metadata().BlockID().ToHex())
+			}
+			defer cachedBlock.Release(true) // block -1
+
+			blocksData = append(blocksData, cachedBlock.Block().Data())
+			return nil
+		},
+		// called on missing parents
+		// missing parents are not part of the cone, because the cone of the milestone was referenced
+		func(parentBlockID iotago.BlockID) error { return nil },
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false); err != nil {
+		return nil, err
+	}
+
+	return blocksData, nil
+}
+
+// processes the given chunk of a milestone cone.
+// once all chunks of the cone were received, the cone is verified against the inclusion merkle root of the milestone
+// and the blocks are processed as if they were requested.
+func (proc *MessageProcessor) processMilestoneCone(p *Protocol, data []byte) {
+	chunk, err := extractMilestoneConeChunk(data)
+	if err != nil {
+		proc.serverMetrics.InvalidBlocks.Inc()
+		p.Reputation.RecordInvalidBlock()
+
+		// drop the connection to the peer
+		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "processMilestoneCone failed"))
+		return
+	}
+
+	if chunk.msIndex <= proc.syncManager.ConfirmedMilestoneIndex() {
+		// the milestone is already confirmed, there is no need to process the cone
+		p.milestoneCones.remove(chunk.msIndex)
+		return
+	}
+
+	blocksData, complete, err := p.milestoneCones.add(chunk)
+	if err != nil || !complete {
+		// the cone is dropped on errors, the missing blocks are requested one by one in that case
+		return
+	}
+
+	// we can only verify cones of milestones we know about
+	cachedMilestone := proc.storage.CachedMilestoneByIndexOrNil(chunk.msIndex) // milestone +1
+	if cachedMilestone == nil {
+		return
+	}
+	milestonePayload := cachedMilestone.Milestone().Milestone()
+	cachedMilestone.Release(true) // milestone -1
+
+	blocks := make([]*storage.Block, len(blocksData))
+	for i, blockData := range blocksData {
+		block, err := storage.BlockFromBytes(blockData, serializer.DeSeriModePerformValidation, proc.protocolManager.Current())
+		if err != nil {
+			proc.punishInvalidMilestoneCone(p, errors.WithMessage(err, "peer sent an invalid block"))
+			return
+		}
+		blocks[i] = block
+	}
+
+	// the cone is verified against the inclusion merkle root of the milestone before any block is applied
+	if err := verifyMilestoneCone(blocks, milestonePayload); err != nil {
+		proc.punishInvalidMilestoneCone(p, err)
+		return
+	}
+
+	// the blocks are processed as if they were requested for the milestone,
+	// the ledger changes of the cone are verified by the white-flag confirmation of the milestone.
+	for _, block := range blocks {
+		proc.processRequestedBlockData(p, block.Data(), NewBlockIDRequest(block.BlockID(), chunk.msIndex))
+	}
+}
+
+// punishes the given peer for sending an invalid milestone cone.
+func (proc *MessageProcessor) punishInvalidMilestoneCone(p *Protocol, err error) {
+	proc.serverMetrics.InvalidBlocks.Inc()
+	p.Reputation.RecordInvalidBlock()
+
+	// drop the connection to the peer
+	_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessage(err, "peer sent an invalid milestone cone"))
+}
+
 // gets or creates a new WorkUnit for the given block data and then processes the WorkUnit.
 func (proc *MessageProcessor) processBlockData(p *Protocol, data []byte) {
+	proc.processRequestedBlockData(p, data, nil)
+}
+
+// gets or creates a new WorkUnit for the given block data and then processes the WorkUnit.
+// the given request is handled as if the block was requested via the request queue, it may be nil.
+func (proc *MessageProcessor) processRequestedBlockData(p *Protocol, data []byte, request *Request) {
 	cachedWorkUnit, newlyAdded := proc.workUnitFor(data) // workUnit +1
 
 	// force release if not newly added, so the cache time is only active the first time the block is received.
@@ -382,25 +621,29 @@ func (proc *MessageProcessor) processBlockData(p *Protocol, data []byte) {
 
 	workUnit := cachedWorkUnit.WorkUnit()
 	workUnit.addReceivedFrom(p)
-	proc.processWorkUnit(workUnit, p)
+	proc.processWorkUnit(workUnit, p, request)
 }
 
 // tries to process the WorkUnit by first checking in what state it is.
 // if the WorkUnit is invalid (because the underlying block is invalid), the given peer is punished.
 // if the WorkUnit is already completed, and the block was requested, this function emits a BlockProcessed event.
+// the given request is handled as if the block was requested via the request queue, it may be nil.
 // it is safe to call this function for the same WorkUnit multiple times.
-func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol) {
+func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol, request *Request) {
 
 	processRequests := func(wu *WorkUnit, block *storage.Block, isMilestonePayload bool) Requests {
 
 		requests := Requests{}
-
-		// mark the block as received
-		request := proc.requestQueue.Received(block.BlockID())
 		if request != nil {
 			requests = append(requests, request)
 		}
 
+		// mark the block as received
+		queuedRequest := proc.requestQueue.Received(block.BlockID())
+		if queuedRequest != nil {
+			requests = append(requests, queuedRequest)
+		}
+
 		if isMilestonePayload {
 			// mark the milestone as received
 			msRequest := proc.requestQueue.Received(block.Milestone().Index)
@@ -424,6 +667,7 @@ func (proc *MessageProcessor) processWorkUnit(wu *WorkUnit, p *Protocol) {
 		wu.processingLock.Unlock()
 
 		proc.serverMetrics.InvalidBlocks.Inc()
+		p.Reputation.RecordInvalidBlock()
 
 		// drop the connection to the peer
 		_ = proc.peeringManager.DisconnectPeer(p.PeerID, errors.New("peer sent an invalid block"))
@@ -1,20 +1,27 @@
 package gossip_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/libp2p/go-libp2p"
 	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	libp2pprotocol "github.com/libp2p/go-libp2p-core/protocol"
 	"github.com/libp2p/go-libp2p/p2p/net/connmgr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/iotaledger/hive.go/protocol/message"
+	"github.com/iotaledger/hive.go/protocol/tlv"
 	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/hornet/v2/pkg/metrics"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/p2p"
+	"github.com/iotaledger/hornet/v2/pkg/protocol"
 	"github.com/iotaledger/hornet/v2/pkg/protocol/gossip"
 	"github.com/iotaledger/hornet/v2/pkg/testsuite"
 	iotago "github.com/iotaledger/iota.go/v3"
@@ -145,3 +152,219 @@ func TestMessageProcessorEmit(t *testing.T) {
 	err = processor.Emit(block)
 	assert.Error(t, err)
 }
+
+// returns the type and the data of the next message enqueued to be sent to the peer.
+func nextSentMessage(t *testing.T, proto *gossip.Protocol) (message.Type, []byte) {
+	select {
+	case msg := <-proto.SendQueue:
+		return message.Type(msg[0]), msg[tlv.HeaderBytesLength:]
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no message was sent")
+		return 0, nil
+	}
+}
+
+func TestMessageProcessorBatchedBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	// we use Ed25519 because otherwise it takes longer as the default is RSA
+	sk, _, _ := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+
+	n, err := libp2p.New(libp2p.Identity(sk))
+	require.NoError(t, err)
+
+	serverMetrics := &metrics.ServerMetrics{}
+
+	manager := p2p.NewManager(n)
+	go manager.Start(ctx)
+
+	processor, err := gossip.NewMessageProcessor(te.Storage(), te.SyncManager(), gossip.NewRequestQueue(), manager, serverMetrics, te.ProtocolManager(), &gossip.Options{
+		WorkUnitCacheOpts: testsuite.TestProfileCaches.IncomingBlocksFilter,
+	})
+	require.NoError(t, err)
+	go processor.Run(ctx)
+
+	var blockIDs iotago.BlockIDs
+	blocksData := make(map[string]struct{})
+	for i := 0; i < 3; i++ {
+		blockID := te.NewTestBlock(i, te.LastMilestoneParents()).BlockID()
+		blockIDs = append(blockIDs, blockID)
+
+		cachedBlock := te.Storage().CachedBlockOrNil(blockID) // block +1
+		require.NotNil(t, cachedBlock)
+		blocksData[string(cachedBlock.Block().Data())] = struct{}{}
+		cachedBlock.Release(true) // block -1
+	}
+
+	// unknown blocks are not part of the response
+	requestedBlockIDs := append(blockIDs, iotago.BlockID{})
+
+	legacyProto := gossip.NewProtocol("legacy", nil, 100, time.Second, time.Second, serverMetrics)
+	legacyProto.Version = protocol.GossipVersionLegacy
+
+	batchedProto := gossip.NewProtocol("batched", nil, 100, time.Second, time.Second, serverMetrics)
+	batchedProto.Version = protocol.GossipVersionBatched
+
+	// legacy peers get a request message per block
+	legacyProto.SendBlockRequests(requestedBlockIDs)
+	for _, blockID := range requestedBlockIDs {
+		msgType, data := nextSentMessage(t, legacyProto)
+		require.Equal(t, gossip.MessageTypeBlockRequest, msgType)
+		require.Equal(t, blockID[:], data)
+	}
+
+	// peers supporting batches get a single request message
+	batchedProto.SendBlockRequests(requestedBlockIDs)
+	msgType, blockRequestBatch := nextSentMessage(t, batchedProto)
+	require.Equal(t, gossip.MessageTypeBlockRequestBatch, msgType)
+	require.Equal(t, len(requestedBlockIDs), gossip.MessageBatchSize(msgType, blockRequestBatch))
+	require.Empty(t, batchedProto.SendQueue)
+
+	// the known blocks are answered within a single block batch
+	processor.Process(batchedProto, gossip.MessageTypeBlockRequestBatch, blockRequestBatch)
+	msgType, blockBatch := nextSentMessage(t, batchedProto)
+	require.Equal(t, gossip.MessageTypeBlockBatch, msgType)
+	require.Equal(t, len(blockIDs), gossip.MessageBatchSize(msgType, blockBatch))
+	for blockData := range blocksData {
+		require.True(t, bytes.Contains(blockBatch, []byte(blockData)))
+	}
+
+	// peers which don't support batches get a block message per known block
+	processor.Process(legacyProto, gossip.MessageTypeBlockRequestBatch, blockRequestBatch)
+	receivedBlocksData := make(map[string]struct{})
+	for range blockIDs {
+		msgType, blockData := nextSentMessage(t, legacyProto)
+		require.Equal(t, gossip.MessageTypeBlock, msgType)
+		receivedBlocksData[string(blockData)] = struct{}{}
+	}
+	require.Equal(t, blocksData, receivedBlocksData)
+
+	// requests exceeding the maximum batch size are split into multiple messages
+	batchedProto.SendBlockRequests(make(iotago.BlockIDs, gossip.MaxBlockRequestBatchSize+1))
+	msgType, blockRequestBatch = nextSentMessage(t, batchedProto)
+	require.Equal(t, gossip.MaxBlockRequestBatchSize, gossip.MessageBatchSize(msgType, blockRequestBatch))
+	msgType, blockRequestBatch = nextSentMessage(t, batchedProto)
+	require.Equal(t, 1, gossip.MessageBatchSize(msgType, blockRequestBatch))
+}
+
+func TestServiceProtocolVersionNegotiation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	batchedProtocolID := libp2pprotocol.ID(protocolID + "/batched")
+	batchedSrvOpts := []gossip.ServiceOption{
+		gossip.WithProtocolVersions(map[byte]libp2pprotocol.ID{protocol.GossipVersionBatched: batchedProtocolID}),
+	}
+
+	newTestNode := func(name string, srvOpts []gossip.ServiceOption) (*p2p.Manager, *gossip.Service, peer.AddrInfo) {
+		sk, _, err := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+		require.NoError(t, err)
+
+		_, manager, service, addrInfo := newNode(name, ctx, t, nil, srvOpts, sk)
+		return manager, service, addrInfo
+	}
+
+	batchedManager1, batchedService1, batchedAddrInfo1 := newTestNode("batched1", batchedSrvOpts)
+	batchedManager2, batchedService2, batchedAddrInfo2 := newTestNode("batched2", batchedSrvOpts)
+	legacyManager, legacyService, legacyAddrInfo := newTestNode("legacy", nil)
+
+	connect := func(manager1 *p2p.Manager, addrInfo1 peer.AddrInfo, manager2 *p2p.Manager, addrInfo2 peer.AddrInfo) {
+		go func() {
+			_ = manager1.ConnectPeer(&addrInfo2, p2p.PeerRelationKnown)
+		}()
+		time.Sleep(100 * time.Millisecond)
+		go func() {
+			_ = manager2.ConnectPeer(&addrInfo1, p2p.PeerRelationKnown)
+		}()
+	}
+
+	negotiatedVersion := func(service *gossip.Service, peerID peer.ID) byte {
+		var version byte
+		require.Eventually(t, func() bool {
+			proto := service.Protocol(peerID)
+			if proto == nil {
+				return false
+			}
+			version = proto.Version
+			return true
+		}, 10*time.Second, 10*time.Millisecond)
+		return version
+	}
+
+	// peers supporting batches negotiate the batched version
+	connect(batchedManager1, batchedAddrInfo1, batchedManager2, batchedAddrInfo2)
+	require.EqualValues(t, protocol.GossipVersionBatched, negotiatedVersion(batchedService1, batchedAddrInfo2.ID))
+	require.EqualValues(t, protocol.GossipVersionBatched, negotiatedVersion(batchedService2, batchedAddrInfo1.ID))
+
+	// legacy peers fall back to the legacy version
+	connect(batchedManager1, batchedAddrInfo1, legacyManager, legacyAddrInfo)
+	require.EqualValues(t, protocol.GossipVersionLegacy, negotiatedVersion(batchedService1, legacyAddrInfo.ID))
+	require.EqualValues(t, protocol.GossipVersionLegacy, negotiatedVersion(legacyService, batchedAddrInfo1.ID))
+	require.False(t, batchedService1.Protocol(legacyAddrInfo.ID).SupportsBatchedBlocks())
+}
+
+func TestMessageProcessorMilestoneCones(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	// we use Ed25519 because otherwise it takes longer as the default is RSA
+	sk, _, _ := crypto.GenerateKeyPair(crypto.Ed25519, -1)
+
+	n, err := libp2p.New(libp2p.Identity(sk))
+	require.NoError(t, err)
+
+	serverMetrics := &metrics.ServerMetrics{}
+
+	manager := p2p.NewManager(n)
+	go manager.Start(ctx)
+
+	processor, err := gossip.NewMessageProcessor(te.Storage(), te.SyncManager(), gossip.NewRequestQueue(), manager, serverMetrics, te.ProtocolManager(), &gossip.Options{
+		WorkUnitCacheOpts: testsuite.TestProfileCaches.IncomingBlocksFilter,
+	})
+	require.NoError(t, err)
+	go processor.Run(ctx)
+
+	first := te.NewTestBlock(1, te.LastMilestoneParents()).BlockID()
+	second := te.NewTestBlock(2, iotago.BlockIDs{first}).BlockID()
+	third := te.NewTestBlock(3, iotago.BlockIDs{first, second}).BlockID()
+	_, confStats := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{third}, false)
+
+	coneProto := gossip.NewProtocol("cones", nil, 100, time.Second, time.Second, serverMetrics)
+	coneProto.Version = protocol.GossipVersionMilestoneCones
+	require.True(t, coneProto.SupportsMilestoneCones())
+
+	coneProto.SendMilestoneConeRequest(confStats.Index-1, confStats.Index)
+	msgType, milestoneConeRequest := nextSentMessage(t, coneProto)
+	require.Equal(t, gossip.MessageTypeMilestoneConeRequest, msgType)
+
+	// the cone is sent in topological order
+	processor.Process(coneProto, gossip.MessageTypeMilestoneConeRequest, milestoneConeRequest)
+	msgType, milestoneCone := nextSentMessage(t, coneProto)
+	require.Equal(t, gossip.MessageTypeMilestoneCone, msgType)
+	require.Equal(t, confStats.BlocksReferenced, gossip.MessageBatchSize(msgType, milestoneCone))
+
+	var positions []int
+	for _, blockID := range (iotago.BlockIDs{first, second, third}) {
+		cachedBlock := te.Storage().CachedBlockOrNil(blockID) // block +1
+		require.NotNil(t, cachedBlock)
+		positions = append(positions, bytes.Index(milestoneCone, cachedBlock.Block().Data()))
+		cachedBlock.Release(true) // block -1
+	}
+	require.Positive(t, positions[0])
+	require.Less(t, positions[0], positions[1])
+	require.Less(t, positions[1], positions[2])
+
+	// invalid ranges drop the connection to the peer
+	invalidRequests := serverMetrics.InvalidRequests.Load()
+	processor.Process(coneProto, gossip.MessageTypeMilestoneConeRequest, make([]byte, len(milestoneConeRequest)))
+	require.Eventually(t, func() bool {
+		return serverMetrics.InvalidRequests.Load() == invalidRequests+1
+	}, 5*time.Second, 10*time.Millisecond)
+}
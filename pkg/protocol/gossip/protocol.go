@@ -12,6 +12,8 @@ import (
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/protocol"
 	"github.com/iotaledger/hornet/v2/pkg/metrics"
+	"github.com/iotaledger/hornet/v2/pkg/p2p"
+	hornetprotocol "github.com/iotaledger/hornet/v2/pkg/protocol"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -60,6 +62,7 @@ func NewProtocol(peerID peer.ID, stream network.Stream, sendQueueSize int, readT
 		readTimeout:    readTimeout,
 		writeTimeout:   writeTimeout,
 		ServerMetrics:  serverMetrics,
+		milestoneCones: newMilestoneConeBuffers(),
 	}
 }
 
@@ -69,6 +72,8 @@ type Protocol struct {
 	Parser *protocol.Protocol
 	// The ID of the peer to which this protocol is associated to.
 	PeerID peer.ID
+	// The gossip protocol version negotiated with the peer.
+	Version byte
 	// The underlying stream for this Protocol.
 	Stream network.Stream
 	// terminatedChan is closed if the protocol was terminated.
@@ -84,12 +89,18 @@ type Protocol struct {
 	// The send queue into which to enqueue messages to send.
 	SendQueue chan []byte
 	// The metrics around this protocol instance.
-	Metrics      Metrics
+	Metrics Metrics
+	// The quotas of the messages received from the peer, nil if no quotas are enforced.
+	Quotas *PeerQuotas
+	// The reputation of the peer, nil if the reputation is not tracked.
+	Reputation   *p2p.PeerReputation
 	sendMu       sync.Mutex
 	readTimeout  time.Duration
 	writeTimeout time.Duration
 	// The shared server metrics instance.
 	ServerMetrics *metrics.ServerMetrics
+	// the milestone cones which are currently received from the peer.
+	milestoneCones *milestoneConeBuffers
 }
 
 // Terminated returns a channel that is closed if the protocol was terminated.
@@ -108,6 +119,23 @@ func (p *Protocol) Enqueue(data []byte) {
 	}
 }
 
+// EnqueueAll enqueues all given gossip protocol messages if the send queue has enough free capacity for them.
+// Returns false without enqueuing any of the messages otherwise.
+// Messages which are enqueued concurrently may still fill up the queue, in which case the remaining messages get dropped.
+func (p *Protocol) EnqueueAll(msgs [][]byte) bool {
+	if cap(p.SendQueue)-len(p.SendQueue) < len(msgs) {
+		p.ServerMetrics.DroppedPackets.Add(uint32(len(msgs)))
+		p.Metrics.DroppedPackets.Add(uint32(len(msgs)))
+		return false
+	}
+
+	for _, msg := range msgs {
+		p.Enqueue(msg)
+	}
+
+	return true
+}
+
 // Read reads from the stream into the given buffer.
 func (p *Protocol) Read(buf []byte) (int, error) {
 	readMessage := func(buf []byte) (int, error) {
@@ -163,6 +191,29 @@ func (p *Protocol) SendBlock(blockData []byte) {
 	p.Enqueue(blockMessage)
 }
 
+// SendBlocks sends the given blocks to the given peer.
+// The blocks are batched if the peer supports it.
+func (p *Protocol) SendBlocks(blocksData [][]byte) {
+	if !p.SupportsBatchedBlocks() {
+		for _, blockData := range blocksData {
+			p.SendBlock(blockData)
+		}
+		return
+	}
+
+	if len(blocksData) == 0 {
+		return
+	}
+
+	blockBatchMessages, err := newBlockBatchMessages(blocksData)
+	if err != nil {
+		return
+	}
+	for _, blockBatchMessage := range blockBatchMessages {
+		p.Enqueue(blockBatchMessage)
+	}
+}
+
 // SendHeartbeat sends a Heartbeat to the given peer.
 func (p *Protocol) SendHeartbeat(solidMsIndex iotago.MilestoneIndex, pruningMsIndex iotago.MilestoneIndex, latestMsIndex iotago.MilestoneIndex, connectedPeers uint8, syncedPeers uint8) {
 	heartbeatData, err := newHeartbeatMessage(solidMsIndex, pruningMsIndex, latestMsIndex, connectedPeers, syncedPeers)
@@ -181,6 +232,25 @@ func (p *Protocol) SendBlockRequest(requestedBlockID iotago.BlockID) {
 	p.Enqueue(blockRequestMessage)
 }
 
+// SendBlockRequests sends block requests for the given block IDs to the given peer.
+// The requests are batched if the peer supports it.
+func (p *Protocol) SendBlockRequests(requestedBlockIDs iotago.BlockIDs) {
+	if !p.SupportsBatchedBlocks() {
+		for _, requestedBlockID := range requestedBlockIDs {
+			p.SendBlockRequest(requestedBlockID)
+		}
+		return
+	}
+
+	blockRequestBatchMessages, err := newBlockRequestBatchMessages(requestedBlockIDs)
+	if err != nil {
+		return
+	}
+	for _, blockRequestBatchMessage := range blockRequestBatchMessages {
+		p.Enqueue(blockRequestBatchMessage)
+	}
+}
+
 // SendMilestoneRequest sends a milestone request to the given peer.
 func (p *Protocol) SendMilestoneRequest(index iotago.MilestoneIndex) {
 	milestoneRequestMessage, err := newMilestoneRequestMessage(index)
@@ -190,11 +260,35 @@ func (p *Protocol) SendMilestoneRequest(index iotago.MilestoneIndex) {
 	p.Enqueue(milestoneRequestMessage)
 }
 
+// SendMilestoneConeRequest sends a request for the past cones of the milestones after startIndex up to and including endIndex to the given peer.
+// The range is capped at MaxMilestoneConeRequestRange milestones.
+func (p *Protocol) SendMilestoneConeRequest(startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex) {
+	if endIndex-startIndex > MaxMilestoneConeRequestRange {
+		endIndex = startIndex + MaxMilestoneConeRequestRange
+	}
+
+	milestoneConeRequestMessage, err := newMilestoneConeRequestMessage(startIndex, endIndex)
+	if err != nil {
+		return
+	}
+	p.Enqueue(milestoneConeRequestMessage)
+}
+
 // SendLatestMilestoneRequest sends a storage.Milestone request which requests the latest known milestone from the given peer.
 func (p *Protocol) SendLatestMilestoneRequest() {
 	p.SendMilestoneRequest(latestMilestoneRequestIndex)
 }
 
+// SupportsBatchedBlocks tells whether the peer supports batched block requests and responses.
+func (p *Protocol) SupportsBatchedBlocks() bool {
+	return p.Version >= hornetprotocol.GossipVersionBatched
+}
+
+// SupportsMilestoneCones tells whether the peer supports milestone cone requests.
+func (p *Protocol) SupportsMilestoneCones() bool {
+	return p.Version >= hornetprotocol.GossipVersionMilestoneCones
+}
+
 // HasDataForMilestone tells whether the underlying peer given the latest heartbeat message, has the cone data for the given milestone.
 // Returns false if no heartbeat message was received yet.
 func (p *Protocol) HasDataForMilestone(index iotago.MilestoneIndex) bool {
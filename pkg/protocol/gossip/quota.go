@@ -0,0 +1,162 @@
+package gossip
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
+
+	"github.com/iotaledger/hive.go/protocol/message"
+)
+
+var (
+	// ErrQuotaExceeded is returned if a peer exceeded one of its gossip quotas.
+	ErrQuotaExceeded = errors.New("gossip quota exceeded")
+)
+
+// Quota defines a token bucket which is refilled with the given rate per second
+// and holds at most burst tokens.
+type Quota struct {
+	// The amount of tokens that are refilled per second. A rate of 0 disables the quota.
+	Rate float64
+	// The maximum amount of tokens that can be consumed at once.
+	Burst int
+}
+
+// QuotaOptions define the quotas which are enforced for the messages received from every peer.
+type QuotaOptions struct {
+	// The quota of received bytes over all message types.
+	// The burst needs to be bigger than the maximum message size, otherwise big messages are never allowed.
+	Bandwidth Quota
+	// The quota of received blocks.
+	Blocks Quota
+	// The quota of received block requests.
+	BlockRequests Quota
+	// The quota of received milestone requests.
+	MilestoneRequests Quota
+	// The quota of received heartbeats.
+	Heartbeats Quota
+	// Whether peers which exceed a quota are disconnected.
+	// Otherwise only the messages exceeding the quota are dropped.
+	DisconnectOnViolation bool
+}
+
+// quotaLimiter limits the received messages of a single quota.
+type quotaLimiter struct {
+	limiter  *rate.Limiter
+	exceeded atomic.Uint32
+}
+
+func newQuotaLimiter(quota Quota) *quotaLimiter {
+	if quota.Rate <= 0 {
+		return nil
+	}
+
+	return &quotaLimiter{
+		limiter: rate.NewLimiter(rate.Limit(quota.Rate), quota.Burst),
+	}
+}
+
+// allow consumes the given amount of tokens.
+// Returns false if there are not enough tokens left.
+func (l *quotaLimiter) allow(tokens int) bool {
+	if l == nil {
+		return true
+	}
+
+	if !l.limiter.AllowN(time.Now(), tokens) {
+		l.exceeded.Inc()
+		return false
+	}
+
+	return true
+}
+
+func (l *quotaLimiter) exceededCount() uint32 {
+	if l == nil {
+		return 0
+	}
+
+	return l.exceeded.Load()
+}
+
+// PeerQuotas holds the state of the quotas of a single peer.
+type PeerQuotas struct {
+	bandwidth             *quotaLimiter
+	blocks                *quotaLimiter
+	blockRequests         *quotaLimiter
+	milestoneRequests     *quotaLimiter
+	heartbeats            *quotaLimiter
+	disconnectOnViolation bool
+}
+
+// NewPeerQuotas creates a new set of quotas for a peer.
+func NewPeerQuotas(opts *QuotaOptions) *PeerQuotas {
+	return &PeerQuotas{
+		bandwidth:             newQuotaLimiter(opts.Bandwidth),
+		blocks:                newQuotaLimiter(opts.Blocks),
+		blockRequests:         newQuotaLimiter(opts.BlockRequests),
+		milestoneRequests:     newQuotaLimiter(opts.MilestoneRequests),
+		heartbeats:            newQuotaLimiter(opts.Heartbeats),
+		disconnectOnViolation: opts.DisconnectOnViolation,
+	}
+}
+
+// Consume consumes the quotas for a received message of the given type and size.
+// Returns ErrQuotaExceeded if the peer exceeded the quota of the message type or the bandwidth quota.
+func (q *PeerQuotas) Consume(msgType message.Type, size int) error {
+	return q.ConsumeBatch(msgType, 1, size)
+}
+
+// ConsumeBatch consumes the quotas for a received message of the given type and size,
+// which contains the given amount of blocks or block requests.
+// Returns ErrQuotaExceeded if the peer exceeded the quota of the message type or the bandwidth quota.
+func (q *PeerQuotas) ConsumeBatch(msgType message.Type, count int, size int) error {
+	var messageLimiter *quotaLimiter
+	switch msgType {
+	case MessageTypeBlock, MessageTypeBlockBatch, MessageTypeMilestoneCone:
+		messageLimiter = q.blocks
+	case MessageTypeBlockRequest, MessageTypeBlockRequestBatch:
+		messageLimiter = q.blockRequests
+	case MessageTypeMilestoneRequest, MessageTypeMilestoneConeRequest:
+		messageLimiter = q.milestoneRequests
+	case MessageTypeHeartbeat:
+		messageLimiter = q.heartbeats
+	}
+
+	if !messageLimiter.allow(count) {
+		return errors.Wrapf(ErrQuotaExceeded, "message rate of type %d", msgType)
+	}
+
+	if !q.bandwidth.allow(size) {
+		return errors.Wrapf(ErrQuotaExceeded, "bandwidth, message type %d, size %d", msgType, size)
+	}
+
+	return nil
+}
+
+// DisconnectOnViolation returns whether the peer should be disconnected if it exceeds a quota.
+func (q *PeerQuotas) DisconnectOnViolation() bool {
+	return q.disconnectOnViolation
+}
+
+// Snapshot returns a PeerQuotasSnapshot of the exceeded quotas.
+func (q *PeerQuotas) Snapshot() PeerQuotasSnapshot {
+	return PeerQuotasSnapshot{
+		ExceededBandwidth:         q.bandwidth.exceededCount(),
+		ExceededBlocks:            q.blocks.exceededCount(),
+		ExceededBlockRequests:     q.blockRequests.exceededCount(),
+		ExceededMilestoneRequests: q.milestoneRequests.exceededCount(),
+		ExceededHeartbeats:        q.heartbeats.exceededCount(),
+	}
+}
+
+// PeerQuotasSnapshot represents a snapshot of the number of times a peer exceeded its quotas.
+type PeerQuotasSnapshot struct {
+	ExceededBandwidth         uint32 `json:"exceededBandwidth"`
+	ExceededBlocks            uint32 `json:"exceededBlocks"`
+	ExceededBlockRequests     uint32 `json:"exceededBlockRequests"`
+	ExceededMilestoneRequests uint32 `json:"exceededMilestoneRequests"`
+	ExceededHeartbeats        uint32 `json:"exceededHeartbeats"`
+}
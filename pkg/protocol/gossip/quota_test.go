@@ -0,0 +1,38 @@
+package gossip_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/protocol/gossip"
+)
+
+func TestPeerQuotas(t *testing.T) {
+	quotas := gossip.NewPeerQuotas(&gossip.QuotaOptions{
+		// the rates are low enough to not refill any tokens during the test
+		Bandwidth:  gossip.Quota{Rate: 0.001, Burst: 1000},
+		Blocks:     gossip.Quota{Rate: 0.001, Burst: 3},
+		Heartbeats: gossip.Quota{Rate: 0.001, Burst: 1},
+	})
+
+	// the burst of blocks can be consumed at once
+	for i := 0; i < 3; i++ {
+		require.NoError(t, quotas.Consume(gossip.MessageTypeBlock, 10))
+	}
+	require.ErrorIs(t, quotas.Consume(gossip.MessageTypeBlock, 10), gossip.ErrQuotaExceeded)
+
+	// the quotas of other message types are independent
+	require.NoError(t, quotas.Consume(gossip.MessageTypeHeartbeat, 10))
+	require.ErrorIs(t, quotas.Consume(gossip.MessageTypeHeartbeat, 10), gossip.ErrQuotaExceeded)
+
+	// message types without a quota are only limited by the bandwidth
+	require.NoError(t, quotas.Consume(gossip.MessageTypeBlockRequest, 900))
+	require.ErrorIs(t, quotas.Consume(gossip.MessageTypeBlockRequest, 100), gossip.ErrQuotaExceeded)
+
+	require.Equal(t, gossip.PeerQuotasSnapshot{
+		ExceededBandwidth:  1,
+		ExceededBlocks:     1,
+		ExceededHeartbeats: 1,
+	}, quotas.Snapshot())
+}
@@ -0,0 +1,330 @@
+package gossip
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	// the request round trip time assumed for peers without any answered requests.
+	defaultRequestRTT = 100 * time.Millisecond
+	// the smoothing factor of the exponential moving average of the request round trip time.
+	requestRTTSmoothing = 0.1
+)
+
+// peerRequestStats holds the request statistics of a single peer.
+type peerRequestStats struct {
+	// the amount of requests which were sent to the peer and are not answered or timed out yet.
+	inFlight int
+	// the amount of requests sent to the peer.
+	sent uint64
+	// the amount of requests answered by the peer.
+	answered uint64
+	// the amount of requests the peer didn't answer in time.
+	timedOut uint64
+	// the exponential moving average of the round trip time of the answered requests.
+	avgRTT time.Duration
+}
+
+// the expected round trip time of the next request sent to the peer.
+func (s *peerRequestStats) expectedRTT() time.Duration {
+	if s.answered == 0 {
+		return defaultRequestRTT
+	}
+	return s.avgRTT
+}
+
+// the ratio of answered requests, smoothed so that peers without statistics are not ruled out.
+func (s *peerRequestStats) successRate() float64 {
+	return float64(s.answered+1) / float64(s.answered+s.timedOut+1)
+}
+
+// the cost of sending another request to the peer.
+// peers with a low latency, a high success rate and few requests in flight are cheaper.
+func (s *peerRequestStats) cost() float64 {
+	return float64(s.expectedRTT()) * float64(s.inFlight+1) / s.successRate()
+}
+
+// requestAttempts holds the peers a request was sent to.
+type requestAttempts struct {
+	request *Request
+	// the peers which have the request in flight and the time it was sent to them.
+	inFlight map[peer.ID]time.Time
+	// the peers which didn't answer the request in time.
+	timedOut map[peer.ID]struct{}
+}
+
+// requestScheduler decides to which peer a request is sent.
+// It spreads the requests across the peers based on their request round trip time and success rate,
+// and caps the amount of requests in flight per peer.
+type requestScheduler struct {
+	sync.Mutex
+
+	// the maximum amount of requests in flight per peer.
+	maxInFlightPerPeer int
+	// the time after which a request is sent to another peer if it was not answered.
+	requestTimeout time.Duration
+
+	peers    map[peer.ID]*peerRequestStats
+	attempts map[string]*requestAttempts
+}
+
+func newRequestScheduler(maxInFlightPerPeer int, requestTimeout time.Duration) *requestScheduler {
+	return &requestScheduler{
+		maxInFlightPerPeer: maxInFlightPerPeer,
+		requestTimeout:     requestTimeout,
+		peers:              make(map[peer.ID]*peerRequestStats),
+		attempts:           make(map[string]*requestAttempts),
+	}
+}
+
+// returns the statistics of the given peer.
+// the lock must be held by the caller.
+func (s *requestScheduler) peerStats(peerID peer.ID) *peerRequestStats {
+	stats, exists := s.peers[peerID]
+	if !exists {
+		stats = &peerRequestStats{}
+		s.peers[peerID] = stats
+	}
+	return stats
+}
+
+// selectPeer selects the peer the given request should be sent to.
+// Peers which have the data for sure are preferred over peers which could have the data.
+// Peers which already have the request in flight or didn't answer it in time are skipped.
+// Returns saturated=true if peers could serve the request, but all of them reached the in-flight limit.
+// Returns nil and saturated=false if no peer can serve the request.
+func (s *requestScheduler) selectPeer(request *Request, protos []*Protocol) (proto *Protocol, saturated bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	attempts := s.attempts[request.MapKey()]
+
+	selectBest := func(hasData func(proto *Protocol) bool) (*Protocol, bool) {
+		var best *Protocol
+		var bestCost float64
+		candidates := false
+
+		for _, proto := range protos {
+			if !hasData(proto) {
+				continue
+			}
+
+			if attempts != nil {
+				if _, inFlight := attempts.inFlight[proto.PeerID]; inFlight {
+					continue
+				}
+				if _, timedOut := attempts.timedOut[proto.PeerID]; timedOut {
+					continue
+				}
+			}
+			candidates = true
+
+			stats := s.peerStats(proto.PeerID)
+			if s.maxInFlightPerPeer > 0 && stats.inFlight >= s.maxInFlightPerPeer {
+				continue
+			}
+
+			// the protocols are ordered by reputation, so the better peer wins on equal costs
+			if cost := stats.cost(); best == nil || cost < bestCost {
+				best = proto
+				bestCost = cost
+			}
+		}
+
+		return best, candidates
+	}
+
+	for i := 0; i < 2; i++ {
+		// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= SolidMilestoneIndex)
+		best, candidates := selectBest(func(proto *Protocol) bool { return proto.HasDataForMilestone(request.MilestoneIndex) })
+		if best != nil || candidates {
+			return best, best == nil
+		}
+
+		// we have no neighbor that has the data for sure, so we ask the peers that could have the data
+		// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= LatestMilestoneIndex)
+		best, candidates = selectBest(func(proto *Protocol) bool { return proto.CouldHaveDataForMilestone(request.MilestoneIndex) })
+		if best != nil || candidates {
+			return best, best == nil
+		}
+
+		if attempts == nil || len(attempts.timedOut) == 0 {
+			break
+		}
+
+		// all peers which could serve the request didn't answer it in time, so we give them another chance
+		attempts.timedOut = make(map[peer.ID]struct{})
+	}
+
+	return nil, false
+}
+
+// sent marks the given request as sent to the given peer.
+func (s *requestScheduler) sent(request *Request, peerID peer.ID) {
+	s.Lock()
+	defer s.Unlock()
+
+	requestMapKey := request.MapKey()
+	attempts, exists := s.attempts[requestMapKey]
+	if !exists {
+		attempts = &requestAttempts{
+			request:  request,
+			inFlight: make(map[peer.ID]time.Time),
+			timedOut: make(map[peer.ID]struct{}),
+		}
+		s.attempts[requestMapKey] = attempts
+	}
+
+	if _, inFlight := attempts.inFlight[peerID]; inFlight {
+		return
+	}
+	attempts.inFlight[peerID] = time.Now()
+
+	stats := s.peerStats(peerID)
+	stats.inFlight++
+	stats.sent++
+}
+
+// received marks the given requests as answered by the given peer.
+// The in-flight slots of all peers the requests were sent to are released.
+// Returns the round trip times of the requests which were sent to the given peer,
+// and whether any in-flight slot was released.
+func (s *requestScheduler) received(requests Requests, peerID peer.ID) (rtts []time.Duration, released bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for _, request := range requests {
+		requestMapKey := request.MapKey()
+		attempts, exists := s.attempts[requestMapKey]
+		if !exists {
+			continue
+		}
+
+		for inFlightPeerID, sentTime := range attempts.inFlight {
+			stats := s.peerStats(inFlightPeerID)
+			stats.inFlight--
+			released = true
+
+			if inFlightPeerID != peerID {
+				continue
+			}
+
+			// the round trip time is measured from the time the request was sent to the peer,
+			// the time the request was waiting in the queue is not the fault of the peer.
+			rtt := time.Since(sentTime)
+			rtts = append(rtts, rtt)
+			if stats.answered == 0 {
+				stats.avgRTT = rtt
+			} else {
+				stats.avgRTT += time.Duration(requestRTTSmoothing * float64(rtt-stats.avgRTT))
+			}
+			stats.answered++
+		}
+		delete(s.attempts, requestMapKey)
+	}
+
+	return rtts, released
+}
+
+// timedOut releases the in-flight slots of the requests which were not answered in time.
+// Returns the requests which were not answered in time.
+func (s *requestScheduler) timedOut() []*Request {
+	s.Lock()
+	defer s.Unlock()
+
+	var requests []*Request
+	now := time.Now()
+	for _, attempts := range s.attempts {
+		timedOut := false
+		for peerID, sentTime := range attempts.inFlight {
+			if now.Sub(sentTime) < s.requestTimeout {
+				continue
+			}
+
+			stats := s.peerStats(peerID)
+			stats.inFlight--
+			stats.timedOut++
+
+			delete(attempts.inFlight, peerID)
+			attempts.timedOut[peerID] = struct{}{}
+			timedOut = true
+		}
+
+		if timedOut {
+			requests = append(requests, attempts.request)
+		}
+	}
+
+	return requests
+}
+
+// cleanup removes the attempts of requests which are not in flight anymore and no longer need to be tracked,
+// and the statistics of peers which are not connected anymore.
+func (s *requestScheduler) cleanup(isTracked func(request *Request) bool, protos []*Protocol) {
+	s.Lock()
+	defer s.Unlock()
+
+	for requestMapKey, attempts := range s.attempts {
+		if len(attempts.inFlight) == 0 && !isTracked(attempts.request) {
+			delete(s.attempts, requestMapKey)
+		}
+	}
+
+	connected := make(map[peer.ID]struct{}, len(protos))
+	for _, proto := range protos {
+		connected[proto.PeerID] = struct{}{}
+	}
+
+	for peerID, stats := range s.peers {
+		if _, isConnected := connected[peerID]; !isConnected && stats.inFlight == 0 {
+			delete(s.peers, peerID)
+		}
+	}
+}
+
+// snapshot returns the request statistics of all peers, ordered by peer ID.
+func (s *requestScheduler) snapshot() []*PeerRequestStats {
+	s.Lock()
+	defer s.Unlock()
+
+	peerStats := make([]*PeerRequestStats, 0, len(s.peers))
+	for peerID, stats := range s.peers {
+		peerStats = append(peerStats, &PeerRequestStats{
+			PeerID:      peerID.String(),
+			InFlight:    stats.inFlight,
+			Sent:        stats.sent,
+			Answered:    stats.answered,
+			TimedOut:    stats.timedOut,
+			AvgRTT:      stats.avgRTT.Milliseconds(),
+			SuccessRate: stats.successRate(),
+		})
+	}
+
+	sort.Slice(peerStats, func(i, j int) bool {
+		return peerStats[i].PeerID < peerStats[j].PeerID
+	})
+
+	return peerStats
+}
+
+// PeerRequestStats represents a snapshot of the request statistics of a peer.
+type PeerRequestStats struct {
+	// The ID of the peer.
+	PeerID string `json:"peerId"`
+	// The amount of requests which were sent to the peer and are not answered or timed out yet.
+	InFlight int `json:"inFlight"`
+	// The amount of requests sent to the peer.
+	Sent uint64 `json:"sent"`
+	// The amount of requests answered by the peer.
+	Answered uint64 `json:"answered"`
+	// The amount of requests the peer didn't answer in time.
+	TimedOut uint64 `json:"timedOut"`
+	// The average round trip time of the answered requests in milliseconds.
+	AvgRTT int64 `json:"avgRttMs"`
+	// The ratio of answered requests.
+	SuccessRate float64 `json:"successRate"`
+}
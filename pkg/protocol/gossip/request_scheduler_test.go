@@ -0,0 +1,101 @@
+package gossip
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/require"
+
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func newSchedulerTestProtocol(peerID peer.ID, solidMsIndex iotago.MilestoneIndex) *Protocol {
+	proto := NewProtocol(peerID, nil, 10, time.Second, time.Second, nil)
+	proto.LatestHeartbeat = &Heartbeat{
+		SolidMilestoneIndex:  solidMsIndex,
+		LatestMilestoneIndex: solidMsIndex,
+	}
+	return proto
+}
+
+func TestRequestScheduler(t *testing.T) {
+	scheduler := newRequestScheduler(2, 0)
+
+	protoA := newSchedulerTestProtocol("A", 100)
+	protoB := newSchedulerTestProtocol("B", 100)
+	protoC := newSchedulerTestProtocol("C", 10)
+	protos := []*Protocol{protoA, protoB, protoC}
+
+	sendNext := func(msIndex iotago.MilestoneIndex) (*Request, *Protocol, bool) {
+		request := NewMilestoneIndexRequest(msIndex)
+		proto, saturated := scheduler.selectPeer(request, protos)
+		if proto != nil {
+			scheduler.sent(request, proto.PeerID)
+		}
+		return request, proto, saturated
+	}
+
+	// the requests are spread across the peers which have the data
+	var requests []*Request
+	sentTo := make(map[peer.ID]int)
+	for i := 0; i < 4; i++ {
+		request, proto, saturated := sendNext(iotago.MilestoneIndex(50 + i))
+		require.False(t, saturated)
+		require.NotNil(t, proto)
+		requests = append(requests, request)
+		sentTo[proto.PeerID]++
+	}
+	require.Equal(t, map[peer.ID]int{"A": 2, "B": 2}, sentTo)
+
+	// the peers reached the in-flight limit
+	_, proto, saturated := sendNext(60)
+	require.True(t, saturated)
+	require.Nil(t, proto)
+
+	// peer C can't serve the request either way
+	_, proto, saturated = sendNext(11)
+	require.True(t, saturated)
+	require.Nil(t, proto)
+
+	// answering a request frees the capacity and records the round trip time
+	rtts, released := scheduler.received(Requests{requests[0]}, protoA.PeerID)
+	require.True(t, released)
+	require.Len(t, rtts, 1)
+	_, proto, saturated = sendNext(60)
+	require.False(t, saturated)
+	require.NotNil(t, proto)
+
+	// no peer has the data
+	_, proto, saturated = sendNext(200)
+	require.False(t, saturated)
+	require.Nil(t, proto)
+
+	// all in-flight requests time out immediately, so the requests are re-requested from other peers
+	timedOutRequests := scheduler.timedOut()
+	require.Len(t, timedOutRequests, 4)
+
+	for _, request := range timedOutRequests {
+		scheduler.Lock()
+		timedOutPeers := scheduler.attempts[request.MapKey()].timedOut
+		scheduler.Unlock()
+		require.Len(t, timedOutPeers, 1)
+
+		proto, saturated := scheduler.selectPeer(request, protos)
+		require.False(t, saturated)
+		require.NotNil(t, proto)
+		require.NotContains(t, timedOutPeers, proto.PeerID)
+	}
+
+	// peer C never had the data, so there are no statistics about it
+	stats := scheduler.snapshot()
+	require.Len(t, stats, 2)
+	for _, peerStats := range stats {
+		require.Zero(t, peerStats.InFlight)
+		require.EqualValues(t, 2, peerStats.TimedOut)
+		if peerStats.PeerID == protoA.PeerID.String() {
+			require.EqualValues(t, 3, peerStats.Sent)
+			require.EqualValues(t, 1, peerStats.Answered)
+		}
+	}
+}
@@ -2,18 +2,30 @@ package gossip
 
 import (
 	"context"
+	"sort"
 	"time"
 
+	"github.com/libp2p/go-libp2p-core/peer"
+
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+const (
+	// the interval in which the requests are checked for timeouts.
+	requestTimeoutCheckInterval = 500 * time.Millisecond
+)
+
 // RequesterOptions are options around a Requester.
 type RequesterOptions struct {
 	// Defines the re-queue interval for pending requests.
 	PendingRequestReEnqueueInterval time.Duration
 	// Defines the max age for requests.
 	DiscardRequestsOlderThan time.Duration
+	// Defines the maximum amount of requests in flight per peer.
+	MaxInFlightPerPeer int
+	// Defines the time after which a request is sent to another peer if it was not answered.
+	RequestTimeout time.Duration
 }
 
 // applies the given RequesterOption.
@@ -30,6 +42,8 @@ type RequestBackPressureFunc func() bool
 var defaultRequesterOpts = []RequesterOption{
 	WithRequesterDiscardRequestsOlderThan(10 * time.Second),
 	WithRequesterPendingRequestReEnqueueInterval(5 * time.Second),
+	WithRequesterMaxInFlightPerPeer(128),
+	WithRequesterRequestTimeout(3 * time.Second),
 }
 
 // RequesterOption is a function which sets an option on a RequesterOptions instance.
@@ -49,12 +63,28 @@ func WithRequesterPendingRequestReEnqueueInterval(dur time.Duration) RequesterOp
 	}
 }
 
+// WithRequesterMaxInFlightPerPeer sets the maximum amount of requests in flight per peer.
+func WithRequesterMaxInFlightPerPeer(maxInFlight int) RequesterOption {
+	return func(options *RequesterOptions) {
+		options.MaxInFlightPerPeer = maxInFlight
+	}
+}
+
+// WithRequesterRequestTimeout sets the time after which a request is sent to another peer if it was not answered.
+func WithRequesterRequestTimeout(dur time.Duration) RequesterOption {
+	return func(options *RequesterOptions) {
+		options.RequestTimeout = dur
+	}
+}
+
 // Requester handles requesting packets.
 type Requester struct {
 	storage *storage.Storage
 	service *Service
 	rQueue  RequestQueue
 	opts    *RequesterOptions
+	// decides to which peer a request is sent.
+	scheduler *requestScheduler
 
 	running     bool
 	backPFuncs  []RequestBackPressureFunc
@@ -77,6 +107,7 @@ func NewRequester(
 		service:     service,
 		rQueue:      rQueue,
 		opts:        reqOpts,
+		scheduler:   newRequestScheduler(reqOpts.MaxInFlightPerPeer, reqOpts.RequestTimeout),
 		drainSignal: make(chan struct{}, 2),
 	}
 }
@@ -84,58 +115,185 @@ func NewRequester(
 // RunRequestQueueDrainer runs the RequestQueue drainer.
 func (r *Requester) RunRequestQueueDrainer(ctx context.Context) {
 	r.running = true
+	timeoutTicker := time.NewTicker(requestTimeoutCheckInterval)
+	defer timeoutTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
+		case <-timeoutTicker.C:
+			r.reRequestTimedOut()
 		case <-r.drainSignal:
+			r.drainRequestQueue()
+		}
+	}
+}
 
-			// drain request queue
-			for request := r.rQueue.Next(); request != nil; request = r.rQueue.Next() {
-
-				sendRequest := func(request *Request, proto *Protocol) {
-					switch request.RequestType {
-					case RequestTypeBlockID:
-						proto.SendBlockRequest(request.BlockID)
-					case RequestTypeMilestoneIndex:
-						proto.SendMilestoneRequest(request.MilestoneIndex)
-					default:
-						panic(ErrUnknownRequestType)
-					}
-				}
-
-				requested := false
-				r.service.ForEach(func(proto *Protocol) bool {
-					// we only send a request block if the peer actually has the data
-					// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= SolidMilestoneIndex)
-					if !proto.HasDataForMilestone(request.MilestoneIndex) {
-						return true
-					}
-
-					sendRequest(request, proto)
-					requested = true
-					return false
-				})
-
-				if !requested {
-					// we have no neighbor that has the data for sure,
-					// so we ask all peers that could have the data
-					// (r.MilestoneIndex > PrunedMilestoneIndex && r.MilestoneIndex <= LatestMilestoneIndex)
-					r.service.ForEach(func(proto *Protocol) bool {
-						// we only send a request block if the peer could have the data
-						if !proto.CouldHaveDataForMilestone(request.MilestoneIndex) {
-							return true
-						}
-
-						sendRequest(request, proto)
-						return true
-					})
-				}
+// blockRequestBatches collects the requested block IDs per peer,
+// so that they can be sent in batched messages to peers which support it.
+type blockRequestBatches map[*Protocol]iotago.BlockIDs
+
+// adds the given block ID to the batch of the given peer and sends the batch if it is full.
+func (b blockRequestBatches) add(proto *Protocol, blockID iotago.BlockID) {
+	b[proto] = append(b[proto], blockID)
+	if len(b[proto]) >= MaxBlockRequestBatchSize {
+		proto.SendBlockRequests(b[proto])
+		delete(b, proto)
+	}
+}
+
+// sends the batches of all peers.
+func (b blockRequestBatches) flush() {
+	for proto, blockIDs := range b {
+		proto.SendBlockRequests(blockIDs)
+		delete(b, proto)
+	}
+}
+
+// sends the given request to the given peer.
+// block requests are added to the given batches and sent once the batches are flushed.
+func (r *Requester) sendRequest(request *Request, proto *Protocol, batches blockRequestBatches) {
+	switch request.RequestType {
+	case RequestTypeBlockID:
+		batches.add(proto, request.BlockID)
+	case RequestTypeMilestoneIndex:
+		proto.SendMilestoneRequest(request.MilestoneIndex)
+	default:
+		panic(ErrUnknownRequestType)
+	}
+	r.scheduler.sent(request, proto.PeerID)
+}
+
+// drains the request queue until it is empty or all peers which could serve the next request are saturated.
+func (r *Requester) drainRequestQueue() {
+	// peers with a better reputation are asked first on equal costs
+	protos := r.protocolsByReputation()
+
+	batches := make(blockRequestBatches)
+	defer batches.flush()
+
+	for {
+		// check whether the next request can be sent before popping it from the queue,
+		// otherwise it would stay pending until it gets re-enqueued.
+		if peeked := r.rQueue.Peek(); peeked != nil && r.rQueue.IsQueued(peeked) {
+			if _, saturated := r.scheduler.selectPeer(peeked, protos); saturated {
+				// the drainer is signaled again as soon as a peer has free capacity
+				return
 			}
 		}
+
+		request := r.rQueue.Next()
+		if request == nil {
+			return
+		}
+
+		proto, saturated := r.scheduler.selectPeer(request, protos)
+		if saturated {
+			// the request stays pending and gets re-enqueued later
+			return
+		}
+
+		if proto == nil {
+			// no peer can serve the request, the request stays pending and gets re-enqueued later
+			continue
+		}
+
+		r.sendRequest(request, proto, batches)
+	}
+}
+
+// sends the requests which were not answered in time to other peers.
+func (r *Requester) reRequestTimedOut() {
+	timedOutRequests := r.scheduler.timedOut()
+
+	protos := r.protocolsByReputation()
+	batches := make(blockRequestBatches)
+	for _, request := range timedOutRequests {
+		// the request may have been answered by another peer or discarded in the meantime
+		if !r.rQueue.IsPending(request) {
+			continue
+		}
+
+		if proto, _ := r.scheduler.selectPeer(request, protos); proto != nil {
+			r.sendRequest(request, proto, batches)
+		}
+	}
+	batches.flush()
+
+	r.scheduler.cleanup(func(request *Request) bool {
+		return r.rQueue.IsQueued(request) || r.rQueue.IsPending(request)
+	}, protos)
+
+	if len(timedOutRequests) > 0 {
+		// the timed out requests freed some capacity
+		r.signalDrain()
 	}
 }
 
+// RequestsReceived marks the given requests as answered by the given peer.
+// This frees the capacity of all peers the requests were sent to.
+func (r *Requester) RequestsReceived(requests Requests, proto *Protocol) {
+	if !requests.HasRequest() {
+		return
+	}
+
+	var peerID peer.ID
+	if proto != nil {
+		peerID = proto.PeerID
+	}
+
+	rtts, released := r.scheduler.received(requests, peerID)
+	for _, rtt := range rtts {
+		proto.Reputation.RecordRequestLatency(rtt)
+	}
+
+	if released {
+		r.signalDrain()
+	}
+}
+
+// RequestMilestoneCones requests the whole past cones of the milestones after startIndex up to and including endIndex
+// from the peer with the best reputation which supports milestone cone requests and has the cone data.
+// Ranges bigger than MaxMilestoneConeRequestRange are split into several requests.
+// Returns false if no such peer is connected.
+func (r *Requester) RequestMilestoneCones(startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex) bool {
+	for _, proto := range r.protocolsByReputation() {
+		if !proto.SupportsMilestoneCones() || !proto.HasDataForMilestone(endIndex) {
+			continue
+		}
+
+		for rangeStartIndex := startIndex; rangeStartIndex < endIndex; rangeStartIndex += MaxMilestoneConeRequestRange {
+			proto.SendMilestoneConeRequest(rangeStartIndex, endIndex)
+		}
+		return true
+	}
+
+	return false
+}
+
+// PeerRequestStats returns the request statistics of all peers.
+func (r *Requester) PeerRequestStats() []*PeerRequestStats {
+	return r.scheduler.snapshot()
+}
+
+// returns the protocols of all peers, ordered by the reputation score of the peers (best first).
+func (r *Requester) protocolsByReputation() []*Protocol {
+	var protos []*Protocol
+	scores := make(map[*Protocol]float64)
+	r.service.ForEach(func(proto *Protocol) bool {
+		protos = append(protos, proto)
+		scores[proto] = proto.Reputation.Score().Total
+		return true
+	})
+
+	sort.SliceStable(protos, func(i, j int) bool {
+		return scores[protos[i]] > scores[protos[j]]
+	})
+
+	return protos
+}
+
 // RunPendingRequestEnqueuer runs the loop to periodically re-request pending requests from the RequestQueue.
 func (r *Requester) RunPendingRequestEnqueuer(ctx context.Context) {
 	r.running = true
@@ -155,10 +313,7 @@ reEnqueueLoop:
 
 			// always fire the signal if something is in the queue, otherwise the sting request is not kicking in
 			if queued := r.rQueue.EnqueuePending(r.opts.DiscardRequestsOlderThan); queued > 0 {
-				select {
-				case r.drainSignal <- struct{}{}:
-				default:
-				}
+				r.signalDrain()
 			}
 		}
 	}
@@ -170,14 +325,18 @@ func (r *Requester) enqueueAndSignal(request *Request) bool {
 		return false
 	}
 
-	// signal requester
+	r.signalDrain()
+	return true
+}
+
+// signals the request drainer to drain the request queue.
+func (r *Requester) signalDrain() {
 	select {
 	case r.drainSignal <- struct{}{}:
 	default:
 		// if the signal queue is full, there's no need to block until it becomes empty
 		// as the requester will drain everything present in the queue
 	}
-	return true
 }
 
 // checks whether any back pressure function is signaling congestion.
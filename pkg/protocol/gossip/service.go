@@ -3,6 +3,7 @@ package gossip
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
@@ -19,6 +20,7 @@ import (
 	"github.com/iotaledger/hive.go/workerpool"
 	"github.com/iotaledger/hornet/v2/pkg/metrics"
 	"github.com/iotaledger/hornet/v2/pkg/p2p"
+	hornetprotocol "github.com/iotaledger/hornet/v2/pkg/protocol"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -91,6 +93,10 @@ type ServiceOptions struct {
 	streamWriteTimeout time.Duration
 	// The amount of unknown peers to allow to have a gossip stream with.
 	unknownPeersLimit int
+	// The quotas enforced for every peer, nil if no quotas are enforced.
+	quotas *QuotaOptions
+	// The libp2p protocol IDs of the offered gossip protocol versions.
+	protocolVersions map[byte]protocol.ID
 }
 
 // applies the given ServiceOption.
@@ -143,6 +149,22 @@ func WithUnknownPeersLimit(limit int) ServiceOption {
 	}
 }
 
+// WithQuotas enforces the given quotas for the messages received from every peer.
+func WithQuotas(quotas *QuotaOptions) ServiceOption {
+	return func(opts *ServiceOptions) {
+		opts.quotas = quotas
+	}
+}
+
+// WithProtocolVersions defines the libp2p protocol IDs of additional gossip protocol versions offered to peers.
+// The protocol ID passed to NewService is offered as the legacy version.
+// The highest version supported by both peers is negotiated when a stream is opened.
+func WithProtocolVersions(protocolIDs map[byte]protocol.ID) ServiceOption {
+	return func(opts *ServiceOptions) {
+		opts.protocolVersions = protocolIDs
+	}
+}
+
 // ServiceOption is a function setting a ServiceOptions option.
 type ServiceOption func(opts *ServiceOptions)
 
@@ -154,8 +176,11 @@ type Service struct {
 	// Events happening around a Service.
 	Events *ServiceEvents
 	// the libp2p host instance from which to work with.
-	host     host.Host
-	protocol protocol.ID
+	host host.Host
+	// the libp2p protocol IDs of the offered gossip protocol versions, ordered by preference (highest version first).
+	protocols []protocol.ID
+	// the gossip protocol versions by their libp2p protocol ID.
+	protocolVersions map[protocol.ID]byte
 	// holds the set of protocols.
 	streams map[peer.ID]*Protocol
 	// the instance of the peeringManager to work with.
@@ -195,7 +220,7 @@ type Service struct {
 
 // NewService creates a new Service.
 func NewService(
-	protocol protocol.ID, host host.Host,
+	protocolID protocol.ID, host host.Host,
 	peeringManager *p2p.Manager,
 	serverMetrics *metrics.ServerMetrics,
 	opts ...ServiceOption) *Service {
@@ -204,6 +229,22 @@ func NewService(
 	srvOpts.apply(defaultServiceOptions...)
 	srvOpts.apply(opts...)
 
+	// the given protocol ID is always offered as the legacy version
+	versionProtocolIDs := map[byte]protocol.ID{hornetprotocol.GossipVersionLegacy: protocolID}
+	for version, id := range srvOpts.protocolVersions {
+		versionProtocolIDs[version] = id
+	}
+
+	protocolVersions := make(map[protocol.ID]byte, len(versionProtocolIDs))
+	protocols := make([]protocol.ID, 0, len(versionProtocolIDs))
+	for version, id := range versionProtocolIDs {
+		protocolVersions[id] = version
+		protocols = append(protocols, id)
+	}
+	sort.Slice(protocols, func(i, j int) bool {
+		return protocolVersions[protocols[i]] > protocolVersions[protocols[j]]
+	})
+
 	gossipService := &Service{
 		Events: &ServiceEvents{
 			ProtocolStarted:       events.NewEvent(ProtocolCaller),
@@ -212,7 +253,8 @@ func NewService(
 			Error:                 events.NewEvent(events.ErrorCaller),
 		},
 		host:                host,
-		protocol:            protocol,
+		protocols:           protocols,
+		protocolVersions:    protocolVersions,
 		streams:             make(map[peer.ID]*Protocol),
 		peeringManager:      peeringManager,
 		serverMetrics:       serverMetrics,
@@ -290,21 +332,25 @@ func (s *Service) Start(ctx context.Context) {
 	s.peeringMngWP.Start()
 	s.attachEvents()
 
-	// libp2p stream handler
-	s.host.SetStreamHandler(s.protocol, func(stream network.Stream) {
-		if s.stopped.IsSet() {
-			return
-		}
-		s.inboundStreamChan <- stream
-	})
+	// libp2p stream handlers, one per offered gossip protocol version
+	for _, protocolID := range s.protocols {
+		s.host.SetStreamHandler(protocolID, func(stream network.Stream) {
+			if s.stopped.IsSet() {
+				return
+			}
+			s.inboundStreamChan <- stream
+		})
+	}
 
 	// manage libp2p network events
 	s.host.Network().Notify((*netNotifiee)(s))
 
 	s.eventLoop(ctx)
 
-	// libp2p stream handler
-	s.host.RemoveStreamHandler(s.protocol)
+	// libp2p stream handlers
+	for _, protocolID := range s.protocols {
+		s.host.RemoveStreamHandler(protocolID)
+	}
 
 	// de-register libp2p network events
 	s.host.Network().StopNotify((*netNotifiee)(s))
@@ -531,7 +577,8 @@ func (s *Service) openStream(peerID peer.ID) (network.Stream, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.opts.streamConnectTimeout)
 	defer cancel()
 
-	stream, err := s.host.NewStream(ctx, peerID, s.protocol)
+	// the protocol IDs are ordered by preference, so the highest version supported by the peer is negotiated
+	stream, err := s.host.NewStream(ctx, peerID, s.protocols...)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create gossip stream to %s: %w", peerID, err)
 	}
@@ -549,6 +596,11 @@ func (s *Service) registerProtocol(peerID peer.ID, stream network.Stream) {
 	}
 
 	proto := NewProtocol(peerID, stream, s.opts.sendQueueSize, s.opts.streamReadTimeout, s.opts.streamWriteTimeout, s.serverMetrics)
+	proto.Version = s.protocolVersions[stream.Protocol()]
+	if s.opts.quotas != nil {
+		proto.Quotas = NewPeerQuotas(s.opts.quotas)
+	}
+	proto.Reputation = s.peeringManager.Reputation().Peer(peerID)
 	s.streams[peerID] = proto
 	s.Events.ProtocolStarted.Trigger(proto)
 }
@@ -563,6 +615,7 @@ func (s *Service) deregisterProtocol(peerID peer.ID) error {
 	defer func() {
 		delete(s.streams, peerID)
 		delete(s.unknownPeers, peerID)
+		s.peeringManager.Reputation().Release(peerID)
 		close(proto.terminatedChan)
 		s.Events.ProtocolTerminated.Trigger(proto)
 	}()
@@ -725,7 +778,7 @@ func (m *netNotifiee) Connected(net network.Network, conn network.Conn)
 func (m *netNotifiee) Disconnected(net network.Network, conn network.Conn)            {}
 func (m *netNotifiee) OpenedStream(net network.Network, stream network.Stream)        {}
 func (m *netNotifiee) ClosedStream(net network.Network, stream network.Stream) {
-	if stream.Protocol() != m.protocol {
+	if _, isGossipStream := m.protocolVersions[stream.Protocol()]; !isGossipStream {
 		return
 	}
 	if m.stopped.IsSet() {
@@ -3,6 +3,7 @@ package gossip
 import (
 	"bytes"
 	"encoding/binary"
+	"math"
 
 	"github.com/pkg/errors"
 
@@ -22,6 +23,14 @@ const (
 	MessageTypeBlock            message.Type = 2
 	MessageTypeBlockRequest     message.Type = 3
 	MessageTypeHeartbeat        message.Type = 4
+	// MessageTypeBlockRequestBatch is only supported by peers speaking protocol.GossipVersionBatched.
+	MessageTypeBlockRequestBatch message.Type = 5
+	// MessageTypeBlockBatch is only supported by peers speaking protocol.GossipVersionBatched.
+	MessageTypeBlockBatch message.Type = 6
+	// MessageTypeMilestoneConeRequest is only supported by peers speaking protocol.GossipVersionMilestoneCones.
+	MessageTypeMilestoneConeRequest message.Type = 7
+	// MessageTypeMilestoneCone is only supported by peers speaking protocol.GossipVersionMilestoneCones.
+	MessageTypeMilestoneCone message.Type = 8
 )
 
 const (
@@ -36,6 +45,28 @@ const (
 
 	// latestMilestoneRequestIndex defines the index to use to request the latest milestone via a milestone request message.
 	latestMilestoneRequestIndex = 0
+
+	// MaxBlockRequestBatchSize defines the maximum amount of block IDs within a block request batch message.
+	MaxBlockRequestBatchSize = 64
+
+	// blockBatchCountBytesLength defines the amount of bytes used for the amount of blocks within a block batch message.
+	blockBatchCountBytesLength = 1
+
+	// blockBatchBlockLengthBytesLength defines the amount of bytes used for the length of a block within a block batch message.
+	blockBatchBlockLengthBytesLength = 2
+
+	// blockBatchMsgMaxBytesLength defines the maximum amount of bytes of a block batch message.
+	blockBatchMsgMaxBytesLength = math.MaxUint16
+
+	// MaxMilestoneConeRequestRange defines the maximum amount of milestones which cones can be requested with a single milestone cone request.
+	MaxMilestoneConeRequestRange = 10
+
+	// milestoneConeHeaderBytesLength defines the amount of bytes used for the milestone index, the chunk index
+	// and the last chunk flag within a milestone cone message.
+	milestoneConeHeaderBytesLength = 4 + 2 + 1
+
+	// maxMilestoneConeChunks defines the maximum amount of chunks a milestone cone is split into.
+	maxMilestoneConeChunks = 1024
 )
 
 var (
@@ -68,6 +99,39 @@ var (
 		MaxBytesLength: requestedMilestoneIndexMsgBytesLength,
 		VariableLength: false,
 	}
+
+	// blockRequestBatchMessageDefinition defines the requested block IDs gossipping packet.
+	// Contains the concatenated IDs of up to MaxBlockRequestBatchSize requested blocks.
+	blockRequestBatchMessageDefinition = &message.Definition{
+		ID:             MessageTypeBlockRequestBatch,
+		MaxBytesLength: requestedBlockIDMsgBytesLength * MaxBlockRequestBatchSize,
+		VariableLength: true,
+	}
+
+	// blockBatchMessageDefinition defines a block batch message's format.
+	// Contains the amount of blocks, followed by the length and the data of every block.
+	blockBatchMessageDefinition = &message.Definition{
+		ID:             MessageTypeBlockBatch,
+		MaxBytesLength: blockBatchMsgMaxBytesLength,
+		VariableLength: true,
+	}
+
+	// milestoneConeRequestMessageDefinition defines the requested milestone range packet.
+	// Contains the milestone index after which the requested range starts and the last milestone index of the range.
+	milestoneConeRequestMessageDefinition = &message.Definition{
+		ID:             MessageTypeMilestoneConeRequest,
+		MaxBytesLength: requestedMilestoneIndexMsgBytesLength * 2,
+		VariableLength: false,
+	}
+
+	// milestoneConeMessageDefinition defines a milestone cone message's format.
+	// Contains the milestone index, the index of the chunk, whether it is the last chunk of the cone
+	// and the blocks of the chunk in the same format as a block batch.
+	milestoneConeMessageDefinition = &message.Definition{
+		ID:             MessageTypeMilestoneCone,
+		MaxBytesLength: blockBatchMsgMaxBytesLength,
+		VariableLength: true,
+	}
 )
 
 // newBlockMessage creates a new block message.
@@ -100,6 +164,189 @@ func newBlockRequestMessage(requestedBlockID iotago.BlockID) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// newBlockRequestBatchMessages creates block request batch messages for the given block IDs.
+// The block IDs are split into multiple messages if they exceed MaxBlockRequestBatchSize.
+func newBlockRequestBatchMessages(requestedBlockIDs iotago.BlockIDs) ([][]byte, error) {
+	var messages [][]byte
+	for len(requestedBlockIDs) > 0 {
+		batchSize := len(requestedBlockIDs)
+		if batchSize > MaxBlockRequestBatchSize {
+			batchSize = MaxBlockRequestBatchSize
+		}
+
+		msgBytesLength := uint16(batchSize * requestedBlockIDMsgBytesLength)
+		buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+msgBytesLength))
+		if err := tlv.WriteHeader(buf, MessageTypeBlockRequestBatch, msgBytesLength); err != nil {
+			return nil, err
+		}
+
+		for _, requestedBlockID := range requestedBlockIDs[:batchSize] {
+			if err := binary.Write(buf, binary.LittleEndian, requestedBlockID[:requestedBlockIDMsgBytesLength]); err != nil {
+				return nil, err
+			}
+		}
+
+		messages = append(messages, buf.Bytes())
+		requestedBlockIDs = requestedBlockIDs[batchSize:]
+	}
+
+	return messages, nil
+}
+
+// splits the given blocks into batches which fit into a single message,
+// considering the given amount of bytes needed for the message specific header.
+func batchBlocks(blocksData [][]byte, headerBytesLength int) ([][][]byte, error) {
+	var batches [][][]byte
+	var batch [][]byte
+	msgBytesLength := headerBytesLength + blockBatchCountBytesLength
+
+	for _, blockData := range blocksData {
+		if len(blockData) == 0 || len(blockData) > iotago.BlockBinSerializedMaxSize {
+			return nil, errors.Wrapf(ErrInvalidSourceLength, "invalid block size: %d", len(blockData))
+		}
+
+		entryBytesLength := blockBatchBlockLengthBytesLength + len(blockData)
+		if len(batch) == math.MaxUint8 || msgBytesLength+entryBytesLength > blockBatchMsgMaxBytesLength {
+			batches = append(batches, batch)
+			batch = nil
+			msgBytesLength = headerBytesLength + blockBatchCountBytesLength
+		}
+
+		batch = append(batch, blockData)
+		msgBytesLength += entryBytesLength
+	}
+
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+
+	return batches, nil
+}
+
+// returns the amount of bytes needed to write the given blocks with writeBlockEntries.
+func blockEntriesBytesLength(blocksData [][]byte) int {
+	bytesLength := blockBatchCountBytesLength
+	for _, blockData := range blocksData {
+		bytesLength += blockBatchBlockLengthBytesLength + len(blockData)
+	}
+	return bytesLength
+}
+
+// writes the amount of blocks, followed by the length and the data of every block.
+func writeBlockEntries(buf *bytes.Buffer, blocksData [][]byte) error {
+	if err := binary.Write(buf, binary.LittleEndian, uint8(len(blocksData))); err != nil {
+		return err
+	}
+
+	for _, blockData := range blocksData {
+		if err := binary.Write(buf, binary.LittleEndian, uint16(len(blockData))); err != nil {
+			return err
+		}
+
+		if err := binary.Write(buf, binary.LittleEndian, blockData); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newBlockBatchMessages creates block batch messages for the given blocks.
+// The blocks are split into multiple messages if they exceed the maximum size of a block batch message.
+func newBlockBatchMessages(blocksData [][]byte) ([][]byte, error) {
+	batches, err := batchBlocks(blocksData, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([][]byte, 0, len(batches))
+	for _, batch := range batches {
+		msgBytesLength := blockEntriesBytesLength(batch)
+		buf := bytes.NewBuffer(make([]byte, 0, int(tlv.HeaderMessageDefinition.MaxBytesLength)+msgBytesLength))
+		if err := tlv.WriteHeader(buf, MessageTypeBlockBatch, uint16(msgBytesLength)); err != nil {
+			return nil, err
+		}
+
+		if err := writeBlockEntries(buf, batch); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, buf.Bytes())
+	}
+
+	return messages, nil
+}
+
+// newMilestoneConeRequestMessage creates a milestone cone request message.
+func newMilestoneConeRequestMessage(startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+milestoneConeRequestMessageDefinition.MaxBytesLength))
+	if err := tlv.WriteHeader(buf, MessageTypeMilestoneConeRequest, milestoneConeRequestMessageDefinition.MaxBytesLength); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, startIndex); err != nil {
+		return nil, err
+	}
+
+	if err := binary.Write(buf, binary.LittleEndian, endIndex); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// newMilestoneConeMessages creates the milestone cone messages for the given blocks of the past cone of a milestone.
+// The blocks are split into multiple chunks if they exceed the maximum size of a milestone cone message.
+// The last chunk is flagged, so that the receiver knows when the cone is complete.
+func newMilestoneConeMessages(msIndex iotago.MilestoneIndex, blocksData [][]byte) ([][]byte, error) {
+	chunks, err := batchBlocks(blocksData, milestoneConeHeaderBytesLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chunks) == 0 {
+		// the cone is empty, but the receiver still needs to know that
+		chunks = [][][]byte{nil}
+	}
+
+	if len(chunks) > maxMilestoneConeChunks {
+		return nil, errors.Wrapf(ErrInvalidSourceLength, "milestone cone exceeds the maximum amount of chunks: %d", len(chunks))
+	}
+
+	messages := make([][]byte, 0, len(chunks))
+	for chunkIndex, chunk := range chunks {
+		msgBytesLength := milestoneConeHeaderBytesLength + blockEntriesBytesLength(chunk)
+		buf := bytes.NewBuffer(make([]byte, 0, int(tlv.HeaderMessageDefinition.MaxBytesLength)+msgBytesLength))
+		if err := tlv.WriteHeader(buf, MessageTypeMilestoneCone, uint16(msgBytesLength)); err != nil {
+			return nil, err
+		}
+
+		if err := binary.Write(buf, binary.LittleEndian, msIndex); err != nil {
+			return nil, err
+		}
+
+		if err := binary.Write(buf, binary.LittleEndian, uint16(chunkIndex)); err != nil {
+			return nil, err
+		}
+
+		var lastChunk uint8
+		if chunkIndex == len(chunks)-1 {
+			lastChunk = 1
+		}
+		if err := binary.Write(buf, binary.LittleEndian, lastChunk); err != nil {
+			return nil, err
+		}
+
+		if err := writeBlockEntries(buf, chunk); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, buf.Bytes())
+	}
+
+	return messages, nil
+}
+
 // newHeartbeatMessage creates a new heartbeat message.
 func newHeartbeatMessage(solidMilestoneIndex iotago.MilestoneIndex, prunedMilestoneIndex iotago.MilestoneIndex, latestMilestoneIndex iotago.MilestoneIndex, connectedPeers uint8, syncedPeers uint8) ([]byte, error) {
 	buf := bytes.NewBuffer(make([]byte, 0, tlv.HeaderMessageDefinition.MaxBytesLength+heartbeatMessageDefinition.MaxBytesLength))
@@ -152,6 +399,122 @@ func extractRequestedMilestoneIndex(source []byte) (iotago.MilestoneIndex, error
 	return binary.LittleEndian.Uint32(source), nil
 }
 
+// extractRequestedBlockIDs extracts the requested block IDs from the given block request batch source.
+func extractRequestedBlockIDs(source []byte) (iotago.BlockIDs, error) {
+	if len(source) == 0 || len(source)%requestedBlockIDMsgBytesLength != 0 || len(source) > int(blockRequestBatchMessageDefinition.MaxBytesLength) {
+		return nil, ErrInvalidSourceLength
+	}
+
+	blockIDs := make(iotago.BlockIDs, len(source)/requestedBlockIDMsgBytesLength)
+	for i := range blockIDs {
+		copy(blockIDs[i][:], source[i*requestedBlockIDMsgBytesLength:])
+	}
+
+	return blockIDs, nil
+}
+
+// extracts the data of the blocks written with writeBlockEntries from the given source.
+func extractBlockEntries(source []byte) ([][]byte, error) {
+	if len(source) < blockBatchCountBytesLength {
+		return nil, ErrInvalidSourceLength
+	}
+
+	blocksCount := int(source[0])
+	blocksData := make([][]byte, 0, blocksCount)
+
+	offset := blockBatchCountBytesLength
+	for i := 0; i < blocksCount; i++ {
+		if len(source) < offset+blockBatchBlockLengthBytesLength {
+			return nil, ErrInvalidSourceLength
+		}
+		blockBytesLength := int(binary.LittleEndian.Uint16(source[offset:]))
+		offset += blockBatchBlockLengthBytesLength
+
+		if blockBytesLength == 0 || len(source) < offset+blockBytesLength {
+			return nil, ErrInvalidSourceLength
+		}
+		blocksData = append(blocksData, source[offset:offset+blockBytesLength])
+		offset += blockBytesLength
+	}
+
+	if offset != len(source) {
+		return nil, ErrInvalidSourceLength
+	}
+
+	return blocksData, nil
+}
+
+// extractBatchedBlocks extracts the data of the blocks from the given block batch source.
+func extractBatchedBlocks(source []byte) ([][]byte, error) {
+	return extractBlockEntries(source)
+}
+
+// extractRequestedMilestoneRange extracts the requested milestone range from the given milestone cone request source.
+func extractRequestedMilestoneRange(source []byte) (startIndex iotago.MilestoneIndex, endIndex iotago.MilestoneIndex, err error) {
+	if len(source) != int(milestoneConeRequestMessageDefinition.MaxBytesLength) {
+		return 0, 0, ErrInvalidSourceLength
+	}
+	return binary.LittleEndian.Uint32(source[:4]), binary.LittleEndian.Uint32(source[4:8]), nil
+}
+
+// milestoneConeChunk is a chunk of the past cone of a milestone.
+type milestoneConeChunk struct {
+	msIndex    iotago.MilestoneIndex
+	chunkIndex int
+	lastChunk  bool
+	blocksData [][]byte
+}
+
+// extractMilestoneConeChunk extracts a chunk of a milestone cone from the given milestone cone source.
+func extractMilestoneConeChunk(source []byte) (*milestoneConeChunk, error) {
+	if len(source) < milestoneConeHeaderBytesLength {
+		return nil, ErrInvalidSourceLength
+	}
+
+	blocksData, err := extractBlockEntries(source[milestoneConeHeaderBytesLength:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &milestoneConeChunk{
+		msIndex:    binary.LittleEndian.Uint32(source[:4]),
+		chunkIndex: int(binary.LittleEndian.Uint16(source[4:6])),
+		lastChunk:  source[6] == 1,
+		blocksData: blocksData,
+	}, nil
+}
+
+// MessageBatchSize returns the amount of blocks, block requests or requested milestone cones within the given message.
+// Returns 1 for messages which are not batched.
+func MessageBatchSize(msgType message.Type, data []byte) int {
+	switch msgType {
+	case MessageTypeBlockRequestBatch:
+		return len(data) / requestedBlockIDMsgBytesLength
+	case MessageTypeBlockBatch:
+		if len(data) < blockBatchCountBytesLength {
+			return 0
+		}
+		return int(data[0])
+	case MessageTypeMilestoneCone:
+		if len(data) < milestoneConeHeaderBytesLength+blockBatchCountBytesLength {
+			return 0
+		}
+		return int(data[milestoneConeHeaderBytesLength])
+	case MessageTypeMilestoneConeRequest:
+		// every requested milestone counts against the quota
+		startIndex, endIndex, err := extractRequestedMilestoneRange(data)
+		if err != nil || endIndex <= startIndex {
+			return 1
+		}
+		if endIndex-startIndex > MaxMilestoneConeRequestRange {
+			return MaxMilestoneConeRequestRange
+		}
+		return int(endIndex - startIndex)
+	default:
+		return 1
+	}
+}
+
 // Heartbeat contains information about a nodes current solid and pruned milestone index
 // and its connected and synced peers count.
 type Heartbeat struct {
@@ -240,6 +240,12 @@ func (ws *WarpSync) reset() {
 	ws.referencedBlocksTotal = 0
 }
 
+const (
+	// the time after which the cone of a milestone is requested parent by parent
+	// if the requested milestone cone was not received.
+	milestoneConeRequestTimeout = 10 * time.Second
+)
+
 // WarpSyncMilestoneRequester walks the cones of existing but non-solid milestones and memoizes already walked blocks and milestones.
 type WarpSyncMilestoneRequester struct {
 	syncutils.Mutex
@@ -254,6 +260,8 @@ type WarpSyncMilestoneRequester struct {
 	preventDiscard bool
 	// map of already traversed blocks to to prevent traversing the same cones multiple times.
 	traversed map[iotago.BlockID]struct{}
+	// map of milestones whose whole cone was requested from a peer and the time of the request.
+	conesRequested map[iotago.MilestoneIndex]time.Time
 }
 
 // NewWarpSyncMilestoneRequester creates a new WarpSyncMilestoneRequester instance.
@@ -269,16 +277,30 @@ func NewWarpSyncMilestoneRequester(
 		requester:      requester,
 		preventDiscard: preventDiscard,
 		traversed:      make(map[iotago.BlockID]struct{}),
+		conesRequested: make(map[iotago.MilestoneIndex]time.Time),
 	}
 }
 
 // RequestMissingMilestoneParents traverses the parents of a given milestone and requests each missing parent.
+// If the whole cone of the milestone was requested from a peer, the parents are only requested
+// one by one if the cone was not received in time.
 // Already requested milestones or traversed blocks will be ignored, to circumvent requesting
 // the same parents multiple times.
 func (w *WarpSyncMilestoneRequester) RequestMissingMilestoneParents(ctx context.Context, msIndex iotago.MilestoneIndex) error {
 	w.Lock()
 	defer w.Unlock()
 
+	if requestTime, coneRequested := w.conesRequested[msIndex]; coneRequested && time.Since(requestTime) < milestoneConeRequestTimeout {
+		// wait for the requested cone
+		return nil
+	}
+
+	return w.requestMissingMilestoneParentsWithoutLocking(ctx, msIndex)
+}
+
+// traverses the parents of a given milestone and requests each missing parent.
+// the lock must be held by the caller.
+func (w *WarpSyncMilestoneRequester) requestMissingMilestoneParentsWithoutLocking(ctx context.Context, msIndex iotago.MilestoneIndex) error {
 	if msIndex <= w.syncManager.ConfirmedMilestoneIndex() {
 		return nil
 	}
@@ -323,12 +345,104 @@ func (w *WarpSyncMilestoneRequester) RequestMissingMilestoneParents(ctx context.
 		false)
 }
 
+// splits the given ascending milestones into ranges of consecutive milestones,
+// which can be requested with a single milestone cone request.
+func milestoneConeRequestRanges(msIndexes []iotago.MilestoneIndex) [][]iotago.MilestoneIndex {
+	var ranges [][]iotago.MilestoneIndex
+
+	var msIndexesRange []iotago.MilestoneIndex
+	for _, msIndex := range msIndexes {
+		if len(msIndexesRange) > 0 && (msIndexesRange[len(msIndexesRange)-1]+1 != msIndex || len(msIndexesRange) == MaxMilestoneConeRequestRange) {
+			ranges = append(ranges, msIndexesRange)
+			msIndexesRange = nil
+		}
+		msIndexesRange = append(msIndexesRange, msIndex)
+	}
+
+	if len(msIndexesRange) > 0 {
+		ranges = append(ranges, msIndexesRange)
+	}
+
+	return ranges
+}
+
+// requests the cones of the given ascending milestones from peers which support milestone cone requests.
+// consecutive milestones are requested with a single request.
+// returns the milestones whose cones could not be requested.
+// the lock must be held by the caller.
+func (w *WarpSyncMilestoneRequester) requestMilestoneConesWithoutLocking(msIndexes []iotago.MilestoneIndex) []iotago.MilestoneIndex {
+	var notRequested []iotago.MilestoneIndex
+
+	for _, msIndexesRange := range milestoneConeRequestRanges(msIndexes) {
+		if !w.requester.RequestMilestoneCones(msIndexesRange[0]-1, msIndexesRange[len(msIndexesRange)-1]) {
+			notRequested = append(notRequested, msIndexesRange...)
+			continue
+		}
+
+		now := time.Now()
+		for _, msIndex := range msIndexesRange {
+			w.conesRequested[msIndex] = now
+		}
+	}
+
+	return notRequested
+}
+
+// RequestMilestoneCones requests the cones of the existing but not confirmed milestones up to the given index,
+// whose cones were not requested yet. Consecutive milestones are requested with a single request.
+// If a requested cone was not received in time, or no peer supports milestone cone requests,
+// the missing parents of the milestone are requested one by one instead.
+// It is meant to be called periodically during the synchronization.
+func (w *WarpSyncMilestoneRequester) RequestMilestoneCones(ctx context.Context, upToIndex iotago.MilestoneIndex) error {
+	w.Lock()
+	defer w.Unlock()
+
+	confirmedMilestoneIndex := w.syncManager.ConfirmedMilestoneIndex()
+	for msIndex := range w.conesRequested {
+		if msIndex <= confirmedMilestoneIndex {
+			delete(w.conesRequested, msIndex)
+		}
+	}
+
+	var msIndexesToRequest []iotago.MilestoneIndex
+	var msIndexesToTraverse []iotago.MilestoneIndex
+	for msIndex := confirmedMilestoneIndex + 1; msIndex <= upToIndex; msIndex++ {
+		if !w.storage.ContainsMilestoneIndex(msIndex) {
+			// the milestone is requested by the milestone range requests
+			continue
+		}
+
+		requestTime, coneRequested := w.conesRequested[msIndex]
+		switch {
+		case !coneRequested:
+			msIndexesToRequest = append(msIndexesToRequest, msIndex)
+		case !requestTime.IsZero() && time.Since(requestTime) >= milestoneConeRequestTimeout:
+			// the cone was not received in time
+			msIndexesToTraverse = append(msIndexesToTraverse, msIndex)
+		}
+	}
+
+	msIndexesToTraverse = append(msIndexesToTraverse, w.requestMilestoneConesWithoutLocking(msIndexesToRequest)...)
+
+	for _, msIndex := range msIndexesToTraverse {
+		// the zero time marks that the parents of the milestone were requested one by one
+		w.conesRequested[msIndex] = time.Time{}
+
+		if err := w.requestMissingMilestoneParentsWithoutLocking(ctx, msIndex); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Cleanup cleans up traversed blocks to free memory.
 func (w *WarpSyncMilestoneRequester) Cleanup() {
 	w.Lock()
 	defer w.Unlock()
 
 	w.traversed = make(map[iotago.BlockID]struct{})
+	w.conesRequested = make(map[iotago.MilestoneIndex]time.Time)
 }
 
 // RequestMilestoneRange requests up to N milestones nearest to the current confirmed milestone index.
@@ -342,6 +456,7 @@ func (w *WarpSyncMilestoneRequester) RequestMilestoneRange(ctx context.Context,
 	}
 
 	var msIndexes []iotago.MilestoneIndex
+	var existingMsIndexes []iotago.MilestoneIndex
 	for i := syncmanager.MilestoneIndexDelta(1); i <= rangeToRequest; i++ {
 		msIndexToRequest := startingPoint + i
 
@@ -353,10 +468,29 @@ func (w *WarpSyncMilestoneRequester) RequestMilestoneRange(ctx context.Context,
 		}
 
 		// milestone already exists
+		existingMsIndexes = append(existingMsIndexes, msIndexToRequest)
+	}
+
+	if len(existingMsIndexes) > 0 {
+		// request the cones of the existing milestones in ranges, the cones of the other milestones
+		// are requested by RequestMilestoneCones after the milestones were received.
+		w.Lock()
+		confirmedMilestoneIndex := w.syncManager.ConfirmedMilestoneIndex()
+		var msIndexesToRequest []iotago.MilestoneIndex
+		for _, msIndex := range existingMsIndexes {
+			if _, coneRequested := w.conesRequested[msIndex]; !coneRequested && msIndex > confirmedMilestoneIndex {
+				msIndexesToRequest = append(msIndexesToRequest, msIndex)
+			}
+		}
+		w.requestMilestoneConesWithoutLocking(msIndexesToRequest)
+		w.Unlock()
+
 		if onExistingMilestoneInRange != nil {
-			if err := onExistingMilestoneInRange(ctx, msIndexToRequest); err != nil && errors.Is(err, common.ErrOperationAborted) {
-				// do not proceed if the node was shut down
-				return 0
+			for _, msIndex := range existingMsIndexes {
+				if err := onExistingMilestoneInRange(ctx, msIndex); err != nil && errors.Is(err, common.ErrOperationAborted) {
+					// do not proceed if the node was shut down
+					return 0
+				}
 			}
 		}
 	}
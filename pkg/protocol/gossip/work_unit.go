@@ -96,7 +96,7 @@ func (wu *WorkUnit) addReceivedFrom(p *Protocol) {
 	wu.receivedFrom = append(wu.receivedFrom, p)
 }
 
-// punishes, respectively increases the invalid block metric of all peers
+// punishes, respectively increases the invalid block metric and lowers the reputation of all peers
 // which sent the given underlying block of this WorkUnit.
 // it also closes the connection to these peers.
 func (wu *WorkUnit) punish(reason error) {
@@ -104,6 +104,7 @@ func (wu *WorkUnit) punish(reason error) {
 	defer wu.receivedFromLock.Unlock()
 	for _, p := range wu.receivedFrom {
 		wu.messageProcessor.serverMetrics.InvalidBlocks.Inc()
+		p.Reputation.RecordInvalidBlock()
 
 		// drop the connection to the peer
 		_ = wu.messageProcessor.peeringManager.DisconnectPeer(p.PeerID, errors.WithMessagef(reason, "peer was punished"))
@@ -1,7 +1,19 @@
 package protocol
 
+const (
+	// GossipVersionLegacy is the gossip protocol version which requests and sends every block in a separate message.
+	GossipVersionLegacy = 1
+	// GossipVersionBatched is the gossip protocol version which adds batched block requests and responses.
+	GossipVersionBatched = 2
+	// GossipVersionMilestoneCones is the gossip protocol version which adds requests for the whole past cone of milestones.
+	GossipVersionMilestoneCones = 3
+)
+
 var (
 	SupportedVersions = Versions{2} // make sure to add the versions sorted asc
+	// SupportedGossipVersions are the gossip protocol versions offered to peers.
+	// The highest version supported by both peers is negotiated when a gossip stream is opened.
+	SupportedGossipVersions = Versions{GossipVersionLegacy, GossipVersionBatched, GossipVersionMilestoneCones} // make sure to add the versions sorted asc
 )
 
 // Versions is a slice of protocol versions.
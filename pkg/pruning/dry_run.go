@@ -0,0 +1,212 @@
+package pruning
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/dag"
+	storagepkg "github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the maximum amount of milestone cones that are traversed to estimate the amount of pruned blocks.
+	estimationSampleMilestonesCount = 10
+)
+
+// ConsumerIndex is the milestone index a consumer of the tangle history has received data up to.
+type ConsumerIndex struct {
+	// The name of the consumer.
+	Name string
+	// The index of the latest milestone the consumer received data for.
+	Index iotago.MilestoneIndex
+}
+
+// ConsumerIndexesFunc returns the indexes of the consumers that depend on the tangle history.
+type ConsumerIndexesFunc func() []*ConsumerIndex
+
+// Report describes the impact of pruning the database up to a target index.
+type Report struct {
+	// The current pruning index of the database.
+	PruningIndex iotago.MilestoneIndex
+	// The index up to which the database would be pruned.
+	TargetIndex iotago.MilestoneIndex
+	// The amount of milestones that would be pruned.
+	MilestonesCount uint32
+	// The estimated amount of blocks that would be pruned.
+	EstimatedBlocksCount uint64
+	// The estimated amount of bytes that would be freed in the tangle database.
+	EstimatedBytesSaved int64
+	// The warnings about components that would be affected by the pruning.
+	Warnings []string
+}
+
+// EstimatePruning estimates the impact of pruning the database from the given pruning index up to the given target index.
+// The amount of blocks is extrapolated from a sample of the pruned milestone cones,
+// the freed space is extrapolated from the size of the tangle database and the amount of milestones in the database.
+func EstimatePruning(ctx context.Context, dbStorage *storagepkg.Storage, tangleDatabaseSizeBytes int64, pruningIndex iotago.MilestoneIndex, targetIndex iotago.MilestoneIndex, confirmedMilestoneIndex iotago.MilestoneIndex) (*Report, error) {
+
+	report := &Report{
+		PruningIndex: pruningIndex,
+		TargetIndex:  targetIndex,
+		Warnings:     []string{},
+	}
+
+	if targetIndex <= pruningIndex {
+		return report, nil
+	}
+	report.MilestonesCount = targetIndex - pruningIndex
+
+	sampleStep := report.MilestonesCount / estimationSampleMilestonesCount
+	if sampleStep == 0 {
+		sampleStep = 1
+	}
+
+	var sampledMilestonesCount, sampledBlocksCount uint64
+	for msIndex := pruningIndex + 1; msIndex <= targetIndex; msIndex += sampleStep {
+		if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
+			return nil, err
+		}
+
+		blocksCount, err := milestoneConeBlocksCount(ctx, dbStorage, msIndex)
+		if err != nil {
+			if errors.Is(err, storagepkg.ErrMilestoneNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		sampledMilestonesCount++
+		sampledBlocksCount += blocksCount
+	}
+
+	if sampledMilestonesCount > 0 {
+		report.EstimatedBlocksCount = sampledBlocksCount * uint64(report.MilestonesCount) / sampledMilestonesCount
+	}
+
+	if confirmedMilestoneIndex > pruningIndex {
+		report.EstimatedBytesSaved = tangleDatabaseSizeBytes * int64(report.MilestonesCount) / int64(confirmedMilestoneIndex-pruningIndex)
+	}
+
+	return report, nil
+}
+
+// milestoneConeBlocksCount returns the amount of blocks that were referenced by the given milestone.
+func milestoneConeBlocksCount(ctx context.Context, dbStorage *storagepkg.Storage, msIndex iotago.MilestoneIndex) (uint64, error) {
+
+	parents, err := dbStorage.MilestoneParentsByIndex(msIndex)
+	if err != nil {
+		return 0, err
+	}
+
+	var blocksCount uint64
+	if err := dag.TraverseParents(
+		ctx,
+		dbStorage,
+		parents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storagepkg.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			referenced, referencedIndex := cachedBlockMeta.Metadata().ReferencedWithIndex()
+
+			// only the blocks referenced by that milestone are part of its cone
+			return referenced && referencedIndex == msIndex, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storagepkg.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			blocksCount++
+			return nil
+		},
+		// called on missing parents
+		func(parentBlockID iotago.BlockID) error { return nil },
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false); err != nil {
+		return 0, err
+	}
+
+	return blocksCount, nil
+}
+
+// AddConsumerIndexesFunc adds a function that returns the indexes of consumers that depend on the tangle history.
+// These consumers are reported in the pruning dry-runs.
+func (p *Manager) AddConsumerIndexesFunc(consumerIndexesFunc ConsumerIndexesFunc) {
+	p.consumersLock.Lock()
+	defer p.consumersLock.Unlock()
+
+	p.consumerIndexesFuncs = append(p.consumerIndexesFuncs, consumerIndexesFunc)
+}
+
+// dryRun reports the impact of pruning the database up to the given target index without modifying the database.
+func (p *Manager) dryRun(ctx context.Context, targetIndex iotago.MilestoneIndex) (*Report, error) {
+
+	requestedTargetIndex := targetIndex
+
+	targetIndex, snapshotInfo, err := p.checkTargetIndex(targetIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	tangleDatabaseSizeBytes, err := p.tangleDatabase.Size()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := EstimatePruning(ctx, p.storage, tangleDatabaseSizeBytes, snapshotInfo.PruningIndex(), targetIndex, p.syncManager.ConfirmedMilestoneIndex())
+	if err != nil {
+		return nil, err
+	}
+
+	if targetIndex < requestedTargetIndex {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("target index %d was limited to %d by the history needed for snapshots (snapshot index: %d)", requestedTargetIndex, targetIndex, snapshotInfo.SnapshotIndex()))
+	}
+
+	p.consumersLock.RLock()
+	defer p.consumersLock.RUnlock()
+
+	for _, consumerIndexesFunc := range p.consumerIndexesFuncs {
+		for _, consumer := range consumerIndexesFunc() {
+			if consumer.Index < targetIndex {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s received data up to milestone %d and would miss the pruned history up to milestone %d", consumer.Name, consumer.Index, targetIndex))
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// DryRunByDepth reports the impact of pruning the database up to the given depth below the confirmed milestone.
+func (p *Manager) DryRunByDepth(ctx context.Context, depth iotago.MilestoneIndex) (*Report, error) {
+
+	confirmedMilestoneIndex := p.syncManager.ConfirmedMilestoneIndex()
+
+	if confirmedMilestoneIndex <= depth {
+		// Not enough history
+		return nil, ErrNotEnoughHistory
+	}
+
+	return p.dryRun(ctx, confirmedMilestoneIndex-depth)
+}
+
+// DryRunByTargetIndex reports the impact of pruning the database up to the given target index.
+func (p *Manager) DryRunByTargetIndex(ctx context.Context, targetIndex iotago.MilestoneIndex) (*Report, error) {
+	return p.dryRun(ctx, targetIndex)
+}
+
+// DryRunBySize reports the impact of pruning the database to the given target size.
+func (p *Manager) DryRunBySize(ctx context.Context, targetSizeBytes int64) (*Report, error) {
+
+	targetIndex, err := p.calcTargetIndexBySize(targetSizeBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.dryRun(ctx, targetIndex)
+}
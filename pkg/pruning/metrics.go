@@ -4,10 +4,26 @@ import (
 	"time"
 )
 
+// PruningReason is the reason why the database was pruned.
+type PruningReason string
+
+const (
+	// PruningReasonManual is used if the pruning was triggered manually.
+	PruningReasonManual PruningReason = "manual"
+	// PruningReasonMilestones is used if the pruning was triggered by the maximum amount of milestones to keep.
+	PruningReasonMilestones PruningReason = "milestones"
+	// PruningReasonSize is used if the pruning was triggered by the target size of the database.
+	PruningReasonSize PruningReason = "size"
+	// PruningReasonAge is used if the pruning was triggered by the maximum age of the milestones to keep.
+	PruningReasonAge PruningReason = "age"
+)
+
 // PruningMetrics holds metrics about a database pruning run.
 type PruningMetrics struct {
+	Reason                               PruningReason
 	DurationPruneUnreferencedBlocks      time.Duration
 	DurationTraverseMilestoneCone        time.Duration
+	DurationExportMilestoneCone          time.Duration
 	DurationPruneMilestone               time.Duration
 	DurationPruneBlocks                  time.Duration
 	DurationSetSnapshotInfo              time.Duration
@@ -11,11 +11,13 @@ import (
 	"github.com/iotaledger/hive.go/events"
 	"github.com/iotaledger/hive.go/logger"
 	"github.com/iotaledger/hive.go/syncutils"
+	"github.com/iotaledger/hornet/v2/pkg/coldstorage"
 	"github.com/iotaledger/hornet/v2/pkg/common"
 	"github.com/iotaledger/hornet/v2/pkg/dag"
 	"github.com/iotaledger/hornet/v2/pkg/database"
 	storagepkg "github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/model/syncmanager"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -45,17 +47,22 @@ type Manager struct {
 	syncManager             *syncmanager.SyncManager
 	tangleDatabase          *database.Database
 	utxoDatabase            *database.Database
+	coldStorage             *coldstorage.ColdStorage
 	getMinimumTangleHistory getMinimumTangleHistoryFunc
 
-	additionalPruningThreshold           iotago.MilestoneIndex
 	pruningMilestonesEnabled             bool
 	pruningMilestonesMaxMilestonesToKeep iotago.MilestoneIndex
 	pruningSizeEnabled                   bool
 	pruningSizeTargetSizeBytes           int64
 	pruningSizeThresholdPercentage       float64
 	pruningSizeCooldownTime              time.Duration
+	pruningAgeEnabled                    bool
+	pruningAgeMaxAge                     time.Duration
 	pruneReceipts                        bool
 
+	consumersLock        syncutils.RWMutex
+	consumerIndexesFuncs []ConsumerIndexesFunc
+
 	snapshotLock          syncutils.Mutex
 	statusLock            syncutils.RWMutex
 	isPruning             bool
@@ -71,6 +78,7 @@ func NewPruningManager(
 	syncManager *syncmanager.SyncManager,
 	tangleDatabase *database.Database,
 	utxoDatabase *database.Database,
+	coldStorage *coldstorage.ColdStorage,
 	getMinimumTangleHistory getMinimumTangleHistoryFunc,
 	pruningMilestonesEnabled bool,
 	pruningMilestonesMaxMilestonesToKeep syncmanager.MilestoneIndexDelta,
@@ -78,6 +86,8 @@ func NewPruningManager(
 	pruningSizeTargetSizeBytes int64,
 	pruningSizeThresholdPercentage float64,
 	pruningSizeCooldownTime time.Duration,
+	pruningAgeEnabled bool,
+	pruningAgeMaxAge time.Duration,
 	pruneReceipts bool) *Manager {
 
 	return &Manager{
@@ -86,14 +96,16 @@ func NewPruningManager(
 		syncManager:                          syncManager,
 		tangleDatabase:                       tangleDatabase,
 		utxoDatabase:                         utxoDatabase,
+		coldStorage:                          coldStorage,
 		getMinimumTangleHistory:              getMinimumTangleHistory,
-		additionalPruningThreshold:           AdditionalPruningThreshold,
 		pruningMilestonesEnabled:             pruningMilestonesEnabled,
 		pruningMilestonesMaxMilestonesToKeep: pruningMilestonesMaxMilestonesToKeep,
 		pruningSizeEnabled:                   pruningSizeEnabled,
 		pruningSizeTargetSizeBytes:           pruningSizeTargetSizeBytes,
 		pruningSizeThresholdPercentage:       pruningSizeThresholdPercentage,
 		pruningSizeCooldownTime:              pruningSizeCooldownTime,
+		pruningAgeEnabled:                    pruningAgeEnabled,
+		pruningAgeMaxAge:                     pruningAgeMaxAge,
 		pruneReceipts:                        pruneReceipts,
 		Events: &Events{
 			PruningMilestoneIndexChanged: events.NewEvent(storagepkg.MilestoneIndexCaller),
@@ -169,6 +181,89 @@ func (p *Manager) calcTargetIndexBySize(targetSizeBytes ...int64) (iotago.Milest
 	return p.syncManager.ConfirmedMilestoneIndex() - milestoneDiff, nil
 }
 
+// calcTargetIndexByAge returns the index of the latest milestone that is older than the maximum age.
+func (p *Manager) calcTargetIndexByAge() (iotago.MilestoneIndex, error) {
+
+	if !p.pruningAgeEnabled || p.pruningAgeMaxAge <= 0 {
+		// pruning by age deactivated
+		return 0, ErrNoPruningNeeded
+	}
+
+	snapshotInfo := p.storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return 0, common.ErrSnapshotInfoNotFound
+	}
+
+	targetIndex, err := p.storage.LatestMilestoneIndexBeforeTimestamp(time.Now().Add(-p.pruningAgeMaxAge), snapshotInfo.PruningIndex()+1, p.syncManager.ConfirmedMilestoneIndex())
+	if err != nil {
+		return 0, err
+	}
+
+	if targetIndex == 0 {
+		return 0, ErrNoPruningNeeded
+	}
+
+	return targetIndex, nil
+}
+
+// retentionConstraint is the target index of an enabled pruning mode that defines how much history is retained.
+type retentionConstraint struct {
+	// keepAll is set if the mode doesn't allow to prune any milestone yet.
+	keepAll     bool
+	targetIndex iotago.MilestoneIndex
+	reason      PruningReason
+}
+
+// milestonesRetentionConstraint returns the constraint of the milestones mode for the given confirmed milestone.
+func (p *Manager) milestonesRetentionConstraint(confirmedMilestoneIndex iotago.MilestoneIndex) retentionConstraint {
+	if confirmedMilestoneIndex <= p.pruningMilestonesMaxMilestonesToKeep {
+		// not enough milestones to prune any of them
+		return retentionConstraint{keepAll: true}
+	}
+
+	return retentionConstraint{
+		targetIndex: confirmedMilestoneIndex - p.pruningMilestonesMaxMilestonesToKeep,
+		reason:      PruningReasonMilestones,
+	}
+}
+
+// ageRetentionConstraint returns the constraint of the age mode.
+func (p *Manager) ageRetentionConstraint() retentionConstraint {
+	targetIndex, err := p.calcTargetIndexByAge()
+	if err != nil {
+		if !errors.Is(err, ErrNoPruningNeeded) {
+			// the history is retained if it is unknown which milestones are older than the maximum age
+			p.LogWarnf("calculating pruning target index by age failed: %s", err)
+		}
+		return retentionConstraint{keepAll: true}
+	}
+
+	return retentionConstraint{
+		targetIndex: targetIndex,
+		reason:      PruningReasonAge,
+	}
+}
+
+// combineRetentionConstraints returns the most conservative target index of the given constraints of the enabled modes.
+// Returns 0 if no mode is enabled or any of them doesn't allow to prune yet.
+func combineRetentionConstraints(constraints []retentionConstraint) (iotago.MilestoneIndex, PruningReason) {
+	var targetIndex iotago.MilestoneIndex
+	var reason PruningReason
+
+	for _, constraint := range constraints {
+		if constraint.keepAll {
+			return 0, ""
+		}
+
+		if targetIndex == 0 || constraint.targetIndex < targetIndex {
+			targetIndex = constraint.targetIndex
+			reason = constraint.reason
+		}
+	}
+
+	return targetIndex, reason
+}
+
 // pruneUnreferencedBlocks prunes all unreferenced blocks from the database for the given milestone
 func (p *Manager) pruneUnreferencedBlocks(targetIndex iotago.MilestoneIndex) (blocksCountDeleted int, blocksCountChecked int) {
 
@@ -241,46 +336,67 @@ func (p *Manager) pruneBlocks(blockIDsToDeleteMap map[iotago.BlockID]struct{}) i
 	return len(blockIDsToDeleteMap)
 }
 
-func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
-
-	if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
-		// do not prune the database if the node was shut down
-		return 0, err
-	}
+// CheckTargetIndex checks whether the database of the given storage can be pruned up to the given target index.
+// The target index is limited by the given maximum target index, which keeps the tangle history that is needed for the snapshots.
+// Returns the resulting target index and the snapshot info of the storage.
+func CheckTargetIndex(dbStorage *storagepkg.Storage, targetIndex iotago.MilestoneIndex, targetIndexMax iotago.MilestoneIndex) (iotago.MilestoneIndex, *storagepkg.SnapshotInfo, error) {
 
-	if p.tangleDatabase.CompactionRunning() || p.utxoDatabase.CompactionRunning() {
-		return 0, ErrDatabaseCompactionRunning
+	if dbStorage.UTXOManager().ArchiveEnabled() {
+		// the history of the database is never pruned in archive mode
+		return 0, nil, utxo.ErrArchivePruningNotAllowed
 	}
 
-	targetIndexMax := p.getMinimumTangleHistory()
-
-	snapshotInfo := p.storage.SnapshotInfo()
+	snapshotInfo := dbStorage.SnapshotInfo()
 	if snapshotInfo == nil {
-		return 0, errors.Wrap(common.ErrCritical, common.ErrSnapshotInfoNotFound.Error())
+		return 0, nil, errors.Wrap(common.ErrCritical, common.ErrSnapshotInfoNotFound.Error())
 	}
 
 	//lint:ignore SA5011 nil pointer is already checked before with a panic
-	//if snapshotInfo.SnapshotIndex() < p.solidEntryPointCheckThresholdPast+p.additionalPruningThreshold+1 {
-	if snapshotInfo.SnapshotIndex() < p.additionalPruningThreshold+1 {
+	//if snapshotInfo.SnapshotIndex() < p.solidEntryPointCheckThresholdPast+AdditionalPruningThreshold+1 {
+	if snapshotInfo.SnapshotIndex() < AdditionalPruningThreshold+1 {
 		// Not enough history
-		//return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.solidEntryPointCheckThresholdPast+p.additionalPruningThreshold+1, targetIndex)
-		return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.additionalPruningThreshold+1, targetIndex)
+		//return 0, nil, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", p.solidEntryPointCheckThresholdPast+AdditionalPruningThreshold+1, targetIndex)
+		return 0, nil, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", AdditionalPruningThreshold+1, targetIndex)
 	}
 
 	// TODO
-	//targetIndexMax := snapshotInfo.SnapshotIndex() - p.solidEntryPointCheckThresholdPast - p.additionalPruningThreshold - 1
+	//targetIndexMax := snapshotInfo.SnapshotIndex() - p.solidEntryPointCheckThresholdPast - AdditionalPruningThreshold - 1
 	if targetIndex > targetIndexMax {
 		targetIndex = targetIndexMax
 	}
 
 	if snapshotInfo.PruningIndex() >= targetIndex {
 		// no pruning needed
-		return 0, errors.Wrapf(ErrNoPruningNeeded, "pruning index: %d, target index: %d", snapshotInfo.PruningIndex(), targetIndex)
+		return 0, nil, errors.Wrapf(ErrNoPruningNeeded, "pruning index: %d, target index: %d", snapshotInfo.PruningIndex(), targetIndex)
 	}
 
-	if snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1 > targetIndex {
+	if snapshotInfo.EntryPointIndex()+AdditionalPruningThreshold+1 > targetIndex {
 		// we prune in "additionalPruningThreshold" steps to recalculate the solidEntryPoints
-		return 0, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", snapshotInfo.EntryPointIndex()+p.additionalPruningThreshold+1, targetIndex)
+		return 0, nil, errors.Wrapf(ErrNotEnoughHistory, "minimum index: %d, target index: %d", snapshotInfo.EntryPointIndex()+AdditionalPruningThreshold+1, targetIndex)
+	}
+
+	return targetIndex, snapshotInfo, nil
+}
+
+// checkTargetIndex checks whether the database can be pruned up to the given target index.
+func (p *Manager) checkTargetIndex(targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, *storagepkg.SnapshotInfo, error) {
+	return CheckTargetIndex(p.storage, targetIndex, p.getMinimumTangleHistory())
+}
+
+func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex, reason PruningReason) (iotago.MilestoneIndex, error) {
+
+	if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
+		// do not prune the database if the node was shut down
+		return 0, err
+	}
+
+	if p.tangleDatabase.CompactionRunning() || p.utxoDatabase.CompactionRunning() {
+		return 0, ErrDatabaseCompactionRunning
+	}
+
+	targetIndex, snapshotInfo, err := p.checkTargetIndex(targetIndex)
+	if err != nil {
+		return 0, err
 	}
 
 	p.setIsPruning(true)
@@ -288,7 +404,7 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 
 	// calculate solid entry points for the new end of the tangle history
 	var solidEntryPoints []*storagepkg.SolidEntryPoint
-	err := dag.ForEachSolidEntryPoint(
+	err = dag.ForEachSolidEntryPoint(
 		ctx,
 		p.storage,
 		targetIndex,
@@ -379,6 +495,20 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 		}
 		timeTraverseMilestoneCone := time.Now()
 
+		if p.coldStorage != nil {
+			blockIDs := make(iotago.BlockIDs, 0, len(blockIDsToDeleteMap))
+			for blockID := range blockIDsToDeleteMap {
+				blockIDs = append(blockIDs, blockID)
+			}
+
+			// the milestone cone is never deleted without being exported to the cold storage first
+			if err := p.coldStorage.ExportMilestoneCone(p.storage, milestoneIndex, blockIDs); err != nil {
+				cachedMilestone.Release(true) // milestone -1
+				return 0, errors.Wrapf(err, "exporting milestone cone (%d) to cold storage failed", milestoneIndex)
+			}
+		}
+		timeExportMilestoneCone := time.Now()
+
 		// check whether milestone contained receipt and delete it accordingly
 		var migratedAtIndex []iotago.MilestoneIndex
 
@@ -405,15 +535,17 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 		}
 		timeSetSnapshotInfo := time.Now()
 
-		p.LogInfof("Pruning milestone (%d) took %v. Pruned %d/%d blocks. ", milestoneIndex, time.Since(timeStart).Truncate(time.Millisecond), blocksCountDeleted, blocksCountChecked)
+		p.LogInfof("Pruning milestone (%d) took %v. Pruned %d/%d blocks. Reason: %s", milestoneIndex, time.Since(timeStart).Truncate(time.Millisecond), blocksCountDeleted, blocksCountChecked, reason)
 
 		p.Events.PruningMilestoneIndexChanged.Trigger(milestoneIndex)
 		timePruningMilestoneIndexChanged := time.Now()
 
 		p.Events.PruningMetricsUpdated.Trigger(&PruningMetrics{
+			Reason:                               reason,
 			DurationPruneUnreferencedBlocks:      timePruneUnreferencedBlocks.Sub(timeStart),
 			DurationTraverseMilestoneCone:        timeTraverseMilestoneCone.Sub(timePruneUnreferencedBlocks),
-			DurationPruneMilestone:               timePruneMilestone.Sub(timeTraverseMilestoneCone),
+			DurationExportMilestoneCone:          timeExportMilestoneCone.Sub(timeTraverseMilestoneCone),
+			DurationPruneMilestone:               timePruneMilestone.Sub(timeExportMilestoneCone),
 			DurationPruneBlocks:                  timePruneBlocks.Sub(timePruneMilestone),
 			DurationSetSnapshotInfo:              timeSetSnapshotInfo.Sub(timePruneBlocks),
 			DurationPruningMilestoneIndexChanged: timePruningMilestoneIndexChanged.Sub(timeSetSnapshotInfo),
@@ -436,9 +568,23 @@ func (p *Manager) pruneDatabase(ctx context.Context, targetIndex iotago.Mileston
 		return 0, err
 	}
 
+	// the disk space of the deleted entries has to be reclaimed before the pruning finished,
+	// otherwise the next pruning by size would calculate its target index based on the outdated size.
+	p.runGarbageCollection()
+
 	return targetIndex, nil
 }
 
+// runGarbageCollection reclaims the disk space of the deleted entries in the databases.
+func (p *Manager) runGarbageCollection() {
+	if err := p.tangleDatabase.RunGarbageCollection(); err != nil {
+		p.LogWarnf("garbage collection of the tangle database failed: %s", err)
+	}
+	if err := p.utxoDatabase.RunGarbageCollection(); err != nil {
+		p.LogWarnf("garbage collection of the utxo database failed: %s", err)
+	}
+}
+
 func (p *Manager) PruneDatabaseByDepth(ctx context.Context, depth iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
 	p.snapshotLock.Lock()
 	defer p.snapshotLock.Unlock()
@@ -450,14 +596,14 @@ func (p *Manager) PruneDatabaseByDepth(ctx context.Context, depth iotago.Milesto
 		return 0, ErrNotEnoughHistory
 	}
 
-	return p.pruneDatabase(ctx, confirmedMilestoneIndex-depth)
+	return p.pruneDatabase(ctx, confirmedMilestoneIndex-depth, PruningReasonManual)
 }
 
 func (p *Manager) PruneDatabaseByTargetIndex(ctx context.Context, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
 	p.snapshotLock.Lock()
 	defer p.snapshotLock.Unlock()
 
-	return p.pruneDatabase(ctx, targetIndex)
+	return p.pruneDatabase(ctx, targetIndex, PruningReasonManual)
 }
 
 func (p *Manager) PruneDatabaseBySize(ctx context.Context, targetSizeBytes int64) (iotago.MilestoneIndex, error) {
@@ -469,7 +615,7 @@ func (p *Manager) PruneDatabaseBySize(ctx context.Context, targetSizeBytes int64
 		return 0, err
 	}
 
-	return p.pruneDatabase(ctx, targetIndex)
+	return p.pruneDatabase(ctx, targetIndex, PruningReasonManual)
 }
 
 // HandleNewConfirmedMilestoneEvent handles new confirmed milestone events which may trigger a snapshot creation.
@@ -480,17 +626,23 @@ func (p *Manager) HandleNewConfirmedMilestoneEvent(ctx context.Context, confirme
 		return
 	}
 
-	var targetIndex iotago.MilestoneIndex = 0
-	if p.pruningMilestonesEnabled && confirmedMilestoneIndex > p.pruningMilestonesMaxMilestonesToKeep {
-		targetIndex = confirmedMilestoneIndex - p.pruningMilestonesMaxMilestonesToKeep
+	// the milestones and the age mode define how much history is retained,
+	// the most conservative target index of the enabled modes is used.
+	// the size mode acts as a cap for the disk usage and overrides the retention modes if it needs to prune more.
+	var constraints []retentionConstraint
+	if p.pruningMilestonesEnabled {
+		constraints = append(constraints, p.milestonesRetentionConstraint(confirmedMilestoneIndex))
+	}
+	if p.pruningAgeEnabled && p.pruningAgeMaxAge > 0 {
+		constraints = append(constraints, p.ageRetentionConstraint())
 	}
+	targetIndex, reason := combineRetentionConstraints(constraints)
 
-	pruningBySize := false
 	if p.pruningSizeEnabled && (p.lastPruningBySizeTime.IsZero() || time.Since(p.lastPruningBySizeTime) > p.pruningSizeCooldownTime) {
 		targetIndexSize, err := p.calcTargetIndexBySize()
-		if err == nil && ((targetIndex == 0) || (targetIndex < targetIndexSize)) {
+		if err == nil && (targetIndex == 0 || targetIndex < targetIndexSize) {
 			targetIndex = targetIndexSize
-			pruningBySize = true
+			reason = PruningReasonSize
 		}
 	}
 
@@ -499,11 +651,11 @@ func (p *Manager) HandleNewConfirmedMilestoneEvent(ctx context.Context, confirme
 		return
 	}
 
-	if _, err := p.pruneDatabase(ctx, targetIndex); err != nil {
+	if _, err := p.pruneDatabase(ctx, targetIndex, reason); err != nil {
 		p.LogDebugf("pruning aborted: %v", err)
 	}
 
-	if pruningBySize {
+	if reason == PruningReasonSize {
 		p.lastPruningBySizeTime = time.Now()
 	}
 }
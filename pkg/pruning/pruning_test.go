@@ -0,0 +1,47 @@
+package pruning
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineRetentionConstraints(t *testing.T) {
+	p := &Manager{
+		pruningMilestonesEnabled:             true,
+		pruningMilestonesMaxMilestonesToKeep: 100,
+	}
+	ageConstraint := retentionConstraint{targetIndex: 40, reason: PruningReasonAge}
+
+	// the milestones mode keeps the whole history below its threshold, even if the age mode allows to prune
+	targetIndex, reason := combineRetentionConstraints([]retentionConstraint{p.milestonesRetentionConstraint(50), ageConstraint})
+	require.Zero(t, targetIndex)
+	require.Empty(t, reason)
+
+	targetIndex, reason = combineRetentionConstraints([]retentionConstraint{p.milestonesRetentionConstraint(100), ageConstraint})
+	require.Zero(t, targetIndex)
+	require.Empty(t, reason)
+
+	// the age mode keeps the whole history if no milestone is older than the maximum age
+	targetIndex, reason = combineRetentionConstraints([]retentionConstraint{p.milestonesRetentionConstraint(200), {keepAll: true}})
+	require.Zero(t, targetIndex)
+	require.Empty(t, reason)
+
+	// the most conservative target index of the active modes is used
+	targetIndex, reason = combineRetentionConstraints([]retentionConstraint{p.milestonesRetentionConstraint(120), ageConstraint})
+	require.EqualValues(t, 20, targetIndex)
+	require.Equal(t, PruningReasonMilestones, reason)
+
+	targetIndex, reason = combineRetentionConstraints([]retentionConstraint{p.milestonesRetentionConstraint(200), ageConstraint})
+	require.EqualValues(t, 40, targetIndex)
+	require.Equal(t, PruningReasonAge, reason)
+
+	// a single mode is used as is
+	targetIndex, reason = combineRetentionConstraints([]retentionConstraint{ageConstraint})
+	require.EqualValues(t, 40, targetIndex)
+	require.Equal(t, PruningReasonAge, reason)
+
+	// nothing is pruned if no retention mode is enabled
+	targetIndex, _ = combineRetentionConstraints(nil)
+	require.Zero(t, targetIndex)
+}
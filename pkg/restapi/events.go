@@ -0,0 +1,346 @@
+package restapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"go.uber.org/atomic"
+)
+
+const (
+	// MIMETextEventStream is the content type of Server-Sent Events.
+	MIMETextEventStream = "text/event-stream"
+
+	// QueryParameterTopics is used to filter for a comma separated list of topics.
+	QueryParameterTopics = "topics"
+
+	// eventStreamKeepAliveInterval is the interval in which keep alive messages are sent to idle clients.
+	eventStreamKeepAliveInterval = 30 * time.Second
+	// eventStreamWriteTimeout is the maximum duration for writing a single message to a WebSocket client.
+	eventStreamWriteTimeout = 10 * time.Second
+)
+
+var (
+	// ErrEventSubscribersLimitReached is returned if the maximum amount of subscriptions of an EventHub is reached.
+	ErrEventSubscribersLimitReached = errors.New("event subscribers limit reached")
+)
+
+var (
+	websocketUpgrader = websocket.Upgrader{
+		// the REST API allows all origins (CORS), so we do the same for the event stream.
+		CheckOrigin: func(r *http.Request) bool { return true },
+	}
+)
+
+// EventMessage is a single message of an event stream.
+type EventMessage struct {
+	// The topic of the message.
+	Topic string `json:"topic"`
+	// The JSON encoded payload of the message.
+	Data json.RawMessage `json:"data"`
+}
+
+// EventSubscription is the subscription of a single client to an EventHub.
+type EventSubscription struct {
+	hub      *EventHub
+	topics   map[string]struct{}
+	messages chan *EventMessage
+	closed   chan struct{}
+	dropped  *atomic.Bool
+
+	closeOnce sync.Once
+}
+
+// Messages returns the channel of the messages for the subscription.
+func (s *EventSubscription) Messages() <-chan *EventMessage {
+	return s.messages
+}
+
+// Closed returns a channel that is closed if the subscription was closed.
+func (s *EventSubscription) Closed() <-chan struct{} {
+	return s.closed
+}
+
+// Dropped returns whether the subscription was dropped because the client was too slow.
+func (s *EventSubscription) Dropped() bool {
+	return s.dropped.Load()
+}
+
+// Close removes the subscription from the hub.
+func (s *EventSubscription) Close() {
+	s.hub.remove(s)
+}
+
+func (s *EventSubscription) subscribed(topic string) bool {
+	if len(s.topics) == 0 {
+		return true
+	}
+	_, exists := s.topics[topic]
+
+	return exists
+}
+
+func (s *EventSubscription) close() {
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+// EventHub distributes event messages to all subscribed clients.
+// Messages are never sent blocking, clients that can't keep up are dropped instead.
+type EventHub struct {
+	sync.RWMutex
+	subscriptions  map[*EventSubscription]struct{}
+	queueSize      int
+	maxSubscribers int
+}
+
+// NewEventHub creates a new EventHub.
+// queueSize is the amount of messages that are buffered per client before the client is dropped.
+// maxSubscribers is the maximum amount of subscriptions at once (0 means no limit).
+func NewEventHub(queueSize int, maxSubscribers int) *EventHub {
+	return &EventHub{
+		subscriptions:  make(map[*EventSubscription]struct{}),
+		queueSize:      queueSize,
+		maxSubscribers: maxSubscribers,
+	}
+}
+
+// Subscribe adds a new subscription for the given topics.
+// If no topics are given, the subscription receives the messages of all topics.
+// Returns ErrEventSubscribersLimitReached if the maximum amount of subscriptions is reached.
+func (h *EventHub) Subscribe(topics ...string) (*EventSubscription, error) {
+	subscription := &EventSubscription{
+		hub:      h,
+		topics:   make(map[string]struct{}, len(topics)),
+		messages: make(chan *EventMessage, h.queueSize),
+		closed:   make(chan struct{}),
+		dropped:  atomic.NewBool(false),
+	}
+	for _, topic := range topics {
+		subscription.topics[topic] = struct{}{}
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	if h.maxSubscribers > 0 && len(h.subscriptions) >= h.maxSubscribers {
+		return nil, ErrEventSubscribersLimitReached
+	}
+
+	h.subscriptions[subscription] = struct{}{}
+
+	return subscription, nil
+}
+
+// HasSubscribers returns whether there are subscriptions for the given topic.
+// This can be used to avoid encoding messages nobody is interested in.
+func (h *EventHub) HasSubscribers(topic string) bool {
+	h.RLock()
+	defer h.RUnlock()
+
+	for subscription := range h.subscriptions {
+		if subscription.subscribed(topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SubscriberCount returns the amount of subscriptions.
+func (h *EventHub) SubscriberCount() int {
+	h.RLock()
+	defer h.RUnlock()
+
+	return len(h.subscriptions)
+}
+
+// Publish sends the message to all subscriptions of the topic.
+// Subscriptions with a full message queue are dropped.
+func (h *EventHub) Publish(topic string, data []byte) {
+	msg := &EventMessage{Topic: topic, Data: data}
+
+	var slowSubscriptions []*EventSubscription
+
+	h.RLock()
+	for subscription := range h.subscriptions {
+		if !subscription.subscribed(topic) {
+			continue
+		}
+
+		select {
+		case subscription.messages <- msg:
+		default:
+			slowSubscriptions = append(slowSubscriptions, subscription)
+		}
+	}
+	h.RUnlock()
+
+	for _, subscription := range slowSubscriptions {
+		subscription.dropped.Store(true)
+		h.remove(subscription)
+	}
+}
+
+// Clear closes all subscriptions.
+func (h *EventHub) Clear() {
+	h.Lock()
+	defer h.Unlock()
+
+	for subscription := range h.subscriptions {
+		subscription.close()
+	}
+	h.subscriptions = make(map[*EventSubscription]struct{})
+}
+
+func (h *EventHub) remove(subscription *EventSubscription) {
+	h.Lock()
+	defer h.Unlock()
+
+	delete(h.subscriptions, subscription)
+	subscription.close()
+}
+
+// ParseTopicsQueryParam parses the comma separated list of topics and checks them against the supported topics.
+func ParseTopicsQueryParam(c echo.Context, supportedTopics ...string) ([]string, error) {
+	topicsParam := strings.TrimSpace(c.QueryParam(QueryParameterTopics))
+	if topicsParam == "" {
+		return nil, nil
+	}
+
+	supported := make(map[string]struct{}, len(supportedTopics))
+	for _, topic := range supportedTopics {
+		supported[topic] = struct{}{}
+	}
+
+	var topics []string
+	for _, topic := range strings.Split(topicsParam, ",") {
+		topic = strings.TrimSpace(topic)
+		if _, exists := supported[topic]; !exists {
+			return nil, errors.WithMessagef(ErrInvalidParameter, "unknown topic: %s, supported topics: %s", topic, strings.Join(supportedTopics, ","))
+		}
+		topics = append(topics, topic)
+	}
+
+	return topics, nil
+}
+
+// StreamEvents sends the messages of the subscription to the client until the subscription is closed,
+// the client disconnects or the context is done.
+// WebSocket upgrade requests are served via WebSocket, all other requests via Server-Sent Events.
+func StreamEvents(ctx context.Context, c echo.Context, subscription *EventSubscription) error {
+	if websocket.IsWebSocketUpgrade(c.Request()) {
+		return streamEventsWebSocket(ctx, c, subscription)
+	}
+
+	return streamEventsSSE(ctx, c, subscription)
+}
+
+func streamEventsSSE(ctx context.Context, c echo.Context, subscription *EventSubscription) error {
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, MIMETextEventStream)
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	// disable response buffering of reverse proxies like nginx
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	keepAliveTicker := time.NewTicker(eventStreamKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-c.Request().Context().Done():
+			return nil
+
+		case <-subscription.Closed():
+			return nil
+
+		case <-keepAliveTicker.C:
+			if _, err := fmt.Fprint(response, ": keep-alive\n\n"); err != nil {
+				return nil
+			}
+			response.Flush()
+
+		case msg := <-subscription.Messages():
+			if _, err := fmt.Fprintf(response, "event: %s\ndata: %s\n\n", msg.Topic, msg.Data); err != nil {
+				return nil
+			}
+			response.Flush()
+		}
+	}
+}
+
+func streamEventsWebSocket(ctx context.Context, c echo.Context, subscription *EventSubscription) error {
+	conn, err := websocketUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// the upgrader already replied with an error to the client
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+
+	readerDone := make(chan struct{})
+	go func() {
+		// we need to read from the connection to process control messages (ping, pong, close).
+		// messages sent by the client are ignored.
+		defer close(readerDone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	writeMessage := func(messageType int, data []byte) error {
+		if err := conn.SetWriteDeadline(time.Now().Add(eventStreamWriteTimeout)); err != nil {
+			return err
+		}
+
+		return conn.WriteMessage(messageType, data)
+	}
+
+	keepAliveTicker := time.NewTicker(eventStreamKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			_ = writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "node is shutting down"))
+			return nil
+
+		case <-readerDone:
+			return nil
+
+		case <-subscription.Closed():
+			if subscription.Dropped() {
+				_ = writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "client too slow"))
+			}
+			return nil
+
+		case <-keepAliveTicker.C:
+			if err := writeMessage(websocket.PingMessage, nil); err != nil {
+				return nil
+			}
+
+		case msg := <-subscription.Messages():
+			data, err := json.Marshal(msg)
+			if err != nil {
+				return err
+			}
+			if err := writeMessage(websocket.TextMessage, data); err != nil {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package restapi
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventHubDropsSlowSubscriptions(t *testing.T) {
+	hub := NewEventHub(2, 0)
+
+	blocks, err := hub.Subscribe("blocks")
+	require.NoError(t, err)
+	all, err := hub.Subscribe()
+	require.NoError(t, err)
+	require.Equal(t, 2, hub.SubscriberCount())
+	require.True(t, hub.HasSubscribers("milestones"))
+
+	hub.Publish("blocks", []byte(`1`))
+	hub.Publish("milestones", []byte(`2`))
+	require.Len(t, blocks.Messages(), 1)
+	require.Len(t, all.Messages(), 2)
+
+	// the queue of the second subscription is full, so it gets dropped
+	hub.Publish("blocks", []byte(`3`))
+	require.Len(t, blocks.Messages(), 2)
+
+	select {
+	case <-all.Closed():
+	default:
+		require.FailNow(t, "slow subscription was not dropped")
+	}
+	require.True(t, all.Dropped())
+	require.False(t, blocks.Dropped())
+	require.Equal(t, 1, hub.SubscriberCount())
+	require.False(t, hub.HasSubscribers("milestones"))
+
+	blocks.Close()
+	require.Equal(t, 0, hub.SubscriberCount())
+	require.False(t, blocks.Dropped())
+}
+
+func TestEventHubSubscribersLimit(t *testing.T) {
+	hub := NewEventHub(1, 2)
+
+	first, err := hub.Subscribe()
+	require.NoError(t, err)
+	_, err = hub.Subscribe("blocks")
+	require.NoError(t, err)
+
+	// new subscriptions are rejected until a subscription is removed
+	_, err = hub.Subscribe()
+	require.ErrorIs(t, err, ErrEventSubscribersLimitReached)
+	require.Equal(t, 2, hub.SubscriberCount())
+
+	first.Close()
+	_, err = hub.Subscribe()
+	require.NoError(t, err)
+}
+
+func TestStreamEventsSSE(t *testing.T) {
+	hub := NewEventHub(10, 0)
+
+	e := echo.New()
+	e.GET("/events", func(c echo.Context) error {
+		topics, err := ParseTopicsQueryParam(c, "blocks", "milestones")
+		if err != nil {
+			require.ErrorIs(t, err, ErrInvalidParameter)
+			return c.NoContent(http.StatusBadRequest)
+		}
+
+		subscription, err := hub.Subscribe(topics...)
+		require.NoError(t, err)
+		defer subscription.Close()
+
+		return StreamEvents(context.Background(), c, subscription)
+	})
+
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	res, err := http.Get(server.URL + "/events?topics=unknown")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	require.NoError(t, res.Body.Close())
+
+	res, err = http.Get(server.URL + "/events?topics=milestones")
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	require.Equal(t, MIMETextEventStream, res.Header.Get(echo.HeaderContentType))
+
+	require.Eventually(t, func() bool { return hub.SubscriberCount() == 1 }, 5*time.Second, 10*time.Millisecond)
+	hub.Publish("blocks", []byte(`{"index":1}`))
+	hub.Publish("milestones", []byte(`{"index":2}`))
+
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "event: milestones\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, "data: {\"index\":2}\n", line)
+
+	// closing the hub ends the stream
+	hub.Clear()
+	require.Eventually(t, func() bool {
+		_, err := reader.ReadString('\n')
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
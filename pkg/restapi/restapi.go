@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/libp2p/go-libp2p-core/peer"
@@ -36,8 +37,23 @@ const (
 	// ParameterPeerID is used to identify a peer.
 	ParameterPeerID = "peerID"
 
+	// ParameterAddress is used to identify an address.
+	ParameterAddress = "address"
+
 	// QueryParameterOutputType is used to filter for a certain output type.
 	QueryParameterOutputType = "type"
+
+	// QueryParameterPageSize is used to define the page size for the results.
+	QueryParameterPageSize = "pageSize"
+
+	// QueryParameterCursor is used to pass the offset for the results.
+	QueryParameterCursor = "cursor"
+
+	// QueryParameterWaitReferenced is used to define the time in seconds a block submission waits until the block is referenced by a milestone.
+	QueryParameterWaitReferenced = "waitReferenced"
+
+	// QueryParameterPromote is used to request that a submitted block is tracked by the promoter.
+	QueryParameterPromote = "promote"
 )
 
 var (
@@ -203,3 +219,83 @@ func ParseOutputTypeQueryParam(c echo.Context) (*iotago.OutputType, error) {
 	}
 	return filteredType, nil
 }
+
+// ParseBech32AddressParam returns the address of the request path, which needs to use the given bech32 prefix.
+func ParseBech32AddressParam(c echo.Context, prefix iotago.NetworkPrefix) (iotago.Address, error) {
+	addressParam := strings.ToLower(c.Param(ParameterAddress))
+
+	hrp, bech32Address, err := iotago.ParseBech32(addressParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid address: %s, error: %s", addressParam, err)
+	}
+
+	if hrp != prefix {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid bech32 address, expected prefix: %s", prefix)
+	}
+
+	return bech32Address, nil
+}
+
+// ParsePageSizeQueryParam returns the requested page size, capped at the given maximum page size.
+func ParsePageSizeQueryParam(c echo.Context, maxPageSize int) (int, error) {
+	pageSizeParam := c.QueryParam(QueryParameterPageSize)
+	if len(pageSizeParam) == 0 {
+		return maxPageSize, nil
+	}
+
+	pageSize, err := strconv.Atoi(pageSizeParam)
+	if err != nil || pageSize < 1 {
+		return 0, errors.WithMessagef(ErrInvalidParameter, "invalid page size: %s", pageSizeParam)
+	}
+
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	return pageSize, nil
+}
+
+// ParseWaitReferencedQueryParam returns how long the request should wait for the block to be referenced by a milestone.
+func ParseWaitReferencedQueryParam(c echo.Context) (time.Duration, error) {
+	waitReferencedParam := c.QueryParam(QueryParameterWaitReferenced)
+	if len(waitReferencedParam) == 0 {
+		return 0, nil
+	}
+
+	waitReferenced, err := strconv.ParseUint(waitReferencedParam, 10, 32)
+	if err != nil {
+		return 0, errors.WithMessagef(ErrInvalidParameter, "invalid wait time: %s", waitReferencedParam)
+	}
+
+	return time.Duration(waitReferenced) * time.Second, nil
+}
+
+// ParsePromoteQueryParam returns whether the submitted block should be tracked by the promoter.
+func ParsePromoteQueryParam(c echo.Context) (bool, error) {
+	promoteParam := c.QueryParam(QueryParameterPromote)
+	if len(promoteParam) == 0 {
+		return false, nil
+	}
+
+	promote, err := strconv.ParseBool(promoteParam)
+	if err != nil {
+		return false, errors.WithMessagef(ErrInvalidParameter, "invalid promote value: %s", promoteParam)
+	}
+
+	return promote, nil
+}
+
+// ParseCursorQueryParam returns the cursor of the requested page, or nil for the first page.
+func ParseCursorQueryParam(c echo.Context) ([]byte, error) {
+	cursorParam := strings.ToLower(c.QueryParam(QueryParameterCursor))
+	if len(cursorParam) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := iotago.DecodeHex(cursorParam)
+	if err != nil {
+		return nil, errors.WithMessagef(ErrInvalidParameter, "invalid cursor: %s, error: %s", cursorParam, err)
+	}
+
+	return cursor, nil
+}
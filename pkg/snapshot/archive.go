@@ -0,0 +1,92 @@
+package snapshot
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrNoMilestoneDiffsInSnapshot is returned if a snapshot file used for backfilling contains no milestone diffs.
+	ErrNoMilestoneDiffsInSnapshot = errors.New("snapshot file contains no milestone diffs")
+)
+
+// BackfillArchiveFromSnapshotFile adds the spending transactions of the milestone diffs
+// in the given snapshot file to the archive of the storage.
+// Full snapshot files contain the milestone diffs between the target and the ledger index,
+// delta snapshot files contain the milestone diffs since the target index of the full snapshot.
+// The referenced blocks can't be backfilled, because snapshot files don't contain the tangle history.
+// Returns the range of the milestone diffs in the snapshot file and the new state of the archive.
+func BackfillArchiveFromSnapshotFile(dbStorage *storage.Storage, filePath string) (iotago.MilestoneIndex, iotago.MilestoneIndex, *utxo.ArchiveState, error) {
+
+	snapshotType, err := ReadSnapshotTypeFromFile(filePath)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	lsFile, err := os.Open(filePath)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("unable to open %s snapshot file for backfilling: %w", snapshotNames[snapshotType], err)
+	}
+	defer func() { _ = lsFile.Close() }()
+
+	var msIndexStart, msIndexEnd iotago.MilestoneIndex
+	spents := utxo.Spents{}
+
+	msDiffConsumer := func(msDiff *MilestoneDiff) error {
+		msIndex := msDiff.Milestone.Index
+		if msIndexStart == 0 || msIndex < msIndexStart {
+			msIndexStart = msIndex
+		}
+		if msIndex > msIndexEnd {
+			msIndexEnd = msIndex
+		}
+
+		spents = append(spents, msDiff.Consumed...)
+
+		return nil
+	}
+	sepConsumer := func(iotago.BlockID, iotago.MilestoneIndex) error { return nil }
+	protocolParamsMilestoneOptConsumer := func(*iotago.ProtocolParamsMilestoneOpt) error { return nil }
+
+	switch snapshotType {
+	case Full:
+		err = StreamFullSnapshotDataFrom(
+			lsFile,
+			func(*FullSnapshotHeader) error { return nil },
+			func(*utxo.TreasuryOutput) error { return nil },
+			func(utxo.Outputs) error { return nil },
+			msDiffConsumer,
+			sepConsumer,
+			protocolParamsMilestoneOptConsumer)
+	case Delta:
+		err = StreamDeltaSnapshotDataFrom(
+			lsFile,
+			newProtocolStorageGetterFunc(dbStorage),
+			func(*DeltaSnapshotHeader) error { return nil },
+			msDiffConsumer,
+			sepConsumer,
+			protocolParamsMilestoneOptConsumer)
+	default:
+		return 0, 0, nil, fmt.Errorf("unknown snapshot type: %d", snapshotType)
+	}
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("unable to read %s snapshot file: %w", snapshotNames[snapshotType], err)
+	}
+
+	if msIndexStart == 0 {
+		return 0, 0, nil, ErrNoMilestoneDiffsInSnapshot
+	}
+
+	archiveState, err := dbStorage.UTXOManager().BackfillArchiveSpents(msIndexStart, msIndexEnd, spents)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+
+	return msIndexStart, msIndexEnd, archiveState, nil
+}
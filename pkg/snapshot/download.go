@@ -1,12 +1,17 @@
 package snapshot
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -79,9 +84,52 @@ type DownloadTarget struct {
 	Full string `usage:"URL of the full snapshot file" json:"full"`
 	// URL of the delta snapshot file.
 	Delta string `usage:"URL of the delta snapshot file" json:"delta"`
+	// the expected hex encoded SHA-256 hash of the full snapshot file (optional).
+	FullSHA256 string `usage:"the expected hex encoded SHA-256 hash of the full snapshot file (optional)" json:"fullSha256,omitempty"`
+	// the expected hex encoded SHA-256 hash of the delta snapshot file (optional).
+	DeltaSHA256 string `usage:"the expected hex encoded SHA-256 hash of the delta snapshot file (optional)" json:"deltaSha256,omitempty"`
 }
 
-func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarget) []*DownloadTarget {
+// downloadTargetWithHeaders is a download target with the headers of the snapshot files it offers.
+type downloadTargetWithHeaders struct {
+	*DownloadTarget
+	fullHeader  *FullSnapshotHeader
+	deltaHeader *DeltaSnapshotHeader
+	index       iotago.MilestoneIndex
+}
+
+// offersFullSnapshotOf checks whether the target offers the same full snapshot file as the other target.
+func (t *downloadTargetWithHeaders) offersFullSnapshotOf(other *downloadTargetWithHeaders) bool {
+	if t.fullHeader.TargetMilestoneID != other.fullHeader.TargetMilestoneID || t.fullHeader.LedgerMilestoneIndex != other.fullHeader.LedgerMilestoneIndex {
+		return false
+	}
+
+	return checksumsCompatible(t.FullSHA256, other.FullSHA256)
+}
+
+// offersDeltaSnapshotOf checks whether the target offers the same delta snapshot file as the other target.
+func (t *downloadTargetWithHeaders) offersDeltaSnapshotOf(other *downloadTargetWithHeaders) bool {
+	if t.deltaHeader == nil || other.deltaHeader == nil {
+		return false
+	}
+
+	if t.deltaHeader.TargetMilestoneIndex != other.deltaHeader.TargetMilestoneIndex || t.deltaHeader.FullSnapshotTargetMilestoneID != other.deltaHeader.FullSnapshotTargetMilestoneID {
+		return false
+	}
+
+	return checksumsCompatible(t.DeltaSHA256, other.DeltaSHA256)
+}
+
+// checksumsCompatible checks whether the configured checksums of two sources of the same file do not contradict each other.
+func checksumsCompatible(checksum1 string, checksum2 string) bool {
+	if checksum1 == "" || checksum2 == "" {
+		return true
+	}
+
+	return strings.EqualFold(strings.TrimPrefix(checksum1, "0x"), strings.TrimPrefix(checksum2, "0x"))
+}
+
+func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarget) []*downloadTargetWithHeaders {
 
 	// check if the remote snapshot files fit the network ID and if delta fits the full snapshot.
 	checkTargetConsistency := func(targetNetworkID uint64, fullHeader *FullSnapshotHeader, deltaHeader *DeltaSnapshotHeader) error {
@@ -110,12 +158,7 @@ func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarg
 		return nil
 	}
 
-	type downloadTargetWithIndex struct {
-		target *DownloadTarget
-		index  iotago.MilestoneIndex
-	}
-
-	filteredTargets := []*downloadTargetWithIndex{}
+	filteredTargets := []*downloadTargetWithHeaders{}
 
 	// search the latest snapshot by scanning all target headers
 	for _, target := range targets {
@@ -166,40 +209,77 @@ func (s *Importer) filterTargets(targetNetworkID uint64, targets []*DownloadTarg
 			target.Delta = ""
 		}
 
-		filteredTargets = append(filteredTargets, &downloadTargetWithIndex{
-			target: target,
-			index:  getSnapshotFilesLedgerIndex(fullHeader, deltaHeader),
+		filteredTargets = append(filteredTargets, &downloadTargetWithHeaders{
+			DownloadTarget: target,
+			fullHeader:     fullHeader,
+			deltaHeader:    deltaHeader,
+			index:          getSnapshotFilesLedgerIndex(fullHeader, deltaHeader),
 		})
 	}
 
 	// sort by snapshot index, latest index first
-	sort.Slice(filteredTargets, func(i int, j int) bool {
+	sort.SliceStable(filteredTargets, func(i int, j int) bool {
 		return filteredTargets[i].index > filteredTargets[j].index
 	})
 
-	results := make([]*DownloadTarget, len(filteredTargets))
-	for i := 0; i < len(filteredTargets); i++ {
-		results[i] = filteredTargets[i].target
-	}
-
-	return results
+	return filteredTargets
 }
 
 // DownloadSnapshotFiles tries to download snapshots files from the given targets.
+// Targets offering the same snapshot file are used as mirrors, so a broken download is resumed from the next mirror.
 func (s *Importer) DownloadSnapshotFiles(ctx context.Context, targetNetworkID uint64, fullPath string, deltaPath string, targets []*DownloadTarget) error {
 
-	for _, target := range s.filterTargets(targetNetworkID, targets) {
+	filteredTargets := s.filterTargets(targetNetworkID, targets)
+
+	for i, target := range filteredTargets {
+
+		alreadyTried := false
+		for _, previousTarget := range filteredTargets[:i] {
+			if target.offersFullSnapshotOf(previousTarget) {
+				alreadyTried = true
+				break
+			}
+		}
+		if alreadyTried {
+			// the full snapshot file of this target was already tried with all its mirrors
+			continue
+		}
+
+		var fullURLs []string
+		var deltaURLs []string
+		fullSHA256 := target.FullSHA256
+		deltaSHA256 := target.DeltaSHA256
+		for _, mirror := range filteredTargets[i:] {
+			if mirror.offersFullSnapshotOf(target) {
+				fullURLs = append(fullURLs, mirror.Full)
+				if fullSHA256 == "" {
+					fullSHA256 = mirror.FullSHA256
+				}
+			}
+			if mirror.offersDeltaSnapshotOf(target) {
+				deltaURLs = append(deltaURLs, mirror.Delta)
+				if deltaSHA256 == "" {
+					deltaSHA256 = mirror.DeltaSHA256
+				}
+			}
+		}
 
 		s.LogInfof("downloading full snapshot file from %s", target.Full)
-		if err := s.downloadFile(ctx, fullPath, target.Full); err != nil {
+		if err := s.downloadFile(ctx, fullPath, fullURLs, fullSHA256, fullSnapshotPartValidator(target.fullHeader)); err != nil {
+			if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+				return err
+			}
 			s.LogWarn(err)
 			// as the full snapshot URL failed to download, we commence further with our targets
 			continue
 		}
 
-		if len(target.Delta) > 0 {
+		if len(deltaURLs) > 0 {
 			s.LogInfof("downloading delta snapshot file from %s", target.Delta)
-			if err := s.downloadFile(ctx, deltaPath, target.Delta); err != nil {
+			if err := s.downloadFile(ctx, deltaPath, deltaURLs, deltaSHA256, deltaSnapshotPartValidator(target.deltaHeader)); err != nil {
+				if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+					return err
+				}
 				// it is valid that no delta snapshot file is available on the target.
 				s.LogWarn(err)
 			}
@@ -233,58 +313,384 @@ func (s *Importer) downloadHeader(url string, headerConsumer func(readCloser io.
 	return headerConsumer(resp.Body)
 }
 
-// downloads a snapshot file from the given url to the specified path.
-func (s *Importer) downloadFile(ctx context.Context, path string, url string) error {
-	downloadCtx, downloadCtxCancel := context.WithTimeout(context.Background(), timeoutDownloadSnapshotFile)
+// fullSnapshotPartValidator returns a function that checks whether a partially downloaded file belongs to the given full snapshot.
+func fullSnapshotPartValidator(expected *FullSnapshotHeader) func(reader io.Reader) error {
+	return func(reader io.Reader) error {
+		header, err := ReadFullSnapshotHeader(reader)
+		if err != nil {
+			return err
+		}
+
+		if header.TargetMilestoneID != expected.TargetMilestoneID || header.LedgerMilestoneIndex != expected.LedgerMilestoneIndex {
+			return fmt.Errorf("partial download belongs to another full snapshot (target milestone ID: %s, ledger index: %d)", header.TargetMilestoneID.ToHex(), header.LedgerMilestoneIndex)
+		}
+
+		return nil
+	}
+}
+
+// deltaSnapshotPartValidator returns a function that checks whether a partially downloaded file belongs to the given delta snapshot.
+func deltaSnapshotPartValidator(expected *DeltaSnapshotHeader) func(reader io.Reader) error {
+	return func(reader io.Reader) error {
+		header, err := ReadDeltaSnapshotHeader(reader)
+		if err != nil {
+			return err
+		}
+
+		if header.TargetMilestoneIndex != expected.TargetMilestoneIndex || header.FullSnapshotTargetMilestoneID != expected.FullSnapshotTargetMilestoneID {
+			return fmt.Errorf("partial download belongs to another delta snapshot (target index: %d)", header.TargetMilestoneIndex)
+		}
+
+		return nil
+	}
+}
+
+// fileDownload holds the state of a single file download over all attempts.
+type fileDownload struct {
+	// the path of the partially downloaded file.
+	partPath string
+	// the size of the file announced by the first source, -1 if unknown.
+	size int64
+	// whether the checksum of the downloaded file is verified.
+	checksumKnown bool
+	// the source the partially downloaded file was written by, empty if unknown.
+	source string
+	// the strong ETag the source announced for the file, empty if unknown.
+	etag string
+}
+
+// canResumeFrom checks whether the partially downloaded file can be resumed from the given source.
+// Data of another source is only resumed if the checksum of the file is verified,
+// or if the source confirms via "If-Range" that it offers the file with the same ETag.
+func (d *fileDownload) canResumeFrom(url string) bool {
+	return url == d.source || d.checksumKnown || d.etag != ""
+}
+
+// downloads a file from one of the given urls to the specified path.
+// The file is downloaded to a ".part" file first, which is resumed via HTTP range requests
+// if the download breaks or the node is restarted. If a source fails, the download continues
+// with the next source. If expectedSHA256 is given, the checksum of the downloaded file is verified.
+// Without a checksum, files of a previous run are not resumed and the data of another source
+// is only resumed if both sources announce the same size and ETag, otherwise the download restarts from zero.
+func (s *Importer) downloadFile(ctx context.Context, path string, urls []string, expectedSHA256 string, validatePart func(reader io.Reader) error) error {
+	var expectedChecksum []byte
+	if expectedSHA256 != "" {
+		checksum, err := hex.DecodeString(strings.TrimPrefix(expectedSHA256, "0x"))
+		if err != nil || len(checksum) != sha256.Size {
+			return fmt.Errorf("invalid SHA-256 checksum: %s", expectedSHA256)
+		}
+		expectedChecksum = checksum
+	}
+
+	download := &fileDownload{
+		partPath:      path + ".part",
+		size:          -1,
+		checksumKnown: expectedChecksum != nil,
+	}
+
+	if err := s.checkPartFile(download.partPath, download.checksumKnown, validatePart); err != nil {
+		return err
+	}
+
+	if err := s.downloadFileWithFailover(ctx, download, urls); err != nil {
+		return err
+	}
+
+	if expectedChecksum != nil {
+		checksum, err := fileSHA256(download.partPath)
+		if err != nil {
+			return fmt.Errorf("unable to compute checksum of downloaded snapshot file: %w", err)
+		}
+
+		if !bytes.Equal(checksum, expectedChecksum) {
+			// the data is corrupted, we need to start from scratch
+			_ = os.Remove(download.partPath)
+			return fmt.Errorf("%w: %s != %s", ErrSnapshotDownloadChecksumMismatch, hex.EncodeToString(checksum), hex.EncodeToString(expectedChecksum))
+		}
+	}
+
+	if err := os.Rename(download.partPath, path); err != nil {
+		return fmt.Errorf("unable to rename downloaded snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// checkPartFile removes an existing partially downloaded file if it doesn't belong to the file that should be downloaded.
+// The source of the file is unknown, so it is only kept if the checksum of the downloaded file is verified.
+func (s *Importer) checkPartFile(partPath string, checksumKnown bool, validatePart func(reader io.Reader) error) error {
+	partFile, err := os.Open(partPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("unable to open partially downloaded snapshot file: %w", err)
+	}
+
+	validationErr := validatePart(bufio.NewReader(partFile))
+	_ = partFile.Close()
+
+	if validationErr == nil && !checksumKnown {
+		validationErr = errors.New("the file can't be verified without a checksum")
+	}
+
+	if validationErr == nil {
+		s.LogInfof("resuming download of partially downloaded snapshot file %s", partPath)
+		return nil
+	}
+
+	s.LogInfof("discarding partially downloaded snapshot file %s: %s", partPath, validationErr)
+	if err := os.Remove(partPath); err != nil {
+		return fmt.Errorf("unable to remove partially downloaded snapshot file: %w", err)
+	}
+
+	return nil
+}
+
+// downloadFileWithFailover downloads the file by switching through the sources until the download is complete.
+// It gives up if the partially downloaded file didn't grow for a whole round over all sources.
+func (s *Importer) downloadFileWithFailover(ctx context.Context, download *fileDownload, urls []string) error {
+	if len(urls) == 0 {
+		return errors.New("download failed, no source given")
+	}
+
+	partSize := func() int64 {
+		fileInfo, err := os.Stat(download.partPath)
+		if err != nil {
+			return 0
+		}
+		return fileInfo.Size()
+	}
+
+	for {
+		var lastErr error
+		sizeBeforeRound := partSize()
+
+		for _, url := range urls {
+			written, err := s.downloadFileFromSource(ctx, download, url)
+			if err == nil {
+				return nil
+			}
+
+			if errors.Is(err, ErrSnapshotDownloadWasAborted) {
+				return err
+			}
+
+			lastErr = err
+			s.LogWarnf("downloading %s failed after %s: %s", url, humanize.Bytes(uint64(written)), err)
+		}
+
+		if partSize() <= sizeBeforeRound {
+			// no source made any progress
+			return lastErr
+		}
+	}
+}
+
+// downloadFileFromSource downloads the file from a single source.
+// An existing partially downloaded file is resumed if the source supports range requests.
+func (s *Importer) downloadFileFromSource(ctx context.Context, download *fileDownload, url string) (int64, error) {
+	if err := contextutils.ReturnErrIfCtxDone(ctx, ErrSnapshotDownloadWasAborted); err != nil {
+		return 0, err
+	}
+
+	downloadCtx, downloadCtxCancel := context.WithTimeout(ctx, timeoutDownloadSnapshotFile)
 	defer downloadCtxCancel()
 
+	partFile, err := os.OpenFile(download.partPath, os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = partFile.Close() }()
+
+	offset, err := partFile.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	if offset > 0 && !download.canResumeFrom(url) {
+		s.LogInfof("restarting download from %s, the partially downloaded file of %s can't be verified", url, download.source)
+		if err := partFile.Truncate(0); err != nil {
+			return 0, err
+		}
+		if offset, err = partFile.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+
 	req, err := http.NewRequestWithContext(downloadCtx, http.MethodGet, url, nil)
 	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		return 0, fmt.Errorf("download failed: %w", err)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if url != download.source && !download.checksumKnown {
+			// the source sends the whole file if it offers another version of the file
+			req.Header.Set("If-Range", download.etag)
+		}
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		if ctx.Err() != nil {
+			return 0, ErrSnapshotDownloadWasAborted
+		}
+		return 0, fmt.Errorf("download failed: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed, server returned status code %d", resp.StatusCode)
-	}
+	size := int64(-1)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		// the source sent the whole file, either because we started from scratch or because it doesn't support range requests
+		if offset > 0 {
+			if err := partFile.Truncate(0); err != nil {
+				return 0, err
+			}
+			if offset, err = partFile.Seek(0, io.SeekStart); err != nil {
+				return 0, err
+			}
+		}
+		size = resp.ContentLength
 
-	tempFileName := path + ".tmp"
-	out, err := os.Create(tempFileName)
-	if err != nil {
-		return err
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return 0, fmt.Errorf("download failed: %w", err)
+		}
+		if start != offset {
+			return 0, fmt.Errorf("download failed, server returned wrong range start (%d != %d)", start, offset)
+		}
+		if url != download.source && !download.checksumKnown && total != download.size {
+			// the source offers another version of the file, so the data can't be resumed
+			if err := partFile.Truncate(0); err != nil {
+				return 0, err
+			}
+			return 0, fmt.Errorf("download failed, file size on the source does not match the partially downloaded file (%d != %d)", total, download.size)
+		}
+		size = total
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		_, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return 0, fmt.Errorf("download failed: %w", err)
+		}
+		if total == offset && (download.size < 0 || download.size == total) {
+			// the file was already downloaded completely
+			return 0, nil
+		}
+
+		// the partially downloaded file is bigger than the file on the source
+		if err := partFile.Truncate(0); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("download failed, partially downloaded file is bigger than the file on the source (%d > %d)", offset, total)
+
+	default:
+		return 0, fmt.Errorf("download failed, server returned status code %d", resp.StatusCode)
 	}
 
-	var ok bool
-	defer func() {
-		if !ok {
-			// we don't need to check the error, maybe the file doesn't exist
-			_ = os.Remove(tempFileName)
+	if size >= 0 {
+		if download.size >= 0 && download.size != size {
+			return 0, fmt.Errorf("download failed, file size on the source does not match the file size on the first source (%d != %d)", size, download.size)
 		}
-	}()
+		download.size = size
+	}
+
+	// the data written from now on belongs to this source
+	download.source = url
+	download.etag = strongETag(resp.Header.Get("ETag"))
 
 	// create our progress reporter and pass it to be used alongside our writer
-	counter := NewWriteCounter(ctx, uint64(resp.ContentLength))
-	if _, err = io.Copy(out, io.TeeReader(resp.Body, counter)); err != nil {
-		_ = out.Close()
-		return fmt.Errorf("download failed: %w", err)
+	var expectedSize uint64
+	if download.size >= 0 {
+		expectedSize = uint64(download.size)
 	}
+	counter := NewWriteCounter(ctx, expectedSize)
+	counter.total = uint64(offset)
+	counter.last = uint64(offset)
+
+	written, err := io.Copy(partFile, io.TeeReader(resp.Body, counter))
 
 	// the progress indicator uses the same line so print a new line once it's finished downloading
 	fmt.Print("\n")
 
-	if err = out.Close(); err != nil {
-		return fmt.Errorf("unable to close downloaded snapshot file: %w", err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return written, ErrSnapshotDownloadWasAborted
+		}
+		return written, fmt.Errorf("download failed: %w", err)
+	}
+
+	if download.size >= 0 && offset+written != download.size {
+		return written, fmt.Errorf("download failed, incomplete file (%d != %d): %w", offset+written, download.size, io.ErrUnexpectedEOF)
 	}
 
-	if err = os.Rename(tempFileName, path); err != nil {
-		return fmt.Errorf("unable to rename downloaded snapshot file: %w", err)
+	if err := partFile.Close(); err != nil {
+		return written, fmt.Errorf("unable to close downloaded snapshot file: %w", err)
 	}
 
-	ok = true
-	return nil
+	return written, nil
+}
+
+// parseContentRange parses the start and the total size of a "Content-Range" header.
+// The start is -1 for unsatisfied ranges, the total size is -1 if it is unknown.
+func parseContentRange(contentRange string) (int64, int64, error) {
+	// e.g. "bytes 100-199/200" or "bytes */200"
+	if !strings.HasPrefix(contentRange, "bytes ") {
+		return 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	rangeAndTotal := strings.Split(strings.TrimPrefix(contentRange, "bytes "), "/")
+	if len(rangeAndTotal) != 2 {
+		return 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+	}
+
+	start := int64(-1)
+	if rangeAndTotal[0] != "*" {
+		startAndEnd := strings.Split(rangeAndTotal[0], "-")
+		if len(startAndEnd) != 2 {
+			return 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+		}
+
+		var err error
+		if start, err = strconv.ParseInt(startAndEnd[0], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+		}
+	}
+
+	total := int64(-1)
+	if rangeAndTotal[1] != "*" {
+		var err error
+		if total, err = strconv.ParseInt(rangeAndTotal[1], 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid content range: %s", contentRange)
+		}
+	}
+
+	return start, total, nil
+}
+
+// strongETag returns the given ETag if it is a strong validator, which is needed to resume a download via "If-Range".
+func strongETag(etag string) string {
+	if strings.HasPrefix(etag, "W/") {
+		return ""
+	}
+
+	return etag
+}
+
+// fileSHA256 computes the SHA-256 checksum of the file at the given path.
+func fileSHA256(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
 }
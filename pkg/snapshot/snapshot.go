@@ -32,15 +32,16 @@ var (
 	// ErrNoMoreSEPToProduce is returned when there are no more solid entry points to produce.
 	ErrNoMoreSEPToProduce = errors.New("no more SEP to produce")
 
-	ErrNoSnapshotSpecified           = errors.New("no snapshot file was specified in the config")
-	ErrNoSnapshotDownloadURL         = errors.New("no download URL specified for snapshot files in config")
-	ErrSnapshotDownloadWasAborted    = errors.New("snapshot download was aborted")
-	ErrSnapshotDownloadNoValidSource = errors.New("no valid source found, snapshot download not possible")
-	ErrSnapshotCreationWasAborted    = errors.New("operation was aborted")
-	ErrSnapshotCreationFailed        = errors.New("creating snapshot failed")
-	ErrTargetIndexTooNew             = errors.New("snapshot target is too new")
-	ErrTargetIndexTooOld             = errors.New("snapshot target is too old")
-	ErrNotEnoughHistory              = errors.New("not enough history")
+	ErrNoSnapshotSpecified              = errors.New("no snapshot file was specified in the config")
+	ErrNoSnapshotDownloadURL            = errors.New("no download URL specified for snapshot files in config")
+	ErrSnapshotDownloadWasAborted       = errors.New("snapshot download was aborted")
+	ErrSnapshotDownloadNoValidSource    = errors.New("no valid source found, snapshot download not possible")
+	ErrSnapshotDownloadChecksumMismatch = errors.New("checksum of downloaded snapshot file does not match")
+	ErrSnapshotCreationWasAborted       = errors.New("operation was aborted")
+	ErrSnapshotCreationFailed           = errors.New("creating snapshot failed")
+	ErrTargetIndexTooNew                = errors.New("snapshot target is too new")
+	ErrTargetIndexTooOld                = errors.New("snapshot target is too old")
+	ErrNotEnoughHistory                 = errors.New("not enough history")
 )
 
 type snapshotAvailability byte
@@ -67,6 +68,7 @@ type Manager struct {
 	solidEntryPointCheckThresholdFuture    syncmanager.MilestoneIndexDelta
 	snapshotDepth                          syncmanager.MilestoneIndexDelta
 	snapshotInterval                       syncmanager.MilestoneIndexDelta
+	formatVersion                          byte
 
 	snapshotLock         syncutils.Mutex
 	statusLock           syncutils.RWMutex
@@ -91,6 +93,7 @@ func NewSnapshotManager(
 	additionalPruningThreshold iotago.MilestoneIndex,
 	snapshotDepth syncmanager.MilestoneIndexDelta,
 	snapshotInterval iotago.MilestoneIndex,
+	compression bool,
 ) *Manager {
 
 	return &Manager{
@@ -106,6 +109,7 @@ func NewSnapshotManager(
 		solidEntryPointCheckThresholdFuture:    solidEntryPointCheckThresholdFuture,
 		snapshotDepth:                          snapshotDepth,
 		snapshotInterval:                       snapshotInterval,
+		formatVersion:                          FormatVersion(compression),
 		Events: &Events{
 			SnapshotMilestoneIndexChanged:         events.NewEvent(storagepkg.MilestoneIndexCaller),
 			HandledConfirmedMilestoneIndexChanged: events.NewEvent(storagepkg.MilestoneIndexCaller),
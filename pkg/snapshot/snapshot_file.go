@@ -18,8 +18,15 @@ import (
 )
 
 const (
-	// SupportedFormatVersion defines the supported snapshot file version.
-	SupportedFormatVersion byte = 2
+	// FormatVersionRaw defines the snapshot file version in which all sections are written as raw sequential streams.
+	FormatVersionRaw byte = 2
+	// FormatVersionCompressed defines the snapshot file version in which all sections are written as zstd compressed chunks,
+	// followed by a section index that contains the file offsets and item counts of all chunks.
+	FormatVersionCompressed byte = 3
+	// SupportedFormatVersion defines the snapshot file version that is written by default.
+	// Compressed snapshot files can't be read by older versions of HORNET and existing tools,
+	// so they are only written if the compression is enabled explicitly.
+	SupportedFormatVersion = FormatVersionRaw
 )
 
 var (
@@ -68,6 +75,19 @@ type ReadWriteTruncateSeeker interface {
 	Truncate(size int64) error
 }
 
+// FormatVersion returns the snapshot file version that is written with or without compression.
+func FormatVersion(compression bool) byte {
+	if compression {
+		return FormatVersionCompressed
+	}
+	return SupportedFormatVersion
+}
+
+// IsSupportedFormatVersion returns whether snapshot files with the given version can be read.
+func IsSupportedFormatVersion(version byte) bool {
+	return version == FormatVersionRaw || version == FormatVersionCompressed
+}
+
 func increaseOffsets(amount int64, offsets ...*int64) {
 	for _, offset := range offsets {
 		*offset += amount
@@ -320,6 +340,10 @@ type OutputProducerFunc func() (*utxo.Output, error)
 // A returned error signals to cancel further reading.
 type OutputConsumerFunc func(output *utxo.Output) error
 
+// OutputsConsumerFunc consumes the given batch of outputs.
+// A returned error signals to cancel further reading.
+type OutputsConsumerFunc func(outputs utxo.Outputs) error
+
 // UnspentTreasuryOutputConsumerFunc consumes the given treasury output.
 // A returned error signals to cancel further reading.
 type UnspentTreasuryOutputConsumerFunc func(output *utxo.TreasuryOutput) error
@@ -503,7 +527,7 @@ func ReadFullSnapshotHeader(reader io.Reader) (*FullSnapshotHeader, error) {
 		return nil, fmt.Errorf("unable to read LS version: %w", err)
 	}
 
-	if readHeader.Version != SupportedFormatVersion {
+	if !IsSupportedFormatVersion(readHeader.Version) {
 		return nil, ErrUnsupportedSnapshot
 	}
 
@@ -669,7 +693,7 @@ func ReadDeltaSnapshotHeader(reader io.Reader) (*DeltaSnapshotHeader, error) {
 		return nil, fmt.Errorf("unable to read LS version: %w", err)
 	}
 
-	if deltaHeader.Version != SupportedFormatVersion {
+	if !IsSupportedFormatVersion(deltaHeader.Version) {
 		return nil, ErrUnsupportedSnapshot
 	}
 
@@ -748,6 +772,16 @@ func StreamFullSnapshotDataTo(
 		return nil, err
 	}
 
+	sectionsPosition, err := writeSeeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get LS sections position: %w", err)
+	}
+
+	sectionWriter, err := newSectionWriter(writeSeeker, header.Version, sectionsPosition, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	writeFunc := func(name string, value any, offsetsToIncrease ...*int64) error {
 		return writeFunc(writeSeeker, name, value, offsetsToIncrease...)
 	}
@@ -770,10 +804,13 @@ func StreamFullSnapshotDataTo(
 		}
 
 		outputCount++
-		if err := writeFunc(fmt.Sprintf("output #%d", outputCount), output.SnapshotBytes()); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeOutputs, fmt.Sprintf("output #%d", outputCount), output.SnapshotBytes()); err != nil {
 			return nil, err
 		}
 	}
+	if _, err := sectionWriter.finishSection(); err != nil {
+		return nil, err
+	}
 	timeOutputs := time.Now()
 
 	// Milestone Diffs
@@ -792,10 +829,13 @@ func StreamFullSnapshotDataTo(
 		if err != nil {
 			return nil, fmt.Errorf("unable to serialize LS milestone diff #%d: %w", msDiffCount, err)
 		}
-		if err := writeFunc(fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeMilestoneDiffs, fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes); err != nil {
 			return nil, err
 		}
 	}
+	if _, err := sectionWriter.finishSection(); err != nil {
+		return nil, err
+	}
 	timeMilestoneDiffs := time.Now()
 
 	// SEPs
@@ -809,10 +849,13 @@ func StreamFullSnapshotDataTo(
 		}
 
 		sepsCount++
-		if err := writeFunc(fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeSolidEntryPoints, fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
 			return nil, err
 		}
 	}
+	if err := sectionWriter.finish(); err != nil {
+		return nil, err
+	}
 	timeSolidEntryPoints := time.Now()
 
 	// seek back to the file position of the counters
@@ -867,7 +910,12 @@ func StreamDeltaSnapshotDataTo(
 
 	timeStart := time.Now()
 
-	sepFileOffsetPosition, sepPosition, err := writeDeltaSnapshotHeader(writeSeeker, header)
+	sepFileOffsetPosition, sectionsPosition, err := writeDeltaSnapshotHeader(writeSeeker, header)
+	if err != nil {
+		return nil, err
+	}
+
+	sectionWriter, err := newSectionWriter(writeSeeker, header.Version, sectionsPosition, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -897,10 +945,16 @@ func StreamDeltaSnapshotDataTo(
 		if err != nil {
 			return nil, fmt.Errorf("unable to serialize LS milestone diff #%d: %w", msDiffCount, err)
 		}
-		if err := writeFunc(fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes, &sepPosition); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeMilestoneDiffs, fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes); err != nil {
 			return nil, err
 		}
 	}
+
+	// this is the offset of the first SEP in the snapshot file
+	sepPosition, err := sectionWriter.finishSection()
+	if err != nil {
+		return nil, err
+	}
 	timeMilestoneDiffs := time.Now()
 
 	// SEPs
@@ -914,10 +968,13 @@ func StreamDeltaSnapshotDataTo(
 		}
 
 		sepsCount++
-		if err := writeFunc(fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeSolidEntryPoints, fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
 			return nil, err
 		}
 	}
+	if err := sectionWriter.finish(); err != nil {
+		return nil, err
+	}
 	timeSolidEntryPoints := time.Now()
 
 	// seek back to the file position of the SEPFileOffset
@@ -1028,8 +1085,15 @@ func StreamDeltaSnapshotDataToExisting(
 
 	timeHeader := time.Now()
 
-	// this is the offset of the first SEP in the snapshot file
-	sepPosition := oldDeltaHeader.SEPFileOffset
+	// the existing milestone diff chunks are kept in the section index.
+	var existingSectionIndex SectionIndex
+	if oldDeltaHeader.Version == FormatVersionCompressed {
+		oldSectionIndex, err := ReadSectionIndex(fileHandle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read existing delta snapshot section index: %w", err)
+		}
+		existingSectionIndex = oldSectionIndex.Entries(SectionTypeMilestoneDiffs)
+	}
 
 	msDiffCount := oldDeltaHeader.MilestoneDiffCount
 	var sepsCount uint16
@@ -1040,6 +1104,11 @@ func StreamDeltaSnapshotDataToExisting(
 	// Truncate the old SEPs
 	fileHandle.Truncate(oldDeltaHeader.SEPFileOffset)
 
+	sectionWriter, err := newSectionWriter(fileHandle, header.Version, oldDeltaHeader.SEPFileOffset, existingSectionIndex)
+	if err != nil {
+		return nil, err
+	}
+
 	// Milestone Diffs
 	for {
 		msDiff, err := msDiffProd()
@@ -1060,10 +1129,16 @@ func StreamDeltaSnapshotDataToExisting(
 		if err != nil {
 			return nil, fmt.Errorf("unable to serialize LS milestone diff #%d: %w", msDiffCount, err)
 		}
-		if err := writeFunc(fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes, &sepPosition); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeMilestoneDiffs, fmt.Sprintf("milestone diff #%d", msDiffCount), msDiffBytes); err != nil {
 			return nil, err
 		}
 	}
+
+	// this is the offset of the first SEP in the snapshot file
+	sepPosition, err := sectionWriter.finishSection()
+	if err != nil {
+		return nil, err
+	}
 	timeMilestoneDiffs := time.Now()
 
 	// SEPs
@@ -1077,10 +1152,13 @@ func StreamDeltaSnapshotDataToExisting(
 		}
 
 		sepsCount++
-		if err := writeFunc(fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
+		if err := sectionWriter.writeItem(SectionTypeSolidEntryPoints, fmt.Sprintf("SEP #%d", sepsCount), sep[:]); err != nil {
 			return nil, err
 		}
 	}
+	if err := sectionWriter.finish(); err != nil {
+		return nil, err
+	}
 	timeSolidEntryPoints := time.Now()
 
 	// seek back to the file position of the counters
@@ -1125,7 +1203,7 @@ func ReadSnapshotType(readSeeker io.ReadSeeker) (Type, error) {
 		return Full, fmt.Errorf("unable to read LS version: %w", err)
 	}
 
-	if version != SupportedFormatVersion {
+	if !IsSupportedFormatVersion(version) {
 		return Full, ErrUnsupportedSnapshot
 	}
 
@@ -1159,14 +1237,18 @@ func ReadSnapshotTypeFromFile(filePath string) (Type, error) {
 }
 
 // StreamFullSnapshotDataFrom consumes a full snapshot from the given reader.
+// The outputs are decoded in parallel and passed to the outputs consumer in batches in file order.
 func StreamFullSnapshotDataFrom(
 	reader io.ReadSeeker,
 	headerConsumer FullHeaderConsumerFunc,
 	unspentTreasuryOutputConsumer UnspentTreasuryOutputConsumerFunc,
-	outputConsumer OutputConsumerFunc,
+	outputsConsumer OutputsConsumerFunc,
 	msDiffConsumer MilestoneDiffConsumerFunc,
 	sepConsumer SEPConsumerFunc,
-	protoParamsMsOptionsConsumer ProtocolParamsMilestoneOptConsumerFunc) error {
+	protoParamsMsOptionsConsumer ProtocolParamsMilestoneOptConsumerFunc,
+	opts ...ReadOption) error {
+
+	readOpts := readOptions(opts)
 
 	fullHeader, err := ReadFullSnapshotHeader(reader)
 	if err != nil {
@@ -1199,15 +1281,115 @@ func StreamFullSnapshotDataFrom(
 		return fmt.Errorf("unable to store protocol parameters milestone option: %w", err)
 	}
 
-	for i := uint64(0); i < fullHeader.OutputCount; i++ {
-		output, err := ReadOutput(reader, fullHeaderProtoParams)
-		if err != nil {
-			return fmt.Errorf("at pos %d: %w", i, err)
+	switch fullHeader.Version {
+	case FormatVersionCompressed:
+		if err := streamCompressedFullSnapshotSectionsFrom(reader, fullHeader, fullHeaderProtoParams, protocolStorage, outputsConsumer, msDiffConsumer, sepConsumer, readOpts); err != nil {
+			return err
 		}
+	default:
+		if err := streamRawFullSnapshotSectionsFrom(reader, fullHeader, fullHeaderProtoParams, protocolStorage, outputsConsumer, msDiffConsumer, sepConsumer, readOpts); err != nil {
+			return err
+		}
+	}
 
-		if err := outputConsumer(output); err != nil {
-			return fmt.Errorf("output consumer error at pos %d: %w", i, err)
+	// consume all parsed protocol parameters milestone options.
+	var innerErr error
+	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
+		if err := protoParamsMsOptionsConsumer(protoParamsMsOption); err != nil {
+			innerErr = err
+			return false
 		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
+	}
+	if innerErr != nil {
+		return fmt.Errorf("unable to consume LS protocol parameters milestone options: %w", innerErr)
+	}
+
+	return nil
+}
+
+// StreamDeltaSnapshotDataFrom consumes a delta snapshot from the given reader.
+func StreamDeltaSnapshotDataFrom(
+	reader io.ReadSeeker,
+	protocolStorageGetter ProtocolStorageGetterFunc,
+	headerConsumer DeltaHeaderConsumerFunc,
+	msDiffConsumer MilestoneDiffConsumerFunc,
+	sepConsumer SEPConsumerFunc,
+	protoParamsMsOptionsConsumer ProtocolParamsMilestoneOptConsumerFunc) error {
+
+	protocolStorage, err := protocolStorageGetter()
+	if err != nil {
+		return err
+	}
+
+	deltaHeader, err := ReadDeltaSnapshotHeader(reader)
+	if err != nil {
+		return err
+	}
+
+	if err := headerConsumer(deltaHeader); err != nil {
+		return err
+	}
+
+	// we need to remember the already stored protocol parameter milestone options
+	// from the full snapshot to avoid duplicates.
+	existingProtoParamsMsOpts := make(map[iotago.MilestoneIndex]struct{})
+	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
+		existingProtoParamsMsOpts[protoParamsMsOption.TargetMilestoneIndex] = struct{}{}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
+	}
+
+	switch deltaHeader.Version {
+	case FormatVersionCompressed:
+		if err := streamCompressedDeltaSnapshotSectionsFrom(reader, deltaHeader, protocolStorage, msDiffConsumer, sepConsumer); err != nil {
+			return err
+		}
+	default:
+		if err := streamRawDeltaSnapshotSectionsFrom(reader, deltaHeader, protocolStorage, msDiffConsumer, sepConsumer); err != nil {
+			return err
+		}
+	}
+
+	// consume all parsed protocol parameters milestone options.
+	var innerErr error
+	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
+		if _, exists := existingProtoParamsMsOpts[protoParamsMsOption.TargetMilestoneIndex]; exists {
+			// do not consume already existing protocol parameter milestone options.
+			return true
+		}
+
+		if err := protoParamsMsOptionsConsumer(protoParamsMsOption); err != nil {
+			innerErr = err
+			return false
+		}
+		return true
+	}); err != nil {
+		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
+	}
+	if innerErr != nil {
+		return fmt.Errorf("unable to consume LS protocol parameters milestone options: %w", innerErr)
+	}
+
+	return nil
+}
+
+// streamRawFullSnapshotSectionsFrom consumes the sections of a full snapshot that are written as raw sequential streams.
+func streamRawFullSnapshotSectionsFrom(
+	reader io.ReadSeeker,
+	fullHeader *FullSnapshotHeader,
+	fullHeaderProtoParams *iotago.ProtocolParameters,
+	protocolStorage *storage.ProtocolStorage,
+	outputsConsumer OutputsConsumerFunc,
+	msDiffConsumer MilestoneDiffConsumerFunc,
+	sepConsumer SEPConsumerFunc,
+	opts *ReadOptions) error {
+
+	if err := streamRawOutputsFrom(reader, fullHeader.OutputCount, fullHeaderProtoParams, outputsConsumer, opts); err != nil {
+		return err
 	}
 
 	// this is the total length of the milestone diffs.
@@ -1262,56 +1444,16 @@ func StreamFullSnapshotDataFrom(
 		}
 	}
 
-	// consume all parsed protocol parameters milestone options.
-	var innerErr error
-	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
-		if err := protoParamsMsOptionsConsumer(protoParamsMsOption); err != nil {
-			innerErr = err
-			return false
-		}
-		return true
-	}); err != nil {
-		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
-	}
-	if innerErr != nil {
-		return fmt.Errorf("unable to consume LS protocol parameters milestone options: %w", innerErr)
-	}
-
 	return nil
 }
 
-// StreamDeltaSnapshotDataFrom consumes a delta snapshot from the given reader.
-func StreamDeltaSnapshotDataFrom(
+// streamRawDeltaSnapshotSectionsFrom consumes the sections of a delta snapshot that are written as raw sequential streams.
+func streamRawDeltaSnapshotSectionsFrom(
 	reader io.ReadSeeker,
-	protocolStorageGetter ProtocolStorageGetterFunc,
-	headerConsumer DeltaHeaderConsumerFunc,
+	deltaHeader *DeltaSnapshotHeader,
+	protocolStorage *storage.ProtocolStorage,
 	msDiffConsumer MilestoneDiffConsumerFunc,
-	sepConsumer SEPConsumerFunc,
-	protoParamsMsOptionsConsumer ProtocolParamsMilestoneOptConsumerFunc) error {
-
-	protocolStorage, err := protocolStorageGetter()
-	if err != nil {
-		return err
-	}
-
-	deltaHeader, err := ReadDeltaSnapshotHeader(reader)
-	if err != nil {
-		return err
-	}
-
-	if err := headerConsumer(deltaHeader); err != nil {
-		return err
-	}
-
-	// we need to remember the already stored protocol parameter milestone options
-	// from the full snapshot to avoid duplicates.
-	existingProtoParamsMsOpts := make(map[iotago.MilestoneIndex]struct{})
-	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
-		existingProtoParamsMsOpts[protoParamsMsOption.TargetMilestoneIndex] = struct{}{}
-		return true
-	}); err != nil {
-		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
-	}
+	sepConsumer SEPConsumerFunc) error {
 
 	for i := uint32(0); i < deltaHeader.MilestoneDiffCount; i++ {
 		_, msDiff, err := ReadMilestoneDiff(reader, protocolStorage, true)
@@ -1334,26 +1476,6 @@ func StreamDeltaSnapshotDataFrom(
 		}
 	}
 
-	// consume all parsed protocol parameters milestone options.
-	var innerErr error
-	if err := protocolStorage.ForEachProtocolParameterMilestoneOption(func(protoParamsMsOption *iotago.ProtocolParamsMilestoneOpt) bool {
-		if _, exists := existingProtoParamsMsOpts[protoParamsMsOption.TargetMilestoneIndex]; exists {
-			// do not consume already existing protocol parameter milestone options.
-			return true
-		}
-
-		if err := protoParamsMsOptionsConsumer(protoParamsMsOption); err != nil {
-			innerErr = err
-			return false
-		}
-		return true
-	}); err != nil {
-		return fmt.Errorf("failed to iterate over LS protocol parameters milestone options: %w", err)
-	}
-	if innerErr != nil {
-		return fmt.Errorf("unable to consume LS protocol parameters milestone options: %w", innerErr)
-	}
-
 	return nil
 }
 
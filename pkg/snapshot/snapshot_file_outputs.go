@@ -0,0 +1,257 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the amount of outputs of a raw outputs section that are decoded by a worker in one batch.
+	rawOutputsDecodingBatchSize = 1000
+	// the size of the read buffer for a raw outputs section.
+	rawOutputsReadBufferSize = 1024 * 1024
+	// the size of the fixed fields of a serialized output in a snapshot file.
+	// output ID + block ID + milestone index booked + milestone timestamp booked + output length
+	rawOutputFixedFieldsSize = iotago.OutputIDLength + iotago.BlockIDLength + serializer.UInt32ByteSize + serializer.UInt32ByteSize + serializer.UInt32ByteSize
+)
+
+// ReadOption is a function setting a snapshot file read option.
+type ReadOption func(opts *ReadOptions)
+
+// ReadOptions define options for reading snapshot files.
+type ReadOptions struct {
+	// the amount of workers that decode the outputs in parallel.
+	decodingWorkerCount int
+}
+
+func readOptions(opts []ReadOption) *ReadOptions {
+	result := &ReadOptions{
+		decodingWorkerCount: runtime.NumCPU(),
+	}
+
+	for _, opt := range opts {
+		opt(result)
+	}
+
+	if result.decodingWorkerCount < 1 {
+		result.decodingWorkerCount = 1
+	}
+
+	return result
+}
+
+// WithDecodingWorkerCount sets the amount of workers that decode the outputs in parallel.
+func WithDecodingWorkerCount(workerCount int) ReadOption {
+	return func(opts *ReadOptions) {
+		opts.decodingWorkerCount = workerCount
+	}
+}
+
+// outputsDecodingJob is a batch of serialized outputs that is decoded by one of the workers.
+type outputsDecodingJob struct {
+	decode  func() (utxo.Outputs, error)
+	outputs utxo.Outputs
+	err     error
+	done    chan struct{}
+}
+
+// outputsDecodingJobSubmitFunc hands a decoding job over to the workers.
+type outputsDecodingJobSubmitFunc func(decode func() (utxo.Outputs, error)) error
+
+// consumeOutputsParallel decodes the outputs of a snapshot file in a pipeline.
+// The producer reads the serialized outputs in its own goroutine and submits them in batches,
+// the batches are decoded by the workers in parallel, and the decoded outputs are
+// passed to the consumer in the same order as they appear in the file.
+func consumeOutputsParallel(workerCount int, producer func(submit outputsDecodingJobSubmitFunc) error, outputsConsumer OutputsConsumerFunc) error {
+
+	// the jobs are passed to the consumer in the order they were submitted.
+	// the size of the channel limits the amount of decoded outputs that are held in memory.
+	orderedJobs := make(chan *outputsDecodingJob, 2*workerCount)
+	pendingJobs := make(chan *outputsDecodingJob, workerCount)
+	abort := make(chan struct{})
+	producerDone := make(chan struct{})
+
+	var producerErr error
+	go func() {
+		defer close(producerDone)
+		defer close(orderedJobs)
+		defer close(pendingJobs)
+
+		producerErr = producer(func(decode func() (utxo.Outputs, error)) error {
+			job := &outputsDecodingJob{
+				decode: decode,
+				done:   make(chan struct{}),
+			}
+
+			select {
+			case orderedJobs <- job:
+			case <-abort:
+				return common.ErrOperationAborted
+			}
+
+			select {
+			case pendingJobs <- job:
+			case <-abort:
+				return common.ErrOperationAborted
+			}
+
+			return nil
+		})
+	}()
+
+	for i := 0; i < workerCount; i++ {
+		go func() {
+			for job := range pendingJobs {
+				job.outputs, job.err = job.decode()
+				close(job.done)
+			}
+		}()
+	}
+
+	abortPipeline := func() {
+		close(abort)
+		<-producerDone
+	}
+
+	var pos uint64
+	for job := range orderedJobs {
+		<-job.done
+
+		if job.err != nil {
+			abortPipeline()
+			return fmt.Errorf("at pos %d: %w", pos, job.err)
+		}
+
+		if err := outputsConsumer(job.outputs); err != nil {
+			abortPipeline()
+			return fmt.Errorf("output consumer error at pos %d: %w", pos, err)
+		}
+
+		pos += uint64(len(job.outputs))
+	}
+
+	// the producer is done if the ordered jobs channel was closed
+	return producerErr
+}
+
+// readRawOutputBytes reads the serialized output from the given reader and appends it to the buffer.
+// Returns the amount of bytes read.
+func readRawOutputBytes(reader io.Reader, buffer *bytes.Buffer) (int64, error) {
+	fixedFields := make([]byte, rawOutputFixedFieldsSize)
+	if _, err := io.ReadFull(reader, fixedFields); err != nil {
+		return 0, fmt.Errorf("unable to read LS output: %w", err)
+	}
+	buffer.Write(fixedFields)
+
+	outputLength := binary.LittleEndian.Uint32(fixedFields[rawOutputFixedFieldsSize-serializer.UInt32ByteSize:])
+	if _, err := io.CopyN(buffer, reader, int64(outputLength)); err != nil {
+		return 0, fmt.Errorf("unable to read LS output bytes: %w", err)
+	}
+
+	return int64(rawOutputFixedFieldsSize) + int64(outputLength), nil
+}
+
+// decodeOutputs decodes the given amount of serialized outputs from the given reader.
+func decodeOutputs(reader *bytes.Reader, count uint64, protoParams *iotago.ProtocolParameters) (utxo.Outputs, error) {
+	outputs := make(utxo.Outputs, count)
+	for i := uint64(0); i < count; i++ {
+		output, err := ReadOutput(reader, protoParams)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = output
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%d trailing bytes after LS outputs", reader.Len())
+	}
+
+	return outputs, nil
+}
+
+// streamRawOutputsFrom consumes the outputs of a raw outputs section.
+// After the function returns, the reader is positioned at the end of the outputs section.
+func streamRawOutputsFrom(reader io.ReadSeeker, outputCount uint64, protoParams *iotago.ProtocolParameters, outputsConsumer OutputsConsumerFunc, opts *ReadOptions) error {
+
+	sectionStart, err := reader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("unable to get LS outputs position: %w", err)
+	}
+
+	// the buffered reader reads beyond the outputs section,
+	// so we need to remember the length of the section to seek to its end afterwards.
+	var sectionLength int64
+
+	if err := consumeOutputsParallel(opts.decodingWorkerCount, func(submit outputsDecodingJobSubmitFunc) error {
+		bufferedReader := bufio.NewReaderSize(reader, rawOutputsReadBufferSize)
+
+		for remaining := outputCount; remaining > 0; {
+			batchSize := remaining
+			if batchSize > rawOutputsDecodingBatchSize {
+				batchSize = rawOutputsDecodingBatchSize
+			}
+			remaining -= batchSize
+
+			batch := &bytes.Buffer{}
+			for i := uint64(0); i < batchSize; i++ {
+				outputLength, err := readRawOutputBytes(bufferedReader, batch)
+				if err != nil {
+					return err
+				}
+				increaseOffsets(outputLength, &sectionLength)
+			}
+
+			if err := submit(func() (utxo.Outputs, error) {
+				return decodeOutputs(bytes.NewReader(batch.Bytes()), batchSize, protoParams)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, outputsConsumer); err != nil {
+		return err
+	}
+
+	if _, err := reader.Seek(sectionStart+sectionLength, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek to the end of the LS outputs: %w", err)
+	}
+
+	return nil
+}
+
+// readOutputs consumes the outputs of all chunks of the outputs section.
+// The chunks are read sequentially, but they are decompressed and decoded in parallel.
+func (r *compressedSectionReader) readOutputs(protoParams *iotago.ProtocolParameters, outputsConsumer OutputsConsumerFunc, opts *ReadOptions) error {
+	return consumeOutputsParallel(opts.decodingWorkerCount, func(submit outputsDecodingJobSubmitFunc) error {
+		for _, entry := range r.sectionIndex.Entries(SectionTypeOutputs) {
+			compressed, err := r.readChunk(entry)
+			if err != nil {
+				return err
+			}
+
+			entry := entry
+			if err := submit(func() (utxo.Outputs, error) {
+				chunkReader, err := r.decodeChunk(entry, compressed)
+				if err != nil {
+					return nil, err
+				}
+
+				return decodeOutputs(chunkReader, entry.Count, protoParams)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}, outputsConsumer)
+}
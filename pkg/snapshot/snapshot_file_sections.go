@@ -0,0 +1,660 @@
+package snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/serializer/v2"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the maximum uncompressed size of a section chunk.
+	// a section is split into several chunks, so that the chunks can be decoded in parallel.
+	sectionChunkMaxSize = 4 * 1024 * 1024
+
+	// the maximum uncompressed size of a section chunk that is accepted while reading.
+	// a chunk is flushed after the item that exceeded sectionChunkMaxSize, so a single large
+	// milestone diff can make it bigger than sectionChunkMaxSize, but never bigger than this.
+	sectionChunkMaxDecodedSize = 256 * 1024 * 1024
+
+	// the size of a serialized section index entry.
+	// type + offset + compressed size + uncompressed size + count
+	sectionIndexEntrySize = serializer.OneByte + serializer.Int64ByteSize + serializer.Int64ByteSize + serializer.Int64ByteSize + serializer.UInt64ByteSize
+
+	// the size of the serialized section index footer.
+	// section count + section index offset + magic
+	sectionIndexFooterSize = serializer.UInt32ByteSize + serializer.Int64ByteSize + serializer.UInt32ByteSize
+
+	// the magic that is written at the end of a snapshot file with a section index.
+	sectionIndexMagic uint32 = 0x58444953 // "SIDX"
+)
+
+var (
+	// ErrInvalidSectionIndex is returned if the section index of a snapshot file is invalid.
+	ErrInvalidSectionIndex = errors.New("invalid snapshot section index")
+	// ErrNoSectionIndex is returned if a snapshot file of the given version does not contain a section index.
+	ErrNoSectionIndex = errors.New("snapshot file version does not contain a section index")
+)
+
+// SectionType defines the type of a section in a snapshot file.
+type SectionType byte
+
+const (
+	// SectionTypeOutputs is the section containing the unspent outputs.
+	SectionTypeOutputs SectionType = iota
+	// SectionTypeMilestoneDiffs is the section containing the milestone diffs.
+	SectionTypeMilestoneDiffs
+	// SectionTypeSolidEntryPoints is the section containing the solid entry points.
+	SectionTypeSolidEntryPoints
+)
+
+// maps the section type to its name.
+var sectionNames = map[SectionType]string{
+	SectionTypeOutputs:          "outputs",
+	SectionTypeMilestoneDiffs:   "milestone diffs",
+	SectionTypeSolidEntryPoints: "solid entry points",
+}
+
+// String returns the name of the section type.
+func (t SectionType) String() string {
+	if name, exists := sectionNames[t]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("unknown (%d)", byte(t))
+}
+
+// SectionIndexEntry describes a compressed chunk of a section in a snapshot file.
+type SectionIndexEntry struct {
+	// Type denotes the type of the section the chunk belongs to.
+	Type SectionType
+	// Offset is the file offset of the compressed chunk.
+	Offset int64
+	// CompressedSize is the size of the compressed chunk in the file.
+	CompressedSize int64
+	// UncompressedSize is the size of the chunk after decompression.
+	UncompressedSize int64
+	// Count is the amount of items contained within the chunk.
+	Count uint64
+}
+
+// SectionIndex is the index of all section chunks in a snapshot file, in the order they appear in the file.
+type SectionIndex []*SectionIndexEntry
+
+// Entries returns all entries of the given section type.
+func (si SectionIndex) Entries(sectionType SectionType) SectionIndex {
+	entries := make(SectionIndex, 0)
+	for _, entry := range si {
+		if entry.Type == sectionType {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Count returns the amount of items of the given section type.
+func (si SectionIndex) Count(sectionType SectionType) uint64 {
+	var count uint64
+	for _, entry := range si.Entries(sectionType) {
+		count += entry.Count
+	}
+
+	return count
+}
+
+// CompressedSize returns the compressed size of the given section type.
+func (si SectionIndex) CompressedSize(sectionType SectionType) int64 {
+	var size int64
+	for _, entry := range si.Entries(sectionType) {
+		size += entry.CompressedSize
+	}
+
+	return size
+}
+
+// UncompressedSize returns the uncompressed size of the given section type.
+func (si SectionIndex) UncompressedSize(sectionType SectionType) int64 {
+	var size int64
+	for _, entry := range si.Entries(sectionType) {
+		size += entry.UncompressedSize
+	}
+
+	return size
+}
+
+// ReadSectionIndex reads the section index from the end of the given snapshot file reader.
+// Only the footer and the index itself are read, the sections are not touched.
+func ReadSectionIndex(readSeeker io.ReadSeeker) (SectionIndex, error) {
+
+	fileSize, err := readSeeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("unable to seek to the end of the LS: %w", err)
+	}
+
+	if fileSize < sectionIndexFooterSize {
+		return nil, errors.Wrap(ErrInvalidSectionIndex, "file too small")
+	}
+
+	if _, err := readSeeker.Seek(fileSize-sectionIndexFooterSize, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to seek to LS section index footer: %w", err)
+	}
+
+	var sectionCount uint32
+	if err := binary.Read(readSeeker, binary.LittleEndian, &sectionCount); err != nil {
+		return nil, fmt.Errorf("unable to read LS section count: %w", err)
+	}
+
+	var indexOffset int64
+	if err := binary.Read(readSeeker, binary.LittleEndian, &indexOffset); err != nil {
+		return nil, fmt.Errorf("unable to read LS section index offset: %w", err)
+	}
+
+	var magic uint32
+	if err := binary.Read(readSeeker, binary.LittleEndian, &magic); err != nil {
+		return nil, fmt.Errorf("unable to read LS section index magic: %w", err)
+	}
+
+	if magic != sectionIndexMagic {
+		return nil, errors.Wrap(ErrInvalidSectionIndex, "wrong magic")
+	}
+
+	if indexOffset < 0 || indexOffset+int64(sectionCount)*sectionIndexEntrySize != fileSize-sectionIndexFooterSize {
+		return nil, errors.Wrapf(ErrInvalidSectionIndex, "section index offset %d does not match the section count %d", indexOffset, sectionCount)
+	}
+
+	if _, err := readSeeker.Seek(indexOffset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to seek to LS section index: %w", err)
+	}
+
+	sectionIndex := make(SectionIndex, sectionCount)
+	for i := uint32(0); i < sectionCount; i++ {
+		entry := &SectionIndexEntry{}
+
+		if err := binary.Read(readSeeker, binary.LittleEndian, &entry.Type); err != nil {
+			return nil, fmt.Errorf("unable to read LS section type at pos %d: %w", i, err)
+		}
+		if err := binary.Read(readSeeker, binary.LittleEndian, &entry.Offset); err != nil {
+			return nil, fmt.Errorf("unable to read LS section offset at pos %d: %w", i, err)
+		}
+		if err := binary.Read(readSeeker, binary.LittleEndian, &entry.CompressedSize); err != nil {
+			return nil, fmt.Errorf("unable to read LS section compressed size at pos %d: %w", i, err)
+		}
+		if err := binary.Read(readSeeker, binary.LittleEndian, &entry.UncompressedSize); err != nil {
+			return nil, fmt.Errorf("unable to read LS section uncompressed size at pos %d: %w", i, err)
+		}
+		if err := binary.Read(readSeeker, binary.LittleEndian, &entry.Count); err != nil {
+			return nil, fmt.Errorf("unable to read LS section count at pos %d: %w", i, err)
+		}
+
+		if _, exists := sectionNames[entry.Type]; !exists {
+			return nil, errors.Wrapf(ErrInvalidSectionIndex, "unknown section type %d at pos %d", entry.Type, i)
+		}
+		if entry.Offset < 0 || entry.CompressedSize < 0 || entry.UncompressedSize < 0 || entry.Offset+entry.CompressedSize > indexOffset {
+			return nil, errors.Wrapf(ErrInvalidSectionIndex, "section at pos %d is out of bounds", i)
+		}
+		if entry.UncompressedSize > sectionChunkMaxDecodedSize {
+			return nil, errors.Wrapf(ErrInvalidSectionIndex, "uncompressed size of section at pos %d exceeds the limit (%d > %d)", i, entry.UncompressedSize, sectionChunkMaxDecodedSize)
+		}
+
+		sectionIndex[i] = entry
+	}
+
+	return sectionIndex, nil
+}
+
+// ReadSectionIndexFromFile reads the section index of the given snapshot file.
+// Returns ErrNoSectionIndex if the file format version does not contain a section index.
+func ReadSectionIndexFromFile(filePath string) (SectionIndex, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open snapshot file to read section index: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var version byte
+	if err := binary.Read(file, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("unable to read LS version: %w", err)
+	}
+
+	if !IsSupportedFormatVersion(version) {
+		return nil, ErrUnsupportedSnapshot
+	}
+
+	if version != FormatVersionCompressed {
+		return nil, ErrNoSectionIndex
+	}
+
+	return ReadSectionIndex(file)
+}
+
+// sectionWriter writes the items of the sections of a snapshot file.
+type sectionWriter interface {
+	// writeItem writes a serialized item of the given section.
+	writeItem(sectionType SectionType, name string, data []byte) error
+	// finishSection finishes the current section and returns the file offset of the next section.
+	finishSection() (int64, error)
+	// finish finishes all sections and writes remaining file data.
+	finish() error
+}
+
+// newSectionWriter creates a section writer for the given file format version.
+// offset is the current file offset and existingIndex contains the section index entries
+// of an existing snapshot file that should be kept.
+func newSectionWriter(writeSeeker io.WriteSeeker, version byte, offset int64, existingIndex SectionIndex) (sectionWriter, error) {
+	switch version {
+	case FormatVersionRaw:
+		return &rawSectionWriter{
+			writeSeeker: writeSeeker,
+			offset:      offset,
+		}, nil
+
+	case FormatVersionCompressed:
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create LS section encoder: %w", err)
+		}
+
+		sectionIndex := make(SectionIndex, len(existingIndex))
+		copy(sectionIndex, existingIndex)
+
+		return &compressedSectionWriter{
+			writeSeeker:  writeSeeker,
+			encoder:      encoder,
+			offset:       offset,
+			sectionIndex: sectionIndex,
+		}, nil
+
+	default:
+		return nil, errors.Wrapf(ErrUnsupportedSnapshot, "unable to write snapshot file version %d", version)
+	}
+}
+
+// rawSectionWriter writes the items as a sequential stream without any additional data.
+type rawSectionWriter struct {
+	writeSeeker io.WriteSeeker
+	offset      int64
+}
+
+func (w *rawSectionWriter) writeItem(_ SectionType, name string, data []byte) error {
+	return writeFunc(w.writeSeeker, name, data, &w.offset)
+}
+
+func (w *rawSectionWriter) finishSection() (int64, error) {
+	return w.offset, nil
+}
+
+func (w *rawSectionWriter) finish() error {
+	return nil
+}
+
+// compressedSectionWriter collects the items of a section in chunks,
+// writes the chunks zstd compressed and appends a section index at the end of the file.
+type compressedSectionWriter struct {
+	writeSeeker  io.WriteSeeker
+	encoder      *zstd.Encoder
+	offset       int64
+	sectionIndex SectionIndex
+
+	chunkType  SectionType
+	chunkCount uint64
+	chunk      bytes.Buffer
+}
+
+func (w *compressedSectionWriter) writeItem(sectionType SectionType, _ string, data []byte) error {
+	if w.chunkCount > 0 && w.chunkType != sectionType {
+		if err := w.flushChunk(); err != nil {
+			return err
+		}
+	}
+
+	w.chunkType = sectionType
+	w.chunkCount++
+	w.chunk.Write(data)
+
+	if w.chunk.Len() >= sectionChunkMaxSize {
+		return w.flushChunk()
+	}
+
+	return nil
+}
+
+// flushChunk compresses the current chunk and writes it to the file.
+func (w *compressedSectionWriter) flushChunk() error {
+	if w.chunkCount == 0 {
+		return nil
+	}
+
+	if w.chunk.Len() > sectionChunkMaxDecodedSize {
+		return fmt.Errorf("LS %s section chunk exceeds the maximum size (%d > %d)", w.chunkType, w.chunk.Len(), sectionChunkMaxDecodedSize)
+	}
+
+	compressed := w.encoder.EncodeAll(w.chunk.Bytes(), nil)
+	if _, err := w.writeSeeker.Write(compressed); err != nil {
+		return fmt.Errorf("unable to write LS %s section chunk: %w", w.chunkType, err)
+	}
+
+	w.sectionIndex = append(w.sectionIndex, &SectionIndexEntry{
+		Type:             w.chunkType,
+		Offset:           w.offset,
+		CompressedSize:   int64(len(compressed)),
+		UncompressedSize: int64(w.chunk.Len()),
+		Count:            w.chunkCount,
+	})
+	increaseOffsets(int64(len(compressed)), &w.offset)
+
+	w.chunkCount = 0
+	w.chunk.Reset()
+
+	return nil
+}
+
+func (w *compressedSectionWriter) finishSection() (int64, error) {
+	if err := w.flushChunk(); err != nil {
+		return 0, err
+	}
+
+	return w.offset, nil
+}
+
+func (w *compressedSectionWriter) finish() error {
+	defer func() { _ = w.encoder.Close() }()
+
+	if err := w.flushChunk(); err != nil {
+		return err
+	}
+
+	writeFunc := func(name string, value any) error {
+		return writeFunc(w.writeSeeker, name, value)
+	}
+
+	indexOffset := w.offset
+
+	// Section Index
+	// The file offsets, sizes and item counts of all compressed section chunks.
+	for i, entry := range w.sectionIndex {
+		if err := writeFunc(fmt.Sprintf("section type #%d", i), entry.Type); err != nil {
+			return err
+		}
+		if err := writeFunc(fmt.Sprintf("section offset #%d", i), entry.Offset); err != nil {
+			return err
+		}
+		if err := writeFunc(fmt.Sprintf("section compressed size #%d", i), entry.CompressedSize); err != nil {
+			return err
+		}
+		if err := writeFunc(fmt.Sprintf("section uncompressed size #%d", i), entry.UncompressedSize); err != nil {
+			return err
+		}
+		if err := writeFunc(fmt.Sprintf("section count #%d", i), entry.Count); err != nil {
+			return err
+		}
+	}
+
+	// Section Count
+	// The amount of entries in the section index.
+	if err := writeFunc("section count", uint32(len(w.sectionIndex))); err != nil {
+		return err
+	}
+
+	// Section Index Offset
+	// The file offset of the section index.
+	if err := writeFunc("section index offset", indexOffset); err != nil {
+		return err
+	}
+
+	// Magic
+	// Marks the end of a snapshot file with a section index.
+	return writeFunc("section index magic", sectionIndexMagic)
+}
+
+// compressedSectionReader reads the section chunks of a snapshot file with a section index.
+type compressedSectionReader struct {
+	reader       io.ReadSeeker
+	decoder      *zstd.Decoder
+	sectionIndex SectionIndex
+}
+
+func newCompressedSectionReader(reader io.ReadSeeker) (*compressedSectionReader, error) {
+
+	// remember the current position to seek back after the section index was read
+	position, err := reader.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get LS position: %w", err)
+	}
+
+	sectionIndex, err := ReadSectionIndex(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := reader.Seek(position, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to seek to LS position: %w", err)
+	}
+
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(sectionChunkMaxDecodedSize))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create LS section decoder: %w", err)
+	}
+
+	return &compressedSectionReader{
+		reader:       reader,
+		decoder:      decoder,
+		sectionIndex: sectionIndex,
+	}, nil
+}
+
+func (r *compressedSectionReader) close() {
+	r.decoder.Close()
+}
+
+// checkCount checks that the amount of items of the given section matches the expected count from the header.
+func (r *compressedSectionReader) checkCount(sectionType SectionType, expected uint64) error {
+	if count := r.sectionIndex.Count(sectionType); count != expected {
+		return errors.Wrapf(ErrInvalidSectionIndex, "%s count in section index does not match the header (%d != %d)", sectionType, count, expected)
+	}
+
+	return nil
+}
+
+// readChunk reads the compressed chunk of the given entry from the file.
+func (r *compressedSectionReader) readChunk(entry *SectionIndexEntry) ([]byte, error) {
+	if _, err := r.reader.Seek(entry.Offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("unable to seek to LS %s section chunk: %w", entry.Type, err)
+	}
+
+	compressed := make([]byte, entry.CompressedSize)
+	if _, err := io.ReadFull(r.reader, compressed); err != nil {
+		return nil, fmt.Errorf("unable to read LS %s section chunk: %w", entry.Type, err)
+	}
+
+	return compressed, nil
+}
+
+// decodeChunk decompresses the given chunk. It is safe to call this function concurrently.
+// The buffer is not preallocated from the size in the section index, since the index is untrusted input.
+func (r *compressedSectionReader) decodeChunk(entry *SectionIndexEntry, compressed []byte) (*bytes.Reader, error) {
+	data, err := r.decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decompress LS %s section chunk: %w", entry.Type, err)
+	}
+
+	if int64(len(data)) != entry.UncompressedSize {
+		return nil, errors.Wrapf(ErrInvalidSectionIndex, "uncompressed size of %s section chunk does not match (%d != %d)", entry.Type, len(data), entry.UncompressedSize)
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// readAndDecodeChunk reads and decompresses the chunk of the given entry.
+func (r *compressedSectionReader) readAndDecodeChunk(entry *SectionIndexEntry) (*bytes.Reader, error) {
+	compressed, err := r.readChunk(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.decodeChunk(entry, compressed)
+}
+
+// forEachItem reads and decompresses all chunks of the given section type
+// and calls the consumer for every item in the chunk.
+func (r *compressedSectionReader) forEachItem(sectionType SectionType, consumer func(chunkReader *bytes.Reader, pos uint64) (bool, error)) error {
+	var pos uint64
+	for _, entry := range r.sectionIndex.Entries(sectionType) {
+		chunkReader, err := r.readAndDecodeChunk(entry)
+		if err != nil {
+			return err
+		}
+
+		for i := uint64(0); i < entry.Count; i++ {
+			cont, err := consumer(chunkReader, pos)
+			if err != nil {
+				return err
+			}
+			if !cont {
+				return nil
+			}
+			pos++
+		}
+
+		if chunkReader.Len() != 0 {
+			return errors.Wrapf(ErrInvalidSectionIndex, "%s section chunk contains %d trailing bytes", sectionType, chunkReader.Len())
+		}
+	}
+
+	return nil
+}
+
+// readSolidEntryPoints reads all solid entry points and passes them to the consumer.
+func (r *compressedSectionReader) readSolidEntryPoints(targetMilestoneIndex iotago.MilestoneIndex, sepConsumer SEPConsumerFunc) error {
+	return r.forEachItem(SectionTypeSolidEntryPoints, func(chunkReader *bytes.Reader, pos uint64) (bool, error) {
+		solidEntryPointBlockID := iotago.BlockID{}
+		if _, err := io.ReadFull(chunkReader, solidEntryPointBlockID[:]); err != nil {
+			return false, fmt.Errorf("unable to read LS SEP at pos %d: %w", pos, err)
+		}
+		if err := sepConsumer(solidEntryPointBlockID, targetMilestoneIndex); err != nil {
+			return false, fmt.Errorf("SEP consumer error at pos %d: %w", pos, err)
+		}
+
+		return true, nil
+	})
+}
+
+// streamCompressedFullSnapshotSectionsFrom consumes the sections of a full snapshot with a section index.
+func streamCompressedFullSnapshotSectionsFrom(
+	reader io.ReadSeeker,
+	fullHeader *FullSnapshotHeader,
+	protoParams *iotago.ProtocolParameters,
+	protocolStorage *storage.ProtocolStorage,
+	outputsConsumer OutputsConsumerFunc,
+	msDiffConsumer MilestoneDiffConsumerFunc,
+	sepConsumer SEPConsumerFunc,
+	opts *ReadOptions) error {
+
+	sectionReader, err := newCompressedSectionReader(reader)
+	if err != nil {
+		return err
+	}
+	defer sectionReader.close()
+
+	if err := sectionReader.checkCount(SectionTypeOutputs, fullHeader.OutputCount); err != nil {
+		return err
+	}
+	if err := sectionReader.checkCount(SectionTypeMilestoneDiffs, uint64(fullHeader.MilestoneDiffCount)); err != nil {
+		return err
+	}
+	if err := sectionReader.checkCount(SectionTypeSolidEntryPoints, uint64(fullHeader.SEPCount)); err != nil {
+		return err
+	}
+
+	if err := sectionReader.readOutputs(protoParams, outputsConsumer, opts); err != nil {
+		return err
+	}
+
+	// we need to parse the milestone diffs twice.
+	// first round is to get the upcoming protocol parameter changes.
+	if err := sectionReader.forEachItem(SectionTypeMilestoneDiffs, func(chunkReader *bytes.Reader, pos uint64) (bool, error) {
+		if _, err := ReadMilestoneDiffProtocolParameters(chunkReader, protocolStorage); err != nil {
+			return false, fmt.Errorf("at pos %d: %w", pos, err)
+		}
+
+		return true, nil
+	}); err != nil {
+		return err
+	}
+
+	// second round is to load the milestone diffs with correct protocol parameters.
+	if err := sectionReader.forEachItem(SectionTypeMilestoneDiffs, func(chunkReader *bytes.Reader, pos uint64) (bool, error) {
+		// the milestone diffs in the full snapshot file are in backwards order.
+		_, msDiff, err := ReadMilestoneDiff(chunkReader, protocolStorage, false)
+		if err != nil {
+			return false, fmt.Errorf("at pos %d: %w", pos, err)
+		}
+
+		// we do not consume milestone diffs that are below the target milestone index.
+		// these additional milestone diffs are only used to get the protocol parameter updates.
+		if msDiff.Milestone.Index <= fullHeader.TargetMilestoneIndex {
+			// we can stop here since we are walking backwards.
+			return false, nil
+		}
+
+		if err := msDiffConsumer(msDiff); err != nil {
+			return false, fmt.Errorf("ms-diff consumer error at pos %d: %w", pos, err)
+		}
+
+		return true, nil
+	}); err != nil {
+		return err
+	}
+
+	return sectionReader.readSolidEntryPoints(fullHeader.TargetMilestoneIndex, sepConsumer)
+}
+
+// streamCompressedDeltaSnapshotSectionsFrom consumes the sections of a delta snapshot with a section index.
+func streamCompressedDeltaSnapshotSectionsFrom(
+	reader io.ReadSeeker,
+	deltaHeader *DeltaSnapshotHeader,
+	protocolStorage *storage.ProtocolStorage,
+	msDiffConsumer MilestoneDiffConsumerFunc,
+	sepConsumer SEPConsumerFunc) error {
+
+	sectionReader, err := newCompressedSectionReader(reader)
+	if err != nil {
+		return err
+	}
+	defer sectionReader.close()
+
+	if err := sectionReader.checkCount(SectionTypeOutputs, 0); err != nil {
+		return err
+	}
+	if err := sectionReader.checkCount(SectionTypeMilestoneDiffs, uint64(deltaHeader.MilestoneDiffCount)); err != nil {
+		return err
+	}
+	if err := sectionReader.checkCount(SectionTypeSolidEntryPoints, uint64(deltaHeader.SEPCount)); err != nil {
+		return err
+	}
+
+	if err := sectionReader.forEachItem(SectionTypeMilestoneDiffs, func(chunkReader *bytes.Reader, pos uint64) (bool, error) {
+		_, msDiff, err := ReadMilestoneDiff(chunkReader, protocolStorage, true)
+		if err != nil {
+			return false, fmt.Errorf("at pos %d: %w", pos, err)
+		}
+
+		if err := msDiffConsumer(msDiff); err != nil {
+			return false, fmt.Errorf("ms-diff consumer error at pos %d: %w", pos, err)
+		}
+
+		return true, nil
+	}); err != nil {
+		return err
+	}
+
+	return sectionReader.readSolidEntryPoints(deltaHeader.TargetMilestoneIndex, sepConsumer)
+}
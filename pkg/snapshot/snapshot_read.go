@@ -43,8 +43,8 @@ func newProtocolStorageGetterFunc(dbStorage *storage.Storage) ProtocolStorageGet
 // the given targetHeader is populated with the value of the read file header.
 func newFullHeaderConsumer(targetFullHeader *FullSnapshotHeader, dbStorage *storage.Storage, utxoManager *utxo.Manager, targetNetworkID ...uint64) FullHeaderConsumerFunc {
 	return func(header *FullSnapshotHeader) error {
-		if header.Version != SupportedFormatVersion {
-			return errors.Wrapf(ErrUnsupportedSnapshot, "snapshot file version is %d but this HORNET version only supports %v", header.Version, SupportedFormatVersion)
+		if !IsSupportedFormatVersion(header.Version) {
+			return errors.Wrapf(ErrUnsupportedSnapshot, "snapshot file version is %d but this HORNET version only supports %v", header.Version, []byte{FormatVersionRaw, FormatVersionCompressed})
 		}
 
 		if header.Type != Full {
@@ -76,8 +76,8 @@ func newFullHeaderConsumer(targetFullHeader *FullSnapshotHeader, dbStorage *stor
 // the given targetHeader is populated with the value of the read file header.
 func newDeltaHeaderConsumer(targetHeader *DeltaSnapshotHeader, utxoManager *utxo.Manager) DeltaHeaderConsumerFunc {
 	return func(header *DeltaSnapshotHeader) error {
-		if header.Version != SupportedFormatVersion {
-			return errors.Wrapf(ErrUnsupportedSnapshot, "snapshot file version is %d but this HORNET version only supports %v", header.Version, SupportedFormatVersion)
+		if !IsSupportedFormatVersion(header.Version) {
+			return errors.Wrapf(ErrUnsupportedSnapshot, "snapshot file version is %d but this HORNET version only supports %v", header.Version, []byte{FormatVersionRaw, FormatVersionCompressed})
 		}
 
 		if header.Type != Delta {
@@ -116,6 +116,11 @@ func NewOutputConsumer(utxoManager *utxo.Manager) OutputConsumerFunc {
 	return utxoManager.AddUnspentOutput
 }
 
+// returns an outputs consumer storing each batch of outputs into the database with a single batched write.
+func NewOutputsConsumer(utxoManager *utxo.Manager) OutputsConsumerFunc {
+	return utxoManager.AddUnspentOutputs
+}
+
 // returns a treasury output consumer which overrides an existing unspent treasury output with the new one.
 func NewUnspentTreasuryOutputConsumer(utxoManager *utxo.Manager) UnspentTreasuryOutputConsumerFunc {
 	// leave like this for now in case we need to do more in the future
@@ -192,7 +197,7 @@ func loadFullSnapshotFileToStorage(
 	fullHeader = &FullSnapshotHeader{}
 	fullHeaderConsumer := newFullHeaderConsumer(fullHeader, dbStorage, dbStorage.UTXOManager(), targetNetworkID)
 	treasuryOutputConsumer := NewUnspentTreasuryOutputConsumer(dbStorage.UTXOManager())
-	outputConsumer := NewOutputConsumer(dbStorage.UTXOManager())
+	outputsConsumer := NewOutputsConsumer(dbStorage.UTXOManager())
 	msDiffConsumer := NewMsDiffConsumer(dbStorage, dbStorage.UTXOManager(), writeMilestonesToStorage)
 	sepConsumer := newSEPsConsumer(dbStorage)
 	protocolParamsMilestoneOptConsumer := newProtocolParamsMilestoneOptConsumerFunc(dbStorage)
@@ -201,7 +206,7 @@ func loadFullSnapshotFileToStorage(
 		lsFile,
 		fullHeaderConsumer,
 		treasuryOutputConsumer,
-		outputConsumer,
+		outputsConsumer,
 		msDiffConsumer,
 		sepConsumer,
 		protocolParamsMilestoneOptConsumer); err != nil {
@@ -221,6 +226,10 @@ func loadFullSnapshotFileToStorage(
 		return nil, err
 	}
 
+	if err := dbStorage.UTXOManager().CheckLedgerStateHash(); err != nil {
+		return nil, err
+	}
+
 	var ledgerIndex iotago.MilestoneIndex
 	ledgerIndex, err = dbStorage.UTXOManager().ReadLedgerIndex()
 	if err != nil {
@@ -284,6 +293,10 @@ func loadDeltaSnapshotFileToStorage(
 		return nil, err
 	}
 
+	if err := dbStorage.UTXOManager().CheckLedgerStateHash(); err != nil {
+		return nil, err
+	}
+
 	var ledgerIndex iotago.MilestoneIndex
 	ledgerIndex, err = dbStorage.UTXOManager().ReadLedgerIndex()
 	if err != nil {
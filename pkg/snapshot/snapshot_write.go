@@ -348,7 +348,7 @@ func (s *Manager) createFullSnapshotWithoutLocking(
 	timeInit := time.Now()
 
 	fullHeader := &FullSnapshotHeader{
-		Version:                    SupportedFormatVersion,
+		Version:                    s.formatVersion,
 		Type:                       Full,
 		GenesisMilestoneIndex:      snapshotInfo.GenesisMilestoneIndex(),
 		TargetMilestoneIndex:       targetIndex,
@@ -482,7 +482,7 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 	}
 
 	deltaHeader := &DeltaSnapshotHeader{
-		Version:                       SupportedFormatVersion,
+		Version:                       s.formatVersion,
 		Type:                          Delta,
 		TargetMilestoneIndex:          targetIndex,
 		TargetMilestoneTimestamp:      targetMilestoneTimestamp,
@@ -495,6 +495,14 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 	_, err = os.Stat(s.snapshotDeltaPath)
 	deltaSnapshotFileExists := !os.IsNotExist(err)
 
+	var oldDeltaHeader *DeltaSnapshotHeader
+	if deltaSnapshotFileExists {
+		oldDeltaHeader, err = ReadDeltaSnapshotHeaderFromFile(s.snapshotDeltaPath)
+		if err != nil {
+			return fmt.Errorf("unable to read delta snapshot header: %w", err)
+		}
+	}
+
 	sepProducer := NewSEPsProducer(ctx, s.storage, targetIndex, s.solidEntryPointCheckThresholdPast)
 
 	var snapshotMetrics *SnapshotMetrics
@@ -503,12 +511,8 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 
 	// a delta snapshot contains the milestone diffs from a full snapshot's target index onwards.
 	// if the delta snapshot already exists, we can reuse the existing file and just append to it.
-	if deltaSnapshotFileExists {
-		oldDeltaHeader, err := ReadDeltaSnapshotHeaderFromFile(s.snapshotDeltaPath)
-		if err != nil {
-			return fmt.Errorf("unable to read delta snapshot header: %w", err)
-		}
-
+	// existing delta snapshot files of an older file format version are replaced by a new file.
+	if deltaSnapshotFileExists && oldDeltaHeader.Version == deltaHeader.Version {
 		// we stream the diff from the old delta header target index to the new target index
 		milestoneDiffProducer := NewMsDiffsProducer(MilestoneRetrieverFromStorage(s.storage), s.utxoManager, MsDiffDirectionOnwards, oldDeltaHeader.TargetMilestoneIndex, targetIndex)
 
@@ -579,7 +583,7 @@ func (s *Manager) createDeltaSnapshotWithoutLocking(ctx context.Context, targetI
 
 // creates a full snapshot file by streaming data from the database into a snapshot file.
 // this should only be used by MergeSnapshotFiles, otherwise the SEP indexes won't be correct.
-func createFullSnapshotFromMergedSnapshotStorageState(dbStorage *storage.Storage, filePath string) (*FullSnapshotHeader, error) {
+func createFullSnapshotFromMergedSnapshotStorageState(dbStorage *storage.Storage, filePath string, formatVersion byte) (*FullSnapshotHeader, error) {
 
 	snapshotInfo := dbStorage.SnapshotInfo()
 	if snapshotInfo == nil {
@@ -627,7 +631,7 @@ func createFullSnapshotFromMergedSnapshotStorageState(dbStorage *storage.Storage
 	}
 
 	fullHeader := &FullSnapshotHeader{
-		Version:                    SupportedFormatVersion,
+		Version:                    formatVersion,
 		Type:                       Full,
 		GenesisMilestoneIndex:      snapshotInfo.GenesisMilestoneIndex(),
 		TargetMilestoneIndex:       targetIndex,
@@ -707,7 +711,7 @@ func createFullSnapshotFromMergedSnapshotStorageState(dbStorage *storage.Storage
 	return fullHeader, nil
 }
 
-// CreateSnapshotFromStorage creates a snapshot file by streaming data from the database into a snapshot file.
+// CreateSnapshotFromStorage creates a snapshot file with the given file format version by streaming data from the database into a snapshot file.
 func CreateSnapshotFromStorage(
 	ctx context.Context,
 	dbStorage *storage.Storage,
@@ -716,6 +720,7 @@ func CreateSnapshotFromStorage(
 	targetIndex iotago.MilestoneIndex,
 	solidEntryPointCheckThresholdPast iotago.MilestoneIndex,
 	solidEntryPointCheckThresholdFuture iotago.MilestoneIndex,
+	formatVersion byte,
 ) (*FullSnapshotHeader, error) {
 
 	snapshotInfo := dbStorage.SnapshotInfo()
@@ -803,7 +808,7 @@ func CreateSnapshotFromStorage(
 	}
 
 	fullHeader := &FullSnapshotHeader{
-		Version:                    SupportedFormatVersion,
+		Version:                    formatVersion,
 		Type:                       Full,
 		GenesisMilestoneIndex:      snapshotInfo.GenesisMilestoneIndex(),
 		TargetMilestoneIndex:       targetIndex,
@@ -873,8 +878,8 @@ func CreateSnapshotFromStorage(
 // snapshot index of the specified delta snapshot. The target file does not include any milestone diffs
 // and the ledger and snapshot index are equal.
 // This function consumes disk space over memory by importing the full snapshot into a temporary database,
-// applying the delta diffs onto it and then writing out the merged state.
-func MergeSnapshotsFiles(fullPath string, deltaPath string, targetFileName string) (*MergeInfo, error) {
+// applying the delta diffs onto it and then writing out the merged state with the given file format version.
+func MergeSnapshotsFiles(fullPath string, deltaPath string, targetFileName string, formatVersion byte) (*MergeInfo, error) {
 
 	targetEngine, err := database.DatabaseEngineAllowed(database.EnginePebble)
 	if err != nil {
@@ -920,7 +925,7 @@ func MergeSnapshotsFiles(fullPath string, deltaPath string, targetFileName strin
 		return nil, err
 	}
 
-	mergedSnapshotHeader, err := createFullSnapshotFromMergedSnapshotStorageState(dbStorage, targetFileName)
+	mergedSnapshotHeader, err := createFullSnapshotFromMergedSnapshotStorageState(dbStorage, targetFileName, formatVersion)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,224 @@
+package snapshot_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/v2/pkg/snapshot"
+)
+
+// droppingResponseWriter drops the connection after the given amount of bytes was written.
+type droppingResponseWriter struct {
+	http.ResponseWriter
+	remaining int
+}
+
+func (w *droppingResponseWriter) Write(p []byte) (int, error) {
+	if len(p) > w.remaining {
+		_, _ = w.ResponseWriter.Write(p[:w.remaining])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.remaining -= len(p)
+
+	return w.ResponseWriter.Write(p)
+}
+
+// snapshotServer serves a file with support for range requests.
+// If dropAfter is bigger than zero, the connection is dropped after dropAfter bytes of each response.
+// If etag is given, it is announced as the ETag of the file.
+type snapshotServer struct {
+	*httptest.Server
+
+	sync.Mutex
+	ranges []string
+}
+
+func newSnapshotServer(data []byte, dropAfter int, etag string) *snapshotServer {
+	server := &snapshotServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.Lock()
+		server.ranges = append(server.ranges, r.Header.Get("Range"))
+		server.Unlock()
+
+		if etag != "" {
+			w.Header().Set("ETag", etag)
+		}
+		if dropAfter > 0 {
+			w = &droppingResponseWriter{ResponseWriter: w, remaining: dropAfter}
+		}
+		http.ServeContent(w, r, "full_snapshot.bin", time.Time{}, bytes.NewReader(data))
+	}))
+
+	return server
+}
+
+func (s *snapshotServer) rangeRequests() []string {
+	s.Lock()
+	defer s.Unlock()
+
+	var ranges []string
+	for _, r := range s.ranges {
+		if r != "" {
+			ranges = append(ranges, r)
+		}
+	}
+
+	return ranges
+}
+
+func randFullSnapshotFileData(t *testing.T) ([]byte, uint64) {
+	header := randFullSnapshotHeader(500, 0, 10)
+
+	outputGenerator, _ := newOutputsGenerator(header.OutputCount)
+	msDiffGenerator, _ := newMsDiffGenerator(header.TargetMilestoneIndex, header.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+	sepGenerator, _ := newSEPGenerator(header.SEPCount)
+
+	filePath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+	snapshotFile, err := os.Create(filePath)
+	require.NoError(t, err)
+	_, err = snapshot.StreamFullSnapshotDataTo(snapshotFile, header, outputGenerator, msDiffGenerator, sepGenerator)
+	require.NoError(t, err)
+	require.NoError(t, snapshotFile.Close())
+
+	data, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	protoParams, err := header.ProtocolParameters()
+	require.NoError(t, err)
+
+	return data, protoParams.NetworkID()
+}
+
+func sha256Hex(data []byte) string {
+	checksum := sha256.Sum256(data)
+	return hex.EncodeToString(checksum[:])
+}
+
+func TestDownloadSnapshotFilesFailover(t *testing.T) {
+	data, networkID := randFullSnapshotFileData(t)
+	otherData, _ := randFullSnapshotFileData(t)
+
+	flakyServer := newSnapshotServer(data, len(data)/3, "")
+	defer flakyServer.Close()
+	mirrorServer := newSnapshotServer(data, 0, "")
+	defer mirrorServer.Close()
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+	// a partial download of another snapshot file needs to be discarded
+	require.NoError(t, os.WriteFile(fullPath+".part", otherData[:len(otherData)/2], 0600))
+
+	importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, "", "", "", nil)
+	require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), networkID, fullPath, "", []*snapshot.DownloadTarget{
+		{Full: flakyServer.URL},
+		{Full: mirrorServer.URL, FullSHA256: sha256Hex(data)},
+	}))
+
+	downloaded, err := os.ReadFile(fullPath)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+	require.NoFileExists(t, fullPath+".part")
+
+	// the download was started on the flaky server and resumed on the mirror
+	require.Empty(t, flakyServer.rangeRequests())
+	require.NotEmpty(t, mirrorServer.rangeRequests())
+}
+
+func TestDownloadSnapshotFilesFailoverWithoutChecksum(t *testing.T) {
+	data, networkID := randFullSnapshotFileData(t)
+
+	flakyServer := newSnapshotServer(data, len(data)/3, "")
+	defer flakyServer.Close()
+	mirrorServer := newSnapshotServer(data, 0, "")
+	defer mirrorServer.Close()
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+	importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, "", "", "", nil)
+	require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), networkID, fullPath, "", []*snapshot.DownloadTarget{
+		{Full: flakyServer.URL},
+		{Full: mirrorServer.URL},
+	}))
+
+	downloaded, err := os.ReadFile(fullPath)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+
+	// the data of the flaky server can't be verified, so the mirror restarted the download from zero
+	require.Empty(t, mirrorServer.rangeRequests())
+}
+
+func TestDownloadSnapshotFilesFailoverSameETag(t *testing.T) {
+	data, networkID := randFullSnapshotFileData(t)
+
+	flakyServer := newSnapshotServer(data, len(data)/3, `"snapshot"`)
+	defer flakyServer.Close()
+	mirrorServer := newSnapshotServer(data, 0, `"snapshot"`)
+	defer mirrorServer.Close()
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+	importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, "", "", "", nil)
+	require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), networkID, fullPath, "", []*snapshot.DownloadTarget{
+		{Full: flakyServer.URL},
+		{Full: mirrorServer.URL},
+	}))
+
+	downloaded, err := os.ReadFile(fullPath)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+
+	// the mirror offers the file with the same ETag, so the download was resumed
+	require.NotEmpty(t, mirrorServer.rangeRequests())
+}
+
+func TestDownloadSnapshotFilesResume(t *testing.T) {
+	data, networkID := randFullSnapshotFileData(t)
+
+	flakyServer := newSnapshotServer(data, len(data)/4, "")
+	defer flakyServer.Close()
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+	importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, "", "", "", nil)
+	require.NoError(t, importer.DownloadSnapshotFiles(context.Background(), networkID, fullPath, "", []*snapshot.DownloadTarget{
+		{Full: flakyServer.URL, FullSHA256: sha256Hex(data)},
+	}))
+
+	downloaded, err := os.ReadFile(fullPath)
+	require.NoError(t, err)
+	require.Equal(t, data, downloaded)
+
+	// the download was resumed from the same server several times
+	require.GreaterOrEqual(t, len(flakyServer.rangeRequests()), 3)
+}
+
+func TestDownloadSnapshotFilesChecksumMismatch(t *testing.T) {
+	data, networkID := randFullSnapshotFileData(t)
+
+	server := newSnapshotServer(data, 0, "")
+	defer server.Close()
+
+	fullPath := filepath.Join(t.TempDir(), "full_snapshot.bin")
+
+	importer := snapshot.NewSnapshotImporter(logger.NewNopLogger(), nil, "", "", "", nil)
+	err := importer.DownloadSnapshotFiles(context.Background(), networkID, fullPath, "", []*snapshot.DownloadTarget{
+		{Full: server.URL, FullSHA256: sha256Hex(data[1:])},
+	})
+	require.ErrorIs(t, err, snapshot.ErrSnapshotDownloadNoValidSource)
+	require.NoFileExists(t, fullPath)
+	require.NoFileExists(t, fullPath+".part")
+}
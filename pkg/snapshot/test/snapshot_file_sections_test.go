@@ -0,0 +1,80 @@
+package snapshot_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/snapshot"
+)
+
+func writeFullSnapshotFile(t testing.TB, filePath string, header *snapshot.FullSnapshotHeader) {
+	outputIterFunc, _ := newOutputsGenerator(header.OutputCount)
+	msDiffIterFunc, _ := newMsDiffGenerator(header.TargetMilestoneIndex, header.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+	sepIterFunc, _ := newSEPGenerator(header.SEPCount)
+
+	snapshotFile, err := os.Create(filePath)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, snapshotFile.Close()) }()
+
+	_, err = snapshot.StreamFullSnapshotDataTo(snapshotFile, header, outputIterFunc, msDiffIterFunc, sepIterFunc)
+	require.NoError(t, err)
+}
+
+func TestSnapshotSectionIndex(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+
+	tempDir := t.TempDir()
+
+	// snapshot files are only compressed if enabled explicitly, so older nodes and tools can read them
+	require.Equal(t, snapshot.FormatVersionRaw, snapshot.FormatVersion(false))
+	require.Equal(t, snapshot.FormatVersionCompressed, snapshot.FormatVersion(true))
+
+	fullHeader := randFullSnapshotHeader(100000, 50, 150)
+	fullHeader.Version = snapshot.FormatVersionCompressed
+	compressedFilePath := filepath.Join(tempDir, "full_snapshot_compressed.bin")
+	writeFullSnapshotFile(t, compressedFilePath, fullHeader)
+
+	sectionIndex, err := snapshot.ReadSectionIndexFromFile(compressedFilePath)
+	require.NoError(t, err)
+
+	// the counts in the section index match the header
+	require.Equal(t, fullHeader.OutputCount, sectionIndex.Count(snapshot.SectionTypeOutputs))
+	require.Equal(t, uint64(fullHeader.MilestoneDiffCount), sectionIndex.Count(snapshot.SectionTypeMilestoneDiffs))
+	require.Equal(t, uint64(fullHeader.SEPCount), sectionIndex.Count(snapshot.SectionTypeSolidEntryPoints))
+
+	// the outputs are split into several chunks, so they can be decoded in parallel
+	require.Greater(t, len(sectionIndex.Entries(snapshot.SectionTypeOutputs)), 1)
+	require.Less(t, sectionIndex.CompressedSize(snapshot.SectionTypeOutputs), sectionIndex.UncompressedSize(snapshot.SectionTypeOutputs))
+
+	// the chunks are stored in order without gaps
+	for i := 1; i < len(sectionIndex); i++ {
+		require.Equal(t, sectionIndex[i-1].Offset+sectionIndex[i-1].CompressedSize, sectionIndex[i].Offset)
+	}
+
+	// snapshot files in the raw format contain no section index
+	rawFullHeader := randFullSnapshotHeader(1000, 50, 150)
+	rawFilePath := filepath.Join(tempDir, "full_snapshot_raw.bin")
+	writeFullSnapshotFile(t, rawFilePath, rawFullHeader)
+
+	_, err = snapshot.ReadSectionIndexFromFile(rawFilePath)
+	require.ErrorIs(t, err, snapshot.ErrNoSectionIndex)
+
+	// a corrupted footer is detected
+	snapshotFile, err := os.OpenFile(compressedFilePath, os.O_RDWR, 0666)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, snapshotFile.Close()) }()
+
+	_, err = snapshotFile.Seek(-1, io.SeekEnd)
+	require.NoError(t, err)
+	_, err = snapshotFile.Write([]byte{0})
+	require.NoError(t, err)
+
+	_, err = snapshot.ReadSectionIndex(snapshotFile)
+	require.ErrorIs(t, err, snapshot.ErrInvalidSectionIndex)
+}
@@ -206,7 +206,7 @@ func TestStreamFullSnapshotDataToAndFrom(t *testing.T) {
 		unspentTreasuryOutputConsumer snapshot.UnspentTreasuryOutputConsumerFunc
 		outputGenerator               snapshot.OutputProducerFunc
 		outputGenRetriever            outputRetrieverFunc
-		outputConsumer                snapshot.OutputConsumerFunc
+		outputConsumer                snapshot.OutputsConsumerFunc
 		outputConRetriever            outputRetrieverFunc
 		msDiffGenerator               snapshot.MilestoneDiffProducerFunc
 		msDiffGenRetriever            msDiffRetrieverFunc
@@ -219,44 +219,48 @@ func TestStreamFullSnapshotDataToAndFrom(t *testing.T) {
 		protoParamsMsOptionsConsumer  snapshot.ProtocolParamsMilestoneOptConsumerFunc
 	}
 
-	testCases := []test{
-		func() test {
-			originFullHeader := randFullSnapshotHeader(1000000, 50, 150)
-
-			// create generators and consumers
-			outputIterFunc, outputGenRetriever := newOutputsGenerator(originFullHeader.OutputCount)
-			outputConsumerFunc, outputCollRetriever := newOutputCollector()
-
-			msDiffIterFunc, msDiffGenRetriever := newMsDiffGenerator(originFullHeader.TargetMilestoneIndex, originFullHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
-			msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
-
-			sepIterFunc, sepGenRetriever := newSEPGenerator(originFullHeader.SEPCount)
-			sepConsumerFunc, sepsCollRetriever := newSEPCollector()
-
-			protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+	newTestCase := func(name string, version byte, outputCount uint64) test {
+		originFullHeader := randFullSnapshotHeader(outputCount, 50, 150)
+		originFullHeader.Version = version
+
+		// create generators and consumers
+		outputIterFunc, outputGenRetriever := newOutputsGenerator(originFullHeader.OutputCount)
+		outputConsumerFunc, outputCollRetriever := newOutputCollector()
+
+		msDiffIterFunc, msDiffGenRetriever := newMsDiffGenerator(originFullHeader.TargetMilestoneIndex, originFullHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+		msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
+
+		sepIterFunc, sepGenRetriever := newSEPGenerator(originFullHeader.SEPCount)
+		sepConsumerFunc, sepsCollRetriever := newSEPCollector()
+
+		protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+
+		t := test{
+			name:                          name,
+			snapshotFileName:              "full_snapshot.bin",
+			originFullHeader:              originFullHeader,
+			fullHeaderConsumer:            fullHeaderEqualFunc(t, originFullHeader),
+			unspentTreasuryOutputConsumer: unspentTreasuryOutputEqualFunc(t, originFullHeader.TreasuryOutput),
+			outputGenerator:               outputIterFunc,
+			outputGenRetriever:            outputGenRetriever,
+			outputConsumer:                outputConsumerFunc,
+			outputConRetriever:            outputCollRetriever,
+			msDiffGenerator:               msDiffIterFunc,
+			msDiffGenRetriever:            msDiffGenRetriever,
+			msDiffConsumer:                msDiffConsumerFunc,
+			msDiffConRetriever:            msDiffCollRetriever,
+			sepGenerator:                  sepIterFunc,
+			sepGenRetriever:               sepGenRetriever,
+			sepConsumer:                   sepConsumerFunc,
+			sepConRetriever:               sepsCollRetriever,
+			protoParamsMsOptionsConsumer:  protoParamsMsOptionsConsumerFunc,
+		}
+		return t
+	}
 
-			t := test{
-				name:                          "full: 150 seps, 1 mil outputs, 50 ms diffs",
-				snapshotFileName:              "full_snapshot.bin",
-				originFullHeader:              originFullHeader,
-				fullHeaderConsumer:            fullHeaderEqualFunc(t, originFullHeader),
-				unspentTreasuryOutputConsumer: unspentTreasuryOutputEqualFunc(t, originFullHeader.TreasuryOutput),
-				outputGenerator:               outputIterFunc,
-				outputGenRetriever:            outputGenRetriever,
-				outputConsumer:                outputConsumerFunc,
-				outputConRetriever:            outputCollRetriever,
-				msDiffGenerator:               msDiffIterFunc,
-				msDiffGenRetriever:            msDiffGenRetriever,
-				msDiffConsumer:                msDiffConsumerFunc,
-				msDiffConRetriever:            msDiffCollRetriever,
-				sepGenerator:                  sepIterFunc,
-				sepGenRetriever:               sepGenRetriever,
-				sepConsumer:                   sepConsumerFunc,
-				sepConRetriever:               sepsCollRetriever,
-				protoParamsMsOptionsConsumer:  protoParamsMsOptionsConsumerFunc,
-			}
-			return t
-		}(),
+	testCases := []test{
+		newTestCase("full: 150 seps, 1 mil outputs, 50 ms diffs", snapshot.FormatVersionCompressed, 1000000),
+		newTestCase("full (raw format): 150 seps, 100k outputs, 50 ms diffs", snapshot.FormatVersionRaw, 100000),
 	}
 
 	for _, tt := range testCases {
@@ -326,36 +330,40 @@ func TestStreamDeltaSnapshotDataToAndFrom(t *testing.T) {
 		protoParamsMsOptionsConsumer snapshot.ProtocolParamsMilestoneOptConsumerFunc
 	}
 
-	testCases := []test{
-		func() test {
-			originDeltaHeader := randDeltaSnapshotHeader(50, 150)
-
-			// create generators and consumers
-			msDiffIterFunc, msDiffGenRetriever := newMsDiffGenerator(originDeltaHeader.TargetMilestoneIndex-syncmanager.MilestoneIndexDelta(originDeltaHeader.MilestoneDiffCount), originDeltaHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
-			msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
-
-			sepIterFunc, sepGenRetriever := newSEPGenerator(originDeltaHeader.SEPCount)
-			sepConsumerFunc, sepsCollRetriever := newSEPCollector()
-
-			protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+	newTestCase := func(name string, version byte) test {
+		originDeltaHeader := randDeltaSnapshotHeader(50, 150)
+		originDeltaHeader.Version = version
+
+		// create generators and consumers
+		msDiffIterFunc, msDiffGenRetriever := newMsDiffGenerator(originDeltaHeader.TargetMilestoneIndex-syncmanager.MilestoneIndexDelta(originDeltaHeader.MilestoneDiffCount), originDeltaHeader.MilestoneDiffCount, snapshot.MsDiffDirectionOnwards)
+		msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
+
+		sepIterFunc, sepGenRetriever := newSEPGenerator(originDeltaHeader.SEPCount)
+		sepConsumerFunc, sepsCollRetriever := newSEPCollector()
+
+		protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+
+		t := test{
+			name:                         name,
+			snapshotFileName:             "delta_snapshot.bin",
+			originDeltaHeader:            originDeltaHeader,
+			deltaHeaderConsumer:          deltaHeaderEqualFunc(t, originDeltaHeader),
+			msDiffGenerator:              msDiffIterFunc,
+			msDiffGenRetriever:           msDiffGenRetriever,
+			msDiffConsumer:               msDiffConsumerFunc,
+			msDiffConRetriever:           msDiffCollRetriever,
+			sepGenerator:                 sepIterFunc,
+			sepGenRetriever:              sepGenRetriever,
+			sepConsumer:                  sepConsumerFunc,
+			sepConRetriever:              sepsCollRetriever,
+			protoParamsMsOptionsConsumer: protoParamsMsOptionsConsumerFunc,
+		}
+		return t
+	}
 
-			t := test{
-				name:                         "delta: 150 seps, 50 ms diffs",
-				snapshotFileName:             "delta_snapshot.bin",
-				originDeltaHeader:            originDeltaHeader,
-				deltaHeaderConsumer:          deltaHeaderEqualFunc(t, originDeltaHeader),
-				msDiffGenerator:              msDiffIterFunc,
-				msDiffGenRetriever:           msDiffGenRetriever,
-				msDiffConsumer:               msDiffConsumerFunc,
-				msDiffConRetriever:           msDiffCollRetriever,
-				sepGenerator:                 sepIterFunc,
-				sepGenRetriever:              sepGenRetriever,
-				sepConsumer:                  sepConsumerFunc,
-				sepConRetriever:              sepsCollRetriever,
-				protoParamsMsOptionsConsumer: protoParamsMsOptionsConsumerFunc,
-			}
-			return t
-		}(),
+	testCases := []test{
+		newTestCase("delta: 150 seps, 50 ms diffs", snapshot.FormatVersionCompressed),
+		newTestCase("delta (raw format): 150 seps, 50 ms diffs", snapshot.FormatVersionRaw),
 	}
 
 	for _, tt := range testCases {
@@ -417,33 +425,37 @@ func TestStreamDeltaSnapshotDataToExistingAndFrom(t *testing.T) {
 		protoParamsMsOptionsConsumer  snapshot.ProtocolParamsMilestoneOptConsumerFunc
 	}
 
-	testCases := []test{
-		func() test {
-			originDeltaHeader := randDeltaSnapshotHeader(50, 150)
-
-			// create generators and consumers
-			snapshotExtensionGenerator, snapshotExtensionGenRetriever := newDeltaSnapshotExtensionGenerator(originDeltaHeader, 10, 50, 30)
-
-			msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
-			sepConsumerFunc, sepsCollRetriever := newSEPCollector()
-
-			protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+	newTestCase := func(name string, version byte) test {
+		originDeltaHeader := randDeltaSnapshotHeader(50, 150)
+		originDeltaHeader.Version = version
+
+		// create generators and consumers
+		snapshotExtensionGenerator, snapshotExtensionGenRetriever := newDeltaSnapshotExtensionGenerator(originDeltaHeader, 10, 50, 30)
+
+		msDiffConsumerFunc, msDiffCollRetriever := newMsDiffCollector()
+		sepConsumerFunc, sepsCollRetriever := newSEPCollector()
+
+		protoParamsMsOptionsConsumerFunc := newProtocolParamsMilestoneOptConsumerFunc()
+
+		t := test{
+			name:                          name,
+			snapshotFileName:              "delta_snapshot.bin",
+			originDeltaHeader:             originDeltaHeader,
+			deltaHeaderConsumer:           deltaHeaderEqualFunc(t, originDeltaHeader),
+			snapshotExtensionGenerator:    snapshotExtensionGenerator,
+			snapshotExtensionGenRetriever: snapshotExtensionGenRetriever,
+			msDiffConsumer:                msDiffConsumerFunc,
+			msDiffConRetriever:            msDiffCollRetriever,
+			sepConsumer:                   sepConsumerFunc,
+			sepConRetriever:               sepsCollRetriever,
+			protoParamsMsOptionsConsumer:  protoParamsMsOptionsConsumerFunc,
+		}
+		return t
+	}
 
-			t := test{
-				name:                          "delta: 150 seps, 50 ms diffs",
-				snapshotFileName:              "delta_snapshot.bin",
-				originDeltaHeader:             originDeltaHeader,
-				deltaHeaderConsumer:           deltaHeaderEqualFunc(t, originDeltaHeader),
-				snapshotExtensionGenerator:    snapshotExtensionGenerator,
-				snapshotExtensionGenRetriever: snapshotExtensionGenRetriever,
-				msDiffConsumer:                msDiffConsumerFunc,
-				msDiffConRetriever:            msDiffCollRetriever,
-				sepConsumer:                   sepConsumerFunc,
-				sepConRetriever:               sepsCollRetriever,
-				protoParamsMsOptionsConsumer:  protoParamsMsOptionsConsumerFunc,
-			}
-			return t
-		}(),
+	testCases := []test{
+		newTestCase("delta: 150 seps, 50 ms diffs", snapshot.FormatVersionCompressed),
+		newTestCase("delta (raw format): 150 seps, 50 ms diffs", snapshot.FormatVersionRaw),
 	}
 
 	for _, tt := range testCases {
@@ -479,7 +491,7 @@ func TestStreamDeltaSnapshotDataToExistingAndFrom(t *testing.T) {
 				tt.originDeltaHeader.TargetMilestoneTimestamp += 1
 
 				// extend the existing delta snapshot file
-				snapshot.StreamDeltaSnapshotDataToExisting(snapshotFileWrite, tt.originDeltaHeader, msDiffGen, sepGen)
+				_, err = snapshot.StreamDeltaSnapshotDataToExisting(snapshotFileWrite, tt.originDeltaHeader, msDiffGen, sepGen)
 				require.NoError(t, err)
 				require.NoError(t, snapshotFileWrite.Close())
 			}
@@ -570,10 +582,10 @@ func newOutputsGenerator(count uint64) (snapshot.OutputProducerFunc, outputRetri
 		}
 }
 
-func newOutputCollector() (snapshot.OutputConsumerFunc, outputRetrieverFunc) {
+func newOutputCollector() (snapshot.OutputsConsumerFunc, outputRetrieverFunc) {
 	var generatedOutputs utxo.Outputs
-	return func(o *utxo.Output) error {
-			generatedOutputs = append(generatedOutputs, o)
+	return func(outputs utxo.Outputs) error {
+			generatedOutputs = append(generatedOutputs, outputs...)
 			return nil
 		}, func() utxo.Outputs {
 			return generatedOutputs
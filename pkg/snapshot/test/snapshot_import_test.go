@@ -0,0 +1,91 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/kvstore/mapdb"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/snapshot"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+// importFullSnapshotFile imports the outputs of the given full snapshot file into a new ledger
+// and checks the final ledger state hash.
+func importFullSnapshotFile(tb testing.TB, filePath string, opts ...snapshot.ReadOption) *utxo.Manager {
+	utxoManager := utxo.New(mapdb.NewMapDB())
+
+	// start with the ledger state hash of an empty ledger
+	require.NoError(tb, utxoManager.ClearLedger(true))
+
+	snapshotFile, err := os.Open(filePath)
+	require.NoError(tb, err)
+	defer func() { require.NoError(tb, snapshotFile.Close()) }()
+
+	require.NoError(tb, snapshot.StreamFullSnapshotDataFrom(
+		snapshotFile,
+		func(header *snapshot.FullSnapshotHeader) error { return nil },
+		func(output *utxo.TreasuryOutput) error { return nil },
+		snapshot.NewOutputsConsumer(utxoManager),
+		func(milestoneDiff *snapshot.MilestoneDiff) error { return nil },
+		func(blockID iotago.BlockID, targetMilestoneIndex iotago.MilestoneIndex) error { return nil },
+		newProtocolParamsMilestoneOptConsumerFunc(),
+		opts...,
+	))
+
+	require.NoError(tb, utxoManager.CheckLedgerStateHash())
+
+	return utxoManager
+}
+
+func TestStreamFullSnapshotDataFromParallel(t *testing.T) {
+	if testing.Short() {
+		return
+	}
+
+	tempDir := t.TempDir()
+
+	for _, version := range []byte{snapshot.FormatVersionRaw, snapshot.FormatVersionCompressed} {
+		fullHeader := randFullSnapshotHeader(20000, 10, 10)
+		fullHeader.Version = version
+		filePath := filepath.Join(tempDir, fmt.Sprintf("full_snapshot_v%d.bin", version))
+		writeFullSnapshotFile(t, filePath, fullHeader)
+
+		// the ledger state hash must not depend on the amount of decoding workers
+		sequentialUTXOManager := importFullSnapshotFile(t, filePath, snapshot.WithDecodingWorkerCount(1))
+		parallelUTXOManager := importFullSnapshotFile(t, filePath, snapshot.WithDecodingWorkerCount(8))
+
+		_, sequentialLedgerStateHash, err := sequentialUTXOManager.LedgerStateHash()
+		require.NoError(t, err)
+		_, parallelLedgerStateHash, err := parallelUTXOManager.LedgerStateHash()
+		require.NoError(t, err)
+		require.Equal(t, sequentialLedgerStateHash, parallelLedgerStateHash)
+
+		unspentOutputs, err := parallelUTXOManager.UnspentOutputs()
+		require.NoError(t, err)
+		require.Len(t, unspentOutputs, int(fullHeader.OutputCount))
+	}
+}
+
+func BenchmarkStreamFullSnapshotDataFrom(b *testing.B) {
+	tempDir := b.TempDir()
+
+	for _, version := range []byte{snapshot.FormatVersionRaw, snapshot.FormatVersionCompressed} {
+		fullHeader := randFullSnapshotHeader(100000, 0, 1)
+		fullHeader.Version = version
+		filePath := filepath.Join(tempDir, fmt.Sprintf("full_snapshot_v%d.bin", version))
+		writeFullSnapshotFile(b, filePath, fullHeader)
+
+		for _, workerCount := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("v%d/workers=%d", version, workerCount), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					importFullSnapshotFile(b, filePath, snapshot.WithDecodingWorkerCount(workerCount))
+				}
+			})
+		}
+	}
+}
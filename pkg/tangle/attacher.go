@@ -26,6 +26,7 @@ type BlockAttacherOption func(opts *BlockAttacherOptions)
 type BlockAttacherOptions struct {
 	tipSelFunc            pow.RefreshTipsFunc
 	blockProcessedTimeout time.Duration
+	maxReferencedWaitTime time.Duration
 
 	powHandler     *pow.Handler
 	powWorkerCount int
@@ -36,6 +37,7 @@ func attacherOptions(opts []BlockAttacherOption) *BlockAttacherOptions {
 	result := &BlockAttacherOptions{
 		tipSelFunc:            nil,
 		blockProcessedTimeout: 100 * time.Second,
+		maxReferencedWaitTime: 1 * time.Minute,
 		powHandler:            nil,
 		powWorkerCount:        0,
 	}
@@ -52,6 +54,12 @@ func WithTimeout(blockProcessedTimeout time.Duration) BlockAttacherOption {
 	}
 }
 
+func WithMaxReferencedWaitTime(maxReferencedWaitTime time.Duration) BlockAttacherOption {
+	return func(opts *BlockAttacherOptions) {
+		opts.maxReferencedWaitTime = maxReferencedWaitTime
+	}
+}
+
 func WithTipSel(tipsFunc pow.RefreshTipsFunc) BlockAttacherOption {
 	return func(opts *BlockAttacherOptions) {
 		opts.tipSelFunc = tipsFunc
@@ -162,3 +170,28 @@ func (a *BlockAttacher) AttachBlock(ctx context.Context, iotaBlock *iotago.Block
 
 	return block.BlockID(), nil
 }
+
+// AttachBlockAndWaitReferenced attaches the block and waits until it is referenced by a milestone.
+// The wait time is limited by the maximum referenced wait time of the attacher.
+// If the block was attached, but not referenced in time, the block ID is returned together with ErrBlockNotReferencedInTime.
+func (a *BlockAttacher) AttachBlockAndWaitReferenced(ctx context.Context, iotaBlock *iotago.Block, waitTime time.Duration) (iotago.BlockID, *BlockReferencedResult, error) {
+
+	blockID, err := a.AttachBlock(ctx, iotaBlock)
+	if err != nil {
+		return iotago.EmptyBlockID(), nil, err
+	}
+
+	if waitTime > a.opts.maxReferencedWaitTime {
+		waitTime = a.opts.maxReferencedWaitTime
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, waitTime)
+	defer waitCancel()
+
+	result, err := a.tangle.WaitForBlockReferenced(waitCtx, blockID)
+	if err != nil {
+		return blockID, nil, err
+	}
+
+	return blockID, result, nil
+}
@@ -0,0 +1,234 @@
+package tangle
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/syncutils"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// the maximum amount of callers waiting for blocks to be referenced at the same time.
+	maxBlockReferencedWaiters = 10000
+)
+
+var (
+	// ErrBlockNotReferencedInTime is returned if a block was not referenced by a milestone before the context was done.
+	ErrBlockNotReferencedInTime = errors.New("block was not referenced by a milestone in time")
+	// ErrBlockReferencedWaitersLimitReached is returned if too many callers are already waiting for blocks to be referenced.
+	ErrBlockReferencedWaitersLimitReached = errors.New("limit of callers waiting for referenced blocks reached")
+)
+
+// BlockReferencedResult contains the information about a block that was referenced by a milestone.
+type BlockReferencedResult struct {
+	// The ID of the block.
+	BlockID iotago.BlockID
+	// The milestone index that references the block.
+	MilestoneIndex iotago.MilestoneIndex
+	// The index of the block inside the milestone by white-flag ordering.
+	WhiteFlagIndex uint32
+	// Whether the transaction of the block was included in the ledger.
+	IncludedInLedger bool
+	// The reason why the transaction of the block is conflicting.
+	Conflict storage.Conflict
+}
+
+func newBlockReferencedResult(metadata *storage.BlockMetadata) *BlockReferencedResult {
+	referenced, msIndex, wfIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
+	if !referenced {
+		return nil
+	}
+
+	return &BlockReferencedResult{
+		BlockID:          metadata.BlockID(),
+		MilestoneIndex:   msIndex,
+		WhiteFlagIndex:   wfIndex,
+		IncludedInLedger: metadata.IsIncludedTxInLedger(),
+		Conflict:         metadata.Conflict(),
+	}
+}
+
+// blockReferencedResult returns the result of the block if it is already referenced by a milestone.
+func (t *Tangle) blockReferencedResult(blockID iotago.BlockID) *BlockReferencedResult {
+	cachedBlockMeta := t.storage.CachedBlockMetadataOrNil(blockID) // meta +1
+	if cachedBlockMeta == nil {
+		return nil
+	}
+	defer cachedBlockMeta.Release(true) // meta -1
+
+	return newBlockReferencedResult(cachedBlockMeta.Metadata())
+}
+
+// blockReferencedWaiter contains the callers waiting for a single block to be referenced.
+type blockReferencedWaiter struct {
+	// the result of the block, set as soon as the block was referenced by a milestone.
+	referenced *BlockReferencedResult
+	// the channels of the callers that receive the result after the confirmation of the milestone is finished.
+	resultChans []chan *BlockReferencedResult
+}
+
+// blockReferencedWaiters keeps track of all callers waiting for blocks to be referenced.
+// A single subscriber on the tangle events notifies all of them.
+type blockReferencedWaiters struct {
+	syncutils.Mutex
+
+	// the maximum amount of callers waiting at the same time.
+	maxWaiters int
+	// the amount of callers currently waiting.
+	count int
+	// the waiters by block ID.
+	waiters map[iotago.BlockID]*blockReferencedWaiter
+}
+
+func newBlockReferencedWaiters(maxWaiters int) *blockReferencedWaiters {
+	return &blockReferencedWaiters{
+		maxWaiters: maxWaiters,
+		waiters:    make(map[iotago.BlockID]*blockReferencedWaiter),
+	}
+}
+
+// register adds a result channel for the given block.
+// referenced is set if the block was already referenced, but the confirmation of the milestone is not finished yet.
+// the caller needs to hold the lock.
+func (w *blockReferencedWaiters) register(blockID iotago.BlockID, referenced *BlockReferencedResult) (chan *BlockReferencedResult, error) {
+	if w.count >= w.maxWaiters {
+		return nil, ErrBlockReferencedWaitersLimitReached
+	}
+
+	waiter, exists := w.waiters[blockID]
+	if !exists {
+		waiter = &blockReferencedWaiter{}
+		w.waiters[blockID] = waiter
+	}
+
+	if referenced != nil {
+		waiter.referenced = referenced
+	}
+
+	resultChan := make(chan *BlockReferencedResult, 1)
+	waiter.resultChans = append(waiter.resultChans, resultChan)
+	w.count++
+
+	return resultChan, nil
+}
+
+// deregister removes the result channel of the given block, if it was not notified yet.
+func (w *blockReferencedWaiters) deregister(blockID iotago.BlockID, resultChan chan *BlockReferencedResult) {
+	w.Lock()
+	defer w.Unlock()
+
+	waiter, exists := w.waiters[blockID]
+	if !exists {
+		return
+	}
+
+	for i, ch := range waiter.resultChans {
+		if ch != resultChan {
+			continue
+		}
+
+		waiter.resultChans = append(waiter.resultChans[:i], waiter.resultChans[i+1:]...)
+		w.count--
+		break
+	}
+
+	if len(waiter.resultChans) == 0 {
+		delete(w.waiters, blockID)
+	}
+}
+
+// onBlockReferenced remembers the result of the block if there are callers waiting for it.
+func (w *blockReferencedWaiters) onBlockReferenced(metadata *storage.BlockMetadata) {
+	w.Lock()
+	defer w.Unlock()
+
+	waiter, exists := w.waiters[metadata.BlockID()]
+	if !exists {
+		return
+	}
+
+	waiter.referenced = newBlockReferencedResult(metadata)
+}
+
+// onConfirmedMilestoneChanged notifies all callers waiting for blocks that were referenced up to the given milestone index.
+func (w *blockReferencedWaiters) onConfirmedMilestoneChanged(msIndex iotago.MilestoneIndex) {
+	w.Lock()
+	defer w.Unlock()
+
+	for blockID, waiter := range w.waiters {
+		if waiter.referenced == nil || waiter.referenced.MilestoneIndex > msIndex {
+			continue
+		}
+
+		for _, resultChan := range waiter.resultChans {
+			// the channels are buffered and only written once
+			resultChan <- waiter.referenced
+		}
+		w.count -= len(waiter.resultChans)
+		delete(w.waiters, blockID)
+	}
+}
+
+// configureBlockReferencedWaiters attaches the subscriber that notifies the callers of WaitForBlockReferenced.
+func (t *Tangle) configureBlockReferencedWaiters() {
+	t.Events.BlockReferenced.Attach(events.NewClosure(func(cachedBlockMeta *storage.CachedMetadata, _ iotago.MilestoneIndex, _ uint32) {
+		defer cachedBlockMeta.Release(true) // meta -1
+
+		t.blockReferencedWaiters.onBlockReferenced(cachedBlockMeta.Metadata())
+	}))
+
+	t.Events.ConfirmedMilestoneChanged.Attach(events.NewClosure(func(cachedMilestone *storage.CachedMilestone) {
+		defer cachedMilestone.Release(true) // milestone -1
+
+		t.blockReferencedWaiters.onConfirmedMilestoneChanged(cachedMilestone.Milestone().Index())
+	}))
+}
+
+// WaitForBlockReferenced waits until the block is referenced by a milestone and the confirmation of that milestone is finished,
+// so that the ledger changes of the block are visible to the caller.
+// Returns ErrBlockNotReferencedInTime if the context is done before,
+// or ErrBlockReferencedWaitersLimitReached if too many callers are already waiting.
+func (t *Tangle) WaitForBlockReferenced(ctx context.Context, blockID iotago.BlockID) (*BlockReferencedResult, error) {
+
+	// the block may have been referenced before the caller was registered.
+	// the confirmed milestone index is updated after the ledger changes were applied, before the events are triggered.
+	// the lock is held during the check, so a confirmation that finishes in the meantime is not missed.
+	t.blockReferencedWaiters.Lock()
+
+	var referenced *BlockReferencedResult
+	if result := t.blockReferencedResult(blockID); result != nil {
+		if result.MilestoneIndex <= t.syncManager.ConfirmedMilestoneIndex() {
+			t.blockReferencedWaiters.Unlock()
+			return result, nil
+		}
+
+		// the confirmation of the milestone is not finished yet
+		referenced = result
+	}
+
+	resultChan, err := t.blockReferencedWaiters.register(blockID, referenced)
+	t.blockReferencedWaiters.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case result := <-resultChan:
+		return result, nil
+	case <-ctx.Done():
+		t.blockReferencedWaiters.deregister(blockID, resultChan)
+
+		// the result may have been sent before the channel was deregistered
+		select {
+		case result := <-resultChan:
+			return result, nil
+		default:
+		}
+
+		return nil, errors.WithMessagef(ErrBlockNotReferencedInTime, "block %s, error: %s", blockID.ToHex(), ctx.Err())
+	}
+}
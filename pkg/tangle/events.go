@@ -34,6 +34,11 @@ func ReceiptCaller(handler interface{}, params ...interface{}) {
 	handler.(func(*iotago.ReceiptMilestoneOpt))(params[0].(*iotago.ReceiptMilestoneOpt))
 }
 
+// LedgerRolledBackCaller is used to signal that the ledger was rolled back from the given ledger index to the given target index.
+func LedgerRolledBackCaller(handler interface{}, params ...interface{}) {
+	handler.(func(targetIndex iotago.MilestoneIndex, ledgerIndex iotago.MilestoneIndex))(params[0].(iotago.MilestoneIndex), params[1].(iotago.MilestoneIndex))
+}
+
 func ReferencedBlocksCountUpdatedCaller(handler interface{}, params ...interface{}) {
 	handler.(func(msIndex iotago.MilestoneIndex, referencedBlocksCount int))(params[0].(iotago.MilestoneIndex), params[1].(int))
 }
@@ -71,4 +76,6 @@ type Events struct {
 	TreasuryMutated *events.Event
 	// Hint: Ledger is not locked
 	NewReceipt *events.Event
+	// Hint: Ledger is not locked
+	LedgerRolledBack *events.Event
 }
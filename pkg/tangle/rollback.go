@@ -0,0 +1,146 @@
+package tangle
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/daemon"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrRollbackRunning is returned if a rollback of the database is already running.
+	ErrRollbackRunning = errors.New("database rollback is already running")
+)
+
+// RollbackStatus is the status of a database rollback that runs in the background.
+type RollbackStatus struct {
+	// Running is set while the confirmations of the milestones are reverted.
+	Running bool
+	// Completed is set once the database was rolled back to the target index.
+	Completed bool
+	// TargetIndex is the milestone index the database is rolled back to.
+	TargetIndex iotago.MilestoneIndex
+	// LedgerIndex is the ledger index before the rollback.
+	LedgerIndex iotago.MilestoneIndex
+	// StartTime is the time the rollback was started at.
+	StartTime time.Time
+	// Err is the error that aborted the rollback.
+	Err error
+}
+
+// RollbackCheckFunc checks whether the database can be rolled back, e.g. that no snapshot is created and no pruning is running.
+// It is called again while the solidifier is locked, before the rollback starts.
+type RollbackCheckFunc func() error
+
+// RollbackStatus returns the status of the latest database rollback.
+func (t *Tangle) RollbackStatus() *RollbackStatus {
+	t.rollbackStatusLock.Lock()
+	defer t.rollbackStatusLock.Unlock()
+
+	status := t.rollbackStatus
+
+	return &status
+}
+
+// IsRollingBack returns whether a database rollback is running.
+func (t *Tangle) IsRollingBack() bool {
+	t.rollbackStatusLock.Lock()
+	defer t.rollbackStatusLock.Unlock()
+
+	return t.rollbackStatus.Running
+}
+
+// StartRollbackDatabase checks the given target index and reverts the confirmations of all milestones newer than it in the background.
+// The milestones and blocks are kept in the database, so they get confirmed again by the solidifier afterwards.
+// The given check function and the target index are checked again once the solidifier is locked.
+func (t *Tangle) StartRollbackDatabase(targetIndex iotago.MilestoneIndex, checkFunc RollbackCheckFunc) error {
+	t.rollbackStatusLock.Lock()
+	defer t.rollbackStatusLock.Unlock()
+
+	if t.rollbackStatus.Running {
+		return ErrRollbackRunning
+	}
+
+	if err := checkFunc(); err != nil {
+		return err
+	}
+
+	ledgerIndex, err := whiteflag.CheckRollbackTargetIndex(t.storage, targetIndex)
+	if err != nil {
+		return err
+	}
+
+	if err := t.daemon.BackgroundWorker("Database rollback", func(ctx context.Context) {
+		err := t.rollbackDatabase(ctx, targetIndex, checkFunc)
+
+		t.rollbackStatusLock.Lock()
+		defer t.rollbackStatusLock.Unlock()
+
+		t.rollbackStatus.Running = false
+		if err != nil {
+			t.LogWarnf("rolling back database to milestone %d failed: %s", targetIndex, err)
+			t.rollbackStatus.Err = err
+			return
+		}
+		t.rollbackStatus.Completed = true
+	}, daemon.PriorityMilestoneSolidifier); err != nil {
+		return err
+	}
+
+	t.rollbackStatus = RollbackStatus{
+		Running:     true,
+		TargetIndex: targetIndex,
+		LedgerIndex: ledgerIndex,
+		StartTime:   time.Now(),
+	}
+
+	return nil
+}
+
+// rollbackDatabase reverts the confirmations of all milestones newer than the given target index.
+func (t *Tangle) rollbackDatabase(ctx context.Context, targetIndex iotago.MilestoneIndex, checkFunc RollbackCheckFunc) error {
+
+	// stop ongoing milestone solidifications and prevent new ones during the rollback
+	t.AbortMilestoneSolidification()
+
+	t.solidifierLock.Lock()
+	defer t.solidifierLock.Unlock()
+
+	// a snapshot or a pruning could have been started since the rollback was requested.
+	// the target index is checked against the pruning bounds again by the rollback while the ledger is locked.
+	if err := checkFunc(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+
+	ledgerIndex, err := whiteflag.RollbackMilestones(ctx, t.storage, targetIndex, func(msIndex iotago.MilestoneIndex) {
+		t.LogInfof("rolled back milestone %d", msIndex)
+	})
+
+	// the ledger index might have changed even if the rollback failed halfway
+	confirmedMilestoneIndex, errLedgerIndex := t.storage.UTXOManager().ReadLedgerIndex()
+	if errLedgerIndex != nil {
+		t.LogPanic(errLedgerIndex)
+	}
+	t.syncManager.OverwriteConfirmedMilestoneIndex(confirmedMilestoneIndex)
+
+	if confirmedMilestoneIndex < ledgerIndex {
+		t.Events.LedgerRolledBack.Trigger(confirmedMilestoneIndex, ledgerIndex)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	t.LogInfof("rolled back database from milestone %d to %d, took %v", ledgerIndex, targetIndex, time.Since(start).Truncate(time.Millisecond))
+
+	// confirm the milestones again
+	t.TriggerSolidifier()
+
+	return nil
+}
@@ -75,6 +75,9 @@ type Tangle struct {
 	milestoneSolidifierWorkerCount int
 	milestoneSolidifierQueueSize   int
 
+	// the callers waiting for blocks to be referenced by a milestone.
+	blockReferencedWaiters *blockReferencedWaiters
+
 	lastIncomingBlocksCount    uint32
 	lastIncomingNewBlocksCount uint32
 	lastOutgoingBlocksCount    uint32
@@ -105,6 +108,9 @@ type Tangle struct {
 	lastConfirmedMilestoneMetricLock syncutils.RWMutex
 	lastConfirmedMilestoneMetric     *ConfirmedMilestoneMetric
 
+	rollbackStatusLock syncutils.Mutex
+	rollbackStatus     RollbackStatus
+
 	Events *Events
 }
 
@@ -154,6 +160,7 @@ func New(
 		processValidMilestoneQueueSize:   1000,
 		milestoneSolidifierWorkerCount:   2, // must be two, so a new request can abort another, in case it is an older milestone
 		milestoneSolidifierQueueSize:     2,
+		blockReferencedWaiters:           newBlockReferencedWaiters(maxBlockReferencedWaiters),
 		blockProcessedSyncEvent:          events.NewSyncEvent(),
 		blockSolidSyncEvent:              events.NewSyncEvent(),
 		Events: &Events{
@@ -173,9 +180,11 @@ func New(
 			LedgerUpdated:                  events.NewEvent(LedgerUpdatedCaller),
 			TreasuryMutated:                events.NewEvent(TreasuryMutationCaller),
 			NewReceipt:                     events.NewEvent(ReceiptCaller),
+			LedgerRolledBack:               events.NewEvent(LedgerRolledBackCaller),
 		},
 	}
 	t.futureConeSolidifier = NewFutureConeSolidifier(t.storage, t.markBlockAsSolid)
+	t.configureBlockReferencedWaiters()
 	t.ResetMilestoneTimeoutTicker()
 	return t
 }
@@ -208,6 +208,7 @@ func (t *Tangle) processIncomingTx(incomingBlock *storage.Block, requests gossip
 
 		if proto != nil {
 			proto.Metrics.NewBlocks.Inc()
+			proto.Reputation.RecordNewBlock()
 		}
 
 		// since we only add the parents if there was a source request, we only
@@ -0,0 +1,153 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hive.go/logger"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	ProtocolVersion = 2
+	BelowMaxDepth   = 15
+	MinPoWScore     = 1.0
+)
+
+type blockReferencedWaitResult struct {
+	result *tangle.BlockReferencedResult
+	err    error
+}
+
+func newTestTangle(te *testsuite.TestEnvironment) *tangle.Tangle {
+	tng := tangle.New(
+		logger.NewLogger("Tangle"),
+		nil,
+		context.Background(),
+		te.Storage(),
+		te.SyncManager(),
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		te.ProtocolManager(),
+		time.Minute,
+		time.Minute,
+		false,
+	)
+	tng.StopMilestoneTimeoutTicker()
+
+	return tng
+}
+
+// triggerConfirmationEvents triggers the events of the tangle for a milestone that was confirmed by the test environment.
+func triggerConfirmationEvents(t *testing.T, te *testsuite.TestEnvironment, tng *tangle.Tangle, msIndex iotago.MilestoneIndex, blockIDs ...iotago.BlockID) {
+
+	for _, blockID := range blockIDs {
+		cachedBlockMeta := te.Storage().CachedBlockMetadataOrNil(blockID) // meta +1
+		require.NotNil(t, cachedBlockMeta)
+		tng.Events.BlockReferenced.Trigger(cachedBlockMeta, msIndex, uint32(0))
+		cachedBlockMeta.Release(true) // meta -1
+	}
+
+	cachedMilestone := te.Storage().CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+	require.NotNil(t, cachedMilestone)
+	defer cachedMilestone.Release(true) // milestone -1
+
+	tng.Events.ConfirmedMilestoneChanged.Trigger(cachedMilestone)
+}
+
+func TestWaitForBlockReferencedAlreadyReferenced(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	tng := newTestTangle(te)
+
+	block := te.NewBlockBuilder("A").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+	_, confStats := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{block.StoredBlockID()}, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := tng.WaitForBlockReferenced(ctx, block.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, block.StoredBlockID(), result.BlockID)
+	require.Equal(t, confStats.Index, result.MilestoneIndex)
+	require.Equal(t, storage.ConflictNone, result.Conflict)
+}
+
+func TestWaitForBlockReferencedWhileWaiting(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	tng := newTestTangle(te)
+
+	block := te.NewBlockBuilder("A").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// two callers wait for the same block
+	resultChan := make(chan *blockReferencedWaitResult, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			result, err := tng.WaitForBlockReferenced(ctx, block.StoredBlockID())
+			resultChan <- &blockReferencedWaitResult{result: result, err: err}
+		}()
+	}
+
+	// the block is not referenced yet
+	require.Never(t, func() bool { return len(resultChan) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	_, confStats := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{block.StoredBlockID()}, false)
+	triggerConfirmationEvents(t, te, tng, confStats.Index, block.StoredBlockID())
+
+	for i := 0; i < 2; i++ {
+		select {
+		case waitResult := <-resultChan:
+			require.NoError(t, waitResult.err)
+			require.Equal(t, block.StoredBlockID(), waitResult.result.BlockID)
+			require.Equal(t, confStats.Index, waitResult.result.MilestoneIndex)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "caller was not notified about the referenced block")
+		}
+	}
+}
+
+func TestWaitForBlockReferencedTimeout(t *testing.T) {
+
+	te := testsuite.SetupTestEnvironment(t, &iotago.Ed25519Address{}, 0, ProtocolVersion, BelowMaxDepth, MinPoWScore, false)
+	defer te.CleanupTestEnvironment(true)
+
+	tng := newTestTangle(te)
+
+	blockA := te.NewBlockBuilder("A").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+	blockB := te.NewBlockBuilder("B").Parents(te.LastMilestoneParents()).BuildTaggedData().Store()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := tng.WaitForBlockReferenced(ctx, blockA.StoredBlockID())
+	require.ErrorIs(t, err, tangle.ErrBlockNotReferencedInTime)
+
+	// a confirmation of another block after the timeout doesn't affect the next caller
+	_, confStats := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockB.StoredBlockID()}, false)
+	triggerConfirmationEvents(t, te, tng, confStats.Index, blockB.StoredBlockID())
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel2()
+
+	_, err = tng.WaitForBlockReferenced(ctx2, blockA.StoredBlockID())
+	require.ErrorIs(t, err, tangle.ErrBlockNotReferencedInTime)
+}
@@ -26,7 +26,7 @@ func benchmarkIO(args []string) error {
 	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
 	objectsCountFlag := fs.Int(FlagToolBenchmarkCount, 500000, "objects count")
 	objectsSizeFlag := fs.Int(FlagToolBenchmarkSize, 1000, "objects size in bytes")
-	databaseEngineFlag := fs.String(FlagToolDatabaseEngine, string(DefaultValueDatabaseEngine), "database engine (optional, values: pebble, rocksdb)")
+	databaseEngineFlag := fs.String(FlagToolDatabaseEngine, string(DefaultValueDatabaseEngine), "database engine (optional, values: pebble, rocksdb, badger)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolBenchmarkIO)
@@ -48,7 +48,7 @@ func benchmarkIO(args []string) error {
 	objectCnt := *objectsCountFlag
 	size := *objectsSizeFlag
 
-	dbEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineFlag, database.EnginePebble, database.EngineRocksDB)
+	dbEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineFlag, database.EnginePebble, database.EngineRocksDB, database.EngineBadger)
 	if err != nil {
 		return err
 	}
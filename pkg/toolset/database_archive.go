@@ -0,0 +1,88 @@
+package toolset
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/snapshot"
+)
+
+func databaseArchive(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueMainnetDatabasePath, "the path to the database")
+	snapshotPathsFlag := fs.StringSlice(FlagToolSnapshotPath, nil, "the paths to older full or delta snapshot files to backfill the spent outputs from (optional)")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabaseArchive)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolDatabaseArchive,
+			FlagToolDatabasePath,
+			DefaultValueMainnetDatabasePath,
+			FlagToolSnapshotPath,
+			"snapshots/old/full_snapshot.bin,snapshots/old/delta_snapshot.bin",
+		))
+		println("\nthe archive mode needs to be enabled in the config of the node afterwards (\"db.archive\")")
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*databasePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabasePath)
+	}
+
+	tangleStore, err := getTangleStorage(*databasePathFlag, "database", string(database.EngineAuto), true, true, false, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		println("\nshutdown storage...")
+		if err := tangleStore.Shutdown(); err != nil {
+			panic(err)
+		}
+	}()
+
+	ts := time.Now()
+	println(fmt.Sprintf("building archive indexes... (path: %s)", *databasePathFlag))
+
+	rebuilt, err := tangleStore.InitArchive(true)
+	if err != nil {
+		return fmt.Errorf("building archive indexes failed: %w", err)
+	}
+
+	archiveState, err := tangleStore.UTXOManager().ArchiveStateWithoutLocking()
+	if err != nil {
+		return err
+	}
+
+	if rebuilt {
+		println(fmt.Sprintf("archive indexes built, referenced blocks since milestone %d, spent outputs since milestone %d", archiveState.BlocksStartIndex, archiveState.SpentsStartIndex))
+	} else {
+		println(fmt.Sprintf("archive indexes already exist, referenced blocks since milestone %d, spent outputs since milestone %d", archiveState.BlocksStartIndex, archiveState.SpentsStartIndex))
+	}
+
+	// the snapshot files need to be passed from the newest to the oldest one,
+	// every file extends the history if its milestone diffs connect to the existing history.
+	for _, snapshotPath := range *snapshotPathsFlag {
+		println(fmt.Sprintf("backfilling spent outputs from snapshot file... (path: %s)", snapshotPath))
+
+		msIndexStart, msIndexEnd, archiveState, err := snapshot.BackfillArchiveFromSnapshotFile(tangleStore, snapshotPath)
+		if err != nil {
+			return fmt.Errorf("backfilling from snapshot file failed: %w", err)
+		}
+
+		println(fmt.Sprintf("backfilled milestones %d-%d, spent outputs since milestone %d", msIndexStart, msIndexEnd, archiveState.SpentsStartIndex))
+	}
+
+	println(fmt.Sprintf("\nsuccessfully built archive indexes, took: %v", time.Since(ts).Truncate(time.Millisecond)))
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package toolset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	databasecore "github.com/iotaledger/hornet/v2/core/database"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+)
+
+func databaseCheckpoint(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueMainnetDatabasePath, "the path to the database")
+	checkpointPathFlag := fs.String(FlagToolCheckpointPath, "", "the path the checkpoint is stored in")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabaseCheckpoint)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolDatabaseCheckpoint,
+			FlagToolDatabasePath,
+			DefaultValueMainnetDatabasePath,
+			FlagToolCheckpointPath,
+			"checkpoints/mainnetdb",
+		))
+		println("\nthe node needs to be stopped, a checkpoint of a running node can be created with the \"POST /api/core/v2/control/database/checkpoint\" route")
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*databasePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabasePath)
+	}
+	if len(*checkpointPathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolCheckpointPath)
+	}
+
+	databasePath := *databasePathFlag
+	databaseExists, err := database.DatabaseExists(databasePath)
+	if err != nil {
+		return err
+	}
+	if !databaseExists {
+		return fmt.Errorf("database does not exist (%s)", databasePath)
+	}
+
+	tangleDatabase, err := database.DatabaseWithDefaultSettings(filepath.Join(databasePath, databasecore.TangleDatabaseDirectoryName), false)
+	if err != nil {
+		return fmt.Errorf("tangle database initialization failed: %w", err)
+	}
+
+	utxoDatabase, err := database.DatabaseWithDefaultSettings(filepath.Join(databasePath, databasecore.UTXODatabaseDirectoryName), false)
+	if err != nil {
+		_ = tangleDatabase.KVStore().Close()
+		return fmt.Errorf("utxo database initialization failed: %w", err)
+	}
+
+	tangleStore, err := storage.New(tangleDatabase.KVStore(), utxoDatabase.KVStore())
+	if err != nil {
+		_ = tangleDatabase.KVStore().Close()
+		_ = utxoDatabase.KVStore().Close()
+		return fmt.Errorf("storage initialization failed: %w", err)
+	}
+	defer func() {
+		println("\nshutdown storage...")
+		if err := tangleStore.Shutdown(); err != nil {
+			panic(err)
+		}
+	}()
+
+	if err := checkDatabaseHealth(tangleStore, false); err != nil {
+		return fmt.Errorf("storage initialization failed: %w", err)
+	}
+
+	ts := time.Now()
+	println(fmt.Sprintf("creating database checkpoint... (source: %s, target: %s)", databasePath, *checkpointPathFlag))
+
+	info, err := tangleStore.CreateCheckpoint(getGracefulStopContext(), *checkpointPathFlag, map[string]*database.Database{
+		databasecore.TangleDatabaseDirectoryName: tangleDatabase,
+		databasecore.UTXODatabaseDirectoryName:   utxoDatabase,
+	})
+	if err != nil {
+		return err
+	}
+
+	println(fmt.Sprintf("\nsuccessfully created %s database checkpoint at ledger index %d, took: %v", info.Engine, info.LedgerIndex, time.Since(ts).Truncate(time.Millisecond)))
+
+	return nil
+}
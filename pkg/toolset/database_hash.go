@@ -16,6 +16,7 @@ import (
 	coreDatabase "github.com/iotaledger/hornet/v2/core/database"
 	"github.com/iotaledger/hornet/v2/pkg/database"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -122,6 +123,19 @@ func calculateDatabaseLedgerHash(dbStorage *storage.Storage, outputJSON bool) er
 
 	snapshotHashSumWithSEPs := lsHash.Sum(nil)
 
+	// the order-independent ledger state hash is maintained incrementally by the node,
+	// it only needs to be computed if the database was not yet opened by a node supporting it.
+	incrementalLedgerStateHash, err := dbStorage.UTXOManager().LedgerStateHashWithoutLocking()
+	if err != nil {
+		if !errors.Is(err, utxo.ErrLedgerStateHashNotAvailable) {
+			return fmt.Errorf("unable to load incremental ledger state hash: %w", err)
+		}
+
+		if incrementalLedgerStateHash, err = dbStorage.UTXOManager().ComputeLedgerStateHashWithoutLocking(); err != nil {
+			return fmt.Errorf("unable to calculate incremental ledger state hash: %w", err)
+		}
+	}
+
 	protocolParametersHashSum, err := dbStorage.ActiveProtocolParameterMilestoneOptionsHash(ledgerIndex)
 	if err != nil {
 		return fmt.Errorf("unable to calculate protocol parameters hash: %w", err)
@@ -143,33 +157,35 @@ func calculateDatabaseLedgerHash(dbStorage *storage.Storage, outputJSON bool) er
 		}
 
 		result := struct {
-			Healthy                bool                  `json:"healthy"`
-			Tainted                bool                  `json:"tainted"`
-			SnapshotTime           time.Time             `json:"snapshotTime"`
-			NetworkID              uint64                `json:"networkID"`
-			Treasury               *treasuryStruct       `json:"treasury"`
-			LedgerIndex            iotago.MilestoneIndex `json:"ledgerIndex"`
-			SnapshotIndex          iotago.MilestoneIndex `json:"snapshotIndex"`
-			PruningIndex           iotago.MilestoneIndex `json:"pruningIndex"`
-			UTXOsCount             int                   `json:"UTXOsCount"`
-			SEPsCount              int                   `json:"SEPsCount"`
-			LedgerStateHash        string                `json:"ledgerStateHash"`
-			LedgerStateHashWithSEP string                `json:"ledgerStateHashWithSEP"`
-			ProtocolParametersHash string                `json:"protocolParametersHash"`
+			Healthy                    bool                  `json:"healthy"`
+			Tainted                    bool                  `json:"tainted"`
+			SnapshotTime               time.Time             `json:"snapshotTime"`
+			NetworkID                  uint64                `json:"networkID"`
+			Treasury                   *treasuryStruct       `json:"treasury"`
+			LedgerIndex                iotago.MilestoneIndex `json:"ledgerIndex"`
+			SnapshotIndex              iotago.MilestoneIndex `json:"snapshotIndex"`
+			PruningIndex               iotago.MilestoneIndex `json:"pruningIndex"`
+			UTXOsCount                 int                   `json:"UTXOsCount"`
+			SEPsCount                  int                   `json:"SEPsCount"`
+			LedgerStateHash            string                `json:"ledgerStateHash"`
+			LedgerStateHashWithSEP     string                `json:"ledgerStateHashWithSEP"`
+			IncrementalLedgerStateHash string                `json:"incrementalLedgerStateHash"`
+			ProtocolParametersHash     string                `json:"protocolParametersHash"`
 		}{
-			Healthy:                !corrupted,
-			Tainted:                tainted,
-			SnapshotTime:           snapshotInfo.SnapshotTimestamp(),
-			NetworkID:              protoParams.NetworkID(),
-			Treasury:               treasury,
-			LedgerIndex:            ledgerIndex,
-			SnapshotIndex:          snapshotInfo.SnapshotIndex(),
-			PruningIndex:           snapshotInfo.PruningIndex(),
-			UTXOsCount:             len(outputIDs),
-			SEPsCount:              len(solidEntryPoints),
-			LedgerStateHash:        hex.EncodeToString(snapshotHashSumWithoutSEPs),
-			LedgerStateHashWithSEP: hex.EncodeToString(snapshotHashSumWithSEPs),
-			ProtocolParametersHash: hex.EncodeToString(protocolParametersHashSum),
+			Healthy:                    !corrupted,
+			Tainted:                    tainted,
+			SnapshotTime:               snapshotInfo.SnapshotTimestamp(),
+			NetworkID:                  protoParams.NetworkID(),
+			Treasury:                   treasury,
+			LedgerIndex:                ledgerIndex,
+			SnapshotIndex:              snapshotInfo.SnapshotIndex(),
+			PruningIndex:               snapshotInfo.PruningIndex(),
+			UTXOsCount:                 len(outputIDs),
+			SEPsCount:                  len(solidEntryPoints),
+			LedgerStateHash:            hex.EncodeToString(snapshotHashSumWithoutSEPs),
+			LedgerStateHashWithSEP:     hex.EncodeToString(snapshotHashSumWithSEPs),
+			IncrementalLedgerStateHash: incrementalLedgerStateHash.ToHex(),
+			ProtocolParametersHash:     hex.EncodeToString(protocolParametersHashSum),
 		}
 
 		return printJSON(result)
@@ -188,6 +204,7 @@ func calculateDatabaseLedgerHash(dbStorage *storage.Storage, outputJSON bool) er
         - SEPs count:     %d
         - Ledger state hash (w/o  solid entry points): %s
         - Ledger state hash (with solid entry points): %s
+        - Incremental ledger state hash:               %s
         - Protocol parameters hash (current+pending):  %s`+"\n\n",
 		yesOrNo(!corrupted),
 		yesOrNo(tainted),
@@ -206,6 +223,7 @@ func calculateDatabaseLedgerHash(dbStorage *storage.Storage, outputJSON bool) er
 		len(solidEntryPoints),
 		hex.EncodeToString(snapshotHashSumWithoutSEPs),
 		hex.EncodeToString(snapshotHashSumWithSEPs),
+		incrementalLedgerStateHash.ToHex(),
 		hex.EncodeToString(protocolParametersHashSum),
 	)
 
@@ -21,7 +21,7 @@ func databaseMigration(args []string) error {
 	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
 	databasePathSourceFlag := fs.String(FlagToolDatabasePathSource, "", "the path to the source database")
 	databasePathTargetFlag := fs.String(FlagToolDatabasePathTarget, "", "the path to the target database")
-	databaseEngineTargetFlag := fs.String(FlagToolDatabaseEngineTarget, string(DefaultValueDatabaseEngine), "the engine of the target database (values: pebble, rocksdb)")
+	databaseEngineTargetFlag := fs.String(FlagToolDatabaseEngineTarget, string(DefaultValueDatabaseEngine), "the engine of the target database (values: pebble, rocksdb, badger)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabaseMigration)
@@ -34,6 +34,7 @@ func databaseMigration(args []string) error {
 			"mainnetdb_new",
 			FlagToolDatabaseEngineTarget,
 			DefaultValueDatabaseEngine))
+		println("\nthe node needs to be stopped, the database of a running node can be migrated with the \"POST /api/core/v2/control/database/migrate\" route")
 	}
 
 	if err := parseFlagSet(fs, args); err != nil {
@@ -60,7 +61,7 @@ func databaseMigration(args []string) error {
 		return fmt.Errorf("'%s' (%s) already exist", FlagToolDatabasePathTarget, targetPath)
 	}
 
-	targetEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineTargetFlag, database.EnginePebble, database.EngineRocksDB)
+	targetEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineTargetFlag, database.EnginePebble, database.EngineRocksDB, database.EngineBadger)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,146 @@
+package toolset
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/labstack/gommon/bytes"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hive.go/ioutils"
+	databasecore "github.com/iotaledger/hornet/v2/core/database"
+	snapCore "github.com/iotaledger/hornet/v2/core/snapshot"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/pruning"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func databasePruneDryRun(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueMainnetDatabasePath, "the path to the database")
+	targetIndexFlag := fs.Uint32(FlagToolDatabaseTargetIndex, 0, "the pruning target index")
+	depthFlag := fs.Uint32(FlagToolDatabasePruneDepth, 0, "the pruning depth below the ledger index")
+	snapshotDepthFlag := fs.Uint32(FlagToolDatabasePruneSnapshotDepth, 50, "the depth at which the snapshots of the node are generated (snapshots.depth)")
+	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabasePruneDryRun)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolDatabasePruneDryRun,
+			FlagToolDatabasePath,
+			DefaultValueMainnetDatabasePath,
+			FlagToolDatabasePruneDepth,
+			"60480",
+		))
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*databasePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabasePath)
+	}
+	if (*targetIndexFlag == 0) == (*depthFlag == 0) {
+		return fmt.Errorf("either '%s' or '%s' has to be specified", FlagToolDatabaseTargetIndex, FlagToolDatabasePruneDepth)
+	}
+
+	tangleStore, err := getTangleStorage(*databasePathFlag, "database", string(database.EngineAuto), true, true, false, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if !*outputJSONFlag {
+			println("\nshutdown storage...")
+		}
+		if err := tangleStore.Shutdown(); err != nil {
+			panic(err)
+		}
+	}()
+
+	ledgerIndex, err := tangleStore.UTXOManager().ReadLedgerIndex()
+	if err != nil {
+		return err
+	}
+
+	targetIndex := *targetIndexFlag
+	if *depthFlag != 0 {
+		if ledgerIndex <= *depthFlag {
+			return pruning.ErrNotEnoughHistory
+		}
+		targetIndex = ledgerIndex - *depthFlag
+	}
+
+	// the node keeps the history that is needed to create the next snapshot
+	var targetIndexMax iotago.MilestoneIndex
+	snapshotInfo := tangleStore.SnapshotInfo()
+	minimumTangleHistory := *snapshotDepthFlag + belowMaxDepth + snapCore.SolidEntryPointCheckAdditionalThresholdPast
+	if snapshotInfo != nil && snapshotInfo.SnapshotIndex() > minimumTangleHistory {
+		targetIndexMax = snapshotInfo.SnapshotIndex() - minimumTangleHistory
+	}
+
+	checkedTargetIndex, snapshotInfo, err := pruning.CheckTargetIndex(tangleStore, targetIndex, targetIndexMax)
+	if err != nil {
+		return err
+	}
+
+	tangleDatabaseSizeBytes, err := ioutils.FolderSize(filepath.Join(*databasePathFlag, databasecore.TangleDatabaseDirectoryName))
+	if err != nil {
+		return err
+	}
+
+	report, err := pruning.EstimatePruning(getGracefulStopContext(), tangleStore, tangleDatabaseSizeBytes, snapshotInfo.PruningIndex(), checkedTargetIndex, ledgerIndex)
+	if err != nil {
+		return err
+	}
+
+	if checkedTargetIndex < targetIndex {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("target index %d was limited to %d by the history needed for snapshots (snapshot index: %d)", targetIndex, checkedTargetIndex, snapshotInfo.SnapshotIndex()))
+	}
+
+	// the indexes of the INX consumers are only known while the node is running
+	report.Warnings = append(report.Warnings, fmt.Sprintf("INX consumers that resume from a milestone before %d won't be able to catch up", checkedTargetIndex+1))
+
+	if *outputJSONFlag {
+		return printJSON(struct {
+			PruningIndex         iotago.MilestoneIndex `json:"pruningIndex"`
+			TargetIndex          iotago.MilestoneIndex `json:"targetIndex"`
+			MilestonesCount      uint32                `json:"milestonesCount"`
+			EstimatedBlocksCount uint64                `json:"estimatedBlocksCount"`
+			EstimatedBytesSaved  int64                 `json:"estimatedBytesSaved"`
+			Warnings             []string              `json:"warnings"`
+		}{
+			PruningIndex:         report.PruningIndex,
+			TargetIndex:          report.TargetIndex,
+			MilestonesCount:      report.MilestonesCount,
+			EstimatedBlocksCount: report.EstimatedBlocksCount,
+			EstimatedBytesSaved:  report.EstimatedBytesSaved,
+			Warnings:             report.Warnings,
+		})
+	}
+
+	fmt.Printf(`    >
+        - Ledger index:          %d
+        - Pruning index:         %d
+        - Target index:          %d
+        - Milestones:            %d
+        - Estimated blocks:      %d
+        - Estimated space saved: %s`+"\n\n",
+		ledgerIndex,
+		report.PruningIndex,
+		report.TargetIndex,
+		report.MilestonesCount,
+		report.EstimatedBlocksCount,
+		bytes.Format(report.EstimatedBytesSaved),
+	)
+
+	for _, warning := range report.Warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+
+	return nil
+}
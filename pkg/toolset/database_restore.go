@@ -0,0 +1,149 @@
+package toolset
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hive.go/ioutils"
+	databasecore "github.com/iotaledger/hornet/v2/core/database"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+)
+
+func databaseRestore(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	checkpointPathFlag := fs.String(FlagToolCheckpointPath, "", "the path to the checkpoint")
+	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueMainnetDatabasePath, "the path the database is restored to")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabaseRestore)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolDatabaseRestore,
+			FlagToolCheckpointPath,
+			"checkpoints/mainnetdb",
+			FlagToolDatabasePath,
+			DefaultValueMainnetDatabasePath,
+		))
+		println("\nthe node needs to be stopped and the existing database needs to be removed or moved to another path")
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*checkpointPathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolCheckpointPath)
+	}
+	if len(*databasePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabasePath)
+	}
+
+	checkpointPath := *checkpointPathFlag
+	databasePath := *databasePathFlag
+
+	databaseExists, err := ioutils.PathExists(databasePath)
+	if err != nil {
+		return err
+	}
+	if databaseExists {
+		return fmt.Errorf("'%s' (%s) already exists", FlagToolDatabasePath, databasePath)
+	}
+
+	info, err := storage.ReadCheckpointInfo(checkpointPath)
+	if err != nil {
+		return err
+	}
+
+	println(fmt.Sprintf("verifying %s database checkpoint at ledger index %d, created at %s... (path: %s)", info.Engine, info.LedgerIndex, info.Timestamp.Format(time.RFC3339), checkpointPath))
+
+	if err := verifyCheckpoint(checkpointPath, info); err != nil {
+		return fmt.Errorf("checkpoint verification failed: %w", err)
+	}
+
+	ts := time.Now()
+	println(fmt.Sprintf("restoring database... (path: %s)", databasePath))
+
+	for _, name := range []string{databasecore.TangleDatabaseDirectoryName, databasecore.UTXODatabaseDirectoryName} {
+		if err := copyDirectory(filepath.Join(checkpointPath, name), filepath.Join(databasePath, name)); err != nil {
+			_ = os.RemoveAll(databasePath)
+			return fmt.Errorf("restoring the %s database failed: %w", name, err)
+		}
+	}
+
+	println(fmt.Sprintf("\nsuccessfully restored database at ledger index %d, took: %v", info.LedgerIndex, time.Since(ts).Truncate(time.Millisecond)))
+
+	return nil
+}
+
+// verifyCheckpoint loads the storage from the checkpoint and verifies the health of the databases and the ledger state.
+func verifyCheckpoint(checkpointPath string, info *storage.CheckpointInfo) error {
+
+	tangleStore, err := getTangleStorage(checkpointPath, "checkpoint", string(info.Engine), true, false, false, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := tangleStore.Shutdown(); err != nil {
+			panic(err)
+		}
+	}()
+
+	return tangleStore.VerifyCheckpoint(info)
+}
+
+// copyDirectory copies all files of the source directory to the target directory.
+func copyDirectory(sourcePath string, targetPath string) error {
+
+	return filepath.WalkDir(sourcePath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(targetPath, relativePath)
+
+		if entry.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+// copyFile copies the source file to the target file.
+func copyFile(sourceFilePath string, targetFilePath string) error {
+
+	source, err := os.Open(sourceFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = source.Close() }()
+
+	target, err := os.OpenFile(targetFilePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0660)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(target, source); err != nil {
+		_ = target.Close()
+		return err
+	}
+
+	if err := target.Sync(); err != nil {
+		_ = target.Close()
+		return err
+	}
+
+	return target.Close()
+}
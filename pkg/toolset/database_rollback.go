@@ -0,0 +1,88 @@
+package toolset
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func databaseRollback(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	databasePathFlag := fs.String(FlagToolDatabasePath, DefaultValueMainnetDatabasePath, "the path to the database")
+	targetIndexFlag := fs.Uint32(FlagToolDatabaseTargetIndex, 0, "the target index")
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolDatabaseRollback)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolDatabaseRollback,
+			FlagToolDatabasePath,
+			DefaultValueMainnetDatabasePath,
+			FlagToolDatabaseTargetIndex,
+			"100",
+		))
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*databasePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabasePath)
+	}
+	if *targetIndexFlag == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolDatabaseTargetIndex)
+	}
+
+	tangleStore, err := getTangleStorage(*databasePathFlag, "database", string(database.EngineAuto), true, true, false, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		println("\nshutdown storage...")
+		if err := tangleStore.Shutdown(); err != nil {
+			panic(err)
+		}
+	}()
+
+	// mark the database as corrupted until the rollback was successful
+	if err := tangleStore.MarkDatabasesCorrupted(); err != nil {
+		return err
+	}
+
+	ts := time.Now()
+	println(fmt.Sprintf("rolling back database to milestone %d... (path: %s)", *targetIndexFlag, *databasePathFlag))
+
+	ledgerIndex, err := whiteflag.RollbackMilestones(getGracefulStopContext(), tangleStore, *targetIndexFlag, func(msIndex iotago.MilestoneIndex) {
+		println(fmt.Sprintf("rolled back milestone %d", msIndex))
+	})
+	if err != nil {
+		// the database is still consistent if the rollback was aborted between milestones or nothing was modified
+		ledgerIndexAfter, errLedgerIndex := tangleStore.UTXOManager().ReadLedgerIndex()
+		if errors.Is(err, common.ErrOperationAborted) || (errLedgerIndex == nil && ledgerIndexAfter == ledgerIndex) {
+			if err := tangleStore.MarkDatabasesHealthy(); err != nil {
+				return err
+			}
+		}
+
+		return err
+	}
+
+	if err := tangleStore.MarkDatabasesHealthy(); err != nil {
+		return err
+	}
+
+	println(fmt.Sprintf("\nsuccessfully rolled back database from milestone %d to %d, took: %v", ledgerIndex, *targetIndexFlag, time.Since(ts).Truncate(time.Millisecond)))
+
+	return nil
+}
@@ -24,6 +24,7 @@ func databaseSnapshot(args []string) error {
 	snapshotPathTargetFlag := fs.String(FlagToolSnapshotPathTarget, "", "the path to the target snapshot file")
 	databasePathSourceFlag := fs.String(FlagToolDatabasePathSource, "", "the path to the source database")
 	targetIndexFlag := fs.Uint32(FlagToolDatabaseTargetIndex, 0, "the target index")
+	compressionFlag := fs.Bool(FlagToolSnapshotCompression, false, FlagToolDescriptionSnapshotCompression)
 	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
 
 	fs.Usage = func() {
@@ -79,6 +80,7 @@ func databaseSnapshot(args []string) error {
 		*targetIndexFlag,
 		solidEntryPointCheckThresholdPast,
 		solidEntryPointCheckThresholdFuture,
+		snapshot.FormatVersion(*compressionFlag),
 	)
 	if err != nil {
 		return err
@@ -88,7 +90,7 @@ func databaseSnapshot(args []string) error {
 		fmt.Printf("metadata:\n")
 	}
 
-	if err := printFullSnapshotHeaderInfo("", *snapshotPathTargetFlag, fullHeader); err != nil {
+	if err := printFullSnapshotHeaderInfo("", *snapshotPathTargetFlag, fullHeader, nil); err != nil {
 		return err
 	}
 
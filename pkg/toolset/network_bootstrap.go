@@ -44,7 +44,7 @@ func networkBootstrap(args []string) error {
 	genesisSnapshotPathFlag := fs.String(FlagToolSnapshotPath, "", "the path to the genesis snapshot file")
 	databasePathFlag := fs.String(FlagToolDatabasePath, "", "the path to the coordinator database")
 	cooStatePathFlag := fs.String(FlagToolCoordinatorStatePath, "", "the path to the coordinator state file")
-	databaseEngineFlag := fs.String(FlagToolDatabaseEngine, string(DefaultValueDatabaseEngine), "database engine (optional, values: pebble, rocksdb)")
+	databaseEngineFlag := fs.String(FlagToolDatabaseEngine, string(DefaultValueDatabaseEngine), "database engine (optional, values: pebble, rocksdb, badger)")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolBootstrapPrivateTangle)
@@ -106,7 +106,7 @@ func networkBootstrap(args []string) error {
 		return fmt.Errorf("'%s' (%s) already exists", FlagToolCoordinatorStatePath, cooStatePath)
 	}
 
-	dbEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineFlag, database.EnginePebble, database.EngineRocksDB)
+	dbEngine, err := database.DatabaseEngineFromStringAllowed(*databaseEngineFlag, database.EnginePebble, database.EngineRocksDB, database.EngineBadger)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,105 @@
+package toolset
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+
+	"github.com/iotaledger/hive.go/configuration"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func proofVerify(args []string) error {
+
+	fs := configuration.NewUnsortedFlagSet("", flag.ContinueOnError)
+	configFilePathFlag := fs.String(FlagToolConfigFilePath, "", "the path to the config file that contains the coordinator public key ranges")
+	proofFilePathFlag := fs.String(FlagToolProofPath, "", "the path to the proof bundle file returned by the node API")
+	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
+
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of %s:\n", ToolProofVerify)
+		fs.PrintDefaults()
+		println(fmt.Sprintf("\nexample: %s --%s %s --%s %s",
+			ToolProofVerify,
+			FlagToolConfigFilePath,
+			"config.json",
+			FlagToolProofPath,
+			"proof.json",
+		))
+	}
+
+	if err := parseFlagSet(fs, args); err != nil {
+		return err
+	}
+
+	if len(*configFilePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolConfigFilePath)
+	}
+	if len(*proofFilePathFlag) == 0 {
+		return fmt.Errorf("'%s' not specified", FlagToolProofPath)
+	}
+
+	milestoneManager, err := getMilestoneManagerFromConfigFile(*configFilePathFlag)
+	if err != nil {
+		return err
+	}
+
+	proofBundleBytes, err := os.ReadFile(*proofFilePathFlag)
+	if err != nil {
+		return errors.Wrapf(err, "unable to read proof bundle file %s", *proofFilePathFlag)
+	}
+
+	proofBundle := &whiteflag.ProofBundle{}
+	if err := json.Unmarshal(proofBundleBytes, proofBundle); err != nil {
+		return errors.Wrapf(err, "unable to parse proof bundle file %s", *proofFilePathFlag)
+	}
+
+	// the milestone needs to be signed by the coordinator keys that were valid at the milestone index
+	if milestoneManager.VerifyMilestonePayload(proofBundle.Milestone) == nil {
+		return fmt.Errorf("invalid signatures of milestone %d", proofBundle.Milestone.Index)
+	}
+
+	if err := whiteflag.VerifyProofBundle(proofBundle); err != nil {
+		return err
+	}
+
+	milestoneID, err := proofBundle.Milestone.ID()
+	if err != nil {
+		return err
+	}
+
+	if *outputJSONFlag {
+		return printJSON(struct {
+			BlockID        string                `json:"blockId"`
+			MilestoneIndex iotago.MilestoneIndex `json:"milestoneIndex"`
+			MilestoneID    string                `json:"milestoneId"`
+			Referenced     bool                  `json:"referenced"`
+			Applied        bool                  `json:"applied"`
+		}{
+			BlockID:        proofBundle.BlockID.ToHex(),
+			MilestoneIndex: proofBundle.Milestone.Index,
+			MilestoneID:    milestoneID.ToHex(),
+			Referenced:     true,
+			Applied:        proofBundle.Applied,
+		})
+	}
+
+	fmt.Printf(`    >
+        - Block ID:        %s
+        - Milestone index: %d
+        - Milestone ID:    %s
+        - Referenced:      %s
+        - Applied:         %s`+"\n\n",
+		proofBundle.BlockID.ToHex(),
+		proofBundle.Milestone.Index,
+		milestoneID.ToHex(),
+		yesOrNo(true),
+		yesOrNo(proofBundle.Applied),
+	)
+
+	return nil
+}
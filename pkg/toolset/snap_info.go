@@ -46,16 +46,71 @@ func snapshotInfo(args []string) error {
 		if err != nil {
 			return err
 		}
-		return printFullSnapshotHeaderInfo("", filePath, fullHeader)
+
+		sections, err := snapshotSectionsInfo(filePath, fullHeader.Version)
+		if err != nil {
+			return err
+		}
+
+		return printFullSnapshotHeaderInfo("", filePath, fullHeader, sections)
 
 	case snapshot.Delta:
 		deltaHeader, err := snapshot.ReadDeltaSnapshotHeaderFromFile(filePath)
 		if err != nil {
 			return err
 		}
-		return printDeltaSnapshotHeaderInfo("", filePath, deltaHeader)
+
+		sections, err := snapshotSectionsInfo(filePath, deltaHeader.Version)
+		if err != nil {
+			return err
+		}
+
+		return printDeltaSnapshotHeaderInfo("", filePath, deltaHeader, sections)
 
 	default:
 		return fmt.Errorf("unknown snapshot type: %d", snapshotType)
 	}
 }
+
+// snapshotSectionInfo contains information about a section of a snapshot file.
+type snapshotSectionInfo struct {
+	Type             string `json:"type"`
+	Chunks           int    `json:"chunks"`
+	Count            uint64 `json:"count"`
+	CompressedSize   int64  `json:"compressedSize"`
+	UncompressedSize int64  `json:"uncompressedSize"`
+}
+
+// returns information about the sections of the given snapshot file.
+// only the section index at the end of the file is read, the sections itself are not decompressed.
+// returns nil if the snapshot file contains no section index.
+func snapshotSectionsInfo(path string, version byte) ([]*snapshotSectionInfo, error) {
+
+	if version != snapshot.FormatVersionCompressed {
+		// the snapshot file contains no section index
+		return nil, nil
+	}
+
+	sectionIndex, err := snapshot.ReadSectionIndexFromFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]*snapshotSectionInfo, 0)
+	for _, sectionType := range []snapshot.SectionType{snapshot.SectionTypeOutputs, snapshot.SectionTypeMilestoneDiffs, snapshot.SectionTypeSolidEntryPoints} {
+		entries := sectionIndex.Entries(sectionType)
+		if len(entries) == 0 {
+			continue
+		}
+
+		sections = append(sections, &snapshotSectionInfo{
+			Type:             sectionType.String(),
+			Chunks:           len(entries),
+			Count:            entries.Count(sectionType),
+			CompressedSize:   entries.CompressedSize(sectionType),
+			UncompressedSize: entries.UncompressedSize(sectionType),
+		})
+	}
+
+	return sections, nil
+}
@@ -19,6 +19,7 @@ func snapshotMerge(args []string) error {
 	snapshotPathFullFlag := fs.String(FlagToolSnapshotPathFull, "", "the path to the full snapshot file")
 	snapshotPathDeltaFlag := fs.String(FlagToolSnapshotPathDelta, "", "the path to the delta snapshot file")
 	snapshotPathTargetFlag := fs.String(FlagToolSnapshotPathTarget, "", "the path to the target/merged snapshot file")
+	compressionFlag := fs.Bool(FlagToolSnapshotCompression, false, FlagToolDescriptionSnapshotCompression)
 	outputJSONFlag := fs.Bool(FlagToolOutputJSON, false, FlagToolDescriptionOutputJSON)
 
 	fs.Usage = func() {
@@ -56,7 +57,7 @@ func snapshotMerge(args []string) error {
 
 	ts := time.Now()
 
-	mergeInfo, err := snapshot.MergeSnapshotsFiles(fullPath, deltaPath, targetPath)
+	mergeInfo, err := snapshot.MergeSnapshotsFiles(fullPath, deltaPath, targetPath, snapshot.FormatVersion(*compressionFlag))
 	if err != nil {
 		return err
 	}
@@ -65,9 +66,9 @@ func snapshotMerge(args []string) error {
 		fmt.Printf("metadata:\n")
 	}
 
-	_ = printFullSnapshotHeaderInfo("full", fullPath, mergeInfo.FullSnapshotHeader)
-	_ = printDeltaSnapshotHeaderInfo("delta", deltaPath, mergeInfo.DeltaSnapshotHeader)
-	_ = printFullSnapshotHeaderInfo("merged", targetPath, mergeInfo.MergedSnapshotHeader)
+	_ = printFullSnapshotHeaderInfo("full", fullPath, mergeInfo.FullSnapshotHeader, nil)
+	_ = printDeltaSnapshotHeaderInfo("delta", deltaPath, mergeInfo.DeltaSnapshotHeader, nil)
+	_ = printFullSnapshotHeaderInfo("merged", targetPath, mergeInfo.MergedSnapshotHeader, nil)
 
 	if !*outputJSONFlag {
 		fmt.Printf("successfully created merged full snapshot '%s', took %v\n", targetPath, time.Since(ts).Truncate(time.Millisecond))
@@ -77,7 +78,7 @@ func snapshotMerge(args []string) error {
 }
 
 // prints information about the given full snapshot file header.
-func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.FullSnapshotHeader) error {
+func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.FullSnapshotHeader, sections []*snapshotSectionInfo) error {
 
 	fullHeaderProtoParams, err := fullHeader.ProtocolParameters()
 	if err != nil {
@@ -99,6 +100,7 @@ func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.
 		OutputCount              uint64                     `json:"outputCount"`
 		MilestoneDiffCount       uint32                     `json:"milestoneDiffCount"`
 		SEPCount                 uint16                     `json:"sepCount"`
+		Sections                 []*snapshotSectionInfo     `json:"sections,omitempty"`
 	}{
 		SnapshotName:             name,
 		FilePath:                 path,
@@ -114,25 +116,27 @@ func printFullSnapshotHeaderInfo(name string, path string, fullHeader *snapshot.
 		OutputCount:              fullHeader.OutputCount,
 		MilestoneDiffCount:       fullHeader.MilestoneDiffCount,
 		SEPCount:                 fullHeader.SEPCount,
+		Sections:                 sections,
 	}
 
 	return printJSON(result)
 }
 
 // prints information about the given delta snapshot file header.
-func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapshot.DeltaSnapshotHeader) error {
+func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapshot.DeltaSnapshotHeader, sections []*snapshotSectionInfo) error {
 
 	result := struct {
-		SnapshotName                  string                `json:"snapshotName,omitempty"`
-		FilePath                      string                `json:"filePath"`
-		Version                       byte                  `json:"version"`
-		Type                          string                `json:"type"`
-		TargetMilestoneIndex          iotago.MilestoneIndex `json:"targetMilestoneIndex"`
-		TargetMilestoneTimestamp      time.Time             `json:"targetMilestoneTimestamp"`
-		FullSnapshotTargetMilestoneID string                `json:"fullSnapshotTargetMilestoneID"`
-		SEPFileOffset                 int64                 `json:"sepFileOffset"`
-		MilestoneDiffCount            uint32                `json:"milestoneDiffCount"`
-		SEPCount                      uint16                `json:"sepCount"`
+		SnapshotName                  string                 `json:"snapshotName,omitempty"`
+		FilePath                      string                 `json:"filePath"`
+		Version                       byte                   `json:"version"`
+		Type                          string                 `json:"type"`
+		TargetMilestoneIndex          iotago.MilestoneIndex  `json:"targetMilestoneIndex"`
+		TargetMilestoneTimestamp      time.Time              `json:"targetMilestoneTimestamp"`
+		FullSnapshotTargetMilestoneID string                 `json:"fullSnapshotTargetMilestoneID"`
+		SEPFileOffset                 int64                  `json:"sepFileOffset"`
+		MilestoneDiffCount            uint32                 `json:"milestoneDiffCount"`
+		SEPCount                      uint16                 `json:"sepCount"`
+		Sections                      []*snapshotSectionInfo `json:"sections,omitempty"`
 	}{
 		SnapshotName:                  name,
 		FilePath:                      path,
@@ -144,6 +148,7 @@ func printDeltaSnapshotHeaderInfo(name string, path string, deltaHeader *snapsho
 		SEPFileOffset:                 deltaHeader.SEPFileOffset,
 		MilestoneDiffCount:            deltaHeader.MilestoneDiffCount,
 		SEPCount:                      deltaHeader.SEPCount,
+		Sections:                      sections,
 	}
 
 	return printJSON(result)
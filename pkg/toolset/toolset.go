@@ -28,6 +28,8 @@ const (
 	FlagToolDatabasePathSource = "sourceDatabasePath"
 	FlagToolDatabasePathTarget = "targetDatabasePath"
 
+	FlagToolCheckpointPath = "checkpointPath"
+
 	FlagToolProtocolParametersPath = "protocolParametersPath"
 	FlagToolCoordinatorStatePath   = "cooStatePath"
 
@@ -38,6 +40,8 @@ const (
 
 	FlagToolOutputPath = "outputPath"
 
+	FlagToolProofPath = "proofPath"
+
 	FlagToolPrivateKey = "privateKey"
 	FlagToolPublicKey  = "publicKey"
 
@@ -47,6 +51,9 @@ const (
 	FlagToolPassword  = "password"
 	FlagToolSalt      = "salt"
 
+	FlagToolSnapshotCompression            = "compression"
+	FlagToolDescriptionSnapshotCompression = "write the snapshot file in the compressed file format (can't be read by older versions of HORNET and existing tools)"
+
 	FlagToolOutputJSON            = "json"
 	FlagToolDescriptionOutputJSON = "format output as JSON"
 
@@ -59,6 +66,8 @@ const (
 	FlagToolSnapGenTreasuryAllocation = "treasuryAllocation"
 
 	FlagToolDatabaseTargetIndex            = "targetIndex"
+	FlagToolDatabasePruneDepth             = "depth"
+	FlagToolDatabasePruneSnapshotDepth     = "snapshotDepth"
 	FlagToolDatabaseMergeNodeURL           = "nodeURL"
 	FlagToolDatabaseMergeChronicle         = "chronicleMode"
 	FlagToolDatabaseMergeChronicleKeyspace = "chronicleKeySpace"
@@ -77,12 +86,18 @@ const (
 	ToolSnapHash               = "snap-hash"
 	ToolBenchmarkIO            = "bench-io"
 	ToolBenchmarkCPU           = "bench-cpu"
+	ToolDatabaseArchive        = "db-archive"
 	ToolDatabaseLedgerHash     = "db-hash"
+	ToolDatabaseCheckpoint     = "db-checkpoint"
 	ToolDatabaseHealth         = "db-health"
 	ToolDatabaseMerge          = "db-merge"
 	ToolDatabaseMigration      = "db-migration"
+	ToolDatabasePruneDryRun    = "db-prune-dry-run"
+	ToolDatabaseRestore        = "db-restore"
+	ToolDatabaseRollback       = "db-rollback"
 	ToolDatabaseSnapshot       = "db-snapshot"
 	ToolDatabaseVerify         = "db-verify"
+	ToolProofVerify            = "proof-verify"
 	ToolBootstrapPrivateTangle = "bootstrap-private-tangle"
 )
 
@@ -134,12 +149,18 @@ func HandleTools() {
 		ToolSnapHash:               snapshotHash,
 		ToolBenchmarkIO:            benchmarkIO,
 		ToolBenchmarkCPU:           benchmarkCPU,
+		ToolDatabaseArchive:        databaseArchive,
 		ToolDatabaseLedgerHash:     databaseLedgerHash,
+		ToolDatabaseCheckpoint:     databaseCheckpoint,
 		ToolDatabaseHealth:         databaseHealth,
 		ToolDatabaseMerge:          databaseMerge,
 		ToolDatabaseMigration:      databaseMigration,
+		ToolDatabasePruneDryRun:    databasePruneDryRun,
+		ToolDatabaseRestore:        databaseRestore,
+		ToolDatabaseRollback:       databaseRollback,
 		ToolDatabaseSnapshot:       databaseSnapshot,
 		ToolDatabaseVerify:         databaseVerify,
+		ToolProofVerify:            proofVerify,
 		ToolBootstrapPrivateTangle: networkBootstrap,
 	}
 
@@ -176,12 +197,18 @@ func listTools() {
 	fmt.Printf("%-20s calculates the sha256 hash of the ledger state inside a snapshot file\n", fmt.Sprintf("%s:", ToolSnapHash))
 	fmt.Printf("%-20s benchmarks the IO throughput\n", fmt.Sprintf("%s:", ToolBenchmarkIO))
 	fmt.Printf("%-20s benchmarks the CPU performance\n", fmt.Sprintf("%s:", ToolBenchmarkCPU))
+	fmt.Printf("%-20s builds the archive indexes of a database and backfills them from older snapshots\n", fmt.Sprintf("%s:", ToolDatabaseArchive))
 	fmt.Printf("%-20s calculates the sha256 hash of the ledger state of a database\n", fmt.Sprintf("%s:", ToolDatabaseLedgerHash))
+	fmt.Printf("%-20s creates a consistent checkpoint of the database\n", fmt.Sprintf("%s:", ToolDatabaseCheckpoint))
 	fmt.Printf("%-20s checks the health status of the database\n", fmt.Sprintf("%s:", ToolDatabaseHealth))
 	fmt.Printf("%-20s merges missing tangle data from a database to another one\n", fmt.Sprintf("%s:", ToolDatabaseMerge))
 	fmt.Printf("%-20s migrates the database to another engine\n", fmt.Sprintf("%s:", ToolDatabaseMigration))
+	fmt.Printf("%-20s estimates the impact of pruning the database without modifying it\n", fmt.Sprintf("%s:", ToolDatabasePruneDryRun))
+	fmt.Printf("%-20s verifies a database checkpoint and restores the database from it\n", fmt.Sprintf("%s:", ToolDatabaseRestore))
+	fmt.Printf("%-20s rolls back the ledger state of a database to an older milestone\n", fmt.Sprintf("%s:", ToolDatabaseRollback))
 	fmt.Printf("%-20s creates a full snapshot from a database\n", fmt.Sprintf("%s:", ToolDatabaseSnapshot))
 	fmt.Printf("%-20s verifies a valid ledger state and the existence of all blocks\n", fmt.Sprintf("%s:", ToolDatabaseVerify))
+	fmt.Printf("%-20s verifies a block proof bundle against the coordinator public keys\n", fmt.Sprintf("%s:", ToolProofVerify))
 	fmt.Printf("%-20s bootstraps a private tangle by creating a snapshot, database and coordinator state file\n", fmt.Sprintf("%s:", ToolBootstrapPrivateTangle))
 }
 
@@ -221,7 +221,16 @@ func ConfirmMilestone(
 		}
 		timeReceipts = time.Now()
 
-		if err = utxoManager.ApplyConfirmationWithoutLocking(milestoneIndex, newOutputs, newSpents, treasuryMutation, newReceipt); err != nil {
+		// the referenced blocks are archived in the same batch as the confirmation
+		var referencedBlockIDs iotago.BlockIDs
+		if utxoManager.ArchiveEnabled() {
+			referencedBlockIDs = make(iotago.BlockIDs, len(mutations.ReferencedBlocks))
+			for wfIndex, referencedBlock := range mutations.ReferencedBlocks {
+				referencedBlockIDs[wfIndex] = referencedBlock.BlockID
+			}
+		}
+
+		if err = utxoManager.ApplyConfirmationWithReferencedBlocksWithoutLocking(milestoneIndex, newOutputs, newSpents, treasuryMutation, newReceipt, referencedBlockIDs); err != nil {
 			return fmt.Errorf("confirmMilestone: utxo.ApplyConfirmation failed: %w", err)
 		}
 		timeConfirmation = time.Now()
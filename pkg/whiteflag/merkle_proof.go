@@ -0,0 +1,333 @@
+package whiteflag
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/dag"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	iotago "github.com/iotaledger/iota.go/v3"
+	"github.com/iotaledger/iota.go/v3/merklehasher"
+)
+
+var (
+	// ErrBlockNotReferenced is returned if a proof is requested for a block that was not referenced by a milestone.
+	ErrBlockNotReferenced = errors.New("block not referenced by a milestone")
+	// ErrMerkleRootMismatch is returned if the audit path of a block doesn't lead to the merkle root of the milestone.
+	ErrMerkleRootMismatch = errors.New("merkle root mismatch")
+	// ErrInvalidProofBundle is returned if a proof bundle is incomplete.
+	ErrInvalidProofBundle = errors.New("invalid proof bundle")
+)
+
+// ProofBundle contains the audit paths of a block within the merkle roots of the milestone that referenced it.
+type ProofBundle struct {
+	// The milestone that referenced the block.
+	Milestone *iotago.Milestone
+	// The ID of the block.
+	BlockID iotago.BlockID
+	// The audit path of the block within the inclusion merkle root of the milestone.
+	// It is nil if the block was the only block referenced by the milestone.
+	InclusionProof *merklehasher.Proof
+	// Whether the block contains a transaction that was applied to the ledger.
+	Applied bool
+	// The audit path of the block within the applied merkle root of the milestone.
+	// It is nil if the transaction was not applied or was the only transaction applied by the milestone.
+	AppliedProof *merklehasher.Proof
+}
+
+type jsonProofBundle struct {
+	Milestone      *json.RawMessage `json:"milestone"`
+	BlockID        string           `json:"blockId"`
+	InclusionProof *json.RawMessage `json:"inclusionProof,omitempty"`
+	Applied        bool             `json:"applied"`
+	AppliedProof   *json.RawMessage `json:"appliedProof,omitempty"`
+}
+
+func marshalRawJSON(value json.Marshaler) (*json.RawMessage, error) {
+	jsonBytes, err := value.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(jsonBytes)
+
+	return &raw, nil
+}
+
+// MarshalJSON returns the JSON representation of the proof bundle.
+func (b *ProofBundle) MarshalJSON() ([]byte, error) {
+	if b.Milestone == nil {
+		return nil, ErrInvalidProofBundle
+	}
+
+	jsonMilestone, err := marshalRawJSON(b.Milestone)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &jsonProofBundle{
+		Milestone: jsonMilestone,
+		BlockID:   b.BlockID.ToHex(),
+		Applied:   b.Applied,
+	}
+
+	if b.InclusionProof != nil {
+		if j.InclusionProof, err = marshalRawJSON(b.InclusionProof); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.AppliedProof != nil {
+		if j.AppliedProof, err = marshalRawJSON(b.AppliedProof); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON parses the JSON representation of a proof bundle.
+func (b *ProofBundle) UnmarshalJSON(data []byte) error {
+	j := &jsonProofBundle{}
+	if err := json.Unmarshal(data, j); err != nil {
+		return err
+	}
+
+	if j.Milestone == nil {
+		return errors.WithMessage(ErrInvalidProofBundle, "missing milestone")
+	}
+
+	milestonePayload := &iotago.Milestone{}
+	if err := milestonePayload.UnmarshalJSON(*j.Milestone); err != nil {
+		return errors.WithMessagef(ErrInvalidProofBundle, "invalid milestone: %s", err)
+	}
+
+	blockID, err := iotago.BlockIDFromHexString(j.BlockID)
+	if err != nil {
+		return errors.WithMessagef(ErrInvalidProofBundle, "invalid block ID: %s", err)
+	}
+
+	var inclusionProof *merklehasher.Proof
+	if j.InclusionProof != nil {
+		inclusionProof = &merklehasher.Proof{}
+		if err := inclusionProof.UnmarshalJSON(*j.InclusionProof); err != nil {
+			return errors.WithMessagef(ErrInvalidProofBundle, "invalid inclusion proof: %s", err)
+		}
+	}
+
+	var appliedProof *merklehasher.Proof
+	if j.AppliedProof != nil {
+		appliedProof = &merklehasher.Proof{}
+		if err := appliedProof.UnmarshalJSON(*j.AppliedProof); err != nil {
+			return errors.WithMessagef(ErrInvalidProofBundle, "invalid applied proof: %s", err)
+		}
+	}
+
+	b.Milestone = milestonePayload
+	b.BlockID = blockID
+	b.InclusionProof = inclusionProof
+	b.Applied = j.Applied
+	b.AppliedProof = appliedProof
+
+	return nil
+}
+
+// referencedBlock is a block in the cone of a milestone with its white flag index.
+type referencedBlock struct {
+	blockID        iotago.BlockID
+	whiteFlagIndex uint32
+	applied        bool
+}
+
+// milestoneConeWhiteFlagOrder returns the blocks referenced by the given milestone in white flag order.
+func milestoneConeWhiteFlagOrder(ctx context.Context, dbStorage *storage.Storage, msIndex iotago.MilestoneIndex, parents iotago.BlockIDs) ([]*referencedBlock, error) {
+
+	var referencedBlocks []*referencedBlock
+	if err := dag.TraverseParents(
+		ctx,
+		dbStorage,
+		parents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storage.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			referenced, referencedIndex := cachedBlockMeta.Metadata().ReferencedWithIndex()
+
+			// only the blocks referenced by that milestone are part of its cone
+			return referenced && referencedIndex == msIndex, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storage.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+			metadata := cachedBlockMeta.Metadata()
+
+			_, _, wfIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
+			referencedBlocks = append(referencedBlocks, &referencedBlock{
+				blockID:        metadata.BlockID(),
+				whiteFlagIndex: wfIndex,
+				applied:        metadata.IsIncludedTxInLedger(),
+			})
+
+			return nil
+		},
+		// called on missing parents
+		func(parentBlockID iotago.BlockID) error { return nil },
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(referencedBlocks, func(i, j int) bool {
+		return referencedBlocks[i].whiteFlagIndex < referencedBlocks[j].whiteFlagIndex
+	})
+
+	return referencedBlocks, nil
+}
+
+// computeAuditPath computes the audit path of the block within the merkle tree of the given block IDs
+// and checks that it leads to the expected merkle root.
+// The audit path is nil if the block is the only leaf of the tree.
+func computeAuditPath(hasher *merklehasher.Hasher, blockIDs iotago.BlockIDs, blockID iotago.BlockID, expectedRoot iotago.MilestoneMerkleProof) (*merklehasher.Proof, error) {
+
+	var proof *merklehasher.Proof
+	if len(blockIDs) > 1 {
+		var err error
+		if proof, err = hasher.ComputeProof(blockIDs, blockID); err != nil {
+			return nil, err
+		}
+	}
+
+	root, err := auditPathRoot(hasher, blockID, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(root, expectedRoot[:]) {
+		// the cone of the milestone in the database is incomplete
+		return nil, errors.WithMessagef(ErrMerkleRootMismatch, "computed: %s, milestone: %s", iotago.EncodeHex(root), iotago.EncodeHex(expectedRoot[:]))
+	}
+
+	return proof, nil
+}
+
+// auditPathRoot returns the merkle root the audit path of the block leads to.
+// A nil audit path stands for a merkle tree that only contains the block.
+func auditPathRoot(hasher *merklehasher.Hasher, blockID iotago.BlockID, proof *merklehasher.Proof) ([]byte, error) {
+
+	if proof == nil {
+		return hasher.HashBlockIDs(iotago.BlockIDs{blockID}), nil
+	}
+
+	contains, err := proof.ContainsValue(blockID)
+	if err != nil {
+		return nil, err
+	}
+	if !contains {
+		return nil, errors.WithMessagef(ErrInvalidProofBundle, "audit path doesn't contain block %s", blockID.ToHex())
+	}
+
+	return proof.Hash(hasher), nil
+}
+
+// ComputeProofBundle computes the audit paths of the given block within the merkle roots of the milestone that referenced it.
+// The cone of the milestone needs to be in the database.
+func ComputeProofBundle(ctx context.Context, dbStorage *storage.Storage, blockID iotago.BlockID) (*ProofBundle, error) {
+
+	cachedBlockMeta := dbStorage.CachedBlockMetadataOrNil(blockID) // meta +1
+	if cachedBlockMeta == nil {
+		return nil, errors.WithMessagef(common.ErrBlockNotFound, "block: %s", blockID.ToHex())
+	}
+	referenced, msIndex := cachedBlockMeta.Metadata().ReferencedWithIndex()
+	cachedBlockMeta.Release(true) // meta -1
+
+	if !referenced {
+		return nil, errors.WithMessagef(ErrBlockNotReferenced, "block: %s", blockID.ToHex())
+	}
+
+	cachedMilestone := dbStorage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+	if cachedMilestone == nil {
+		return nil, errors.WithMessagef(storage.ErrMilestoneNotFound, "index: %d", msIndex)
+	}
+	milestonePayload := cachedMilestone.Milestone().Milestone()
+	cachedMilestone.Release(true) // milestone -1
+
+	referencedBlocks, err := milestoneConeWhiteFlagOrder(ctx, dbStorage, msIndex, milestonePayload.Parents)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &ProofBundle{
+		Milestone: milestonePayload,
+		BlockID:   blockID,
+	}
+
+	includedBlockIDs := make(iotago.BlockIDs, 0, len(referencedBlocks))
+	appliedBlockIDs := make(iotago.BlockIDs, 0)
+	for _, block := range referencedBlocks {
+		includedBlockIDs = append(includedBlockIDs, block.blockID)
+		if block.applied {
+			appliedBlockIDs = append(appliedBlockIDs, block.blockID)
+		}
+		if block.blockID == blockID {
+			bundle.Applied = block.applied
+		}
+	}
+
+	hasher := merklehasher.NewHasher(crypto.BLAKE2b_256)
+
+	if bundle.InclusionProof, err = computeAuditPath(hasher, includedBlockIDs, blockID, milestonePayload.InclusionMerkleRoot); err != nil {
+		return nil, errors.WithMessagef(err, "computing inclusion proof for block %s failed", blockID.ToHex())
+	}
+
+	if bundle.Applied {
+		if bundle.AppliedProof, err = computeAuditPath(hasher, appliedBlockIDs, blockID, milestonePayload.AppliedMerkleRoot); err != nil {
+			return nil, errors.WithMessagef(err, "computing applied proof for block %s failed", blockID.ToHex())
+		}
+	}
+
+	return bundle, nil
+}
+
+// VerifyProofBundle checks that the audit paths of the proof bundle lead to the merkle roots of the milestone.
+// Attention: It does not verify the signatures of the milestone.
+func VerifyProofBundle(bundle *ProofBundle) error {
+
+	if bundle.Milestone == nil {
+		return errors.WithMessage(ErrInvalidProofBundle, "missing milestone")
+	}
+
+	hasher := merklehasher.NewHasher(crypto.BLAKE2b_256)
+
+	inclusionRoot, err := auditPathRoot(hasher, bundle.BlockID, bundle.InclusionProof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(inclusionRoot, bundle.Milestone.InclusionMerkleRoot[:]) {
+		return errors.WithMessagef(ErrMerkleRootMismatch, "inclusion proof of block %s doesn't lead to the inclusion merkle root of milestone %d", bundle.BlockID.ToHex(), bundle.Milestone.Index)
+	}
+
+	if !bundle.Applied {
+		if bundle.AppliedProof != nil {
+			return errors.WithMessage(ErrInvalidProofBundle, "applied proof given for a block that was not applied")
+		}
+
+		return nil
+	}
+
+	appliedRoot, err := auditPathRoot(hasher, bundle.BlockID, bundle.AppliedProof)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(appliedRoot, bundle.Milestone.AppliedMerkleRoot[:]) {
+		return errors.WithMessagef(ErrMerkleRootMismatch, "applied proof of block %s doesn't lead to the applied merkle root of milestone %d", bundle.BlockID.ToHex(), bundle.Milestone.Index)
+	}
+
+	return nil
+}
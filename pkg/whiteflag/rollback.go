@@ -0,0 +1,209 @@
+package whiteflag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/contextutils"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/dag"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrRollbackTargetIndexBelowPruningIndex is returned if the target index of a rollback is below the pruning index.
+	ErrRollbackTargetIndexBelowPruningIndex = errors.New("target index is below the pruning index")
+	// ErrRollbackTargetIndexNotBelowLedgerIndex is returned if the target index of a rollback is not below the ledger index.
+	ErrRollbackTargetIndexNotBelowLedgerIndex = errors.New("target index is not below the ledger index")
+)
+
+// CheckRollbackTargetIndex checks whether the confirmations of all milestones newer than the given target index can be reverted.
+// The target index needs to be below the ledger index and not below the pruning index and the entry point index.
+// Returns the current ledger index.
+func CheckRollbackTargetIndex(dbStorage *storage.Storage, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+
+	utxoManager := dbStorage.UTXOManager()
+
+	utxoManager.ReadLockLedger()
+	defer utxoManager.ReadUnlockLedger()
+
+	return checkRollbackTargetIndexWithoutLocking(dbStorage, targetIndex)
+}
+
+// checkRollbackTargetIndexWithoutLocking checks the target index of a rollback against the current ledger index and snapshot info.
+// the caller needs to hold the ledger lock.
+func checkRollbackTargetIndexWithoutLocking(dbStorage *storage.Storage, targetIndex iotago.MilestoneIndex) (iotago.MilestoneIndex, error) {
+
+	snapshotInfo := dbStorage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return 0, common.ErrSnapshotInfoNotFound
+	}
+
+	ledgerIndex, err := dbStorage.UTXOManager().ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return 0, err
+	}
+
+	if targetIndex >= ledgerIndex {
+		return ledgerIndex, errors.Wrapf(ErrRollbackTargetIndexNotBelowLedgerIndex, "target index: %d, ledger index: %d", targetIndex, ledgerIndex)
+	}
+
+	if targetIndex < snapshotInfo.PruningIndex() {
+		return ledgerIndex, errors.Wrapf(ErrRollbackTargetIndexBelowPruningIndex, "target index: %d, pruning index: %d", targetIndex, snapshotInfo.PruningIndex())
+	}
+
+	// a running pruning moves the entry point index before the milestones are pruned
+	if targetIndex < snapshotInfo.EntryPointIndex() {
+		return ledgerIndex, errors.Wrapf(ErrRollbackTargetIndexBelowPruningIndex, "target index: %d, entry point index: %d", targetIndex, snapshotInfo.EntryPointIndex())
+	}
+
+	return ledgerIndex, nil
+}
+
+// RollbackMilestones reverts the confirmations of all milestones newer than the given target index.
+// The milestone diffs are walked backwards to revert the ledger changes, and the metadata of all blocks
+// referenced by the reverted milestones is reset afterwards, so the milestones can be confirmed again.
+// If the metadata can't be reset after the ledger changes of a milestone were reverted, the databases are marked as tainted.
+// Milestones and blocks are kept in the database.
+// The target index is checked with CheckRollbackTargetIndex while the ledger is locked.
+// If the context is canceled, the rollback stops at a consistent milestone and common.ErrOperationAborted is returned.
+// Returns the ledger index before the rollback.
+func RollbackMilestones(ctx context.Context, dbStorage *storage.Storage, targetIndex iotago.MilestoneIndex, onMilestoneRolledBack func(msIndex iotago.MilestoneIndex)) (iotago.MilestoneIndex, error) {
+
+	utxoManager := dbStorage.UTXOManager()
+
+	utxoManager.WriteLockLedger()
+	defer utxoManager.WriteUnlockLedger()
+
+	ledgerIndex, err := checkRollbackTargetIndexWithoutLocking(dbStorage, targetIndex)
+	if err != nil {
+		return ledgerIndex, err
+	}
+
+	// check that all needed data is available before modifying the database
+	for msIndex := ledgerIndex; msIndex > targetIndex; msIndex-- {
+		if _, err := dbStorage.MilestoneParentsByIndex(msIndex); err != nil {
+			return ledgerIndex, fmt.Errorf("loading milestone %d failed: %w", msIndex, err)
+		}
+
+		if _, err := utxoManager.MilestoneDiffWithoutLocking(msIndex); err != nil {
+			return ledgerIndex, fmt.Errorf("loading milestone diff %d failed: %w", msIndex, err)
+		}
+	}
+
+	for msIndex := ledgerIndex; msIndex > targetIndex; msIndex-- {
+		if err := contextutils.ReturnErrIfCtxDone(ctx, common.ErrOperationAborted); err != nil {
+			return ledgerIndex, err
+		}
+
+		// the ledger changes are reverted in a single batch, so the metadata is only reset if the ledger was rolled back.
+		if err := rollbackMilestoneLedgerChanges(dbStorage, utxoManager, msIndex); err != nil {
+			return ledgerIndex, fmt.Errorf("rolling back ledger changes of milestone %d failed: %w", msIndex, err)
+		}
+
+		// we pass a background context here to not cancel the rollback of a milestone halfway.
+		// the rollback can only be aborted between milestones to keep the database consistent.
+		if err := resetReferencedBlocksMetadata(context.Background(), dbStorage, msIndex); err != nil {
+			// the blocks are still marked as referenced by a milestone that is not part of the ledger anymore
+			if errTainted := dbStorage.MarkDatabasesTainted(); errTainted != nil {
+				return ledgerIndex, fmt.Errorf("resetting block metadata of milestone %d failed: %w, marking databases as tainted failed: %s", msIndex, err, errTainted)
+			}
+
+			return ledgerIndex, fmt.Errorf("resetting block metadata of milestone %d failed, databases marked as tainted: %w", msIndex, err)
+		}
+
+		if onMilestoneRolledBack != nil {
+			onMilestoneRolledBack(msIndex)
+		}
+	}
+
+	return ledgerIndex, nil
+}
+
+// resetReferencedBlocksMetadata resets the metadata of all blocks that were referenced by the given milestone.
+func resetReferencedBlocksMetadata(ctx context.Context, dbStorage *storage.Storage, msIndex iotago.MilestoneIndex) error {
+
+	milestoneParents, err := dbStorage.MilestoneParentsByIndex(msIndex)
+	if err != nil {
+		return err
+	}
+
+	return dag.TraverseParents(
+		ctx,
+		dbStorage,
+		milestoneParents,
+		// traversal stops if no more blocks pass the given condition
+		// Caution: condition func is not in DFS order
+		func(cachedBlockMeta *storage.CachedMetadata) (bool, error) { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			// only walk the blocks that were referenced by the milestone
+			referenced, at := cachedBlockMeta.Metadata().ReferencedWithIndex()
+
+			return referenced && at >= msIndex, nil
+		},
+		// consumer
+		func(cachedBlockMeta *storage.CachedMetadata) error { // meta +1
+			defer cachedBlockMeta.Release(true) // meta -1
+
+			metadata := cachedBlockMeta.Metadata()
+			metadata.SetReferenced(false, 0, 0)
+			metadata.SetIsNoTransaction(false)
+			metadata.SetConflictingTx(storage.ConflictNone)
+
+			// the cone root indexes need to be calculated again
+			metadata.SetConeRootIndexes(0, 0, 0)
+
+			return nil
+		},
+		// called on missing parents
+		// return error on missing parents
+		nil,
+		// called on solid entry points
+		// Ignore solid entry points (snapshot milestone included)
+		nil,
+		false)
+}
+
+// rollbackMilestoneLedgerChanges reverts the ledger changes of the given milestone with the help of the milestone diff.
+func rollbackMilestoneLedgerChanges(dbStorage *storage.Storage, utxoManager *utxo.Manager, msIndex iotago.MilestoneIndex) error {
+
+	msDiff, err := utxoManager.MilestoneDiffWithoutLocking(msIndex)
+	if err != nil {
+		return err
+	}
+
+	var treasuryMutation *utxo.TreasuryMutationTuple
+	var receipt *utxo.ReceiptTuple
+
+	if msDiff.TreasuryOutput != nil {
+		treasuryMutation = &utxo.TreasuryMutationTuple{
+			NewOutput:   msDiff.TreasuryOutput,
+			SpentOutput: msDiff.SpentTreasuryOutput,
+		}
+
+		cachedMilestone := dbStorage.CachedMilestoneByIndexOrNil(msIndex) // milestone +1
+		if cachedMilestone == nil {
+			return errors.Wrapf(storage.ErrMilestoneNotFound, "index: %d", msIndex)
+		}
+		defer cachedMilestone.Release(true) // milestone -1
+
+		opts, err := cachedMilestone.Milestone().Milestone().Opts.Set()
+		if err != nil {
+			return err
+		}
+
+		if r := opts.Receipt(); r != nil {
+			receipt = &utxo.ReceiptTuple{
+				Receipt:        r,
+				MilestoneIndex: msIndex,
+			}
+		}
+	}
+
+	return utxoManager.RollbackConfirmationWithoutLocking(msIndex, msDiff.Outputs, msDiff.Spents, treasuryMutation, receipt)
+}
@@ -0,0 +1,47 @@
+package whiteflag
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+var (
+	// ErrInvalidTransaction is returned if the transaction can not be simulated because it is malformed.
+	ErrInvalidTransaction = errors.New("invalid transaction")
+)
+
+// SimulateTransaction validates the transaction against the current confirmed ledger state in the same way
+// white-flag validates it during the confirmation of a milestone, without applying it to the ledger.
+// Timelocks and expirations are checked relative to the given milestone timestamp.
+// Returns the conflict reason the transaction would have if it was referenced by the next milestone.
+// The transaction needs to be syntactically valid.
+// The ledger state must be read locked while this function is getting called in order to ensure consistency.
+func SimulateTransaction(utxoManager *utxo.Manager, transaction *iotago.Transaction, msTimestamp uint32) (storage.Conflict, error) {
+
+	essence := transaction.Essence
+	if essence == nil {
+		return storage.ConflictNone, errors.WithMessage(ErrInvalidTransaction, "essence missing")
+	}
+
+	inputs := make(iotago.OutputIDs, 0, len(essence.Inputs))
+	for _, input := range essence.Inputs {
+		utxoInput, ok := input.(*iotago.UTXOInput)
+		if !ok {
+			return storage.ConflictNone, errors.WithMessagef(ErrInvalidTransaction, "%s: %T", iotago.ErrUnsupportedInputType, input)
+		}
+		inputs = append(inputs, utxoInput.ID())
+	}
+
+	semValCtx := &iotago.SemanticValidationContext{
+		ExtParas: &iotago.ExternalUnlockParameters{
+			ConfUnix: msTimestamp,
+		},
+	}
+
+	_, conflict, err := validateTransaction(utxoManager, semValCtx, transaction, inputs, nil, nil)
+
+	return conflict, err
+}
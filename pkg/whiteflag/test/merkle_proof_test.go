@@ -0,0 +1,84 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite/utils"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestProofBundle(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	genesisAddress := seed1Wallet.Address()
+
+	te := testsuite.SetupTestEnvironment(t, genesisAddress, 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	//Add token supply to our local HDWallet
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	blockA := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(1_000_000).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	blockB := te.NewBlockBuilder("B").
+		Parents(append(te.LastMilestoneParents(), blockA.StoredBlockID())).
+		BuildTaggedData().
+		Store()
+
+	conf, _ := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockB.StoredBlockID()}, false)
+	require.Len(t, conf.Mutations.ReferencedBlocks.IncludedTransactionBlockIDs(), 1)
+
+	blockC := te.NewBlockBuilder("C").
+		Parents(te.LastMilestoneParents()).
+		BuildTaggedData().
+		Store()
+
+	// the transaction is the only applied block of the milestone
+	bundleA, err := whiteflag.ComputeProofBundle(context.Background(), te.Storage(), blockA.StoredBlockID())
+	require.NoError(t, err)
+	require.Equal(t, conf.MilestoneIndex, bundleA.Milestone.Index)
+	require.NotNil(t, bundleA.InclusionProof)
+	require.True(t, bundleA.Applied)
+	require.Nil(t, bundleA.AppliedProof)
+	require.NoError(t, whiteflag.VerifyProofBundle(bundleA))
+
+	bundleB, err := whiteflag.ComputeProofBundle(context.Background(), te.Storage(), blockB.StoredBlockID())
+	require.NoError(t, err)
+	require.NotNil(t, bundleB.InclusionProof)
+	require.False(t, bundleB.Applied)
+	require.NoError(t, whiteflag.VerifyProofBundle(bundleB))
+
+	// the bundle can be verified offline after a JSON round trip
+	bundleJSON, err := json.Marshal(bundleB)
+	require.NoError(t, err)
+
+	bundleFromJSON := &whiteflag.ProofBundle{}
+	require.NoError(t, json.Unmarshal(bundleJSON, bundleFromJSON))
+	require.Equal(t, bundleB.BlockID, bundleFromJSON.BlockID)
+	require.NoError(t, whiteflag.VerifyProofBundle(bundleFromJSON))
+
+	// the audit path of a block can't be used for another block
+	bundleFromJSON.BlockID = blockA.StoredBlockID()
+	require.ErrorIs(t, whiteflag.VerifyProofBundle(bundleFromJSON), whiteflag.ErrInvalidProofBundle)
+
+	// the block was not applied to the ledger
+	bundleB.Applied = true
+	require.ErrorIs(t, whiteflag.VerifyProofBundle(bundleB), whiteflag.ErrMerkleRootMismatch)
+
+	_, err = whiteflag.ComputeProofBundle(context.Background(), te.Storage(), blockC.StoredBlockID())
+	require.ErrorIs(t, err, whiteflag.ErrBlockNotReferenced)
+}
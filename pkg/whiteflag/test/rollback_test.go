@@ -0,0 +1,121 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite/utils"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestRollbackMilestones(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	genesisAddress := seed1Wallet.Address()
+
+	te := testsuite.SetupTestEnvironment(t, genesisAddress, 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	//Add token supply to our local HDWallet
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	blockA := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(1_000_000).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	_, confStats := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockA.StoredBlockID()}, false)
+	targetIndex := confStats.Index
+
+	targetLedgerState, err := te.UTXOManager().LedgerStateSHA256Sum()
+	require.NoError(t, err)
+
+	blockB := te.NewBlockBuilder("B").
+		Parents(append(te.LastMilestoneParents(), blockA.StoredBlockID())).
+		FromWallet(seed2Wallet).
+		Amount(1_000_000).
+		BuildTransactionToWallet(seed1Wallet).
+		Store().
+		BookOnWallets()
+
+	_, confStats = te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockB.StoredBlockID()}, false)
+	ledgerIndex := confStats.Index
+
+	ledgerState, err := te.UTXOManager().LedgerStateSHA256Sum()
+	require.NoError(t, err)
+
+	// the target index needs to be below the ledger index
+	_, err = whiteflag.RollbackMilestones(context.Background(), te.Storage(), ledgerIndex, nil)
+	require.ErrorIs(t, err, whiteflag.ErrRollbackTargetIndexNotBelowLedgerIndex)
+
+	// the target index must not be below the pruning index
+	require.NoError(t, te.Storage().SetPruningIndex(ledgerIndex))
+	_, err = whiteflag.RollbackMilestones(context.Background(), te.Storage(), targetIndex, nil)
+	require.ErrorIs(t, err, whiteflag.ErrRollbackTargetIndexBelowPruningIndex)
+	require.NoError(t, te.Storage().SetPruningIndex(0))
+
+	var rolledBack []iotago.MilestoneIndex
+	previousLedgerIndex, err := whiteflag.RollbackMilestones(context.Background(), te.Storage(), targetIndex, func(msIndex iotago.MilestoneIndex) {
+		rolledBack = append(rolledBack, msIndex)
+	})
+	require.NoError(t, err)
+	require.Equal(t, ledgerIndex, previousLedgerIndex)
+	require.Equal(t, []iotago.MilestoneIndex{ledgerIndex}, rolledBack)
+
+	// the ledger state equals the state of the target milestone
+	currentLedgerIndex, err := te.UTXOManager().ReadLedgerIndex()
+	require.NoError(t, err)
+	require.Equal(t, targetIndex, currentLedgerIndex)
+
+	currentLedgerState, err := te.UTXOManager().LedgerStateSHA256Sum()
+	require.NoError(t, err)
+	require.Equal(t, targetLedgerState, currentLedgerState)
+
+	te.AssertLedgerBalance(seed2Wallet, 1_000_000)
+
+	// the blocks referenced by the reverted milestone are not referenced anymore
+	cachedBlockMetaB := te.Storage().CachedBlockMetadataOrNil(blockB.StoredBlockID()) // meta +1
+	require.NotNil(t, cachedBlockMetaB)
+	require.False(t, cachedBlockMetaB.Metadata().IsReferenced())
+	require.False(t, cachedBlockMetaB.Metadata().IsIncludedTxInLedger())
+	cachedBlockMetaB.Release(true) // meta -1
+
+	cachedBlockMetaA := te.Storage().CachedBlockMetadataOrNil(blockA.StoredBlockID()) // meta +1
+	require.NotNil(t, cachedBlockMetaA)
+	referenced, at := cachedBlockMetaA.Metadata().ReferencedWithIndex()
+	require.True(t, referenced)
+	require.Equal(t, targetIndex, at)
+	cachedBlockMetaA.Release(true) // meta -1
+
+	// the reverted milestone can be confirmed again
+	te.SyncManager().OverwriteConfirmedMilestoneIndex(targetIndex)
+
+	cachedMilestone := te.Storage().CachedMilestoneByIndexOrNil(ledgerIndex) // milestone +1
+	require.NotNil(t, cachedMilestone)
+	defer cachedMilestone.Release(true) // milestone -1
+
+	_, confStats = te.ConfirmMilestone(cachedMilestone.Milestone(), false)
+	require.Equal(t, 1, confStats.BlocksIncludedWithTransactions)
+
+	currentLedgerState, err = te.UTXOManager().LedgerStateSHA256Sum()
+	require.NoError(t, err)
+	require.Equal(t, ledgerState, currentLedgerState)
+
+	te.AssertLedgerBalance(seed2Wallet, 0)
+
+	// the rollback can be aborted
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	_, err = whiteflag.RollbackMilestones(ctx, te.Storage(), targetIndex, nil)
+	require.Error(t, err)
+}
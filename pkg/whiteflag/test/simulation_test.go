@@ -0,0 +1,192 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite"
+	"github.com/iotaledger/hornet/v2/pkg/testsuite/utils"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func TestSimulateTransaction(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	genesisAddress := seed1Wallet.Address()
+
+	te := testsuite.SetupTestEnvironment(t, genesisAddress, 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	//Add token supply to our local HDWallet
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	blockA := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(te.ProtocolParameters().TokenSupply).
+		BuildTransactionToWallet(seed2Wallet).
+		Store().
+		BookOnWallets()
+
+	transaction := blockA.IotaBlock().Payload.(*iotago.Transaction)
+
+	// the transaction would be applied, but the simulation does not modify the ledger
+	for i := 0; i < 2; i++ {
+		conflict, err := whiteflag.SimulateTransaction(te.UTXOManager(), transaction, te.LastMilestonePayload().Timestamp)
+		require.NoError(t, err)
+		require.Equal(t, storage.ConflictNone, conflict)
+	}
+
+	conf, _ := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{blockA.StoredBlockID()}, false)
+	require.Len(t, conf.Mutations.ReferencedBlocks.IncludedTransactionBlockIDs(), 1)
+
+	// the inputs were spent by the confirmation of the transaction
+	conflict, err := whiteflag.SimulateTransaction(te.UTXOManager(), transaction, te.LastMilestonePayload().Timestamp)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictInputUTXOAlreadySpent, conflict)
+}
+
+// sendOutputWithConditionsToWallet confirms a transaction that sends the whole token supply from the genesis wallet
+// to the given wallet, using an output with the additional unlock conditions. Returns the created output.
+func sendOutputWithConditionsToWallet(te *testsuite.TestEnvironment, fromWallet *utils.HDWallet, toWallet *utils.HDWallet, conditions ...iotago.UnlockCondition) *utxo.Output {
+	output := &iotago.BasicOutput{
+		Amount:     te.ProtocolParameters().TokenSupply,
+		Conditions: append(iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: toWallet.Address()}}, conditions...),
+	}
+
+	block := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(fromWallet).
+		Amount(te.ProtocolParameters().TokenSupply).
+		BuildTransactionSendingOutputsAndCalculateRemainder(output).
+		Store().
+		BookOnWallets()
+
+	conf, _ := te.IssueAndConfirmMilestoneOnTips(iotago.BlockIDs{block.StoredBlockID()}, false)
+	require.Len(te.TestInterface, conf.Mutations.ReferencedBlocks.IncludedTransactionBlockIDs(), 1)
+
+	return block.GeneratedUTXO()
+}
+
+func TestSimulateTransactionTimelock(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	te := testsuite.SetupTestEnvironment(t, seed1Wallet.Address(), 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	timelockUnix := te.LastMilestonePayload().Timestamp + 100
+	timelockedOutput := sendOutputWithConditionsToWallet(te, seed1Wallet, seed2Wallet, &iotago.TimelockUnlockCondition{UnixTime: timelockUnix})
+
+	transaction := te.NewBlockBuilder("B").
+		Parents(te.LastMilestoneParents()).
+		BuildTransactionWithInputsAndOutputs(utxo.Outputs{timelockedOutput}, iotago.Outputs{
+			&iotago.BasicOutput{Amount: timelockedOutput.Deposit(), Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: seed1Wallet.Address()}}},
+		}, []*utils.HDWallet{seed2Wallet}).
+		IotaBlock().Payload.(*iotago.Transaction)
+
+	// the timelock is checked relative to the given milestone timestamp
+	conflict, err := whiteflag.SimulateTransaction(te.UTXOManager(), transaction, timelockUnix-1)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictTimelockNotExpired, conflict)
+
+	conflict, err = whiteflag.SimulateTransaction(te.UTXOManager(), transaction, timelockUnix)
+	require.NoError(t, err)
+	require.Equal(t, storage.ConflictNone, conflict)
+}
+
+func TestSimulateTransactionExpiration(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	te := testsuite.SetupTestEnvironment(t, seed1Wallet.Address(), 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	expirationUnix := te.LastMilestonePayload().Timestamp + 100
+	expiringOutput := sendOutputWithConditionsToWallet(te, seed1Wallet, seed2Wallet, &iotago.ExpirationUnlockCondition{ReturnAddress: seed1Wallet.Address(), UnixTime: expirationUnix})
+
+	transaction := te.NewBlockBuilder("B").
+		Parents(te.LastMilestoneParents()).
+		BuildTransactionWithInputsAndOutputs(utxo.Outputs{expiringOutput}, iotago.Outputs{
+			&iotago.BasicOutput{Amount: expiringOutput.Deposit(), Conditions: iotago.UnlockConditions{&iotago.AddressUnlockCondition{Address: seed2Wallet.Address()}}},
+		}, []*utils.HDWallet{seed2Wallet}).
+		IotaBlock().Payload.(*iotago.Transaction)
+
+	// the recipient can only unlock the output before it expired
+	conflict, err := whiteflag.SimulateTransaction(te.UTXOManager(), transaction, expirationUnix-1)
+	require.NoError(t, err)
+	require.Equal(t, storage.ConflictNone, conflict)
+
+	// afterwards the output can only be unlocked by the return address
+	conflict, err = whiteflag.SimulateTransaction(te.UTXOManager(), transaction, expirationUnix)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictInvalidSignature, conflict)
+}
+
+func TestSimulateTransactionInvalidUnlock(t *testing.T) {
+
+	seed1Wallet := utils.NewHDWallet("Seed1", seed1, 0)
+	seed2Wallet := utils.NewHDWallet("Seed2", seed2, 0)
+
+	te := testsuite.SetupTestEnvironment(t, seed1Wallet.Address(), 2, ProtocolVersion, BelowMaxDepth, MinPoWScore, ShowConfirmationGraphs)
+	defer te.CleanupTestEnvironment(!ShowConfirmationGraphs)
+
+	seed1Wallet.BookOutput(te.GenesisOutput)
+
+	transaction := te.NewBlockBuilder("A").
+		Parents(te.LastMilestoneParents()).
+		FromWallet(seed1Wallet).
+		Amount(te.ProtocolParameters().TokenSupply).
+		BuildTransactionToWallet(seed2Wallet).
+		IotaBlock().Payload.(*iotago.Transaction)
+
+	signature := transaction.Unlocks[0].(*iotago.SignatureUnlock).Signature.(*iotago.Ed25519Signature)
+
+	// the signature does not match the essence
+	tamperedSignature := &iotago.Ed25519Signature{PublicKey: signature.PublicKey, Signature: signature.Signature}
+	tamperedSignature.Signature[0] ^= 0xFF
+
+	conflict, err := whiteflag.SimulateTransaction(te.UTXOManager(), &iotago.Transaction{
+		Essence: transaction.Essence,
+		Unlocks: iotago.Unlocks{&iotago.SignatureUnlock{Signature: tamperedSignature}},
+	}, te.LastMilestonePayload().Timestamp)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictInvalidSignature, conflict)
+
+	// the public key of the signature does not belong to the address of the input
+	_, seed2PublicKey := seed2Wallet.KeyPair()
+	foreignKeySignature := &iotago.Ed25519Signature{Signature: signature.Signature}
+	copy(foreignKeySignature.PublicKey[:], seed2PublicKey)
+
+	conflict, err = whiteflag.SimulateTransaction(te.UTXOManager(), &iotago.Transaction{
+		Essence: transaction.Essence,
+		Unlocks: iotago.Unlocks{&iotago.SignatureUnlock{Signature: foreignKeySignature}},
+	}, te.LastMilestonePayload().Timestamp)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictInvalidSignature, conflict)
+
+	// the input is referenced by an unlock that does not exist
+	conflict, err = whiteflag.SimulateTransaction(te.UTXOManager(), &iotago.Transaction{
+		Essence: transaction.Essence,
+		Unlocks: iotago.Unlocks{&iotago.ReferenceUnlock{Reference: 0}},
+	}, te.LastMilestonePayload().Timestamp)
+	require.NoError(t, err)
+	require.EqualValues(t, storage.ConflictInvalidInputUnlock, conflict)
+
+	// the untampered transaction is still valid
+	conflict, err = whiteflag.SimulateTransaction(te.UTXOManager(), transaction, te.LastMilestonePayload().Timestamp)
+	require.NoError(t, err)
+	require.Equal(t, storage.ConflictNone, conflict)
+}
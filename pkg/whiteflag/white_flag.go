@@ -214,8 +214,6 @@ func ComputeWhiteFlagMutations(ctx context.Context,
 			return nil
 		}
 
-		var conflict = storage.ConflictNone
-
 		transaction := block.Transaction()
 		transactionID, err := transaction.ID()
 		if err != nil {
@@ -223,59 +221,9 @@ func ComputeWhiteFlagMutations(ctx context.Context,
 		}
 
 		// go through all the inputs and validate that they are still unspent, in the ledger or were created during confirmation
-		inputOutputs := utxo.Outputs{}
-		if conflict == storage.ConflictNone {
-			inputs := block.TransactionEssenceUTXOInputs()
-			for _, input := range inputs {
-
-				// check if this input was already spent during the confirmation
-				_, hasSpent := wfConf.NewSpents[input]
-				if hasSpent {
-					// UTXO already spent, so mark as conflict
-					conflict = storage.ConflictInputUTXOAlreadySpentInThisMilestone
-					break
-				}
-
-				// check if this input was newly created during the confirmation
-				output, hasOutput := wfConf.NewOutputs[input]
-				if hasOutput {
-					// UTXO is in the current ledger mutation, so use it
-					inputOutputs = append(inputOutputs, output)
-					continue
-				}
-
-				// check current ledger for this input
-				output, err = utxoManager.ReadOutputByOutputIDWithoutLocking(input)
-				if err != nil {
-					if errors.Is(err, kvstore.ErrKeyNotFound) {
-						// input not found, so mark as invalid tx
-						conflict = storage.ConflictInputUTXONotFound
-						break
-					}
-					return err
-				}
-
-				// check if this output is unspent
-				unspent, err := utxoManager.IsOutputUnspentWithoutLocking(output)
-				if err != nil {
-					return err
-				}
-
-				if !unspent {
-					// output is already spent, so mark as conflict
-					conflict = storage.ConflictInputUTXOAlreadySpent
-					break
-				}
-
-				inputOutputs = append(inputOutputs, output)
-			}
-
-			if conflict == storage.ConflictNone {
-				// Verify that all outputs consume all inputs and have valid signatures. Also verify that the amounts match.
-				if err := transaction.SemanticallyValidate(semValCtx, inputOutputs.ToOutputSet()); err != nil {
-					conflict = storage.ConflictFromSemanticValidationError(err)
-				}
-			}
+		inputOutputs, conflict, err := validateTransaction(utxoManager, semValCtx, transaction, block.TransactionEssenceUTXOInputs(), wfConf.NewOutputs, wfConf.NewSpents)
+		if err != nil {
+			return err
 		}
 
 		// go through all deposits and generate unspent outputs
@@ -360,3 +308,65 @@ func ComputeWhiteFlagMutations(ctx context.Context,
 
 	return wfConf, nil
 }
+
+// validateTransaction validates that the inputs of the transaction are still unspent, in the ledger or were created
+// during the confirmation, and semantically validates the transaction against the inputs.
+// The outputs and spents created during the confirmation may be nil, in that case only the ledger is checked.
+// Returns the outputs of the inputs and the conflict reason if the transaction would not be applied to the ledger.
+// The ledger state must be locked while this function is getting called in order to ensure consistency.
+func validateTransaction(utxoManager *utxo.Manager,
+	semValCtx *iotago.SemanticValidationContext,
+	transaction *iotago.Transaction,
+	inputs iotago.OutputIDs,
+	newOutputs map[iotago.OutputID]*utxo.Output,
+	newSpents map[iotago.OutputID]*utxo.Spent) (utxo.Outputs, storage.Conflict, error) {
+
+	inputOutputs := utxo.Outputs{}
+	for _, input := range inputs {
+
+		// check if this input was already spent during the confirmation
+		_, hasSpent := newSpents[input]
+		if hasSpent {
+			// UTXO already spent, so mark as conflict
+			return nil, storage.ConflictInputUTXOAlreadySpentInThisMilestone, nil
+		}
+
+		// check if this input was newly created during the confirmation
+		output, hasOutput := newOutputs[input]
+		if hasOutput {
+			// UTXO is in the current ledger mutation, so use it
+			inputOutputs = append(inputOutputs, output)
+			continue
+		}
+
+		// check current ledger for this input
+		output, err := utxoManager.ReadOutputByOutputIDWithoutLocking(input)
+		if err != nil {
+			if errors.Is(err, kvstore.ErrKeyNotFound) {
+				// input not found, so mark as invalid tx
+				return nil, storage.ConflictInputUTXONotFound, nil
+			}
+			return nil, storage.ConflictNone, err
+		}
+
+		// check if this output is unspent
+		unspent, err := utxoManager.IsOutputUnspentWithoutLocking(output)
+		if err != nil {
+			return nil, storage.ConflictNone, err
+		}
+
+		if !unspent {
+			// output is already spent, so mark as conflict
+			return nil, storage.ConflictInputUTXOAlreadySpent, nil
+		}
+
+		inputOutputs = append(inputOutputs, output)
+	}
+
+	// Verify that all outputs consume all inputs and have valid signatures. Also verify that the amounts match.
+	if err := transaction.SemanticallyValidate(semValCtx, inputOutputs.ToOutputSet()); err != nil {
+		return nil, storage.ConflictFromSemanticValidationError(err), nil
+	}
+
+	return inputOutputs, storage.ConflictNone, nil
+}
@@ -0,0 +1,225 @@
+package coreapi
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/restapi"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func parseAddressIndexQuery(c echo.Context) (iotago.Address, []byte, int, error) {
+	address, err := restapi.ParseBech32AddressParam(c, deps.ProtocolManager.Current().Bech32HRP)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	cursor, err := restapi.ParseCursorQueryParam(c)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	pageSize, err := restapi.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	return address, cursor, pageSize, nil
+}
+
+func addressIndexError(address iotago.Address, err error) error {
+	if errors.Is(err, utxo.ErrInvalidAddressIndexCursor) {
+		return errors.WithMessagef(restapi.ErrInvalidParameter, "invalid cursor, error: %s", err)
+	}
+	if errors.Is(err, utxo.ErrAddressOutputsLimitReached) {
+		return errors.WithMessagef(restapi.ErrInvalidParameter, "address %s has more than %d unspent outputs, use the paginated outputs endpoint instead", address.Bech32(deps.ProtocolManager.Current().Bech32HRP), deps.RestAPILimitsMaxAddressOutputs)
+	}
+	return errors.WithMessagef(echo.ErrInternalServerError, "reading outputs by address failed: %s, error: %s", address.Bech32(deps.ProtocolManager.Current().Bech32HRP), err)
+}
+
+func nextCursorResponse(cursor []byte) *string {
+	if cursor == nil {
+		return nil
+	}
+	cursorHex := iotago.EncodeHex(cursor)
+	return &cursorHex
+}
+
+func unspentOutputsByAddress(c echo.Context) (*addressOutputsResponse, error) {
+	address, cursor, pageSize, err := parseAddressIndexQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to have the correct index for unspent info of the outputs.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	outputIDs, nextCursor, err := deps.UTXOManager.UnspentOutputIDsByAddressWithoutLocking(address, cursor, pageSize)
+	if err != nil {
+		return nil, addressIndexError(address, err)
+	}
+
+	return &addressOutputsResponse{
+		LedgerIndex: ledgerIndex,
+		PageSize:    pageSize,
+		Items:       outputIDs.ToHex(),
+		Cursor:      nextCursorResponse(nextCursor),
+	}, nil
+}
+
+func spentOutputsByAddress(c echo.Context) (*addressSpentOutputsResponse, error) {
+	address, cursor, pageSize, err := parseAddressIndexQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to have the correct index for spent info of the outputs.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	spentsByAddress, nextCursor, err := deps.UTXOManager.SpentOutputsByAddressWithoutLocking(address, cursor, pageSize)
+	if err != nil {
+		return nil, addressIndexError(address, err)
+	}
+
+	items := make([]*addressSpentOutputResponse, 0, len(spentsByAddress))
+	for _, spentByAddress := range spentsByAddress {
+		spent, err := deps.UTXOManager.ReadSpentForOutputIDWithoutLocking(spentByAddress.OutputID)
+		if err != nil {
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading spent output failed: %s, error: %s", spentByAddress.OutputID.ToHex(), err)
+		}
+
+		items = append(items, &addressSpentOutputResponse{
+			OutputID:                spentByAddress.OutputID.ToHex(),
+			MilestoneIndexSpent:     spent.MilestoneIndexSpent(),
+			MilestoneTimestampSpent: spent.MilestoneTimestampSpent(),
+			TransactionIDSpent:      spent.TransactionIDSpent().ToHex(),
+		})
+	}
+
+	return &addressSpentOutputsResponse{
+		LedgerIndex: ledgerIndex,
+		PageSize:    pageSize,
+		Items:       items,
+		Cursor:      nextCursorResponse(nextCursor),
+	}, nil
+}
+
+func balanceByAddress(c echo.Context) (*addressBalanceResponse, error) {
+	address, err := restapi.ParseBech32AddressParam(c, deps.ProtocolManager.Current().Bech32HRP)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to have the correct index for the balance.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	balance, count, err := deps.UTXOManager.AddressBalanceWithoutLocking(address, deps.RestAPILimitsMaxAddressOutputs)
+	if err != nil {
+		return nil, addressIndexError(address, err)
+	}
+
+	return &addressBalanceResponse{
+		Address:     address.Bech32(deps.ProtocolManager.Current().Bech32HRP),
+		Balance:     strconv.FormatUint(balance, 10),
+		OutputCount: count,
+		LedgerIndex: ledgerIndex,
+	}, nil
+}
+
+func addressAtMilestoneIndex(c echo.Context) (*addressAtMilestoneResponse, error) {
+	address, cursor, pageSize, err := parseAddressIndexQuery(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if cursor != nil && len(cursor) != iotago.OutputIDLength {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid cursor, error: %s", utxo.ErrInvalidAddressIndexCursor)
+	}
+
+	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to reconstruct the state from a consistent ledger index.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	if err := checkMilestoneIndexWithinPruningWindow(msIndex); err != nil {
+		return nil, err
+	}
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	unspentOutputs, err := deps.UTXOManager.UnspentOutputsByAddressAtMilestoneIndexWithoutLocking(address, msIndex, deps.RestAPILimitsMaxAddressOutputs, deps.RestAPILimitsMaxRevertedMilestones)
+	if err != nil {
+		if errors.Is(err, utxo.ErrAddressOutputsLimitReached) {
+			return nil, addressIndexError(address, err)
+		}
+		return nil, ledgerStateAtMilestoneError(err, msIndex)
+	}
+
+	var balance uint64
+	for _, output := range unspentOutputs {
+		balance += output.Deposit()
+	}
+
+	// the outputs are sorted by their output IDs, so the page starts at the first output after the cursor.
+	start := 0
+	if cursor != nil {
+		start = sort.Search(len(unspentOutputs), func(i int) bool {
+			outputID := unspentOutputs[i].OutputID()
+			return bytes.Compare(outputID[:], cursor) > 0
+		})
+	}
+
+	end := len(unspentOutputs)
+	var nextCursor []byte
+	if end-start > pageSize {
+		end = start + pageSize
+		lastOutputID := unspentOutputs[end-1].OutputID()
+		nextCursor = lastOutputID[:]
+	}
+
+	items := make([]string, 0, end-start)
+	for _, output := range unspentOutputs[start:end] {
+		items = append(items, output.OutputID().ToHex())
+	}
+
+	return &addressAtMilestoneResponse{
+		Address:        address.Bech32(deps.ProtocolManager.Current().Bech32HRP),
+		MilestoneIndex: msIndex,
+		Balance:        strconv.FormatUint(balance, 10),
+		OutputCount:    len(unspentOutputs),
+		LedgerIndex:    ledgerIndex,
+		PageSize:       pageSize,
+		Items:          items,
+		Cursor:         nextCursorResponse(nextCursor),
+	}, nil
+}
@@ -0,0 +1,138 @@
+package coreapi
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/restapi"
+)
+
+func archiveStateWithoutLocking() (*utxo.ArchiveState, error) {
+	archiveState, err := deps.UTXOManager.ArchiveStateWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading archive state failed, error: %s", err)
+	}
+
+	return archiveState, nil
+}
+
+func blockReferencedBy(c echo.Context) (*blockReferencedByResponse, error) {
+	blockID, err := restapi.ParseBlockIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	archiveState, err := archiveStateWithoutLocking()
+	if err != nil {
+		return nil, err
+	}
+
+	referencingMilestone, err := deps.UTXOManager.ReferencingMilestoneWithoutLocking(blockID)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "block not referenced since milestone %d: %s", archiveState.BlocksStartIndex, blockID.ToHex())
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading referencing milestone failed: %s, error: %s", blockID.ToHex(), err)
+	}
+
+	return &blockReferencedByResponse{
+		BlockID:        blockID.ToHex(),
+		MilestoneIndex: referencingMilestone.MilestoneIndex,
+		WhiteFlagIndex: referencingMilestone.WhiteFlagIndex,
+	}, nil
+}
+
+func referencedBlocksByMilestoneIndex(c echo.Context) (*milestoneReferencedBlocksResponse, error) {
+	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := restapi.ParseCursorQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize, err := restapi.ParsePageSizeQueryParam(c, deps.RestAPILimitsMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	archiveState, err := archiveStateWithoutLocking()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	if archiveState.BlocksStartIndex == 0 || msIndex < archiveState.BlocksStartIndex || msIndex > ledgerIndex {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "referenced blocks of milestone not found: %d", msIndex)
+	}
+
+	blockIDs, nextCursor, err := deps.UTXOManager.ReferencedBlocksWithoutLocking(msIndex, cursor, pageSize)
+	if err != nil {
+		if errors.Is(err, utxo.ErrInvalidArchiveCursor) {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid cursor, error: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading referenced blocks failed: %d, error: %s", msIndex, err)
+	}
+
+	return &milestoneReferencedBlocksResponse{
+		Index:    msIndex,
+		PageSize: pageSize,
+		Items:    blockIDs.ToHex(),
+		Cursor:   nextCursorResponse(nextCursor),
+	}, nil
+}
+
+func spendingTransactionsByTransactionID(c echo.Context) (*transactionSpendingTransactionsResponse, error) {
+	transactionID, err := restapi.ParseTransactionIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	archiveState, err := archiveStateWithoutLocking()
+	if err != nil {
+		return nil, err
+	}
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	spendingTransactions, err := deps.UTXOManager.SpendingTransactionsWithoutLocking(transactionID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading spending transactions failed: %s, error: %s", transactionID.ToHex(), err)
+	}
+
+	items := make([]*spendingTransactionResponse, 0, len(spendingTransactions))
+	for _, spendingTransaction := range spendingTransactions {
+		items = append(items, &spendingTransactionResponse{
+			OutputID:            spendingTransaction.OutputID.ToHex(),
+			TransactionIDSpent:  spendingTransaction.TransactionIDSpent.ToHex(),
+			MilestoneIndexSpent: spendingTransaction.MilestoneIndexSpent,
+		})
+	}
+
+	return &transactionSpendingTransactionsResponse{
+		TransactionID:     transactionID.ToHex(),
+		LedgerIndex:       ledgerIndex,
+		HistoryStartIndex: archiveState.SpentsStartIndex,
+		Items:             items,
+	}, nil
+}
@@ -11,15 +11,72 @@ import (
 	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/hornet/v2/pkg/common"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/promoter"
 	"github.com/iotaledger/hornet/v2/pkg/restapi"
 	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+const (
+	// headerArchived is set on block responses that were served from the cold storage.
+	headerArchived = "X-Hornet-Archived"
+)
+
 var (
 	blockProcessedTimeout = 1 * time.Second
 )
 
+// ledgerInclusionState returns the ledger inclusion state of a referenced block.
+func ledgerInclusionState(conflict storage.Conflict, includedInLedger bool) string {
+	switch {
+	case conflict != storage.ConflictNone:
+		return "conflicting"
+	case includedInLedger:
+		return "included"
+	default:
+		return "noTransaction"
+	}
+}
+
+// archivedBlockMetadata returns the metadata of a pruned block from the cold storage.
+func archivedBlockMetadata(blockID iotago.BlockID) (*blockMetadataResponse, error) {
+	block, metadata, err := deps.Storage.ColdBlockOrNil(blockID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading block from cold storage failed: %s, error: %s", blockID.ToHex(), err)
+	}
+	if block == nil {
+		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+	}
+
+	referenced, referencedIndex, wfIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
+
+	response := &blockMetadataResponse{
+		BlockID:                    blockID.ToHex(),
+		Parents:                    metadata.Parents().ToHex(),
+		Solid:                      metadata.IsSolid(),
+		ReferencedByMilestoneIndex: referencedIndex,
+		Archived:                   true,
+	}
+
+	if milestone := block.Milestone(); milestone != nil {
+		response.MilestoneIndex = milestone.Index
+	}
+
+	// only blocks of pruned milestone cones are part of the cold storage, so they are always referenced
+	if referenced {
+		response.WhiteFlagIndex = &wfIndex
+
+		conflict := metadata.Conflict()
+		response.LedgerInclusionState = ledgerInclusionState(conflict, metadata.IsIncludedTxInLedger())
+		if conflict != storage.ConflictNone {
+			response.ConflictReason = &conflict
+		}
+	}
+
+	return response, nil
+}
+
 func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 	blockID, err := restapi.ParseBlockIDParam(c)
 	if err != nil {
@@ -28,7 +85,8 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 
 	cachedBlockMeta := deps.Storage.CachedBlockMetadataOrNil(blockID)
 	if cachedBlockMeta == nil {
-		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+		// the block may have been pruned already
+		return archivedBlockMetadata(blockID)
 	}
 	defer cachedBlockMeta.Release(true) // meta -1
 
@@ -59,14 +117,11 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 
 	if referenced {
 		response.WhiteFlagIndex = &wfIndex
-		response.LedgerInclusionState = "noTransaction"
 
 		conflict := metadata.Conflict()
+		response.LedgerInclusionState = ledgerInclusionState(conflict, metadata.IsIncludedTxInLedger())
 		if conflict != storage.ConflictNone {
-			response.LedgerInclusionState = "conflicting"
 			response.ConflictReason = &conflict
-		} else if metadata.IsIncludedTxInLedger() {
-			response.LedgerInclusionState = "included"
 		}
 	} else if metadata.IsSolid() {
 		// determine info about the quality of the tip if not referenced
@@ -104,6 +159,29 @@ func blockMetadataByID(c echo.Context) (*blockMetadataResponse, error) {
 	return response, nil
 }
 
+func blockProofByID(c echo.Context) (*whiteflag.ProofBundle, error) {
+	blockID, err := restapi.ParseBlockIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	proofBundle, err := whiteflag.ComputeProofBundle(c.Request().Context(), deps.Storage, blockID)
+	if err != nil {
+		switch {
+		case errors.Is(err, common.ErrBlockNotFound):
+			return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+		case errors.Is(err, whiteflag.ErrBlockNotReferenced):
+			return nil, errors.WithMessagef(echo.ErrNotFound, "block not referenced by a milestone: %s", blockID.ToHex())
+		case errors.Is(err, storage.ErrMilestoneNotFound):
+			return nil, errors.WithMessagef(echo.ErrNotFound, "referencing milestone of block not found: %s", blockID.ToHex())
+		default:
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "computing proof failed: %s, error: %s", blockID.ToHex(), err)
+		}
+	}
+
+	return proofBundle, nil
+}
+
 func storageBlockByID(c echo.Context) (*storage.Block, error) {
 	blockID, err := restapi.ParseBlockIDParam(c)
 	if err != nil {
@@ -112,7 +190,17 @@ func storageBlockByID(c echo.Context) (*storage.Block, error) {
 
 	cachedBlock := deps.Storage.CachedBlockOrNil(blockID) // block +1
 	if cachedBlock == nil {
-		return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+		// the block may have been pruned already
+		block, _, err := deps.Storage.ColdBlockOrNil(blockID)
+		if err != nil {
+			return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading block from cold storage failed: %s, error: %s", blockID.ToHex(), err)
+		}
+		if block == nil {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "block not found: %s", blockID.ToHex())
+		}
+		c.Response().Header().Set(headerArchived, "true")
+
+		return block, nil
 	}
 	defer cachedBlock.Release(true) // block -1
 
@@ -135,12 +223,34 @@ func blockBytesByID(c echo.Context) ([]byte, error) {
 	return block.Data(), nil
 }
 
-func sendBlock(c echo.Context) (*blockCreatedResponse, error) {
+// sendBlock attaches the block of the request.
+// The block is only handed over to the promoter if the "promote" query parameter is set
+// and the request was made on a protected route (allowPromote).
+func sendBlock(c echo.Context, allowPromote bool) (*blockCreatedResponse, error) {
 	mimeType, err := restapi.GetRequestContentType(c, restapi.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 	if err != nil {
 		return nil, err
 	}
 
+	waitReferenced, err := restapi.ParseWaitReferencedQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	promote, err := restapi.ParsePromoteQueryParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if promote {
+		if !allowPromote {
+			return nil, errors.WithMessagef(echo.ErrForbidden, "promotion is only available on the protected route %s", RouteControlBlocks)
+		}
+		if deps.Promoter == nil {
+			return nil, errors.WithMessage(echo.ErrServiceUnavailable, "promoter is not enabled")
+		}
+	}
+
 	iotaBlock := &iotago.Block{}
 
 	switch mimeType {
@@ -184,18 +294,97 @@ func sendBlock(c echo.Context) (*blockCreatedResponse, error) {
 	mergedCtx, mergedCtxCancel := contextutils.MergeContexts(c.Request().Context(), Plugin.Daemon().ContextStopped())
 	defer mergedCtxCancel()
 
-	blockID, err := attacher.AttachBlock(mergedCtx, iotaBlock)
-	if err != nil {
-		if errors.Is(err, tangle.ErrBlockAttacherAttachingNotPossible) {
-			return nil, errors.WithMessage(echo.ErrServiceUnavailable, err.Error())
+	if waitReferenced == 0 {
+		blockID, err := attacher.AttachBlock(mergedCtx, iotaBlock)
+		if err != nil {
+			return nil, attachBlockError(err)
 		}
-		if errors.Is(err, tangle.ErrBlockAttacherInvalidBlock) {
-			return nil, errors.WithMessage(restapi.ErrInvalidParameter, err.Error())
+		return &blockCreatedResponse{
+			BlockID: blockID.ToHex(),
+			Tracked: trackBlock(promote, blockID, iotaBlock),
+		}, nil
+	}
+
+	blockID, result, err := attacher.AttachBlockAndWaitReferenced(mergedCtx, iotaBlock, waitReferenced)
+	if err != nil {
+		if errors.Is(err, tangle.ErrBlockNotReferencedInTime) || errors.Is(err, tangle.ErrBlockReferencedWaitersLimitReached) {
+			// the block was attached, the client can continue to check the metadata of the block
+			return &blockCreatedResponse{
+				BlockID: blockID.ToHex(),
+				Tracked: trackBlock(promote, blockID, iotaBlock),
+			}, nil
 		}
+		return nil, attachBlockError(err)
+	}
+
+	response := &blockCreatedResponse{
+		BlockID:                    blockID.ToHex(),
+		ReferencedByMilestoneIndex: result.MilestoneIndex,
+		LedgerInclusionState:       ledgerInclusionState(result.Conflict, result.IncludedInLedger),
+	}
+	if result.Conflict != storage.ConflictNone {
+		response.ConflictReason = &result.Conflict
+	}
+
+	return response, nil
+}
+
+// trackBlock hands a submitted block over to the promoter, if the promotion was requested.
+// Returns whether the block is tracked, or nil if no promotion was requested.
+func trackBlock(promote bool, blockID iotago.BlockID, block *iotago.Block) *bool {
+	if !promote || deps.Promoter == nil {
+		return nil
+	}
+
+	tracked, err := deps.Promoter.TrackBlock(blockID, block)
+	if err != nil {
+		Plugin.LogDebugf("tracking block %s failed: %s", blockID.ToHex(), err)
+	}
+
+	return &tracked
+}
+
+func blockPromotionByID(c echo.Context) (*blockPromotionResponse, error) {
+	blockID, err := restapi.ParseBlockIDParam(c)
+	if err != nil {
 		return nil, err
 	}
 
-	return &blockCreatedResponse{
-		BlockID: blockID.ToHex(),
-	}, nil
+	status, err := deps.Promoter.BlockStatus(blockID)
+	if err != nil {
+		if errors.Is(err, promoter.ErrBlockNotTracked) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "block not tracked: %s", blockID.ToHex())
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading promotion status failed: %s, error: %s", blockID.ToHex(), err)
+	}
+
+	response := &blockPromotionResponse{
+		BlockID:       status.BlockID.ToHex(),
+		State:         string(status.State),
+		Promotions:    status.Promotions.ToHex(),
+		Reattachments: status.Reattachments.ToHex(),
+		Error:         status.LastError,
+	}
+
+	if status.State == promoter.BlockStateReferenced {
+		response.ReferencedBlockID = status.ReferencedBlockID.ToHex()
+		response.ReferencedByMilestoneIndex = status.ReferencedByMilestoneIndex
+		response.LedgerInclusionState = ledgerInclusionState(status.Conflict, status.IncludedInLedger)
+		if status.Conflict != storage.ConflictNone {
+			response.ConflictReason = &status.Conflict
+		}
+	}
+
+	return response, nil
+}
+
+// attachBlockError maps the errors of the block attacher to REST API errors.
+func attachBlockError(err error) error {
+	if errors.Is(err, tangle.ErrBlockAttacherAttachingNotPossible) {
+		return errors.WithMessage(echo.ErrServiceUnavailable, err.Error())
+	}
+	if errors.Is(err, tangle.ErrBlockAttacherInvalidBlock) {
+		return errors.WithMessage(restapi.ErrInvalidParameter, err.Error())
+	}
+	return err
 }
@@ -3,20 +3,23 @@ package coreapi
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/gommon/bytes"
 	"github.com/pkg/errors"
 
+	"github.com/iotaledger/hornet/v2/pkg/database"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/pruning"
 	"github.com/iotaledger/hornet/v2/pkg/restapi"
+	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
-func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
-
-	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
-		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is already creating a snapshot or pruning is running")
-	}
+func parsePruneDatabaseRequest(c echo.Context) (*pruneDatabaseRequest, error) {
 
 	request := &pruneDatabaseRequest{}
 	if err := c.Bind(request); err != nil {
@@ -30,7 +33,24 @@ func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
 		return nil, errors.WithMessage(restapi.ErrInvalidParameter, "either index, depth or size has to be specified")
 	}
 
-	var err error
+	return request, nil
+}
+
+func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
+
+	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
+		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is already creating a snapshot or pruning is running")
+	}
+
+	if deps.Tangle.IsRollingBack() {
+		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "database rollback is running")
+	}
+
+	request, err := parsePruneDatabaseRequest(c)
+	if err != nil {
+		return nil, err
+	}
+
 	var targetIndex iotago.MilestoneIndex
 
 	if request.Index != nil {
@@ -64,6 +84,202 @@ func pruneDatabase(c echo.Context) (*pruneDatabaseResponse, error) {
 	}, nil
 }
 
+func pruneDatabaseDryRun(c echo.Context) (*pruneDatabaseDryRunResponse, error) {
+
+	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
+		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is creating a snapshot or pruning is running")
+	}
+
+	request, err := parsePruneDatabaseRequest(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var report *pruning.Report
+
+	switch {
+	case request.Index != nil:
+		report, err = deps.PruningManager.DryRunByTargetIndex(c.Request().Context(), *request.Index)
+
+	case request.Depth != nil:
+		report, err = deps.PruningManager.DryRunByDepth(c.Request().Context(), *request.Depth)
+
+	default:
+		pruningTargetDatabaseSizeBytes, errParse := bytes.Parse(*request.TargetDatabaseSize)
+		if errParse != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid target database size: %s", errParse)
+		}
+
+		report, err = deps.PruningManager.DryRunBySize(c.Request().Context(), pruningTargetDatabaseSizeBytes)
+	}
+	if err != nil {
+		if errors.Is(err, pruning.ErrNoPruningNeeded) || errors.Is(err, pruning.ErrNotEnoughHistory) || errors.Is(err, utxo.ErrArchivePruningNotAllowed) {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "pruning dry-run failed: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "pruning dry-run failed: %s", err)
+	}
+
+	return &pruneDatabaseDryRunResponse{
+		PruningIndex:         report.PruningIndex,
+		TargetIndex:          report.TargetIndex,
+		MilestonesCount:      report.MilestonesCount,
+		EstimatedBlocksCount: report.EstimatedBlocksCount,
+		EstimatedBytesSaved:  report.EstimatedBytesSaved,
+		Warnings:             report.Warnings,
+	}, nil
+}
+
+// errSnapshottingOrPruning is returned if a rollback of the database is requested while a snapshot is created or pruning is running.
+var errSnapshottingOrPruning = errors.New("node is creating a snapshot or pruning is running")
+
+func checkNotSnapshottingOrPruning() error {
+	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
+		return errSnapshottingOrPruning
+	}
+
+	return nil
+}
+
+func rollbackDatabase(c echo.Context) (*databaseRollbackResponse, error) {
+
+	request := &rollbackDatabaseRequest{}
+	if err := c.Bind(request); err != nil {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid request, error: %s", err)
+	}
+
+	if request.Index == 0 {
+		return nil, errors.WithMessage(restapi.ErrInvalidParameter, "index needs to be specified")
+	}
+
+	if err := deps.Tangle.StartRollbackDatabase(request.Index, checkNotSnapshottingOrPruning); err != nil {
+		if errors.Is(err, whiteflag.ErrRollbackTargetIndexBelowPruningIndex) || errors.Is(err, whiteflag.ErrRollbackTargetIndexNotBelowLedgerIndex) {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "rolling back database failed: %s", err)
+		}
+		if errors.Is(err, errSnapshottingOrPruning) || errors.Is(err, tangle.ErrRollbackRunning) {
+			return nil, errors.WithMessagef(echo.ErrServiceUnavailable, "rolling back database failed: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "rolling back database failed: %s", err)
+	}
+
+	return databaseRollbackStatus(), nil
+}
+
+func databaseRollbackStatus() *databaseRollbackResponse {
+	status := deps.Tangle.RollbackStatus()
+
+	resp := &databaseRollbackResponse{
+		Running:     status.Running,
+		Completed:   status.Completed,
+		TargetIndex: status.TargetIndex,
+		LedgerIndex: status.LedgerIndex,
+	}
+
+	if !status.StartTime.IsZero() {
+		resp.StartTime = status.StartTime.Unix()
+	}
+
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	return resp
+}
+
+func migrateDatabase(c echo.Context) (*databaseMigrationResponse, error) {
+
+	request := &migrateDatabaseRequest{}
+	if err := c.Bind(request); err != nil {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid request, error: %s", err)
+	}
+
+	targetEngine, err := database.DatabaseEngineFromStringAllowed(request.TargetEngine, database.EnginePebble, database.EngineRocksDB, database.EngineBadger)
+	if err != nil {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid target engine: %s", err)
+	}
+
+	if err := deps.OnlineMigration.Start(Plugin.Daemon().ContextStopped(), targetEngine); err != nil {
+		if errors.Is(err, database.ErrOnlineMigrationRunning) {
+			return nil, errors.WithMessagef(echo.ErrServiceUnavailable, "migrating database failed: %s", err)
+		}
+		if errors.Is(err, database.ErrOnlineMigrationNotSupported) {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "migrating database failed: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "migrating database failed: %s", err)
+	}
+
+	Plugin.LogInfof("Started online database migration to %s, please set \"db.engine\" to \"%s\" before the next restart of the node", targetEngine, targetEngine)
+
+	return databaseMigrationStatus(), nil
+}
+
+func databaseMigrationStatus() *databaseMigrationResponse {
+	status := deps.OnlineMigration.Status()
+
+	resp := &databaseMigrationResponse{
+		Running:       status.Running,
+		Completed:     status.Completed,
+		TargetEngine:  string(status.TargetEngine),
+		TargetPath:    status.TargetPath,
+		CopiedEntries: status.CopiedEntries,
+	}
+
+	if !status.StartTime.IsZero() {
+		resp.StartTime = status.StartTime.Unix()
+	}
+
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	return resp
+}
+
+func createDatabaseCheckpoint() (*databaseCheckpointResponse, error) {
+
+	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
+		return nil, errors.WithMessage(echo.ErrServiceUnavailable, "node is creating a snapshot or pruning is running")
+	}
+
+	checkpointPath := filepath.Join(deps.DatabaseCheckpointsPath, fmt.Sprintf("checkpoint_%d", time.Now().Unix()))
+
+	if err := deps.Checkpointer.Start(Plugin.Daemon().ContextStopped(), checkpointPath); err != nil {
+		if errors.Is(err, storage.ErrCheckpointRunning) {
+			return nil, errors.WithMessagef(echo.ErrServiceUnavailable, "creating database checkpoint failed: %s", err)
+		}
+		if errors.Is(err, database.ErrCheckpointNotSupported) || errors.Is(err, database.ErrCheckpointExists) {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "creating database checkpoint failed: %s", err)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "creating database checkpoint failed: %s", err)
+	}
+
+	status := databaseCheckpointStatus()
+	Plugin.LogInfof("Started database checkpoint at ledger index %d (%s)", status.LedgerIndex, status.Path)
+
+	return status, nil
+}
+
+func databaseCheckpointStatus() *databaseCheckpointResponse {
+	status := deps.Checkpointer.Status()
+
+	resp := &databaseCheckpointResponse{
+		Running:     status.Running,
+		Completed:   status.Completed,
+		LedgerIndex: status.LedgerIndex,
+		Engine:      string(status.Engine),
+		Path:        status.Path,
+	}
+
+	if !status.StartTime.IsZero() {
+		resp.StartTime = status.StartTime.Unix()
+	}
+
+	if status.Err != nil {
+		resp.Error = status.Err.Error()
+	}
+
+	return resp
+}
+
 func createSnapshots(c echo.Context) (*createSnapshotsResponse, error) {
 
 	if deps.SnapshotManager.IsSnapshotting() || deps.PruningManager.IsPruning() {
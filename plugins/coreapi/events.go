@@ -0,0 +1,196 @@
+package coreapi
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hornet/v2/pkg/daemon"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/restapi"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+const (
+	// EventTopicBlocks is the topic for newly received blocks.
+	EventTopicBlocks = "blocks"
+	// EventTopicBlocksReferenced is the topic for the metadata of blocks referenced by a milestone.
+	EventTopicBlocksReferenced = "blocks/referenced"
+	// EventTopicMilestonesConfirmed is the topic for confirmed milestones.
+	EventTopicMilestonesConfirmed = "milestones/confirmed"
+	// EventTopicLedgerUpdates is the topic for the created and consumed outputs of a confirmed milestone.
+	EventTopicLedgerUpdates = "ledger-updates"
+)
+
+var (
+	eventTopics = []string{
+		EventTopicBlocks,
+		EventTopicBlocksReferenced,
+		EventTopicMilestonesConfirmed,
+		EventTopicLedgerUpdates,
+	}
+
+	eventHub *restapi.EventHub
+
+	// closures
+	onReceivedNewBlock          *events.Closure
+	onBlockReferenced           *events.Closure
+	onConfirmedMilestoneChanged *events.Closure
+	onLedgerUpdated             *events.Closure
+)
+
+// blockEvent defines the payload of the blocks topic.
+type blockEvent struct {
+	// The hex encoded block ID of the block.
+	BlockID string `json:"blockId"`
+	// The block.
+	Block *iotago.Block `json:"block"`
+}
+
+func publishEvent(topic string, payloadFunc func() (interface{}, error)) {
+	if !eventHub.HasSubscribers(topic) {
+		// nobody is interested in the event, no need to encode it
+		return
+	}
+
+	payload, err := payloadFunc()
+	if err != nil {
+		Plugin.LogWarnf("failed to create event payload for topic %s: %s", topic, err)
+		return
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		Plugin.LogWarnf("failed to encode event payload for topic %s: %s", topic, err)
+		return
+	}
+
+	eventHub.Publish(topic, data)
+}
+
+func referencedBlockMetadata(metadata *storage.BlockMetadata) *blockMetadataResponse {
+	referenced, referencedIndex, wfIndex := metadata.ReferencedWithIndexAndWhiteFlagIndex()
+
+	response := &blockMetadataResponse{
+		BlockID:                    metadata.BlockID().ToHex(),
+		Parents:                    metadata.Parents().ToHex(),
+		Solid:                      metadata.IsSolid(),
+		ReferencedByMilestoneIndex: referencedIndex,
+	}
+
+	if referenced {
+		response.WhiteFlagIndex = &wfIndex
+		response.LedgerInclusionState = "noTransaction"
+
+		conflict := metadata.Conflict()
+		if conflict != storage.ConflictNone {
+			response.LedgerInclusionState = "conflicting"
+			response.ConflictReason = &conflict
+		} else if metadata.IsIncludedTxInLedger() {
+			response.LedgerInclusionState = "included"
+		}
+	}
+
+	return response
+}
+
+func configureEvents() {
+	eventHub = restapi.NewEventHub(deps.RestAPILimitsEventQueueSize, deps.RestAPILimitsMaxEventSubscribers)
+
+	onReceivedNewBlock = events.NewClosure(func(cachedBlock *storage.CachedBlock, _ iotago.MilestoneIndex, _ iotago.MilestoneIndex) {
+		defer cachedBlock.Release(true) // block -1
+
+		publishEvent(EventTopicBlocks, func() (interface{}, error) {
+			return &blockEvent{
+				BlockID: cachedBlock.Block().BlockID().ToHex(),
+				Block:   cachedBlock.Block().Block(),
+			}, nil
+		})
+	})
+
+	onBlockReferenced = events.NewClosure(func(cachedBlockMeta *storage.CachedMetadata, _ iotago.MilestoneIndex, _ uint32) {
+		defer cachedBlockMeta.Release(true) // meta -1
+
+		publishEvent(EventTopicBlocksReferenced, func() (interface{}, error) {
+			return referencedBlockMetadata(cachedBlockMeta.Metadata()), nil
+		})
+	})
+
+	onConfirmedMilestoneChanged = events.NewClosure(func(cachedMilestone *storage.CachedMilestone) {
+		defer cachedMilestone.Release(true) // milestone -1
+
+		publishEvent(EventTopicMilestonesConfirmed, func() (interface{}, error) {
+			return &milestoneInfoResponse{
+				Index:       cachedMilestone.Milestone().Index(),
+				Timestamp:   cachedMilestone.Milestone().TimestampUnix(),
+				MilestoneID: cachedMilestone.Milestone().MilestoneIDHex(),
+			}, nil
+		})
+	})
+
+	onLedgerUpdated = events.NewClosure(func(index iotago.MilestoneIndex, newOutputs utxo.Outputs, newSpents utxo.Spents) {
+		publishEvent(EventTopicLedgerUpdates, func() (interface{}, error) {
+			createdOutputs := make([]string, len(newOutputs))
+			for i, output := range newOutputs {
+				createdOutputs[i] = output.OutputID().ToHex()
+			}
+
+			consumedOutputs := make([]string, len(newSpents))
+			for i, spent := range newSpents {
+				consumedOutputs[i] = spent.OutputID().ToHex()
+			}
+
+			return &milestoneUTXOChangesResponse{
+				Index:           index,
+				CreatedOutputs:  createdOutputs,
+				ConsumedOutputs: consumedOutputs,
+			}, nil
+		})
+	})
+}
+
+func attachEvents() {
+	deps.Tangle.Events.ReceivedNewBlock.Attach(onReceivedNewBlock)
+	deps.Tangle.Events.BlockReferenced.Attach(onBlockReferenced)
+	deps.Tangle.Events.ConfirmedMilestoneChanged.Attach(onConfirmedMilestoneChanged)
+	deps.Tangle.Events.LedgerUpdated.Attach(onLedgerUpdated)
+}
+
+func detachEvents() {
+	deps.Tangle.Events.ReceivedNewBlock.Detach(onReceivedNewBlock)
+	deps.Tangle.Events.BlockReferenced.Detach(onBlockReferenced)
+	deps.Tangle.Events.ConfirmedMilestoneChanged.Detach(onConfirmedMilestoneChanged)
+	deps.Tangle.Events.LedgerUpdated.Detach(onLedgerUpdated)
+}
+
+func runEvents() {
+	if err := Plugin.Daemon().BackgroundWorker("CoreAPIV2[Events]", func(ctx context.Context) {
+		attachEvents()
+		<-ctx.Done()
+		detachEvents()
+
+		// close all client streams
+		eventHub.Clear()
+	}, daemon.PriorityRestAPI); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+}
+
+func streamEvents(c echo.Context) error {
+	topics, err := restapi.ParseTopicsQueryParam(c, eventTopics...)
+	if err != nil {
+		return err
+	}
+
+	subscription, err := eventHub.Subscribe(topics...)
+	if err != nil {
+		return errors.WithMessagef(echo.ErrServiceUnavailable, "%s: %d", err, deps.RestAPILimitsMaxEventSubscribers)
+	}
+	defer subscription.Close()
+
+	return restapi.StreamEvents(Plugin.Daemon().ContextStopped(), c, subscription)
+}
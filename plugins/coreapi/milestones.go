@@ -5,6 +5,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
 	"github.com/iotaledger/hornet/v2/pkg/restapi"
 
 	"github.com/iotaledger/hive.go/kvstore"
@@ -119,3 +120,75 @@ func milestoneUTXOChangesByID(c echo.Context) (*milestoneUTXOChangesResponse, er
 
 	return milestoneUTXOChanges(ms.Index())
 }
+
+func milestoneLedgerStateHash(msIndex iotago.MilestoneIndex) (*milestoneLedgerStateHashResponse, error) {
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerStateHash, err := deps.UTXOManager.LedgerStateHashByMilestoneIndexWithoutLocking(msIndex)
+	if err != nil {
+		if errors.Is(err, utxo.ErrLedgerStateHashNotAvailable) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "ledger state hash not available for index: %d", msIndex)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "can't load ledger state hash for index: %d, error: %s", msIndex, err)
+	}
+
+	return &milestoneLedgerStateHashResponse{
+		Index:           msIndex,
+		LedgerStateHash: ledgerStateHash.ToHex(),
+	}, nil
+}
+
+func milestoneLedgerStateHashByIndex(c echo.Context) (*milestoneLedgerStateHashResponse, error) {
+	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneLedgerStateHash(msIndex)
+}
+
+func milestoneLedgerStateHashByID(c echo.Context) (*milestoneLedgerStateHashResponse, error) {
+	ms, err := storageMilestoneByID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneLedgerStateHash(ms.Index())
+}
+
+func milestoneLedgerCommitment(msIndex iotago.MilestoneIndex) (*milestoneLedgerCommitmentResponse, error) {
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerCommitment, err := deps.UTXOManager.LedgerCommitmentByMilestoneIndexWithoutLocking(msIndex)
+	if err != nil {
+		if errors.Is(err, utxo.ErrLedgerCommitmentNotAvailable) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "ledger commitment not available for index: %d", msIndex)
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "can't load ledger commitment for index: %d, error: %s", msIndex, err)
+	}
+
+	return &milestoneLedgerCommitmentResponse{
+		Index:            msIndex,
+		LedgerCommitment: ledgerCommitment.ToHex(),
+	}, nil
+}
+
+func milestoneLedgerCommitmentByIndex(c echo.Context) (*milestoneLedgerCommitmentResponse, error) {
+	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneLedgerCommitment(msIndex)
+}
+
+func milestoneLedgerCommitmentByID(c echo.Context) (*milestoneLedgerCommitmentResponse, error) {
+	ms, err := storageMilestoneByID(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return milestoneLedgerCommitment(ms.Index())
+}
@@ -37,6 +37,7 @@ func WrapInfoSnapshot(info *p2p.PeerInfoSnapshot) *PeerResponse {
 		Relation:       info.Relation,
 		Connected:      info.Connected,
 		Gossip:         gossipInfo,
+		Reputation:     info.Reputation,
 	}
 }
 
@@ -9,12 +9,14 @@ import (
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hornet/v2/core/protocfg"
+	"github.com/iotaledger/hornet/v2/pkg/database"
 	"github.com/iotaledger/hornet/v2/pkg/metrics"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/model/syncmanager"
 	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
 	"github.com/iotaledger/hornet/v2/pkg/p2p"
 	"github.com/iotaledger/hornet/v2/pkg/pow"
+	"github.com/iotaledger/hornet/v2/pkg/promoter"
 	"github.com/iotaledger/hornet/v2/pkg/protocol"
 	"github.com/iotaledger/hornet/v2/pkg/protocol/gossip"
 	"github.com/iotaledger/hornet/v2/pkg/pruning"
@@ -44,19 +46,45 @@ const (
 	// GET returns block metadata (including info about "promotion/reattachment needed").
 	RouteBlockMetadata = "/blocks/:" + restapipkg.ParameterBlockID + "/metadata"
 
+	// RouteBlockProof is the route for getting the proof that a block was referenced by a milestone.
+	// GET returns the milestone payload and the audit paths of the block within the inclusion and applied merkle roots of the milestone.
+	// INX clients request the proof via PerformAPIRequest.
+	RouteBlockProof = "/blocks/:" + restapipkg.ParameterBlockID + "/proof"
+
+	// RouteBlockPromotion is the route for getting the promotion status of a block that was submitted with "promote=true" on RouteControlBlocks (only available if the promoter is enabled).
+	// GET returns the state of the block and the promotions and reattachments that were issued by the node.
+	// The block can be identified by the ID of the submitted block or by the ID of one of its reattachments.
+	// INX clients request the status via PerformAPIRequest.
+	RouteBlockPromotion = "/blocks/:" + restapipkg.ParameterBlockID + "/promotion"
+
 	// RouteBlocks is the route for creating new blocks.
 	// POST creates a single new block and returns the new block ID.
+	// If the "waitReferenced" query parameter is given, the response is delayed for at most the given seconds
+	// until the block is referenced by a milestone and contains the ledger inclusion state of the block.
 	// The block is parsed based on the given type in the request "Content-Type" header.
 	// MIMEApplicationJSON => json
 	// MIMEVendorIOTASerializer => bytes
 	RouteBlocks = "/blocks"
 
+	// RouteControlBlocks is the protected route for creating new blocks.
+	// POST behaves like RouteBlocks, but additionally accepts the "promote" query parameter.
+	// If "promote=true" is given, the block is tracked by the promoter (only available if the promoter is enabled).
+	RouteControlBlocks = "/control/blocks"
+
 	// RouteTransactionsIncludedBlock is the route for getting the block that was included in the ledger for a given transaction ID.
 	// GET returns the block based on the given type in the request "Accept" header.
 	// MIMEApplicationJSON => json
 	// MIMEVendorIOTASerializer => bytes
 	RouteTransactionsIncludedBlock = "/transactions/:" + restapipkg.ParameterTransactionID + "/included-block"
 
+	// RouteTransactionsSimulate is the route for simulating whether a transaction would be applied to the ledger.
+	// POST validates the transaction payload against the current confirmed ledger state and returns the conflict reason, without attaching it.
+	// The transaction is parsed based on the given type in the request "Content-Type" header.
+	// MIMEApplicationJSON => json
+	// MIMEVendorIOTASerializer => bytes
+	// INX clients simulate transactions via PerformAPIRequest, which runs this handler in-process and returns the same response.
+	RouteTransactionsSimulate = "/transactions/simulate"
+
 	// RouteMilestoneByID is the route for getting a milestone by its ID.
 	// GET returns the milestone.
 	// MIMEApplicationJSON => json
@@ -67,6 +95,15 @@ const (
 	// GET returns the output IDs of all UTXO changes.
 	RouteMilestoneByIDUTXOChanges = "/milestones/:" + restapipkg.ParameterMilestoneID + "/utxo-changes"
 
+	// RouteMilestoneByIDLedgerStateHash is the route for getting the ledger state hash after the confirmation of a milestone by its ID.
+	// GET returns the order-independent hash of all unspent outputs.
+	// INX clients read the hash via PerformAPIRequest.
+	RouteMilestoneByIDLedgerStateHash = "/milestones/:" + restapipkg.ParameterMilestoneID + "/ledger-state-hash"
+
+	// RouteMilestoneByIDLedgerCommitment is the route for getting the ledger commitment after the confirmation of a milestone by its ID (only available if the ledger commitment is enabled).
+	// GET returns the root of the sparse merkle tree over all unspent outputs.
+	RouteMilestoneByIDLedgerCommitment = "/milestones/:" + restapipkg.ParameterMilestoneID + "/ledger-commitment"
+
 	// RouteMilestoneByIndex is the route for getting a milestone by its milestoneIndex.
 	// GET returns the milestone.
 	// MIMEApplicationJSON => json
@@ -77,6 +114,15 @@ const (
 	// GET returns the output IDs of all UTXO changes.
 	RouteMilestoneByIndexUTXOChanges = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/utxo-changes"
 
+	// RouteMilestoneByIndexLedgerStateHash is the route for getting the ledger state hash after the confirmation of a milestone by its milestoneIndex.
+	// GET returns the order-independent hash of all unspent outputs.
+	// INX clients read the hash via PerformAPIRequest.
+	RouteMilestoneByIndexLedgerStateHash = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/ledger-state-hash"
+
+	// RouteMilestoneByIndexLedgerCommitment is the route for getting the ledger commitment after the confirmation of a milestone by its milestoneIndex (only available if the ledger commitment is enabled).
+	// GET returns the root of the sparse merkle tree over all unspent outputs.
+	RouteMilestoneByIndexLedgerCommitment = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/ledger-commitment"
+
 	// RouteOutput is the route for getting an output by its outputID (transactionHash + outputIndex).
 	// GET returns the output based on the given type in the request "Accept" header.
 	// MIMEApplicationJSON => json
@@ -87,6 +133,48 @@ const (
 	// GET returns the output metadata.
 	RouteOutputMetadata = "/outputs/:" + restapipkg.ParameterOutputID + "/metadata"
 
+	// RouteOutputAtMilestoneIndex is the route for getting the state of an output at a past milestone by its outputID and the milestoneIndex.
+	// The milestone index needs to be within the pruning window of the node.
+	// GET returns the output and whether it was spent at the given milestone.
+	// INX clients query the state via PerformAPIRequest.
+	RouteOutputAtMilestoneIndex = "/outputs/:" + restapipkg.ParameterOutputID + "/at/:" + restapipkg.ParameterMilestoneIndex
+
+	// RouteOutputLedgerCommitmentProof is the route for getting the proof whether an output is unspent (only available if the ledger commitment is enabled).
+	// GET returns the membership or non-membership proof of the output against the ledger commitment of the current ledger index.
+	// Only the latest version of the tree is kept, so there are no proofs against the ledger commitments of past milestones.
+	RouteOutputLedgerCommitmentProof = "/outputs/:" + restapipkg.ParameterOutputID + "/proof"
+
+	// RouteAddressOutputs is the route for getting the unspent outputs owned by an address (only available if the address index is enabled).
+	// GET returns the output IDs of the unspent outputs.
+	RouteAddressOutputs = "/addresses/:" + restapipkg.ParameterAddress + "/outputs"
+
+	// RouteAddressSpentOutputs is the route for getting the outputs spent by an address (only available if the address index is enabled).
+	// GET returns the spent outputs ordered by the milestone index they were spent at.
+	RouteAddressSpentOutputs = "/addresses/:" + restapipkg.ParameterAddress + "/spent-outputs"
+
+	// RouteAddressBalance is the route for getting the balance of an address (only available if the address index is enabled).
+	// GET returns the balance.
+	RouteAddressBalance = "/addresses/:" + restapipkg.ParameterAddress + "/balance"
+
+	// RouteAddressAtMilestoneIndex is the route for getting the unspent outputs and the balance of an address at a past milestone (only available if the address index is enabled).
+	// The milestone index needs to be within the pruning window of the node and at most "restAPI.limits.maxRevertedMilestones" behind the ledger index.
+	// GET returns the output IDs of the unspent outputs and the balance.
+	// The output IDs are paginated with the "pageSize" and "cursor" query parameters, the balance covers all outputs.
+	// INX clients query the state via PerformAPIRequest.
+	RouteAddressAtMilestoneIndex = "/addresses/:" + restapipkg.ParameterAddress + "/at/:" + restapipkg.ParameterMilestoneIndex
+
+	// RouteBlockReferencedBy is the route for getting the milestone that referenced a block (only available in archive mode).
+	// GET returns the index of the milestone and the white flag index of the block.
+	RouteBlockReferencedBy = "/blocks/:" + restapipkg.ParameterBlockID + "/referenced-by"
+
+	// RouteMilestoneByIndexReferencedBlocks is the route for getting the blocks referenced by a milestone (only available in archive mode).
+	// GET returns the block IDs in white flag ordering.
+	RouteMilestoneByIndexReferencedBlocks = "/milestones/by-index/:" + restapipkg.ParameterMilestoneIndex + "/referenced-blocks"
+
+	// RouteTransactionsSpendingTransactions is the route for getting the transactions that spent the outputs of a transaction (only available in archive mode).
+	// GET returns the spending transaction of every spent output.
+	RouteTransactionsSpendingTransactions = "/transactions/:" + restapipkg.ParameterTransactionID + "/spending-transactions"
+
 	// RouteTreasury is the route for getting the current treasury output.
 	// GET returns the treasury.
 	RouteTreasury = "/treasury"
@@ -113,10 +201,36 @@ const (
 	// POST adds a new peer.
 	RoutePeers = "/peers"
 
+	// RouteEvents is the route for streaming node events.
+	// GET streams the events via Server-Sent Events, or via WebSocket if the request is a WebSocket upgrade request.
+	// The optional "topics" query parameter filters the events by a comma separated list of topics.
+	// At most "restAPI.limits.maxEventSubscribers" clients can be subscribed at once, further requests are rejected.
+	RouteEvents = "/events"
+
 	// RouteControlDatabasePrune is the control route to manually prune the database.
 	// POST prunes the database.
 	RouteControlDatabasePrune = "/control/database/prune"
 
+	// RouteControlDatabasePruneDryRun is the control route to estimate the impact of a manual database pruning.
+	// POST returns the target index, the amount of pruned milestones and blocks and the freed space without pruning the database.
+	RouteControlDatabasePruneDryRun = "/control/database/prune/dry-run"
+
+	// RouteControlDatabaseRollback is the control route to roll back the ledger state to an older milestone.
+	// GET returns the status of the rollback.
+	// POST starts to revert the confirmations of all milestones newer than the target index in the background.
+	RouteControlDatabaseRollback = "/control/database/rollback"
+
+	// RouteControlDatabaseMigrate is the control route to migrate the database to another engine while the node is running.
+	// GET returns the status of the migration.
+	// POST starts to copy the database to the target engine. The node switches to the new database on the next restart.
+	RouteControlDatabaseMigrate = "/control/database/migrate"
+
+	// RouteControlDatabaseCheckpoint is the control route to create a checkpoint of the database while the node is running.
+	// GET returns the status of the latest checkpoint.
+	// POST fixes the state of consistent checkpoints of the tangle and UTXO databases at the confirmed milestone
+	// and writes the checkpoints in the background. Checkpoints of RocksDB databases are not supported.
+	RouteControlDatabaseCheckpoint = "/control/database/checkpoint"
+
 	// RouteControlSnapshotsCreate is the control route to manually create a snapshot files.
 	// POST creates a full snapshot.
 	RouteControlSnapshotsCreate = "/control/snapshots/create"
@@ -128,6 +242,7 @@ func init() {
 			Name:      "CoreAPIV2",
 			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
 			Configure: configure,
+			Run:       run,
 		},
 		IsEnabled: func() bool {
 			return restapi.ParamsRestAPI.Enabled
@@ -145,26 +260,36 @@ var (
 
 type dependencies struct {
 	dig.In
-	Storage                 *storage.Storage
-	SyncManager             *syncmanager.SyncManager
-	Tangle                  *tangle.Tangle
-	TipScoreCalculator      *tangle.TipScoreCalculator
-	PeeringManager          *p2p.Manager
-	GossipService           *gossip.Service
-	UTXOManager             *utxo.Manager
-	PoWHandler              *pow.Handler
-	SnapshotManager         *snapshot.Manager
-	PruningManager          *pruning.Manager
-	AppInfo                 *app.AppInfo
-	PeeringConfigManager    *p2p.ConfigManager
-	ProtocolManager         *protocol.Manager
-	BaseToken               *protocfg.BaseToken
-	RestAPILimitsMaxResults int                       `name:"restAPILimitsMaxResults"`
-	SnapshotsFullPath       string                    `name:"snapshotsFullPath"`
-	SnapshotsDeltaPath      string                    `name:"snapshotsDeltaPath"`
-	TipSelector             *tipselect.TipSelector    `optional:"true"`
-	RestRouteManager        *restapi.RestRouteManager `optional:"true"`
-	RestAPIMetrics          *metrics.RestAPIMetrics
+	Storage                            *storage.Storage
+	SyncManager                        *syncmanager.SyncManager
+	Tangle                             *tangle.Tangle
+	TipScoreCalculator                 *tangle.TipScoreCalculator
+	PeeringManager                     *p2p.Manager
+	GossipService                      *gossip.Service
+	UTXOManager                        *utxo.Manager
+	PoWHandler                         *pow.Handler
+	SnapshotManager                    *snapshot.Manager
+	PruningManager                     *pruning.Manager
+	OnlineMigration                    *database.OnlineMigration
+	Checkpointer                       *storage.Checkpointer
+	TangleDatabase                     *database.Database `name:"tangleDatabase"`
+	UTXODatabase                       *database.Database `name:"utxoDatabase"`
+	DatabaseCheckpointsPath            string             `name:"databaseCheckpointsPath"`
+	AppInfo                            *app.AppInfo
+	PeeringConfigManager               *p2p.ConfigManager
+	ProtocolManager                    *protocol.Manager
+	BaseToken                          *protocfg.BaseToken
+	RestAPILimitsMaxResults            int                       `name:"restAPILimitsMaxResults"`
+	RestAPILimitsMaxAddressOutputs     int                       `name:"restAPILimitsMaxAddressOutputs"`
+	RestAPILimitsMaxRevertedMilestones int                       `name:"restAPILimitsMaxRevertedMilestones"`
+	RestAPILimitsMaxEventSubscribers   int                       `name:"restAPILimitsMaxEventSubscribers"`
+	RestAPILimitsEventQueueSize        int                       `name:"restAPILimitsEventQueueSize"`
+	SnapshotsFullPath                  string                    `name:"snapshotsFullPath"`
+	SnapshotsDeltaPath                 string                    `name:"snapshotsDeltaPath"`
+	TipSelector                        *tipselect.TipSelector    `optional:"true"`
+	RestRouteManager                   *restapi.RestRouteManager `optional:"true"`
+	Promoter                           *promoter.Promoter        `optional:"true"`
+	RestAPIMetrics                     *metrics.RestAPIMetrics
 }
 
 func configure() error {
@@ -178,6 +303,7 @@ func configure() error {
 	attacherOpts := []tangle.BlockAttacherOption{
 		tangle.WithTimeout(blockProcessedTimeout),
 		tangle.WithPoWMetrics(deps.RestAPIMetrics),
+		tangle.WithMaxReferencedWaitTime(restapi.ParamsRestAPI.Limits.MaxReferencedWaitTime),
 	}
 	if deps.TipSelector != nil {
 		attacherOpts = append(attacherOpts, tangle.WithTipSel(deps.TipSelector.SelectNonLazyTips))
@@ -218,6 +344,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	}, checkNodeAlmostSynced())
 
+	routeGroup.GET(RouteBlockProof, func(c echo.Context) error {
+		resp, err := blockProofByID(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteBlock, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -243,7 +377,7 @@ func configure() error {
 	})
 
 	routeGroup.POST(RouteBlocks, func(c echo.Context) error {
-		resp, err := sendBlock(c)
+		resp, err := sendBlock(c, false)
 		if err != nil {
 			return err
 		}
@@ -251,6 +385,23 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusCreated, resp)
 	}, checkNodeAlmostSynced(), checkUpcomingUnsupportedProtocolVersion())
 
+	routeGroup.POST(RouteControlBlocks, func(c echo.Context) error {
+		resp, err := sendBlock(c, true)
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(echo.HeaderLocation, resp.BlockID)
+		return restapipkg.JSONResponse(c, http.StatusCreated, resp)
+	}, checkNodeAlmostSynced(), checkUpcomingUnsupportedProtocolVersion())
+
+	routeGroup.POST(RouteTransactionsSimulate, func(c echo.Context) error {
+		resp, err := simulateTransaction(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	}, checkNodeAlmostSynced())
+
 	routeGroup.GET(RouteTransactionsIncludedBlock, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -307,6 +458,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteMilestoneByIDLedgerStateHash, func(c echo.Context) error {
+		resp, err := milestoneLedgerStateHashByID(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteMilestoneByIndex, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -339,6 +498,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteMilestoneByIndexLedgerStateHash, func(c echo.Context) error {
+		resp, err := milestoneLedgerStateHashByIndex(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.GET(RouteOutput, func(c echo.Context) error {
 		mimeType, err := restapipkg.GetAcceptHeaderContentType(c, restapipkg.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
 		if err != nil && err != restapipkg.ErrNotAcceptable {
@@ -371,6 +538,114 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.GET(RouteOutputAtMilestoneIndex, func(c echo.Context) error {
+		resp, err := outputAtMilestoneIndex(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
+	// only handle address api calls if the address index is enabled
+	if deps.UTXOManager.AddressIndexEnabled() {
+		routeGroup.GET(RouteAddressOutputs, func(c echo.Context) error {
+			resp, err := unspentOutputsByAddress(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteAddressSpentOutputs, func(c echo.Context) error {
+			resp, err := spentOutputsByAddress(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteAddressBalance, func(c echo.Context) error {
+			resp, err := balanceByAddress(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteAddressAtMilestoneIndex, func(c echo.Context) error {
+			resp, err := addressAtMilestoneIndex(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+	}
+
+	// only handle archive api calls if the archive mode is enabled
+	if deps.UTXOManager.ArchiveEnabled() {
+		routeGroup.GET(RouteBlockReferencedBy, func(c echo.Context) error {
+			resp, err := blockReferencedBy(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteMilestoneByIndexReferencedBlocks, func(c echo.Context) error {
+			resp, err := referencedBlocksByMilestoneIndex(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteTransactionsSpendingTransactions, func(c echo.Context) error {
+			resp, err := spendingTransactionsByTransactionID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+	}
+
+	// only handle promotion api calls if the promoter is enabled
+	if deps.Promoter != nil {
+		routeGroup.GET(RouteBlockPromotion, func(c echo.Context) error {
+			resp, err := blockPromotionByID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+	}
+
+	// only handle ledger commitment api calls if the ledger commitment is enabled
+	if deps.UTXOManager.LedgerCommitmentEnabled() {
+		routeGroup.GET(RouteMilestoneByIDLedgerCommitment, func(c echo.Context) error {
+			resp, err := milestoneLedgerCommitmentByID(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteMilestoneByIndexLedgerCommitment, func(c echo.Context) error {
+			resp, err := milestoneLedgerCommitmentByIndex(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+
+		routeGroup.GET(RouteOutputLedgerCommitmentProof, func(c echo.Context) error {
+			resp, err := outputLedgerCommitmentProof(c)
+			if err != nil {
+				return err
+			}
+			return restapipkg.JSONResponse(c, http.StatusOK, resp)
+		})
+	}
+
 	routeGroup.GET(RouteTreasury, func(c echo.Context) error {
 		resp, err := treasury(c)
 		if err != nil {
@@ -434,6 +709,8 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	}, checkNodeAlmostSynced(), checkUpcomingUnsupportedProtocolVersion())
 
+	routeGroup.GET(RouteEvents, streamEvents)
+
 	routeGroup.POST(RouteControlDatabasePrune, func(c echo.Context) error {
 		resp, err := pruneDatabase(c)
 		if err != nil {
@@ -442,6 +719,50 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	routeGroup.POST(RouteControlDatabasePruneDryRun, func(c echo.Context) error {
+		resp, err := pruneDatabaseDryRun(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
+	routeGroup.GET(RouteControlDatabaseRollback, func(c echo.Context) error {
+		return restapipkg.JSONResponse(c, http.StatusOK, databaseRollbackStatus())
+	})
+
+	routeGroup.POST(RouteControlDatabaseRollback, func(c echo.Context) error {
+		resp, err := rollbackDatabase(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
+	routeGroup.GET(RouteControlDatabaseMigrate, func(c echo.Context) error {
+		return restapipkg.JSONResponse(c, http.StatusOK, databaseMigrationStatus())
+	})
+
+	routeGroup.POST(RouteControlDatabaseMigrate, func(c echo.Context) error {
+		resp, err := migrateDatabase(c)
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
+	routeGroup.GET(RouteControlDatabaseCheckpoint, func(c echo.Context) error {
+		return restapipkg.JSONResponse(c, http.StatusOK, databaseCheckpointStatus())
+	})
+
+	routeGroup.POST(RouteControlDatabaseCheckpoint, func(c echo.Context) error {
+		resp, err := createDatabaseCheckpoint()
+		if err != nil {
+			return err
+		}
+		return restapipkg.JSONResponse(c, http.StatusOK, resp)
+	})
+
 	routeGroup.POST(RouteControlSnapshotsCreate, func(c echo.Context) error {
 		resp, err := createSnapshots(c)
 		if err != nil {
@@ -450,6 +771,14 @@ func configure() error {
 		return restapipkg.JSONResponse(c, http.StatusOK, resp)
 	})
 
+	configureEvents()
+
+	return nil
+}
+
+func run() error {
+	runEvents()
+
 	return nil
 }
 
@@ -1,12 +1,16 @@
 package coreapi
 
 import (
+	"io/ioutil"
+
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 
 	"github.com/iotaledger/hive.go/kvstore"
+	"github.com/iotaledger/hive.go/serializer/v2"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/restapi"
+	"github.com/iotaledger/hornet/v2/pkg/whiteflag"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
@@ -53,3 +57,85 @@ func blockBytesByTransactionID(c echo.Context) ([]byte, error) {
 	}
 	return block.Data(), nil
 }
+
+func simulateTransaction(c echo.Context) (*transactionSimulationResponse, error) {
+	mimeType, err := restapi.GetRequestContentType(c, restapi.MIMEApplicationVendorIOTASerializerV1, echo.MIMEApplicationJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	transaction := &iotago.Transaction{}
+
+	switch mimeType {
+	case echo.MIMEApplicationJSON:
+		if err := c.Bind(transaction); err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: %s", err)
+		}
+
+		if transaction.Essence == nil {
+			return nil, errors.WithMessage(restapi.ErrInvalidParameter, "invalid transaction, error: essence missing")
+		}
+
+		// the transaction is only syntactically validated during serialization
+		if _, err := transaction.Serialize(serializer.DeSeriModePerformValidation, deps.ProtocolManager.Current()); err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: %s", err)
+		}
+
+	case restapi.MIMEApplicationVendorIOTASerializerV1:
+		if c.Request().Body == nil {
+			return nil, errors.WithMessage(restapi.ErrInvalidParameter, "invalid transaction, error: request body missing")
+			// bad request
+		}
+
+		bytes, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: %s", err)
+		}
+
+		if _, err := transaction.Deserialize(bytes, serializer.DeSeriModePerformValidation, deps.ProtocolManager.Current()); err != nil {
+			return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: %s", err)
+		}
+
+	default:
+		return nil, echo.ErrUnsupportedMediaType
+	}
+
+	if transaction.Essence.NetworkID != deps.ProtocolManager.Current().NetworkID() {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: wrong networkID: %d", transaction.Essence.NetworkID)
+	}
+
+	transactionID, err := transaction.ID()
+	if err != nil {
+		return nil, errors.WithMessagef(restapi.ErrInvalidParameter, "invalid transaction, error: %s", err)
+	}
+
+	// we need to lock the ledger here to validate the transaction against a consistent ledger state.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	msTimestamp, err := deps.Storage.MilestoneTimestampUnixByIndex(ledgerIndex)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading milestone timestamp failed: %d, error: %s", ledgerIndex, err)
+	}
+
+	conflict, err := whiteflag.SimulateTransaction(deps.UTXOManager, transaction, msTimestamp)
+	if err != nil {
+		if errors.Is(err, whiteflag.ErrInvalidTransaction) {
+			return nil, errors.WithMessage(restapi.ErrInvalidParameter, err.Error())
+		}
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "simulating transaction failed: %s, error: %s", transactionID.ToHex(), err)
+	}
+
+	return &transactionSimulationResponse{
+		TransactionID:      transactionID.ToHex(),
+		LedgerIndex:        ledgerIndex,
+		MilestoneTimestamp: msTimestamp,
+		Applied:            conflict == storage.ConflictNone,
+		ConflictReason:     conflict,
+	}, nil
+}
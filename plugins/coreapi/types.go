@@ -8,6 +8,7 @@ import (
 	"github.com/iotaledger/hornet/v2/core/protocfg"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
+	"github.com/iotaledger/hornet/v2/pkg/p2p"
 	"github.com/iotaledger/hornet/v2/pkg/protocol/gossip"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -101,12 +102,58 @@ type blockMetadataResponse struct {
 	ShouldReattach *bool `json:"shouldReattach,omitempty"`
 	// If this block is referenced by a milestone this returns the index of that block inside the milestone by whiteflag ordering.
 	WhiteFlagIndex *uint32 `json:"whiteFlagIndex,omitempty"`
+	// Whether the block was pruned and is served from the cold storage.
+	Archived bool `json:"archived,omitempty"`
+}
+
+// blockPromotionResponse defines the response of a GET block promotion REST API call.
+type blockPromotionResponse struct {
+	// The hex encoded block ID of the submitted block.
+	BlockID string `json:"blockId"`
+	// The state of the block ("pending", "referenced" or "failed").
+	State string `json:"state"`
+	// The hex encoded block IDs of the blocks that were issued to promote the block or its reattachments.
+	Promotions []string `json:"promotions"`
+	// The hex encoded block IDs of the blocks that were issued to reattach the payload of the block.
+	Reattachments []string `json:"reattachments"`
+	// The hex encoded block ID of the block or reattachment that was referenced by a milestone.
+	ReferencedBlockID string `json:"referencedBlockId,omitempty"`
+	// The milestone index that references the block or reattachment.
+	ReferencedByMilestoneIndex iotago.MilestoneIndex `json:"referencedByMilestoneIndex,omitempty"`
+	// The ledger inclusion state of the transaction payload of the referenced block.
+	LedgerInclusionState string `json:"ledgerInclusionState,omitempty"`
+	// The reason why the referenced block is marked as conflicting.
+	ConflictReason *storage.Conflict `json:"conflictReason,omitempty"`
+	// The last error that occurred while promoting or reattaching the block.
+	Error string `json:"error,omitempty"`
+}
+
+// transactionSimulationResponse defines the response of a POST transactions simulate REST API call.
+type transactionSimulationResponse struct {
+	// The hex encoded transaction ID of the transaction.
+	TransactionID string `json:"transactionId"`
+	// The ledger index the transaction was validated against.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The timestamp of the milestone of the ledger index that was used to validate timelocks and expirations.
+	MilestoneTimestamp uint32 `json:"milestoneTimestamp"`
+	// Whether the transaction would be applied to the ledger.
+	Applied bool `json:"applied"`
+	// The reason why the transaction would not be applied to the ledger.
+	ConflictReason storage.Conflict `json:"conflictReason"`
 }
 
 // blockCreatedResponse defines the response of a POST blocks REST API call.
 type blockCreatedResponse struct {
 	// The hex encoded block ID of the block.
 	BlockID string `json:"blockId"`
+	// The milestone index that references the block (only set if the submission waited until the block was referenced).
+	ReferencedByMilestoneIndex iotago.MilestoneIndex `json:"referencedByMilestoneIndex,omitempty"`
+	// The ledger inclusion state of the transaction payload (only set if the submission waited until the block was referenced).
+	LedgerInclusionState string `json:"ledgerInclusionState,omitempty"`
+	// The reason why this block is marked as conflicting.
+	ConflictReason *storage.Conflict `json:"conflictReason,omitempty"`
+	// Whether the block is tracked by the promoter (only set if the promotion was requested and the block was not referenced yet).
+	Tracked *bool `json:"tracked,omitempty"`
 }
 
 // milestoneUTXOChangesResponse defines the response of a GET milestone UTXO changes REST API call.
@@ -119,6 +166,34 @@ type milestoneUTXOChangesResponse struct {
 	ConsumedOutputs []string `json:"consumedOutputs"`
 }
 
+// milestoneLedgerStateHashResponse defines the response of a GET milestone ledger state hash REST API call.
+type milestoneLedgerStateHashResponse struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The hex encoded order-independent hash of all unspent outputs after the confirmation of the milestone.
+	LedgerStateHash string `json:"ledgerStateHash"`
+}
+
+// milestoneLedgerCommitmentResponse defines the response of a GET milestone ledger commitment REST API call.
+type milestoneLedgerCommitmentResponse struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The hex encoded root of the sparse merkle tree over all unspent outputs after the confirmation of the milestone.
+	LedgerCommitment string `json:"ledgerCommitment"`
+}
+
+// outputLedgerCommitmentProofResponse defines the response of a GET output proof REST API call.
+type outputLedgerCommitmentProofResponse struct {
+	// The ledger index the proof was created at.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The hex encoded ledger commitment of the ledger index.
+	LedgerCommitment string `json:"ledgerCommitment"`
+	// Whether the output is unspent at the ledger index.
+	Unspent bool `json:"unspent"`
+	// The membership proof if the output is unspent, the non-membership proof otherwise.
+	Proof *utxo.LedgerCommitmentProof `json:"proof"`
+}
+
 // OutputMetadataResponse defines the response of a GET outputs metadata REST API call.
 type OutputMetadataResponse struct {
 	// The hex encoded block ID of the block.
@@ -150,6 +225,131 @@ type OutputResponse struct {
 	RawOutput *json.RawMessage `json:"output"`
 }
 
+// outputAtMilestoneResponse defines the response of a GET output at milestone REST API call.
+type outputAtMilestoneResponse struct {
+	// The milestone index the state of the output was reconstructed for.
+	MilestoneIndex iotago.MilestoneIndex `json:"milestoneIndex"`
+	// Whether this output was spent at the milestone.
+	SpentAtMilestone bool `json:"isSpentAtMilestone"`
+	// The metadata of the output at the ledger index.
+	// The spent information is also set if the output was spent after the milestone.
+	Metadata *OutputMetadataResponse `json:"metadata"`
+	// The output in its serialized form.
+	RawOutput *json.RawMessage `json:"output"`
+}
+
+// addressOutputsResponse defines the response of a GET address outputs REST API call.
+type addressOutputsResponse struct {
+	// The ledger index at which the outputs were collected.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The maximum count of results that are returned.
+	PageSize int `json:"pageSize"`
+	// The hex encoded output IDs of the unspent outputs.
+	Items []string `json:"items"`
+	// The cursor to use for getting the next results.
+	Cursor *string `json:"cursor,omitempty"`
+}
+
+// addressSpentOutputResponse defines an output that was spent by an address.
+type addressSpentOutputResponse struct {
+	// The hex encoded output ID of the spent output.
+	OutputID string `json:"outputId"`
+	// The milestone index at which this output was spent.
+	MilestoneIndexSpent iotago.MilestoneIndex `json:"milestoneIndexSpent"`
+	// The milestone timestamp this output was spent.
+	MilestoneTimestampSpent uint32 `json:"milestoneTimestampSpent"`
+	// The transaction this output was spent with.
+	TransactionIDSpent string `json:"transactionIdSpent"`
+}
+
+// addressSpentOutputsResponse defines the response of a GET address spent outputs REST API call.
+type addressSpentOutputsResponse struct {
+	// The ledger index at which the outputs were collected.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The maximum count of results that are returned.
+	PageSize int `json:"pageSize"`
+	// The spent outputs ordered by the milestone index they were spent at.
+	Items []*addressSpentOutputResponse `json:"items"`
+	// The cursor to use for getting the next results.
+	Cursor *string `json:"cursor,omitempty"`
+}
+
+// blockReferencedByResponse defines the response of a GET block referenced-by REST API call.
+type blockReferencedByResponse struct {
+	// The hex encoded block ID of the block.
+	BlockID string `json:"blockId"`
+	// The index of the milestone that referenced the block.
+	MilestoneIndex iotago.MilestoneIndex `json:"referencedByMilestoneIndex"`
+	// The index of the block in the white flag ordering of the milestone.
+	WhiteFlagIndex uint32 `json:"whiteFlagIndex"`
+}
+
+// milestoneReferencedBlocksResponse defines the response of a GET milestone referenced blocks REST API call.
+type milestoneReferencedBlocksResponse struct {
+	// The index of the milestone.
+	Index iotago.MilestoneIndex `json:"index"`
+	// The maximum count of results that are returned.
+	PageSize int `json:"pageSize"`
+	// The hex encoded block IDs of the referenced blocks in white flag ordering.
+	Items []string `json:"items"`
+	// The cursor to use for getting the next results.
+	Cursor *string `json:"cursor,omitempty"`
+}
+
+// spendingTransactionResponse defines the transaction that spent an output.
+type spendingTransactionResponse struct {
+	// The hex encoded output ID of the spent output.
+	OutputID string `json:"outputId"`
+	// The hex encoded ID of the transaction that spent the output.
+	TransactionIDSpent string `json:"transactionIdSpent"`
+	// The index of the milestone that confirmed the spending transaction.
+	MilestoneIndexSpent iotago.MilestoneIndex `json:"milestoneIndexSpent"`
+}
+
+// transactionSpendingTransactionsResponse defines the response of a GET transaction spending transactions REST API call.
+type transactionSpendingTransactionsResponse struct {
+	// The hex encoded ID of the transaction.
+	TransactionID string `json:"transactionId"`
+	// The ledger index at which the spending transactions were collected.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The index of the first milestone whose spending transactions are known.
+	HistoryStartIndex iotago.MilestoneIndex `json:"historyStartIndex"`
+	// The transactions that spent the outputs of the transaction.
+	Items []*spendingTransactionResponse `json:"items"`
+}
+
+// addressBalanceResponse defines the response of a GET address balance REST API call.
+type addressBalanceResponse struct {
+	// The bech32 encoded address.
+	Address string `json:"address"`
+	// The sum of the deposits of all unspent outputs owned by the address.
+	Balance string `json:"balance"`
+	// The amount of unspent outputs owned by the address.
+	OutputCount int `json:"outputCount"`
+	// The ledger index at which the balance was calculated.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+}
+
+// addressAtMilestoneResponse defines the response of a GET address at milestone REST API call.
+type addressAtMilestoneResponse struct {
+	// The bech32 encoded address.
+	Address string `json:"address"`
+	// The milestone index the state of the address was reconstructed for.
+	MilestoneIndex iotago.MilestoneIndex `json:"milestoneIndex"`
+	// The sum of the deposits of all unspent outputs owned by the address at the milestone.
+	Balance string `json:"balance"`
+	// The amount of unspent outputs owned by the address at the milestone.
+	OutputCount int `json:"outputCount"`
+	// The ledger index at which the state of the address was reconstructed.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex"`
+	// The maximum count of results that are returned.
+	PageSize int `json:"pageSize"`
+	// The hex encoded output IDs of the unspent outputs at the milestone.
+	Items []string `json:"items"`
+	// The cursor to use for getting the next results.
+	Cursor *string `json:"cursor,omitempty"`
+}
+
 // addPeerRequest defines the request for a POST peer REST API call.
 type addPeerRequest struct {
 	// The libp2p multi address of the peer.
@@ -172,6 +372,8 @@ type PeerResponse struct {
 	Connected bool `json:"connected"`
 	// The gossip protocol information of the peer.
 	Gossip *gossip.Info `json:"gossip,omitempty"`
+	// The reputation score of the peer and its breakdown.
+	Reputation *p2p.ReputationScore `json:"reputation,omitempty"`
 }
 
 // pruneDatabaseRequest defines the request of a prune database REST API call.
@@ -190,6 +392,86 @@ type pruneDatabaseResponse struct {
 	Index iotago.MilestoneIndex `json:"index"`
 }
 
+// pruneDatabaseDryRunResponse defines the response of a prune database dry-run REST API call.
+type pruneDatabaseDryRunResponse struct {
+	// The current pruning index of the database.
+	PruningIndex iotago.MilestoneIndex `json:"pruningIndex"`
+	// The index up to which the database would be pruned.
+	TargetIndex iotago.MilestoneIndex `json:"targetIndex"`
+	// The amount of milestones that would be pruned.
+	MilestonesCount uint32 `json:"milestonesCount"`
+	// The estimated amount of blocks that would be pruned.
+	EstimatedBlocksCount uint64 `json:"estimatedBlocksCount"`
+	// The estimated amount of bytes that would be freed in the tangle database.
+	EstimatedBytesSaved int64 `json:"estimatedBytesSaved"`
+	// The warnings about components that would be affected by the pruning.
+	Warnings []string `json:"warnings"`
+}
+
+// rollbackDatabaseRequest defines the request of a rollback database REST API call.
+type rollbackDatabaseRequest struct {
+	// The target index of the rollback.
+	Index iotago.MilestoneIndex `json:"index"`
+}
+
+// databaseRollbackResponse defines the response of a database rollback REST API call.
+type databaseRollbackResponse struct {
+	// Whether the confirmations of the milestones are currently reverted.
+	Running bool `json:"running"`
+	// Whether the database was rolled back to the target index.
+	Completed bool `json:"completed"`
+	// The target index of the rollback.
+	TargetIndex iotago.MilestoneIndex `json:"targetIndex,omitempty"`
+	// The ledger index before the rollback.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex,omitempty"`
+	// The unix timestamp the rollback was started at.
+	StartTime int64 `json:"startTime,omitempty"`
+	// The error that aborted the rollback.
+	Error string `json:"error,omitempty"`
+}
+
+// migrateDatabaseRequest defines the request of a migrate database REST API call.
+type migrateDatabaseRequest struct {
+	// The engine the database is migrated to.
+	TargetEngine string `json:"targetEngine"`
+}
+
+// databaseMigrationResponse defines the response of a database migration REST API call.
+type databaseMigrationResponse struct {
+	// Whether the database is currently copied.
+	Running bool `json:"running"`
+	// Whether the database was copied. The node switches to the new database on the next restart.
+	Completed bool `json:"completed"`
+	// The engine the database is migrated to.
+	TargetEngine string `json:"targetEngine,omitempty"`
+	// The path the database is migrated to.
+	TargetPath string `json:"targetPath,omitempty"`
+	// The unix timestamp the migration was started at.
+	StartTime int64 `json:"startTime,omitempty"`
+	// The amount of database entries that were copied.
+	CopiedEntries int64 `json:"copiedEntries"`
+	// The error that aborted the migration.
+	Error string `json:"error,omitempty"`
+}
+
+// databaseCheckpointResponse defines the response of a database checkpoint REST API call.
+type databaseCheckpointResponse struct {
+	// Whether the checkpoint is currently written.
+	Running bool `json:"running"`
+	// Whether the checkpoint was written.
+	Completed bool `json:"completed"`
+	// The confirmed milestone index of the ledger state in the checkpoint.
+	LedgerIndex iotago.MilestoneIndex `json:"ledgerIndex,omitempty"`
+	// The engine of the databases in the checkpoint.
+	Engine string `json:"engine,omitempty"`
+	// The path of the checkpoint.
+	Path string `json:"path,omitempty"`
+	// The unix timestamp the checkpoint was started at.
+	StartTime int64 `json:"startTime,omitempty"`
+	// The error that aborted the checkpoint.
+	Error string `json:"error,omitempty"`
+}
+
 // createSnapshotsRequest defines the request of a create snapshots REST API call.
 type createSnapshotsRequest struct {
 	// The index of the snapshot.
@@ -146,6 +146,114 @@ func outputMetadataByID(c echo.Context) (*OutputMetadataResponse, error) {
 	return NewSpentMetadataResponse(spent, ledgerIndex), nil
 }
 
+func outputLedgerCommitmentProof(c echo.Context) (*outputLedgerCommitmentProofResponse, error) {
+	outputID, err := restapi.ParseOutputIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to have the proof and the ledger commitment of the same ledger index.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	ledgerCommitment, err := deps.UTXOManager.LedgerCommitmentWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger commitment failed, error: %s", err)
+	}
+
+	proof, err := deps.UTXOManager.LedgerCommitmentProofWithoutLocking(outputID)
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "creating proof failed: %s, error: %s", outputID.ToHex(), err)
+	}
+
+	return &outputLedgerCommitmentProofResponse{
+		LedgerIndex:      ledgerIndex,
+		LedgerCommitment: ledgerCommitment.ToHex(),
+		Unspent:          proof.Included(),
+		Proof:            proof,
+	}, nil
+}
+
+// checkMilestoneIndexWithinPruningWindow checks that the ledger state of the given milestone can still be reconstructed.
+func checkMilestoneIndexWithinPruningWindow(msIndex iotago.MilestoneIndex) error {
+	snapshotInfo := deps.Storage.SnapshotInfo()
+	if snapshotInfo == nil {
+		return errors.WithMessage(echo.ErrInternalServerError, "snapshot info not found")
+	}
+
+	if msIndex < snapshotInfo.PruningIndex() {
+		return errors.WithMessagef(restapi.ErrInvalidParameter, "milestone index %d is below the pruning index %d", msIndex, snapshotInfo.PruningIndex())
+	}
+
+	return nil
+}
+
+// ledgerStateAtMilestoneError maps the errors of the ledger state reconstruction to REST API errors.
+func ledgerStateAtMilestoneError(err error, msIndex iotago.MilestoneIndex) error {
+	if errors.Is(err, utxo.ErrMilestoneIndexAboveLedgerIndex) || errors.Is(err, utxo.ErrMilestoneIndexTooFarBehindLedgerIndex) {
+		return errors.WithMessagef(restapi.ErrInvalidParameter, "invalid milestone index: %d, error: %s", msIndex, err)
+	}
+	return errors.WithMessagef(echo.ErrInternalServerError, "reconstructing ledger state at milestone %d failed, error: %s", msIndex, err)
+}
+
+func outputAtMilestoneIndex(c echo.Context) (*outputAtMilestoneResponse, error) {
+	outputID, err := restapi.ParseOutputIDParam(c)
+	if err != nil {
+		return nil, err
+	}
+
+	msIndex, err := restapi.ParseMilestoneIndexParam(c, restapi.ParameterMilestoneIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// we need to lock the ledger here to reconstruct the state from a consistent ledger index.
+	deps.UTXOManager.ReadLockLedger()
+	defer deps.UTXOManager.ReadUnlockLedger()
+
+	if err := checkMilestoneIndexWithinPruningWindow(msIndex); err != nil {
+		return nil, err
+	}
+
+	ledgerIndex, err := deps.UTXOManager.ReadLedgerIndexWithoutLocking()
+	if err != nil {
+		return nil, errors.WithMessagef(echo.ErrInternalServerError, "reading ledger index failed, error: %s", err)
+	}
+
+	outputAtMilestone, err := deps.UTXOManager.OutputAtMilestoneIndexWithoutLocking(outputID, msIndex)
+	if err != nil {
+		if errors.Is(err, kvstore.ErrKeyNotFound) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "output not found: %s", outputID.ToHex())
+		}
+		if errors.Is(err, utxo.ErrOutputNotCreatedAtMilestone) {
+			return nil, errors.WithMessagef(echo.ErrNotFound, "output not found at milestone %d: %s, error: %s", msIndex, outputID.ToHex(), err)
+		}
+		return nil, ledgerStateAtMilestoneError(err, msIndex)
+	}
+
+	var outputResponse *OutputResponse
+	if outputAtMilestone.Spent != nil {
+		outputResponse, err = NewSpentResponse(outputAtMilestone.Spent, ledgerIndex)
+	} else {
+		outputResponse, err = NewOutputResponse(outputAtMilestone.Output, ledgerIndex)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &outputAtMilestoneResponse{
+		MilestoneIndex:   msIndex,
+		SpentAtMilestone: outputAtMilestone.SpentAtMilestone(),
+		Metadata:         outputResponse.Metadata,
+		RawOutput:        outputResponse.RawOutput,
+	}, nil
+}
+
 func rawOutputByID(c echo.Context) ([]byte, error) {
 	outputID, err := restapi.ParseOutputIDParam(c)
 	if err != nil {
@@ -194,6 +194,7 @@ func requests(_ echo.Context) (*requestsResponse, error) {
 
 	return &requestsResponse{
 		Requests: debugReqs,
+		Peers:    deps.Requester.PeerRequestStats(),
 	}, nil
 }
 
@@ -38,7 +38,7 @@ const (
 	RouteDebugMilestoneDiffs = "/ms-diff/:" + restapipkg.ParameterMilestoneIndex
 
 	// RouteDebugRequests is the debug route for getting all pending requests.
-	// GET returns a list of all pending requests.
+	// GET returns a list of all pending requests and the request statistics of the peers.
 	RouteDebugRequests = "/requests"
 
 	// RouteDebugBlockCone is the debug route for traversing a cone of a block.
@@ -72,6 +72,7 @@ type dependencies struct {
 	SyncManager      *syncmanager.SyncManager
 	Tangle           *tangle.Tangle
 	RequestQueue     gossip.RequestQueue
+	Requester        *gossip.Requester
 	UTXOManager      *utxo.Manager
 	RestRouteManager *restapi.RestRouteManager `optional:"true"`
 }
@@ -1,6 +1,7 @@
 package debug
 
 import (
+	"github.com/iotaledger/hornet/v2/pkg/protocol/gossip"
 	"github.com/iotaledger/hornet/v2/plugins/coreapi"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -49,6 +50,8 @@ type request struct {
 type requestsResponse struct {
 	// The pending requests of the node.
 	Requests []*request `json:"requests"`
+	// The request statistics of the peers.
+	Peers []*gossip.PeerRequestStats `json:"peers"`
 }
 
 // entryPoint defines an entryPoint with information about the milestone index of the cone it references.
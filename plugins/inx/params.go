@@ -1,6 +1,8 @@
 package inx
 
 import (
+	"time"
+
 	"github.com/iotaledger/hive.go/app"
 )
 
@@ -10,6 +12,8 @@ type ParametersINX struct {
 	Enabled bool `default:"false" usage:"whether the INX plugin is enabled"`
 	// the bind address on which the INX can be accessed from
 	BindAddress string `default:"localhost:9029" usage:"the bind address on which the INX can be accessed from"`
+	// the maximum time a block submission may wait until the block is referenced by a milestone
+	MaxReferencedWaitTime time.Duration `default:"1m" usage:"the maximum time a block submission may wait until the block is referenced by a milestone"`
 
 	PoW struct {
 		// the amount of workers used for calculating PoW when issuing blocks via INX
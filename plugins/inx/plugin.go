@@ -17,6 +17,7 @@ import (
 	"github.com/iotaledger/hornet/v2/pkg/model/utxo"
 	"github.com/iotaledger/hornet/v2/pkg/pow"
 	"github.com/iotaledger/hornet/v2/pkg/protocol"
+	"github.com/iotaledger/hornet/v2/pkg/pruning"
 	"github.com/iotaledger/hornet/v2/pkg/tangle"
 	"github.com/iotaledger/hornet/v2/pkg/tipselect"
 	"github.com/iotaledger/hornet/v2/plugins/restapi"
@@ -60,6 +61,7 @@ type dependencies struct {
 	ProtocolManager         *protocol.Manager
 	BaseToken               *protocfg.BaseToken
 	PoWHandler              *pow.Handler
+	PruningManager          *pruning.Manager `optional:"true"`
 	INXServer               *INXServer
 	INXMetrics              *metrics.INXMetrics
 	Echo                    *echo.Echo                `optional:"true"`
@@ -93,6 +95,7 @@ func configure() error {
 		tangle.WithTimeout(blockProcessedTimeout),
 		tangle.WithPoW(deps.PoWHandler, ParamsINX.PoW.WorkerCount),
 		tangle.WithPoWMetrics(deps.INXMetrics),
+		tangle.WithMaxReferencedWaitTime(ParamsINX.MaxReferencedWaitTime),
 	}
 	if deps.TipSelector != nil {
 		attacherOpts = append(attacherOpts, tangle.WithTipSel(deps.TipSelector.SelectNonLazyTips))
@@ -100,6 +103,11 @@ func configure() error {
 
 	attacher = deps.Tangle.BlockAttacher(attacherOpts...)
 
+	if deps.PruningManager != nil {
+		// INX consumers that stream the tangle history are reported in pruning dry-runs
+		deps.PruningManager.AddConsumerIndexesFunc(deps.INXServer.ConsumerIndexes)
+	}
+
 	return nil
 }
 
@@ -2,13 +2,21 @@ package inx
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"sync/atomic"
 
 	grpcprometheus "github.com/grpc-ecosystem/go-grpc-prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/syncutils"
 	"github.com/iotaledger/hive.go/workerpool"
 	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/pruning"
 	inx "github.com/iotaledger/inx/go"
 	iotago "github.com/iotaledger/iota.go/v3"
 )
@@ -23,7 +31,10 @@ func newINXServer() *INXServer {
 		grpc.StreamInterceptor(grpcprometheus.StreamServerInterceptor),
 		grpc.UnaryInterceptor(grpcprometheus.UnaryServerInterceptor),
 	)
-	s := &INXServer{grpcServer: grpcServer}
+	s := &INXServer{
+		grpcServer: grpcServer,
+		streams:    make(map[*streamRange]string),
+	}
 	inx.RegisterINXServer(grpcServer, s)
 	return s
 }
@@ -31,6 +42,10 @@ func newINXServer() *INXServer {
 type INXServer struct {
 	inx.UnimplementedINXServer
 	grpcServer *grpc.Server
+
+	// the active ranged streams and the names of their consumers.
+	streamsLock syncutils.RWMutex
+	streams     map[*streamRange]string
 }
 
 func (s *INXServer) ConfigurePrometheus() {
@@ -152,6 +167,84 @@ type streamRange struct {
 	start    iotago.MilestoneIndex
 	end      iotago.MilestoneIndex
 	lastSent iotago.MilestoneIndex
+	// progress is a copy of lastSent that can be read from other goroutines.
+	progress uint32
+}
+
+// sets the index of the latest milestone that was sent.
+func (stream *streamRange) setLastSent(index iotago.MilestoneIndex) {
+	stream.lastSent = index
+	atomic.StoreUint32(&stream.progress, index)
+}
+
+// tracks the given ranged stream until the returned function is called.
+func (s *INXServer) trackStream(ctx context.Context, name string, stream *streamRange) func() {
+	if !stream.rangeRequested() {
+		// the stream only sends new data, it doesn't depend on the history
+		return func() {}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		name = fmt.Sprintf("%s (%s)", name, p.Addr.String())
+	}
+
+	s.streamsLock.Lock()
+	s.streams[stream] = name
+	s.streamsLock.Unlock()
+
+	return func() {
+		s.streamsLock.Lock()
+		delete(s.streams, stream)
+		s.streamsLock.Unlock()
+	}
+}
+
+// abortOnLedgerRollback cancels a stream if the ledger is rolled back, because the updates that were already sent are not valid anymore.
+// The returned function detaches the handler and returns the error the stream needs to be closed with, if the ledger was rolled back.
+func abortOnLedgerRollback(cancel context.CancelFunc) func() error {
+	var rollbackErr error
+	var rollbackErrLock syncutils.Mutex
+
+	closure := events.NewClosure(func(targetIndex iotago.MilestoneIndex, ledgerIndex iotago.MilestoneIndex) {
+		rollbackErrLock.Lock()
+		defer rollbackErrLock.Unlock()
+
+		// the client needs to resync from the target index
+		rollbackErr = status.Errorf(codes.Aborted, "ledger was rolled back from milestone %d to %d", ledgerIndex, targetIndex)
+		cancel()
+	})
+	deps.Tangle.Events.LedgerRolledBack.Attach(closure)
+
+	return func() error {
+		deps.Tangle.Events.LedgerRolledBack.Detach(closure)
+
+		rollbackErrLock.Lock()
+		defer rollbackErrLock.Unlock()
+
+		return rollbackErr
+	}
+}
+
+// ConsumerIndexes returns the indexes of the INX consumers that requested ranged streams of the tangle history.
+func (s *INXServer) ConsumerIndexes() []*pruning.ConsumerIndex {
+	s.streamsLock.RLock()
+	defer s.streamsLock.RUnlock()
+
+	consumers := make([]*pruning.ConsumerIndex, 0, len(s.streams))
+	for stream, name := range s.streams {
+		index := atomic.LoadUint32(&stream.progress)
+		if index < stream.start {
+			// nothing was sent yet
+			index = stream.start - 1
+		}
+
+		consumers = append(consumers, &pruning.ConsumerIndex{
+			Name:  name,
+			Index: index,
+		})
+	}
+
+	return consumers
 }
 
 // tells whether the stream range has a range requested.
@@ -196,7 +289,7 @@ func handleRangedSend(task *workerpool.Task, index iotago.MilestoneIndex, stream
 			return false, err
 		}
 
-		streamRange.lastSent = endIndex
+		streamRange.setLastSent(endIndex)
 	}
 
 	// stream finished
@@ -208,7 +301,7 @@ func handleRangedSend(task *workerpool.Task, index iotago.MilestoneIndex, stream
 		return false, err
 	}
 
-	streamRange.lastSent = index
+	streamRange.setLastSent(index)
 
 	// stream finished
 	if streamRange.isBounded() && index >= streamRange.end {
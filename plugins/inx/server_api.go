@@ -3,8 +3,11 @@ package inx
 import (
 	"bytes"
 	"context"
+	"net/http"
 	"net/http/httptest"
 
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
@@ -47,6 +50,9 @@ func (s *INXServer) UnregisterAPIRoute(_ context.Context, req *inx.APIRouteReque
 	return &inx.NoParams{}, nil
 }
 
+// PerformAPIRequest runs the handler of the given REST API route in-process and returns its response.
+// It needs the RestAPI plugin, and the middlewares of the REST API, e.g. the JWT check of the protected routes, are not executed.
+// Query parameters are passed as part of the path. Errors of the route handlers are returned as gRPC status errors.
 func (s *INXServer) PerformAPIRequest(_ context.Context, req *inx.APIRequest) (*inx.APIResponse, error) {
 	if Plugin.App.IsPluginSkipped(restapi.Plugin) {
 		return nil, status.Error(codes.Unavailable, "RestAPI plugin is not enabled")
@@ -57,9 +63,10 @@ func (s *INXServer) PerformAPIRequest(_ context.Context, req *inx.APIRequest) (*
 
 	rec := httptest.NewRecorder()
 	c := deps.Echo.NewContext(httpReq, rec)
-	deps.Echo.Router().Find(req.GetMethod(), req.GetPath(), c)
+	// the router only matches the path, the query parameters are read from the request
+	deps.Echo.Router().Find(req.GetMethod(), echo.GetPath(httpReq), c)
 	if err := c.Handler()(c); err != nil {
-		return nil, err
+		return nil, apiRequestError(err)
 	}
 
 	return &inx.APIResponse{
@@ -68,3 +75,36 @@ func (s *INXServer) PerformAPIRequest(_ context.Context, req *inx.APIRequest) (*
 		Body:    rec.Body.Bytes(),
 	}, nil
 }
+
+// apiRequestError converts the error of an API route handler to a gRPC status,
+// so INX clients can distinguish e.g. invalid requests from missing resources.
+func apiRequestError(err error) error {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, &httpErr) {
+		return status.Error(codes.Unknown, err.Error())
+	}
+
+	code := codes.Unknown
+	switch httpErr.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusInternalServerError:
+		code = codes.Internal
+	case http.StatusNotImplemented:
+		code = codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+
+	return status.Error(code, err.Error())
+}
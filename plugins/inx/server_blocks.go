@@ -2,9 +2,13 @@ package inx
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/iotaledger/hive.go/contextutils"
@@ -19,6 +23,18 @@ import (
 	iotago "github.com/iotaledger/iota.go/v3"
 )
 
+const (
+	// MetadataKeyWaitReferenced is the request metadata key to define the time in seconds
+	// SubmitBlock waits until the block is referenced by a milestone.
+	MetadataKeyWaitReferenced = "wait-referenced"
+	// MetadataKeyReferencedByMilestoneIndex is the response header metadata key of the milestone index that references the submitted block.
+	MetadataKeyReferencedByMilestoneIndex = "referenced-by-milestone-index"
+	// MetadataKeyLedgerInclusionState is the response header metadata key of the ledger inclusion state of the submitted block.
+	MetadataKeyLedgerInclusionState = "ledger-inclusion-state"
+	// MetadataKeyConflictReason is the response header metadata key of the conflict reason of the submitted block.
+	MetadataKeyConflictReason = "conflict-reason"
+)
+
 func INXNewBlockMetadata(blockID iotago.BlockID, metadata *storage.BlockMetadata, tip ...*tipselect.Tip) (*inx.BlockMetadata, error) {
 	m := &inx.BlockMetadata{
 		BlockId: inx.NewBlockId(blockID),
@@ -253,12 +269,71 @@ func (s *INXServer) SubmitBlock(context context.Context, rawBlock *inx.RawBlock)
 		return nil, err
 	}
 
+	waitReferenced, err := waitReferencedFromMetadata(context)
+	if err != nil {
+		return nil, err
+	}
+
 	mergedCtx, mergedCtxCancel := contextutils.MergeContexts(context, Plugin.Daemon().ContextStopped())
 	defer mergedCtxCancel()
 
-	blockID, err := attacher.AttachBlock(mergedCtx, block)
+	if waitReferenced == 0 {
+		blockID, err := attacher.AttachBlock(mergedCtx, block)
+		if err != nil {
+			return nil, err
+		}
+		return inx.NewBlockId(blockID), nil
+	}
+
+	blockID, result, err := attacher.AttachBlockAndWaitReferenced(mergedCtx, block, waitReferenced)
 	if err != nil {
+		if errors.Is(err, tangle.ErrBlockNotReferencedInTime) || errors.Is(err, tangle.ErrBlockReferencedWaitersLimitReached) {
+			// the block was attached, the client can continue to check the metadata of the block
+			return inx.NewBlockId(blockID), nil
+		}
 		return nil, err
 	}
+
+	if err := grpc.SetHeader(context, blockReferencedMetadata(result)); err != nil {
+		return nil, status.Errorf(codes.Internal, "setting header failed: %s", err)
+	}
+
 	return inx.NewBlockId(blockID), nil
 }
+
+// waitReferencedFromMetadata returns the time SubmitBlock waits until the block is referenced by a milestone.
+// The time in seconds is passed by the client in the request metadata, since the INX messages can't be extended.
+func waitReferencedFromMetadata(ctx context.Context) (time.Duration, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return 0, nil
+	}
+
+	values := md.Get(MetadataKeyWaitReferenced)
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	waitReferenced, err := strconv.ParseUint(values[0], 10, 32)
+	if err != nil {
+		return 0, status.Errorf(codes.InvalidArgument, "invalid %s metadata: %s", MetadataKeyWaitReferenced, values[0])
+	}
+
+	return time.Duration(waitReferenced) * time.Second, nil
+}
+
+// blockReferencedMetadata returns the response metadata of a block that was referenced by a milestone.
+func blockReferencedMetadata(result *tangle.BlockReferencedResult) metadata.MD {
+	inclusionState := inx.BlockMetadata_NO_TRANSACTION
+	if result.Conflict != storage.ConflictNone {
+		inclusionState = inx.BlockMetadata_CONFLICTING
+	} else if result.IncludedInLedger {
+		inclusionState = inx.BlockMetadata_INCLUDED
+	}
+
+	return metadata.Pairs(
+		MetadataKeyReferencedByMilestoneIndex, strconv.FormatUint(uint64(result.MilestoneIndex), 10),
+		MetadataKeyLedgerInclusionState, inclusionState.String(),
+		MetadataKeyConflictReason, inx.BlockMetadata_ConflictReason(result.Conflict).String(),
+	)
+}
@@ -166,11 +166,14 @@ func (s *INXServer) ListenToConfirmedMilestones(req *inx.MilestoneRangeRequest,
 		end:   req.GetEndMilestoneIndex(),
 	}
 
-	var err error
-	stream.lastSent, err = sendPreviousMilestones(stream.start, stream.end)
+	untrackStream := s.trackStream(srv.Context(), "INX confirmed milestones stream", stream)
+	defer untrackStream()
+
+	lastSent, err := sendPreviousMilestones(stream.start, stream.end)
 	if err != nil {
 		return err
 	}
+	stream.setLastSent(lastSent)
 
 	if stream.isBounded() && stream.lastSent >= stream.end {
 		// We are done sending, so close the stream
@@ -219,11 +222,17 @@ func (s *INXServer) ListenToConfirmedMilestones(req *inx.MilestoneRangeRequest,
 	})
 
 	wp.Start()
+	detachRollback := abortOnLedgerRollback(cancel)
 	deps.Tangle.Events.ConfirmedMilestoneChanged.Attach(closure)
 	<-ctx.Done()
 	deps.Tangle.Events.ConfirmedMilestoneChanged.Detach(closure)
+	rollbackErr := detachRollback()
 	wp.Stop()
 
+	if rollbackErr != nil {
+		return rollbackErr
+	}
+
 	return innerErr
 }
 
@@ -240,11 +240,14 @@ func (s *INXServer) ListenToLedgerUpdates(req *inx.MilestoneRangeRequest, srv in
 		end:   req.GetEndMilestoneIndex(),
 	}
 
-	var err error
-	stream.lastSent, err = sendPreviousMilestoneDiffs(stream.start, stream.end)
+	untrackStream := s.trackStream(srv.Context(), "INX ledger updates stream", stream)
+	defer untrackStream()
+
+	lastSent, err := sendPreviousMilestoneDiffs(stream.start, stream.end)
 	if err != nil {
 		return err
 	}
+	stream.setLastSent(lastSent)
 
 	if stream.isBounded() && stream.lastSent >= stream.end {
 		// We are done sending, so close the stream
@@ -291,11 +294,17 @@ func (s *INXServer) ListenToLedgerUpdates(req *inx.MilestoneRangeRequest, srv in
 	})
 
 	wp.Start()
+	detachRollback := abortOnLedgerRollback(cancel)
 	deps.Tangle.Events.LedgerUpdated.Attach(closure)
 	<-ctx.Done()
 	deps.Tangle.Events.LedgerUpdated.Detach(closure)
+	rollbackErr := detachRollback()
 	wp.Stop()
 
+	if rollbackErr != nil {
+		return rollbackErr
+	}
+
 	return innerErr
 }
 
@@ -408,11 +417,14 @@ func (s *INXServer) ListenToTreasuryUpdates(req *inx.MilestoneRangeRequest, srv
 		end:   req.GetEndMilestoneIndex(),
 	}
 
-	var err error
-	stream.lastSent, err = sendPreviousTreasuryUpdates(stream.start, stream.end)
+	untrackStream := s.trackStream(srv.Context(), "INX treasury updates stream", stream)
+	defer untrackStream()
+
+	lastSent, err := sendPreviousTreasuryUpdates(stream.start, stream.end)
 	if err != nil {
 		return err
 	}
+	stream.setLastSent(lastSent)
 
 	if !treasuryUpdateSent {
 		// Since treasury mutations do not happen on every milestone, send the stored unspent output that we have
@@ -420,7 +432,7 @@ func (s *INXServer) ListenToTreasuryUpdates(req *inx.MilestoneRangeRequest, srv
 		if err != nil {
 			return err
 		}
-		stream.lastSent = ledgerIndex
+		stream.setLastSent(ledgerIndex)
 	}
 
 	if stream.isBounded() && stream.lastSent >= stream.end {
@@ -466,11 +478,17 @@ func (s *INXServer) ListenToTreasuryUpdates(req *inx.MilestoneRangeRequest, srv
 	})
 
 	wp.Start()
+	detachRollback := abortOnLedgerRollback(cancel)
 	deps.Tangle.Events.TreasuryMutated.Attach(closure)
 	<-ctx.Done()
 	deps.Tangle.Events.TreasuryMutated.Detach(closure)
+	rollbackErr := detachRollback()
 	wp.Stop()
 
+	if rollbackErr != nil {
+		return rollbackErr
+	}
+
 	return innerErr
 }
 
@@ -137,6 +137,7 @@ func collectDebug() {
 	if lastDatabasePruningMetrics != nil {
 		databasePruningDurations.WithLabelValues("prune_unreferenced_blocks").Set(lastDatabasePruningMetrics.DurationPruneUnreferencedBlocks.Seconds())
 		databasePruningDurations.WithLabelValues("traverse_milestone_cone").Set(lastDatabasePruningMetrics.DurationTraverseMilestoneCone.Seconds())
+		databasePruningDurations.WithLabelValues("export_milestone_cone").Set(lastDatabasePruningMetrics.DurationExportMilestoneCone.Seconds())
 		databasePruningDurations.WithLabelValues("prune_milestone").Set(lastDatabasePruningMetrics.DurationPruneMilestone.Seconds())
 		databasePruningDurations.WithLabelValues("prune_blocks").Set(lastDatabasePruningMetrics.DurationPruneBlocks.Seconds())
 		databasePruningDurations.WithLabelValues("set_snapshot_info").Set(lastDatabasePruningMetrics.DurationSetSnapshotInfo.Seconds())
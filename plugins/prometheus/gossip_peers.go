@@ -10,6 +10,7 @@ var (
 	gossipPeersHeartbeats     *prometheus.GaugeVec
 	gossipPeersDroppedPackets *prometheus.GaugeVec
 	gossipPeersConnected      *prometheus.GaugeVec
+	gossipPeersExceededQuotas *prometheus.GaugeVec
 )
 
 func configureGossipPeers() {
@@ -64,11 +65,22 @@ func configureGossipPeers() {
 		[]string{"address", "alias", "id"},
 	)
 
+	gossipPeersExceededQuotas = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "iota",
+			Subsystem: "gossip_peers",
+			Name:      "exceeded_quota_count",
+			Help:      "Number of messages exceeding the gossip quotas by peer.",
+		},
+		[]string{"address", "alias", "id", "type"},
+	)
+
 	registry.MustRegister(gossipPeersBlocks)
 	registry.MustRegister(gossipPeersRequests)
 	registry.MustRegister(gossipPeersHeartbeats)
 	registry.MustRegister(gossipPeersDroppedPackets)
 	registry.MustRegister(gossipPeersConnected)
+	registry.MustRegister(gossipPeersExceededQuotas)
 
 	addCollect(collectGossipPeers)
 }
@@ -79,6 +91,7 @@ func collectGossipPeers() {
 	gossipPeersHeartbeats.Reset()
 	gossipPeersDroppedPackets.Reset()
 	gossipPeersConnected.Reset()
+	gossipPeersExceededQuotas.Reset()
 
 	for _, peer := range deps.PeeringManager.PeerInfoSnapshots() {
 
@@ -117,6 +130,15 @@ func collectGossipPeers() {
 
 		gossipPeersDroppedPackets.With(getLabels("sent")).Set(float64(peer.DroppedSentPackets))
 
+		if gossipProto.Quotas != nil {
+			exceededQuotas := gossipProto.Quotas.Snapshot()
+			gossipPeersExceededQuotas.With(getLabels("bandwidth")).Set(float64(exceededQuotas.ExceededBandwidth))
+			gossipPeersExceededQuotas.With(getLabels("blocks")).Set(float64(exceededQuotas.ExceededBlocks))
+			gossipPeersExceededQuotas.With(getLabels("block_requests")).Set(float64(exceededQuotas.ExceededBlockRequests))
+			gossipPeersExceededQuotas.With(getLabels("milestone_requests")).Set(float64(exceededQuotas.ExceededMilestoneRequests))
+			gossipPeersExceededQuotas.With(getLabels("heartbeats")).Set(float64(exceededQuotas.ExceededHeartbeats))
+		}
+
 		gossipPeersConnected.With(peerLabels).Set(0)
 		if peer.Connected {
 			gossipPeersConnected.With(peerLabels).Set(1)
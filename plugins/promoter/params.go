@@ -0,0 +1,33 @@
+package promoter
+
+import (
+	"github.com/iotaledger/hive.go/app"
+)
+
+// ParametersPromoter contains the definition of the parameters used by the promoter.
+type ParametersPromoter struct {
+	// Enabled defines whether the promoter plugin is enabled.
+	Enabled bool `default:"false" usage:"whether the promoter plugin is enabled"`
+	// the maximum amount of blocks submitted via API or INX that are tracked at the same time
+	MaxTrackedBlocks int `default:"1000" usage:"the maximum amount of blocks submitted via API or INX that are tracked at the same time"`
+	// the maximum amount of promotions of an attachment of a tracked block
+	MaxPromotions int `default:"3" usage:"the maximum amount of promotions of an attachment of a tracked block"`
+	// the maximum amount of reattachments of a tracked block
+	MaxReattachments int `default:"3" usage:"the maximum amount of reattachments of a tracked block"`
+	// the amount of milestones the status of a referenced or failed block is kept
+	StatusRetentionMilestones int `default:"30" usage:"the amount of milestones the status of a referenced or failed block is kept"`
+
+	PoW struct {
+		// the amount of workers used for calculating PoW when issuing promotions and reattachments
+		WorkerCount int `default:"1" usage:"the amount of workers used for calculating PoW when issuing promotions and reattachments"`
+	} `name:"pow"`
+}
+
+var ParamsPromoter = &ParametersPromoter{}
+
+var params = &app.ComponentParams{
+	Params: map[string]any{
+		"promoter": ParamsPromoter,
+	},
+	Masked: nil,
+}
@@ -0,0 +1,156 @@
+package promoter
+
+import (
+	"context"
+
+	"go.uber.org/dig"
+
+	"github.com/iotaledger/hive.go/app"
+	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/workerpool"
+	"github.com/iotaledger/hornet/v2/pkg/common"
+	"github.com/iotaledger/hornet/v2/pkg/daemon"
+	"github.com/iotaledger/hornet/v2/pkg/model/storage"
+	"github.com/iotaledger/hornet/v2/pkg/model/syncmanager"
+	"github.com/iotaledger/hornet/v2/pkg/pow"
+	"github.com/iotaledger/hornet/v2/pkg/promoter"
+	"github.com/iotaledger/hornet/v2/pkg/tangle"
+	"github.com/iotaledger/hornet/v2/pkg/tipselect"
+	iotago "github.com/iotaledger/iota.go/v3"
+)
+
+func init() {
+	Plugin = &app.Plugin{
+		Component: &app.Component{
+			Name:      "Promoter",
+			DepsFunc:  func(cDeps dependencies) { deps = cDeps },
+			Params:    params,
+			Provide:   provide,
+			Configure: configure,
+			Run:       run,
+		},
+		IsEnabled: func() bool {
+			return ParamsPromoter.Enabled
+		},
+	}
+}
+
+var (
+	Plugin *app.Plugin
+	deps   dependencies
+
+	checkWorkerPool *workerpool.WorkerPool
+
+	// closures
+	onBlockReferenced           *events.Closure
+	onConfirmedMilestoneChanged *events.Closure
+)
+
+type dependencies struct {
+	dig.In
+	Promoter    *promoter.Promoter
+	SyncManager *syncmanager.SyncManager
+	Tangle      *tangle.Tangle
+}
+
+func provide(c *dig.Container) error {
+
+	type promoterDeps struct {
+		dig.In
+		Storage            *storage.Storage
+		SyncManager        *syncmanager.SyncManager
+		Tangle             *tangle.Tangle
+		TipScoreCalculator *tangle.TipScoreCalculator
+		PoWHandler         *pow.Handler
+		TipSelector        *tipselect.TipSelector `optional:"true"`
+	}
+
+	if err := c.Provide(func(deps promoterDeps) *promoter.Promoter {
+		if deps.TipSelector == nil {
+			Plugin.LogPanic("the promoter plugin needs the tipselection plugin to be enabled")
+		}
+
+		// the promoter always does PoW for the blocks it issues
+		attacher := deps.Tangle.BlockAttacher(
+			tangle.WithTipSel(deps.TipSelector.SelectNonLazyTips),
+			tangle.WithPoW(deps.PoWHandler, ParamsPromoter.PoW.WorkerCount),
+		)
+
+		return promoter.New(
+			Plugin.Logger(),
+			deps.Storage,
+			deps.SyncManager,
+			deps.TipScoreCalculator,
+			attacher.AttachBlock,
+			deps.TipSelector.SelectNonLazyTips,
+			ParamsPromoter.MaxTrackedBlocks,
+			ParamsPromoter.MaxPromotions,
+			ParamsPromoter.MaxReattachments,
+			syncmanager.MilestoneIndexDelta(ParamsPromoter.StatusRetentionMilestones),
+		)
+	}); err != nil {
+		Plugin.LogPanic(err)
+	}
+
+	return nil
+}
+
+func configure() error {
+
+	checkWorkerPool = workerpool.New(func(task workerpool.Task) {
+		if err := deps.Promoter.CheckTrackedBlocks(Plugin.Daemon().ContextStopped()); err != nil && err != common.ErrOperationAborted {
+			Plugin.LogWarnf("checking tracked blocks failed: %s", err)
+		}
+		task.Return(nil)
+	}, workerpool.WorkerCount(1), workerpool.QueueSize(1))
+
+	configureEvents()
+
+	return nil
+}
+
+func run() error {
+
+	if err := Plugin.Daemon().BackgroundWorker("Promoter", func(ctx context.Context) {
+		Plugin.LogInfo("Starting Promoter ... done")
+		checkWorkerPool.Start()
+		attachEvents()
+		<-ctx.Done()
+		Plugin.LogInfo("Stopping Promoter ...")
+		detachEvents()
+		checkWorkerPool.StopAndWait()
+		Plugin.LogInfo("Stopping Promoter ... done")
+	}, daemon.PriorityPromoter); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+
+	return nil
+}
+
+func configureEvents() {
+	onBlockReferenced = events.NewClosure(func(cachedBlockMeta *storage.CachedMetadata, _ iotago.MilestoneIndex, _ uint32) {
+		cachedBlockMeta.ConsumeMetadata(deps.Promoter.BlockReferenced) // meta -1
+	})
+
+	onConfirmedMilestoneChanged = events.NewClosure(func(cachedMilestone *storage.CachedMilestone) {
+		cachedMilestone.Release(true) // milestone -1
+
+		// the tip scores are not meaningful during syncing
+		if !deps.SyncManager.IsNodeAlmostSynced() {
+			return
+		}
+
+		// the tracked blocks are checked in a separate worker, since promotions and reattachments may need PoW
+		checkWorkerPool.TrySubmit()
+	})
+}
+
+func attachEvents() {
+	deps.Tangle.Events.BlockReferenced.Attach(onBlockReferenced)
+	deps.Tangle.Events.ConfirmedMilestoneChanged.Attach(onConfirmedMilestoneChanged)
+}
+
+func detachEvents() {
+	deps.Tangle.Events.BlockReferenced.Detach(onBlockReferenced)
+	deps.Tangle.Events.ConfirmedMilestoneChanged.Detach(onConfirmedMilestoneChanged)
+}
@@ -1,6 +1,8 @@
 package restapi
 
 import (
+	"time"
+
 	"github.com/iotaledger/hive.go/app"
 )
 
@@ -32,6 +34,16 @@ type ParametersRestAPI struct {
 		MaxBodyLength string `default:"1M" usage:"the maximum number of characters that the body of an API call may contain"`
 		// the maximum number of results that may be returned by an endpoint
 		MaxResults int `default:"1000" usage:"the maximum number of results that may be returned by an endpoint"`
+		// the maximum number of unspent outputs of an address that are aggregated to compute its balance
+		MaxAddressOutputs int `default:"10000" usage:"the maximum number of unspent outputs of an address that are aggregated to compute its balance"`
+		// the maximum number of milestones that are reverted to reconstruct the ledger state of an address at a past milestone
+		MaxRevertedMilestones int `default:"1000" usage:"the maximum number of milestones that are reverted to reconstruct the ledger state of an address at a past milestone"`
+		// the maximum number of clients that may be subscribed to the event stream at once
+		MaxEventSubscribers int `default:"100" usage:"the maximum number of clients that may be subscribed to the event stream at once"`
+		// the maximum number of event messages that are buffered per client before the client is dropped
+		EventQueueSize int `default:"1000" usage:"the maximum number of event messages that are buffered per client before the client is dropped"`
+		// the maximum time a block submission may wait until the block is referenced by a milestone
+		MaxReferencedWaitTime time.Duration `default:"1m" usage:"the maximum time a block submission may wait until the block is referenced by a milestone"`
 	}
 }
 
@@ -45,8 +57,10 @@ var ParamsRestAPI = &ParametersRestAPI{
 		"/api/core/v2/transactions*",
 		"/api/core/v2/milestones*",
 		"/api/core/v2/outputs*",
+		"/api/core/v2/addresses*",
 		"/api/core/v2/treasury",
 		"/api/core/v2/receipts*",
+		"/api/core/v2/events",
 		"/api/debug/v1/*",
 		"/api/indexer/v1/*",
 		"/api/mqtt/v1",
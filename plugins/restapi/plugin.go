@@ -58,14 +58,22 @@ func initConfigPars(c *dig.Container) error {
 
 	type cfgResult struct {
 		dig.Out
-		RestAPIBindAddress      string `name:"restAPIBindAddress"`
-		RestAPILimitsMaxResults int    `name:"restAPILimitsMaxResults"`
+		RestAPIBindAddress                 string `name:"restAPIBindAddress"`
+		RestAPILimitsMaxResults            int    `name:"restAPILimitsMaxResults"`
+		RestAPILimitsMaxAddressOutputs     int    `name:"restAPILimitsMaxAddressOutputs"`
+		RestAPILimitsMaxRevertedMilestones int    `name:"restAPILimitsMaxRevertedMilestones"`
+		RestAPILimitsMaxEventSubscribers   int    `name:"restAPILimitsMaxEventSubscribers"`
+		RestAPILimitsEventQueueSize        int    `name:"restAPILimitsEventQueueSize"`
 	}
 
 	if err := c.Provide(func() cfgResult {
 		return cfgResult{
-			RestAPIBindAddress:      ParamsRestAPI.BindAddress,
-			RestAPILimitsMaxResults: ParamsRestAPI.Limits.MaxResults,
+			RestAPIBindAddress:                 ParamsRestAPI.BindAddress,
+			RestAPILimitsMaxResults:            ParamsRestAPI.Limits.MaxResults,
+			RestAPILimitsMaxAddressOutputs:     ParamsRestAPI.Limits.MaxAddressOutputs,
+			RestAPILimitsMaxRevertedMilestones: ParamsRestAPI.Limits.MaxRevertedMilestones,
+			RestAPILimitsMaxEventSubscribers:   ParamsRestAPI.Limits.MaxEventSubscribers,
+			RestAPILimitsEventQueueSize:        ParamsRestAPI.Limits.EventQueueSize,
 		}
 	}); err != nil {
 		Plugin.LogPanic(err)
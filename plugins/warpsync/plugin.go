@@ -8,6 +8,7 @@ import (
 
 	"github.com/iotaledger/hive.go/app"
 	"github.com/iotaledger/hive.go/events"
+	"github.com/iotaledger/hive.go/timeutil"
 	"github.com/iotaledger/hornet/v2/pkg/daemon"
 	"github.com/iotaledger/hornet/v2/pkg/model/storage"
 	"github.com/iotaledger/hornet/v2/pkg/model/syncmanager"
@@ -31,6 +32,12 @@ func init() {
 	}
 }
 
+const (
+	// the interval in which the cones of received milestones are requested during the synchronization,
+	// and in which the requested cones which were not received in time are requested parent by parent.
+	milestoneConesRequestInterval = 1 * time.Second
+)
+
 var (
 	Plugin *app.Plugin
 	deps   dependencies
@@ -73,6 +80,27 @@ func run() error {
 	}, daemon.PriorityWarpSync); err != nil {
 		Plugin.LogPanicf("failed to start worker: %s", err)
 	}
+
+	if err := Plugin.Daemon().BackgroundWorker("WarpSync[MilestoneCones]", func(ctx context.Context) {
+		ticker := timeutil.NewTicker(func() {
+			warpSync.Lock()
+			checkpoint := warpSync.CurrentCheckpoint
+			warpSync.Unlock()
+
+			if checkpoint == 0 {
+				// synchronization not started
+				return
+			}
+
+			if err := warpSyncMilestoneRequester.RequestMilestoneCones(ctx, checkpoint); err != nil {
+				Plugin.LogDebugf("requesting milestone cones failed: %s", err)
+			}
+		}, milestoneConesRequestInterval, ctx)
+		ticker.WaitForGracefulShutdown()
+	}, daemon.PriorityWarpSync); err != nil {
+		Plugin.LogPanicf("failed to start worker: %s", err)
+	}
+
 	return nil
 }
 
@@ -0,0 +1,10 @@
+#!/bin/bash
+#
+# Builds a statically linked HORNET without cgo with the latest commit hash (short)
+# The rocksdb database engine is not available in this build, use pebble or badger instead.
+# E.g.: ./hornet -v --> HORNET 75316fe
+
+DIR="$( cd -- "$(dirname "$0")" >/dev/null 2>&1 ; pwd -P )"
+
+commit_hash=$(git rev-parse --short HEAD)
+CGO_ENABLED=0 go build -ldflags="-s -w -X github.com/iotaledger/hornet/core/app.Version=$commit_hash"